@@ -22,6 +22,8 @@ import (
 	"github.com/go-logr/logr"
 	"github.com/google/uuid"
 	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/domains"
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/projects"
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/roles"
 	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/users"
 	"golang.org/x/exp/maps"
 
@@ -62,6 +64,40 @@ type KeystoneAPIFixture struct {
 	// Domains is the map of domain objects known by the fixture keyed by the
 	// domain name
 	Domains map[string]domains.Domain
+	// Projects is the map of project objects known by the fixture keyed by
+	// the project name
+	Projects map[string]projects.Project
+	// Roles is the map of role objects known by the fixture keyed by the role
+	// name
+	Roles map[string]roles.Role
+	// RoleAssignments is the set of role assignments known by the fixture
+	RoleAssignments map[RoleAssignment]bool
+	// Passwords is the map of the last password set for a user keyed by the
+	// user name
+	Passwords map[string]string
+	// Services is the map of service objects known by the fixture keyed by
+	// the service name
+	Services map[string]Service
+}
+
+// Service is a service of the catalog, as keystone returns it with the name
+// and description next to the fields of services.Service
+type Service struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Description string `json:"description"`
+	Enabled     bool   `json:"enabled"`
+}
+
+// RoleAssignment is a role assignment of a user on a project, a domain or
+// the system scope all
+type RoleAssignment struct {
+	RoleID    string
+	UserID    string
+	ProjectID string
+	DomainID  string
+	System    string
 }
 
 // NewKeystoneAPIFixtureWithServer set up a keystone-api simulator with an
@@ -85,8 +121,13 @@ func AddKeystoneAPIFixture(log logr.Logger, server *api.FakeAPIServer) *Keystone
 			URLBase:    "/identity",
 			OwnsServer: false,
 		},
-		Users:   map[string]users.User{},
-		Domains: map[string]domains.Domain{},
+		Users:           map[string]users.User{},
+		Domains:         map[string]domains.Domain{},
+		Projects:        map[string]projects.Project{},
+		Roles:           map[string]roles.Role{},
+		RoleAssignments: map[RoleAssignment]bool{},
+		Passwords:       map[string]string{},
+		Services:        map[string]Service{},
 	}
 	return fixture
 }
@@ -109,7 +150,18 @@ func (f *KeystoneAPIFixture) registerNormalHandlers() {
 	f.registerHandler(api.Handler{Pattern: "/", Func: f.HandleVersion})
 	f.registerHandler(api.Handler{Pattern: "/v3/auth/tokens", Func: f.HandleToken})
 	f.registerHandler(api.Handler{Pattern: "/v3/users", Func: f.HandleUsers})
+	f.registerHandler(api.Handler{Pattern: "/v3/users/{id}", Func: f.HandleUser})
 	f.registerHandler(api.Handler{Pattern: "/v3/domains", Func: f.HandleDomains})
+	f.registerHandler(api.Handler{Pattern: "/v3/domains/{id}", Func: f.HandleDomain})
+	f.registerHandler(api.Handler{Pattern: "/v3/projects", Func: f.HandleProjects})
+	f.registerHandler(api.Handler{Pattern: "/v3/projects/{id}", Func: f.HandleProject})
+	f.registerHandler(api.Handler{Pattern: "/v3/roles", Func: f.HandleRoles})
+	f.registerHandler(api.Handler{Pattern: "/v3/role_assignments", Func: f.HandleRoleAssignments})
+	f.registerHandler(api.Handler{Pattern: "/v3/projects/{project}/users/{user}/roles/{role}", Func: f.HandleRoleAssignment})
+	f.registerHandler(api.Handler{Pattern: "/v3/domains/{domain}/users/{user}/roles/{role}", Func: f.HandleRoleAssignment})
+	f.registerHandler(api.Handler{Pattern: "/v3/system/users/{user}/roles/{role}", Func: f.HandleRoleAssignment})
+	f.registerHandler(api.Handler{Pattern: "/v3/services", Func: f.HandleServices})
+	f.registerHandler(api.Handler{Pattern: "/v3/services/{id}", Func: f.HandleService})
 }
 
 func (f *KeystoneAPIFixture) registerHandler(handler api.Handler) {
@@ -206,22 +258,25 @@ func (f *KeystoneAPIFixture) HandleUsers(w http.ResponseWriter, r *http.Request)
 	}
 }
 
+// userJSON adds the enabled flag of the user, which users.User leaves out
+// when it gets marshalled
+type userJSON struct {
+	users.User
+	Enabled bool `json:"enabled"`
+}
+
 // GetUsers handles GET /v3/users based on the fixture internal state
 func (f *KeystoneAPIFixture) GetUsers(w http.ResponseWriter, r *http.Request) {
 	nameFilter := r.URL.Query().Get("name")
-	var us []users.User
-	if nameFilter == "" {
-		us = maps.Values(f.Users)
-	} else {
-		for name, user := range f.Users {
-			if name == nameFilter {
-				us = append(us, user)
-			}
+	var us []userJSON
+	for name, user := range f.Users {
+		if nameFilter == "" || name == nameFilter {
+			us = append(us, userJSON{User: user, Enabled: user.Enabled})
 		}
 	}
 
 	var s struct {
-		Users []users.User `json:"users"`
+		Users []userJSON `json:"users"`
 	}
 	s.Users = us
 
@@ -250,22 +305,151 @@ func (f *KeystoneAPIFixture) CreateUser(w http.ResponseWriter, r *http.Request)
 		f.InternalError(err, "Error during unmarshalling request", w, r)
 		return
 	}
+	var opts struct {
+		User struct {
+			Password string `json:"password"`
+			Enabled  *bool  `json:"enabled"`
+		} `json:"user"`
+	}
+	err = json.Unmarshal(bytes, &opts)
+	if err != nil {
+		f.InternalError(err, "Error during unmarshalling request", w, r)
+		return
+	}
 	if s.User.ID == "" {
 		s.User.ID = uuid.NewString()
 	}
+	// users are enabled unless requested otherwise
+	s.User.Enabled = opts.User.Enabled == nil || *opts.User.Enabled
 
 	f.Users[s.User.Name] = s.User
+	f.Passwords[s.User.Name] = opts.User.Password
 
-	bytes, err = json.Marshal(&s)
+	f.writeUser(w, r, s.User, 201)
+}
+
+// HandleUser handles the happy path of GET, PATCH and DELETE /v3/users/{id}
+// API
+func (f *KeystoneAPIFixture) HandleUser(w http.ResponseWriter, r *http.Request) {
+	f.LogRequest(r)
+	switch r.Method {
+	case "GET":
+		f.GetUser(w, r)
+	case "PATCH":
+		f.UpdateUser(w, r)
+	case "DELETE":
+		f.DeleteUser(w, r)
+	default:
+		f.UnexpectedRequest(w, r)
+		return
+	}
+}
+
+func (f *KeystoneAPIFixture) findUser(id string) (users.User, bool) {
+	for _, user := range f.Users {
+		if user.ID == id {
+			return user, true
+		}
+	}
+	return users.User{}, false
+}
+
+func (f *KeystoneAPIFixture) writeUser(w http.ResponseWriter, r *http.Request, user users.User, code int) {
+	var s struct {
+		User userJSON `json:"user"`
+	}
+	s.User = userJSON{User: user, Enabled: user.Enabled}
+
+	bytes, err := json.Marshal(&s)
 	if err != nil {
 		f.InternalError(err, "Error during marshalling response", w, r)
 		return
 	}
 	w.Header().Add("Content-Type", "application/json")
-	w.WriteHeader(201)
+	w.WriteHeader(code)
 	fmt.Fprint(w, string(bytes))
 }
 
+// GetUser handles GET /v3/users/{id} based on the fixture internal state
+func (f *KeystoneAPIFixture) GetUser(w http.ResponseWriter, r *http.Request) {
+	user, found := f.findUser(r.PathValue("id"))
+	if !found {
+		w.WriteHeader(404)
+		return
+	}
+	f.writeUser(w, r, user, 200)
+}
+
+// UpdateUser handles PATCH /v3/users/{id} and records the changed name,
+// description, enabled flag, default project and password in memory
+func (f *KeystoneAPIFixture) UpdateUser(w http.ResponseWriter, r *http.Request) {
+	user, found := f.findUser(r.PathValue("id"))
+	if !found {
+		w.WriteHeader(404)
+		return
+	}
+	bytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		f.InternalError(err, "Error reading request body", w, r)
+		return
+	}
+	var s struct {
+		User struct {
+			Name             *string `json:"name"`
+			Description      *string `json:"description"`
+			Enabled          *bool   `json:"enabled"`
+			DefaultProjectID *string `json:"default_project_id"`
+			Password         *string `json:"password"`
+		} `json:"user"`
+	}
+	err = json.Unmarshal(bytes, &s)
+	if err != nil {
+		f.InternalError(err, "Error during unmarshalling request", w, r)
+		return
+	}
+
+	password := f.Passwords[user.Name]
+	delete(f.Users, user.Name)
+	delete(f.Passwords, user.Name)
+	if s.User.Name != nil {
+		user.Name = *s.User.Name
+	}
+	if s.User.Description != nil {
+		user.Description = *s.User.Description
+	}
+	if s.User.Enabled != nil {
+		user.Enabled = *s.User.Enabled
+	}
+	if s.User.DefaultProjectID != nil {
+		user.DefaultProjectID = *s.User.DefaultProjectID
+	}
+	if s.User.Password != nil {
+		password = *s.User.Password
+	}
+	f.Users[user.Name] = user
+	f.Passwords[user.Name] = password
+
+	f.writeUser(w, r, user, 200)
+}
+
+// DeleteUser handles DELETE /v3/users/{id} and removes the user and its role
+// assignments from memory
+func (f *KeystoneAPIFixture) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	user, found := f.findUser(r.PathValue("id"))
+	if !found {
+		w.WriteHeader(404)
+		return
+	}
+	delete(f.Users, user.Name)
+	delete(f.Passwords, user.Name)
+	for assignment := range f.RoleAssignments {
+		if assignment.UserID == user.ID {
+			delete(f.RoleAssignments, assignment)
+		}
+	}
+	w.WriteHeader(204)
+}
+
 // HandleDomains handles the happy path of GET /v3/domains and POST /v3/domains API
 func (f *KeystoneAPIFixture) HandleDomains(w http.ResponseWriter, r *http.Request) {
 	f.LogRequest(r)
@@ -339,3 +523,639 @@ func (f *KeystoneAPIFixture) CreateDomain(w http.ResponseWriter, r *http.Request
 	w.WriteHeader(201)
 	fmt.Fprint(w, string(bytes))
 }
+
+// HandleDomain handles the happy path of GET, PATCH and DELETE
+// /v3/domains/{id} API
+func (f *KeystoneAPIFixture) HandleDomain(w http.ResponseWriter, r *http.Request) {
+	f.LogRequest(r)
+	switch r.Method {
+	case "GET":
+		f.GetDomain(w, r)
+	case "PATCH":
+		f.UpdateDomain(w, r)
+	case "DELETE":
+		f.DeleteDomain(w, r)
+	default:
+		f.UnexpectedRequest(w, r)
+		return
+	}
+}
+
+func (f *KeystoneAPIFixture) findDomain(id string) (domains.Domain, bool) {
+	for _, domain := range f.Domains {
+		if domain.ID == id {
+			return domain, true
+		}
+	}
+	return domains.Domain{}, false
+}
+
+func (f *KeystoneAPIFixture) writeDomain(w http.ResponseWriter, r *http.Request, domain domains.Domain) {
+	var s struct {
+		Domain domains.Domain `json:"domain"`
+	}
+	s.Domain = domain
+
+	bytes, err := json.Marshal(&s)
+	if err != nil {
+		f.InternalError(err, "Error during marshalling response", w, r)
+		return
+	}
+	w.Header().Add("Content-Type", "application/json")
+	w.WriteHeader(200)
+	fmt.Fprint(w, string(bytes))
+}
+
+// GetDomain handles GET /v3/domains/{id} based on the fixture internal state
+func (f *KeystoneAPIFixture) GetDomain(w http.ResponseWriter, r *http.Request) {
+	domain, found := f.findDomain(r.PathValue("id"))
+	if !found {
+		w.WriteHeader(404)
+		return
+	}
+	f.writeDomain(w, r, domain)
+}
+
+// UpdateDomain handles PATCH /v3/domains/{id} and records the changed name,
+// description and enabled flag in memory
+func (f *KeystoneAPIFixture) UpdateDomain(w http.ResponseWriter, r *http.Request) {
+	domain, found := f.findDomain(r.PathValue("id"))
+	if !found {
+		w.WriteHeader(404)
+		return
+	}
+	bytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		f.InternalError(err, "Error reading request body", w, r)
+		return
+	}
+	var s struct {
+		Domain struct {
+			Name        *string `json:"name"`
+			Description *string `json:"description"`
+			Enabled     *bool   `json:"enabled"`
+		} `json:"domain"`
+	}
+	err = json.Unmarshal(bytes, &s)
+	if err != nil {
+		f.InternalError(err, "Error during unmarshalling request", w, r)
+		return
+	}
+
+	delete(f.Domains, domain.Name)
+	if s.Domain.Name != nil {
+		domain.Name = *s.Domain.Name
+	}
+	if s.Domain.Description != nil {
+		domain.Description = *s.Domain.Description
+	}
+	if s.Domain.Enabled != nil {
+		domain.Enabled = *s.Domain.Enabled
+	}
+	f.Domains[domain.Name] = domain
+
+	f.writeDomain(w, r, domain)
+}
+
+// DeleteDomain handles DELETE /v3/domains/{id} and removes the domain from
+// memory. Like keystone it refuses to delete an enabled domain.
+func (f *KeystoneAPIFixture) DeleteDomain(w http.ResponseWriter, r *http.Request) {
+	domain, found := f.findDomain(r.PathValue("id"))
+	if !found {
+		w.WriteHeader(404)
+		return
+	}
+	if domain.Enabled {
+		w.WriteHeader(403)
+		return
+	}
+	delete(f.Domains, domain.Name)
+	w.WriteHeader(204)
+}
+
+// HandleProjects handles the happy path of GET /v3/projects and POST
+// /v3/projects API
+func (f *KeystoneAPIFixture) HandleProjects(w http.ResponseWriter, r *http.Request) {
+	f.LogRequest(r)
+	switch r.Method {
+	case "GET":
+		f.GetProjects(w, r)
+	case "POST":
+		f.CreateProject(w, r)
+	default:
+		f.UnexpectedRequest(w, r)
+		return
+	}
+}
+
+// GetProjects handles GET /v3/projects based on the fixture internal state
+func (f *KeystoneAPIFixture) GetProjects(w http.ResponseWriter, r *http.Request) {
+	nameFilter := r.URL.Query().Get("name")
+	domainFilter := r.URL.Query().Get("domain_id")
+	var ps []projects.Project
+	for name, project := range f.Projects {
+		if (nameFilter == "" || name == nameFilter) &&
+			(domainFilter == "" || project.DomainID == domainFilter) {
+			ps = append(ps, project)
+		}
+	}
+
+	var s struct {
+		Projects []projects.Project `json:"projects"`
+	}
+	s.Projects = ps
+
+	bytes, err := json.Marshal(&s)
+	if err != nil {
+		f.InternalError(err, "Error during marshalling response", w, r)
+		return
+	}
+	w.Header().Add("Content-Type", "application/json")
+	w.WriteHeader(200)
+	fmt.Fprint(w, string(bytes))
+}
+
+// CreateProject handles POST /v3/projects and records the created project in
+// memory
+func (f *KeystoneAPIFixture) CreateProject(w http.ResponseWriter, r *http.Request) {
+	bytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		f.InternalError(err, "Error reading request body", w, r)
+		return
+	}
+	var s struct {
+		Project struct {
+			Name        string `json:"name"`
+			Description string `json:"description"`
+			DomainID    string `json:"domain_id"`
+			Enabled     *bool  `json:"enabled"`
+		} `json:"project"`
+	}
+	err = json.Unmarshal(bytes, &s)
+	if err != nil {
+		f.InternalError(err, "Error during unmarshalling request", w, r)
+		return
+	}
+
+	project := projects.Project{
+		ID:          uuid.NewString(),
+		Name:        s.Project.Name,
+		Description: s.Project.Description,
+		DomainID:    s.Project.DomainID,
+		// projects are enabled unless requested otherwise
+		Enabled: s.Project.Enabled == nil || *s.Project.Enabled,
+	}
+	f.Projects[project.Name] = project
+
+	f.writeProject(w, r, project, 201)
+}
+
+// HandleProject handles the happy path of GET, PATCH and DELETE
+// /v3/projects/{id} API
+func (f *KeystoneAPIFixture) HandleProject(w http.ResponseWriter, r *http.Request) {
+	f.LogRequest(r)
+	switch r.Method {
+	case "GET":
+		f.GetProject(w, r)
+	case "PATCH":
+		f.UpdateProject(w, r)
+	case "DELETE":
+		f.DeleteProject(w, r)
+	default:
+		f.UnexpectedRequest(w, r)
+		return
+	}
+}
+
+func (f *KeystoneAPIFixture) findProject(id string) (projects.Project, bool) {
+	for _, project := range f.Projects {
+		if project.ID == id {
+			return project, true
+		}
+	}
+	return projects.Project{}, false
+}
+
+func (f *KeystoneAPIFixture) writeProject(w http.ResponseWriter, r *http.Request, project projects.Project, code int) {
+	var s struct {
+		Project projects.Project `json:"project"`
+	}
+	s.Project = project
+
+	bytes, err := json.Marshal(&s)
+	if err != nil {
+		f.InternalError(err, "Error during marshalling response", w, r)
+		return
+	}
+	w.Header().Add("Content-Type", "application/json")
+	w.WriteHeader(code)
+	fmt.Fprint(w, string(bytes))
+}
+
+// GetProject handles GET /v3/projects/{id} based on the fixture internal state
+func (f *KeystoneAPIFixture) GetProject(w http.ResponseWriter, r *http.Request) {
+	project, found := f.findProject(r.PathValue("id"))
+	if !found {
+		w.WriteHeader(404)
+		return
+	}
+	f.writeProject(w, r, project, 200)
+}
+
+// UpdateProject handles PATCH /v3/projects/{id} and records the changed name,
+// description and enabled flag in memory
+func (f *KeystoneAPIFixture) UpdateProject(w http.ResponseWriter, r *http.Request) {
+	project, found := f.findProject(r.PathValue("id"))
+	if !found {
+		w.WriteHeader(404)
+		return
+	}
+	bytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		f.InternalError(err, "Error reading request body", w, r)
+		return
+	}
+	var s struct {
+		Project struct {
+			Name        *string `json:"name"`
+			Description *string `json:"description"`
+			Enabled     *bool   `json:"enabled"`
+		} `json:"project"`
+	}
+	err = json.Unmarshal(bytes, &s)
+	if err != nil {
+		f.InternalError(err, "Error during unmarshalling request", w, r)
+		return
+	}
+
+	delete(f.Projects, project.Name)
+	if s.Project.Name != nil {
+		project.Name = *s.Project.Name
+	}
+	if s.Project.Description != nil {
+		project.Description = *s.Project.Description
+	}
+	if s.Project.Enabled != nil {
+		project.Enabled = *s.Project.Enabled
+	}
+	f.Projects[project.Name] = project
+
+	f.writeProject(w, r, project, 200)
+}
+
+// DeleteProject handles DELETE /v3/projects/{id} and removes the project and
+// the role assignments on it from memory
+func (f *KeystoneAPIFixture) DeleteProject(w http.ResponseWriter, r *http.Request) {
+	project, found := f.findProject(r.PathValue("id"))
+	if !found {
+		w.WriteHeader(404)
+		return
+	}
+	delete(f.Projects, project.Name)
+	for assignment := range f.RoleAssignments {
+		if assignment.ProjectID == project.ID {
+			delete(f.RoleAssignments, assignment)
+		}
+	}
+	w.WriteHeader(204)
+}
+
+// HandleRoles handles the happy path of GET /v3/roles and POST /v3/roles API
+func (f *KeystoneAPIFixture) HandleRoles(w http.ResponseWriter, r *http.Request) {
+	f.LogRequest(r)
+	switch r.Method {
+	case "GET":
+		f.GetRoles(w, r)
+	case "POST":
+		f.CreateRole(w, r)
+	default:
+		f.UnexpectedRequest(w, r)
+		return
+	}
+}
+
+// GetRoles handles GET /v3/roles based on the fixture internal state
+func (f *KeystoneAPIFixture) GetRoles(w http.ResponseWriter, r *http.Request) {
+	nameFilter := r.URL.Query().Get("name")
+	var rs []roles.Role
+	for name, role := range f.Roles {
+		if nameFilter == "" || name == nameFilter {
+			rs = append(rs, role)
+		}
+	}
+
+	var s struct {
+		Roles []roles.Role `json:"roles"`
+	}
+	s.Roles = rs
+
+	bytes, err := json.Marshal(&s)
+	if err != nil {
+		f.InternalError(err, "Error during marshalling response", w, r)
+		return
+	}
+	w.Header().Add("Content-Type", "application/json")
+	w.WriteHeader(200)
+	fmt.Fprint(w, string(bytes))
+}
+
+// CreateRole handles POST /v3/roles and records the created role in memory
+func (f *KeystoneAPIFixture) CreateRole(w http.ResponseWriter, r *http.Request) {
+	bytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		f.InternalError(err, "Error reading request body", w, r)
+		return
+	}
+	var s struct {
+		Role roles.Role `json:"role"`
+	}
+	err = json.Unmarshal(bytes, &s)
+	if err != nil {
+		f.InternalError(err, "Error during unmarshalling request", w, r)
+		return
+	}
+	if s.Role.ID == "" {
+		s.Role.ID = uuid.NewString()
+	}
+
+	f.Roles[s.Role.Name] = s.Role
+
+	bytes, err = json.Marshal(&s)
+	if err != nil {
+		f.InternalError(err, "Error during marshalling response", w, r)
+		return
+	}
+	w.Header().Add("Content-Type", "application/json")
+	w.WriteHeader(201)
+	fmt.Fprint(w, string(bytes))
+}
+
+// HandleRoleAssignment handles PUT, HEAD and DELETE of
+// /v3/projects/{project}/users/{user}/roles/{role},
+// /v3/domains/{domain}/users/{user}/roles/{role} and
+// /v3/system/users/{user}/roles/{role} based on the fixture internal state
+func (f *KeystoneAPIFixture) HandleRoleAssignment(w http.ResponseWriter, r *http.Request) {
+	f.LogRequest(r)
+	assignment := RoleAssignment{
+		RoleID:    r.PathValue("role"),
+		UserID:    r.PathValue("user"),
+		ProjectID: r.PathValue("project"),
+		DomainID:  r.PathValue("domain"),
+	}
+	if assignment.ProjectID == "" && assignment.DomainID == "" {
+		assignment.System = "all"
+	}
+
+	switch r.Method {
+	case "PUT":
+		f.RoleAssignments[assignment] = true
+		w.WriteHeader(204)
+	case "HEAD":
+		if !f.RoleAssignments[assignment] {
+			w.WriteHeader(404)
+			return
+		}
+		w.WriteHeader(204)
+	case "DELETE":
+		if !f.RoleAssignments[assignment] {
+			w.WriteHeader(404)
+			return
+		}
+		delete(f.RoleAssignments, assignment)
+		w.WriteHeader(204)
+	default:
+		f.UnexpectedRequest(w, r)
+		return
+	}
+}
+
+// HandleRoleAssignments handles GET /v3/role_assignments filtered by
+// user.id, role.id, scope.project.id and scope.domain.id based on the fixture
+// internal state
+func (f *KeystoneAPIFixture) HandleRoleAssignments(w http.ResponseWriter, r *http.Request) {
+	f.LogRequest(r)
+	if r.Method != "GET" {
+		f.UnexpectedRequest(w, r)
+		return
+	}
+
+	query := r.URL.Query()
+	var ras []roles.RoleAssignment
+	for assignment := range f.RoleAssignments {
+		if (query.Get("user.id") != "" && query.Get("user.id") != assignment.UserID) ||
+			(query.Get("role.id") != "" && query.Get("role.id") != assignment.RoleID) ||
+			(query.Get("scope.project.id") != "" && query.Get("scope.project.id") != assignment.ProjectID) ||
+			(query.Get("scope.domain.id") != "" && query.Get("scope.domain.id") != assignment.DomainID) {
+			continue
+		}
+		ras = append(ras, roles.RoleAssignment{
+			Role: roles.AssignedRole{ID: assignment.RoleID},
+			User: roles.User{ID: assignment.UserID},
+			Scope: roles.Scope{
+				Project: roles.Project{ID: assignment.ProjectID},
+				Domain:  roles.Domain{ID: assignment.DomainID},
+			},
+		})
+	}
+
+	var s struct {
+		RoleAssignments []roles.RoleAssignment `json:"role_assignments"`
+	}
+	s.RoleAssignments = ras
+
+	bytes, err := json.Marshal(&s)
+	if err != nil {
+		f.InternalError(err, "Error during marshalling response", w, r)
+		return
+	}
+	w.Header().Add("Content-Type", "application/json")
+	w.WriteHeader(200)
+	fmt.Fprint(w, string(bytes))
+}
+
+// HandleServices handles the happy path of GET /v3/services and POST
+// /v3/services API
+func (f *KeystoneAPIFixture) HandleServices(w http.ResponseWriter, r *http.Request) {
+	f.LogRequest(r)
+	switch r.Method {
+	case "GET":
+		f.GetServices(w, r)
+	case "POST":
+		f.CreateService(w, r)
+	default:
+		f.UnexpectedRequest(w, r)
+		return
+	}
+}
+
+// GetServices handles GET /v3/services filtered by type and name based on the
+// fixture internal state
+func (f *KeystoneAPIFixture) GetServices(w http.ResponseWriter, r *http.Request) {
+	nameFilter := r.URL.Query().Get("name")
+	typeFilter := r.URL.Query().Get("type")
+	var ss []Service
+	for name, service := range f.Services {
+		if (nameFilter == "" || name == nameFilter) &&
+			(typeFilter == "" || service.Type == typeFilter) {
+			ss = append(ss, service)
+		}
+	}
+
+	var s struct {
+		Services []Service `json:"services"`
+	}
+	s.Services = ss
+
+	bytes, err := json.Marshal(&s)
+	if err != nil {
+		f.InternalError(err, "Error during marshalling response", w, r)
+		return
+	}
+	w.Header().Add("Content-Type", "application/json")
+	w.WriteHeader(200)
+	fmt.Fprint(w, string(bytes))
+}
+
+// CreateService handles POST /v3/services and records the created service in
+// memory
+func (f *KeystoneAPIFixture) CreateService(w http.ResponseWriter, r *http.Request) {
+	bytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		f.InternalError(err, "Error reading request body", w, r)
+		return
+	}
+	var s struct {
+		Service struct {
+			Name        string `json:"name"`
+			Type        string `json:"type"`
+			Description string `json:"description"`
+			Enabled     *bool  `json:"enabled"`
+		} `json:"service"`
+	}
+	err = json.Unmarshal(bytes, &s)
+	if err != nil {
+		f.InternalError(err, "Error during unmarshalling request", w, r)
+		return
+	}
+
+	service := Service{
+		ID:          uuid.NewString(),
+		Name:        s.Service.Name,
+		Type:        s.Service.Type,
+		Description: s.Service.Description,
+		// services are enabled unless requested otherwise
+		Enabled: s.Service.Enabled == nil || *s.Service.Enabled,
+	}
+	f.Services[service.Name] = service
+
+	f.writeService(w, r, service, 201)
+}
+
+// HandleService handles the happy path of GET, PATCH and DELETE
+// /v3/services/{id} API
+func (f *KeystoneAPIFixture) HandleService(w http.ResponseWriter, r *http.Request) {
+	f.LogRequest(r)
+	switch r.Method {
+	case "GET":
+		f.GetService(w, r)
+	case "PATCH":
+		f.UpdateService(w, r)
+	case "DELETE":
+		f.DeleteService(w, r)
+	default:
+		f.UnexpectedRequest(w, r)
+		return
+	}
+}
+
+func (f *KeystoneAPIFixture) findService(id string) (Service, bool) {
+	for _, service := range f.Services {
+		if service.ID == id {
+			return service, true
+		}
+	}
+	return Service{}, false
+}
+
+func (f *KeystoneAPIFixture) writeService(w http.ResponseWriter, r *http.Request, service Service, code int) {
+	var s struct {
+		Service Service `json:"service"`
+	}
+	s.Service = service
+
+	bytes, err := json.Marshal(&s)
+	if err != nil {
+		f.InternalError(err, "Error during marshalling response", w, r)
+		return
+	}
+	w.Header().Add("Content-Type", "application/json")
+	w.WriteHeader(code)
+	fmt.Fprint(w, string(bytes))
+}
+
+// GetService handles GET /v3/services/{id} based on the fixture internal state
+func (f *KeystoneAPIFixture) GetService(w http.ResponseWriter, r *http.Request) {
+	service, found := f.findService(r.PathValue("id"))
+	if !found {
+		w.WriteHeader(404)
+		return
+	}
+	f.writeService(w, r, service, 200)
+}
+
+// UpdateService handles PATCH /v3/services/{id} and records the changed name,
+// type, description and enabled flag in memory
+func (f *KeystoneAPIFixture) UpdateService(w http.ResponseWriter, r *http.Request) {
+	service, found := f.findService(r.PathValue("id"))
+	if !found {
+		w.WriteHeader(404)
+		return
+	}
+	bytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		f.InternalError(err, "Error reading request body", w, r)
+		return
+	}
+	var s struct {
+		Service struct {
+			Name        *string `json:"name"`
+			Type        *string `json:"type"`
+			Description *string `json:"description"`
+			Enabled     *bool   `json:"enabled"`
+		} `json:"service"`
+	}
+	err = json.Unmarshal(bytes, &s)
+	if err != nil {
+		f.InternalError(err, "Error during unmarshalling request", w, r)
+		return
+	}
+
+	delete(f.Services, service.Name)
+	if s.Service.Name != nil {
+		service.Name = *s.Service.Name
+	}
+	if s.Service.Type != nil {
+		service.Type = *s.Service.Type
+	}
+	if s.Service.Description != nil {
+		service.Description = *s.Service.Description
+	}
+	if s.Service.Enabled != nil {
+		service.Enabled = *s.Service.Enabled
+	}
+	f.Services[service.Name] = service
+
+	f.writeService(w, r, service, 200)
+}
+
+// DeleteService handles DELETE /v3/services/{id} and removes the service from
+// memory
+func (f *KeystoneAPIFixture) DeleteService(w http.ResponseWriter, r *http.Request) {
+	service, found := f.findService(r.PathValue("id"))
+	if !found {
+		w.WriteHeader(404)
+		return
+	}
+	delete(f.Services, service.Name)
+	w.WriteHeader(204)
+}
@@ -284,7 +284,7 @@ func (th *TestHelper) SimulateKeystoneEndpointReady(name types.NamespacedName) {
 			endpoint.Status.EndpointIDs = map[string]string{}
 		}
 
-		for endpointType, endpointURL := range endpoint.Spec.Endpoints {
+		for endpointType, entry := range endpoint.Spec.GetEndpointEntries() {
 			var endpointID string
 			var ok bool
 			if endpointID, ok = endpoint.Status.EndpointIDs[endpointType]; !ok {
@@ -297,13 +297,17 @@ func (th *TestHelper) SimulateKeystoneEndpointReady(name types.NamespacedName) {
 			idx := slices.IndexFunc(endpoint.Status.Endpoints, f)
 			if idx >= 0 {
 				endpoint.Status.Endpoints[idx].ID = endpointID
-				endpoint.Status.Endpoints[idx].URL = endpointURL
+				endpoint.Status.Endpoints[idx].URL = entry.URL
+				endpoint.Status.Endpoints[idx].Region = entry.Region
+				endpoint.Status.Endpoints[idx].Enabled = entry.IsEnabled()
 			} else {
 				endpoint.Status.Endpoints = append(endpoint.Status.Endpoints,
 					keystonev1.Endpoint{
 						Interface: endpointType,
-						URL:       endpointURL,
+						URL:       entry.URL,
 						ID:        endpointID,
+						Region:    entry.Region,
+						Enabled:   entry.IsEnabled(),
 					})
 			}
 		}
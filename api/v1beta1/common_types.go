@@ -0,0 +1,74 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import "k8s.io/apimachinery/pkg/types"
+
+// DeletionPolicy - defines what happens to the object in keystone when the
+// CR managing it gets deleted
+type DeletionPolicy string
+
+const (
+	// DeletionPolicyDelete - the object gets removed from keystone
+	DeletionPolicyDelete DeletionPolicy = "Delete"
+	// DeletionPolicyRetain - the object is kept in keystone, only the CR goes away
+	DeletionPolicyRetain DeletionPolicy = "Retain"
+	// DeletionPolicyOrphan - the object and everything it manages in keystone,
+	// e.g. role assignments, are left untouched, only the CR goes away
+	DeletionPolicyOrphan DeletionPolicy = "Orphan"
+
+	// DefaultDomainName - name of the domain keystone creates during bootstrap
+	DefaultDomainName = "Default"
+
+	// DefaultServiceProjectName - name of the project the service users get
+	// created in
+	DefaultServiceProjectName = "service"
+)
+
+// DriftPolicy - defines what happens when an object registered in keystone
+// drifted from the spec of the CR managing it, e.g. after a change with the
+// openstack CLI
+type DriftPolicy string
+
+const (
+	// DriftPolicyCorrect - the object gets changed back to match the spec
+	DriftPolicyCorrect DriftPolicy = "Correct"
+	// DriftPolicyReport - the drift is only reported in the CatalogInSync
+	// condition
+	DriftPolicyReport DriftPolicy = "Report"
+)
+
+// KeystoneAPIRef - reference to the KeystoneAPI a resource gets registered in
+type KeystoneAPIRef struct {
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	// Name - name of the KeystoneAPI
+	Name string `json:"name"`
+
+	// +kubebuilder:validation:Optional
+	// Namespace - namespace of the KeystoneAPI, defaults to the namespace of
+	// the resource
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// GetNamespacedName - returns the name and namespace of the referenced
+// KeystoneAPI, namespace is the one of the referencing resource
+func (ref KeystoneAPIRef) GetNamespacedName(namespace string) types.NamespacedName {
+	if ref.Namespace != "" {
+		namespace = ref.Namespace
+	}
+	return types.NamespacedName{Name: ref.Name, Namespace: namespace}
+}
@@ -38,6 +38,54 @@ const (
 
 	// KeystoneApplicationCredentialReadyCondition Status=True condition which indicates if the ApplicationCredential has been created and is ready
 	KeystoneApplicationCredentialReadyCondition condition.Type = "KeystoneApplicationCredentialReady"
+
+	// KeystoneDomainOSDomainReadyCondition Status=True condition which indicates if the domain got created in the keystone instance is ready/was successful
+	KeystoneDomainOSDomainReadyCondition condition.Type = "KeystoneDomainOSDomainReady"
+
+	// KeystoneProjectOSProjectReadyCondition Status=True condition which indicates if the project got created in the keystone instance is ready/was successful
+	KeystoneProjectOSProjectReadyCondition condition.Type = "KeystoneProjectOSProjectReady"
+
+	// KeystoneUserOSUserReadyCondition Status=True condition which indicates if the user got created in the keystone instance is ready/was successful
+	KeystoneUserOSUserReadyCondition condition.Type = "KeystoneUserOSUserReady"
+
+	// KeystoneRoleAssignmentOSRoleAssignmentReadyCondition Status=True condition which indicates if the role got assigned in the keystone instance
+	KeystoneRoleAssignmentOSRoleAssignmentReadyCondition condition.Type = "KeystoneRoleAssignmentOSRoleAssignmentReady"
+
+	// KeystoneIdentityProviderOSIdentityProviderReadyCondition Status=True condition which indicates if the identity provider got registered in the keystone instance
+	KeystoneIdentityProviderOSIdentityProviderReadyCondition condition.Type = "KeystoneIdentityProviderOSIdentityProviderReady"
+
+	// KeystoneMappingOSMappingReadyCondition Status=True condition which indicates if the mapping got registered in the keystone instance
+	KeystoneMappingOSMappingReadyCondition condition.Type = "KeystoneMappingOSMappingReady"
+
+	// KeystoneProtocolOSProtocolReadyCondition Status=True condition which indicates if the protocol got registered in the keystone instance
+	KeystoneProtocolOSProtocolReadyCondition condition.Type = "KeystoneProtocolOSProtocolReady"
+
+	// KeystoneAPIFernetKeysReadyCondition Status=True condition which indicates if the fernet keys exist and got rotated in time
+	KeystoneAPIFernetKeysReadyCondition condition.Type = "FernetKeysReady"
+
+	// KeystoneAPICredentialKeysReadyCondition Status=True condition which indicates if the credential keys exist and no rotation is pending
+	KeystoneAPICredentialKeysReadyCondition condition.Type = "CredentialKeysReady"
+
+	// KeystoneAPIReceiptKeysReadyCondition Status=True condition which indicates if the fernet receipt keys exist and got rotated in time
+	KeystoneAPIReceiptKeysReadyCondition condition.Type = "ReceiptKeysReady"
+
+	// KeystoneAPIDatabaseUpgradeReadyCondition Status=True condition which indicates if the database schema is upgraded for the container image
+	KeystoneAPIDatabaseUpgradeReadyCondition condition.Type = "DatabaseUpgradeReady"
+
+	// KeystoneAPIUpgradeReadinessCondition Status=True condition which indicates if the pre-upgrade checks passed for the new container image
+	KeystoneAPIUpgradeReadinessCondition condition.Type = "UpgradeReadiness"
+
+	// KeystoneAPICanaryReadyCondition Status=True condition which indicates if no canary rollout is pending or failed
+	KeystoneAPICanaryReadyCondition condition.Type = "CanaryReady"
+
+	// KeystoneCatalogInSyncCondition Status=True condition which indicates if the service or endpoints registered in keystone match the spec
+	KeystoneCatalogInSyncCondition condition.Type = "CatalogInSync"
+
+	// KeystoneCatalogAuditReadyCondition Status=True condition which indicates if the catalog got audited for orphaned services and endpoints
+	KeystoneCatalogAuditReadyCondition condition.Type = "CatalogAuditReady"
+
+	// KeystoneServicePasswordSyncedCondition Status=True condition which indicates if the current password of the service user secret is set in keystone
+	KeystoneServicePasswordSyncedCondition condition.Type = "PasswordSynced"
 )
 
 // Common Messages used by API objects.
@@ -61,6 +109,12 @@ const (
 	// KeystoneAPIReadyErrorMessage
 	KeystoneAPIReadyErrorMessage = "KeystoneAPI error occured %s"
 
+	// KeystoneAPIReadyRefNotFoundMessage
+	KeystoneAPIReadyRefNotFoundMessage = "KeystoneAPI %s referenced by keystoneAPIRef not found"
+
+	// KeystoneAPIReadyRefWaitingMessage
+	KeystoneAPIReadyRefWaitingMessage = "KeystoneAPI %s referenced by keystoneAPIRef not yet ready"
+
 	//
 	// AdminServiceClientReady condition messages
 	//
@@ -100,6 +154,9 @@ const (
 	// KeystoneServiceOSEndpointsReadyErrorMessage
 	KeystoneServiceOSEndpointsReadyErrorMessage = "Keystone Endpoints error occured %s"
 
+	// KeystoneServiceOSEndpointsReadyKeystoneAPIMessage
+	KeystoneServiceOSEndpointsReadyKeystoneAPIMessage = "Keystone Endpoints waiting for KeystoneService %s registered in KeystoneAPI %s to register in %s"
+
 	//
 	// KeystoneServiceOSUserReady condition messages
 	//
@@ -129,4 +186,247 @@ const (
 
 	// KeystoneApplicationCredentialReadyErrorMessage
 	KeystoneApplicationCredentialReadyErrorMessage = "ApplicationCredential error occurred: %s"
+
+	//
+	// KeystoneDomainOSDomainReady condition messages
+	//
+	// KeystoneDomainOSDomainReadyInitMessage
+	KeystoneDomainOSDomainReadyInitMessage = "Keystone Domain registration not started"
+
+	// KeystoneDomainOSDomainReadyMessage
+	KeystoneDomainOSDomainReadyMessage = "Keystone Domain %s - %s ready"
+
+	// KeystoneDomainOSDomainReadyErrorMessage
+	KeystoneDomainOSDomainReadyErrorMessage = "Keystone Domain error occured %s"
+
+	//
+	// KeystoneProjectOSProjectReady condition messages
+	//
+	// KeystoneProjectOSProjectReadyInitMessage
+	KeystoneProjectOSProjectReadyInitMessage = "Keystone Project registration not started"
+
+	// KeystoneProjectOSProjectReadyMessage
+	KeystoneProjectOSProjectReadyMessage = "Keystone Project %s - %s ready"
+
+	// KeystoneProjectOSProjectReadyErrorMessage
+	KeystoneProjectOSProjectReadyErrorMessage = "Keystone Project error occured %s"
+
+	//
+	// KeystoneUserOSUserReady condition messages
+	//
+	// KeystoneUserOSUserReadyInitMessage
+	KeystoneUserOSUserReadyInitMessage = "Keystone User registration not started"
+
+	// KeystoneUserOSUserReadyMessage
+	KeystoneUserOSUserReadyMessage = "Keystone User %s - %s ready"
+
+	// KeystoneUserOSUserReadyErrorMessage
+	KeystoneUserOSUserReadyErrorMessage = "Keystone User error occured %s"
+
+	//
+	// KeystoneRoleAssignmentOSRoleAssignmentReady condition messages
+	//
+	// KeystoneRoleAssignmentOSRoleAssignmentReadyInitMessage
+	KeystoneRoleAssignmentOSRoleAssignmentReadyInitMessage = "Keystone Role assignment not started"
+
+	// KeystoneRoleAssignmentOSRoleAssignmentReadyMessage
+	KeystoneRoleAssignmentOSRoleAssignmentReadyMessage = "Keystone Role %s assigned to user %s"
+
+	// KeystoneRoleAssignmentOSRoleAssignmentReadyErrorMessage
+	KeystoneRoleAssignmentOSRoleAssignmentReadyErrorMessage = "Keystone Role assignment error occured %s"
+
+	//
+	// KeystoneIdentityProviderOSIdentityProviderReady condition messages
+	//
+	// KeystoneIdentityProviderOSIdentityProviderReadyInitMessage
+	KeystoneIdentityProviderOSIdentityProviderReadyInitMessage = "Keystone Identity provider registration not started"
+
+	// KeystoneIdentityProviderOSIdentityProviderReadyMessage
+	KeystoneIdentityProviderOSIdentityProviderReadyMessage = "Keystone Identity provider %s ready"
+
+	// KeystoneIdentityProviderOSIdentityProviderReadyErrorMessage
+	KeystoneIdentityProviderOSIdentityProviderReadyErrorMessage = "Keystone Identity provider error occured %s"
+
+	//
+	// KeystoneMappingOSMappingReady condition messages
+	//
+	// KeystoneMappingOSMappingReadyInitMessage
+	KeystoneMappingOSMappingReadyInitMessage = "Keystone Mapping registration not started"
+
+	// KeystoneMappingOSMappingReadyMessage
+	KeystoneMappingOSMappingReadyMessage = "Keystone Mapping %s ready"
+
+	// KeystoneMappingOSMappingReadyErrorMessage
+	KeystoneMappingOSMappingReadyErrorMessage = "Keystone Mapping error occured %s"
+
+	//
+	// KeystoneProtocolOSProtocolReady condition messages
+	//
+	// KeystoneProtocolOSProtocolReadyInitMessage
+	KeystoneProtocolOSProtocolReadyInitMessage = "Keystone Protocol registration not started"
+
+	// KeystoneProtocolOSProtocolReadyMessage
+	KeystoneProtocolOSProtocolReadyMessage = "Keystone Protocol %s of identity provider %s ready"
+
+	// KeystoneProtocolOSProtocolReadyWaitingMessage
+	KeystoneProtocolOSProtocolReadyWaitingMessage = "Keystone Protocol waiting for %s %s to be ready"
+
+	// KeystoneProtocolOSProtocolReadyErrorMessage
+	KeystoneProtocolOSProtocolReadyErrorMessage = "Keystone Protocol error occured %s"
+
+	//
+	// FernetKeysReady condition messages
+	//
+	// KeystoneAPIFernetKeysReadyInitMessage
+	KeystoneAPIFernetKeysReadyInitMessage = "Fernet keys not started"
+
+	// KeystoneAPIFernetKeysReadyMessage
+	KeystoneAPIFernetKeysReadyMessage = "Fernet keys ready, next rotation at %s"
+
+	// KeystoneAPIFernetKeysReadyOverdueMessage
+	KeystoneAPIFernetKeysReadyOverdueMessage = "Fernet key rotation overdue since %s"
+
+	// KeystoneAPIFernetKeysReadyRotationHeldMessage
+	KeystoneAPIFernetKeysReadyRotationHeldMessage = "Fernet key rotation held back, pods %s did not yet observe the current keys"
+
+	// KeystoneAPIFernetKeysReadyErrorMessage
+	KeystoneAPIFernetKeysReadyErrorMessage = "Fernet keys error occured %s"
+
+	//
+	// CredentialKeysReady condition messages
+	//
+	// KeystoneAPICredentialKeysReadyInitMessage
+	KeystoneAPICredentialKeysReadyInitMessage = "Credential keys not started"
+
+	// KeystoneAPICredentialKeysReadyMessage
+	KeystoneAPICredentialKeysReadyMessage = "Credential keys ready, next rotation at %s"
+
+	// KeystoneAPICredentialKeysReadyRotationDisabledMessage
+	KeystoneAPICredentialKeysReadyRotationDisabledMessage = "Credential keys ready, rotation disabled"
+
+	// KeystoneAPICredentialKeysReadyRotationHeldMessage
+	KeystoneAPICredentialKeysReadyRotationHeldMessage = "Credential key rotation held back, pods %s did not yet observe the current keys"
+
+	// KeystoneAPICredentialKeysReadyStagedMessage
+	KeystoneAPICredentialKeysReadyStagedMessage = "Credential key rotation waiting for pods %s to observe the new primary key"
+
+	// KeystoneAPICredentialKeysReadyMigratingMessage
+	KeystoneAPICredentialKeysReadyMigratingMessage = "Credential key rotation re-encrypting the credentials with the new primary key"
+
+	// KeystoneAPICredentialKeysReadyErrorMessage
+	KeystoneAPICredentialKeysReadyErrorMessage = "Credential keys error occured %s"
+
+	//
+	// ReceiptKeysReady condition messages
+	//
+	// KeystoneAPIReceiptKeysReadyInitMessage
+	KeystoneAPIReceiptKeysReadyInitMessage = "Receipt keys not started"
+
+	// KeystoneAPIReceiptKeysReadyMessage
+	KeystoneAPIReceiptKeysReadyMessage = "Receipt keys ready, next rotation at %s"
+
+	// KeystoneAPIReceiptKeysReadyRotationHeldMessage
+	KeystoneAPIReceiptKeysReadyRotationHeldMessage = "Receipt key rotation held back, pods %s did not yet observe the current keys"
+
+	// KeystoneAPIReceiptKeysReadyErrorMessage
+	KeystoneAPIReceiptKeysReadyErrorMessage = "Receipt keys error occured %s"
+
+	//
+	// DatabaseUpgradeReady condition messages
+	//
+	// KeystoneAPIDatabaseUpgradeReadyInitMessage
+	KeystoneAPIDatabaseUpgradeReadyInitMessage = "Database upgrade not started"
+
+	// KeystoneAPIDatabaseUpgradeReadyMessage
+	KeystoneAPIDatabaseUpgradeReadyMessage = "Database schema is up to date"
+
+	// KeystoneAPIDatabaseUpgradeReadyRunningMessage
+	KeystoneAPIDatabaseUpgradeReadyRunningMessage = "Database upgrade to %s in phase %s"
+
+	// KeystoneAPIDatabaseUpgradeReadyErrorMessage
+	KeystoneAPIDatabaseUpgradeReadyErrorMessage = "Database upgrade error occured %s"
+
+	//
+	// UpgradeReadiness condition messages
+	//
+	// KeystoneAPIUpgradeReadinessInitMessage
+	KeystoneAPIUpgradeReadinessInitMessage = "Upgrade checks not started"
+
+	// KeystoneAPIUpgradeReadinessNotRequiredMessage
+	KeystoneAPIUpgradeReadinessNotRequiredMessage = "Upgrade checks not required, no upgrade pending"
+
+	// KeystoneAPIUpgradeReadinessMessage
+	KeystoneAPIUpgradeReadinessMessage = "Upgrade checks passed for %s"
+
+	// KeystoneAPIUpgradeReadinessIgnoredMessage
+	KeystoneAPIUpgradeReadinessIgnoredMessage = "Upgrade checks %s failed for %s, ignored"
+
+	// KeystoneAPIUpgradeReadinessRunningMessage
+	KeystoneAPIUpgradeReadinessRunningMessage = "Upgrade checks running for %s"
+
+	// KeystoneAPIUpgradeReadinessFailedMessage
+	KeystoneAPIUpgradeReadinessFailedMessage = "Upgrade checks %s failed for %s, set ignoreUpgradeCheckFailures to upgrade anyway"
+
+	// KeystoneAPIUpgradeReadinessErrorMessage
+	KeystoneAPIUpgradeReadinessErrorMessage = "Upgrade checks error occured %s"
+
+	//
+	// CanaryReady condition messages
+	//
+	// KeystoneAPICanaryReadyInitMessage
+	KeystoneAPICanaryReadyInitMessage = "Canary rollout not started"
+
+	// KeystoneAPICanaryReadyMessage
+	KeystoneAPICanaryReadyMessage = "No canary rollout pending"
+
+	// KeystoneAPICanaryReadyVerifyingMessage
+	KeystoneAPICanaryReadyVerifyingMessage = "Canary rollout verifying, %s"
+
+	// KeystoneAPICanaryReadyRolledBackMessage
+	KeystoneAPICanaryReadyRolledBackMessage = "Canary rollout rolled back, %s"
+
+	// KeystoneAPICanaryReadyErrorMessage
+	KeystoneAPICanaryReadyErrorMessage = "Canary rollout error occured %s"
+
+	//
+	// CatalogInSync condition messages
+	//
+	// KeystoneCatalogInSyncInitMessage
+	KeystoneCatalogInSyncInitMessage = "Catalog not compared yet"
+
+	// KeystoneCatalogInSyncMessage
+	KeystoneCatalogInSyncMessage = "Catalog in sync"
+
+	// KeystoneCatalogInSyncCorrectedMessage
+	KeystoneCatalogInSyncCorrectedMessage = "Catalog in sync, corrected drift: %s"
+
+	// KeystoneCatalogInSyncDriftedMessage
+	KeystoneCatalogInSyncDriftedMessage = "Catalog drifted from the spec: %s"
+
+	//
+	// CatalogAuditReady condition messages
+	//
+	// KeystoneCatalogAuditReadyInitMessage
+	KeystoneCatalogAuditReadyInitMessage = "Catalog audit not started"
+
+	// KeystoneCatalogAuditReadyMessage
+	KeystoneCatalogAuditReadyMessage = "Catalog audit found %d orphaned services and %d orphaned endpoints"
+
+	// KeystoneCatalogAuditReadyPrunedMessage
+	KeystoneCatalogAuditReadyPrunedMessage = "Catalog audit pruned %d orphaned services and %d orphaned endpoints"
+
+	// KeystoneCatalogAuditReadyErrorMessage
+	KeystoneCatalogAuditReadyErrorMessage = "Catalog audit error occured %s"
+
+	//
+	// PasswordSynced condition messages
+	//
+	// KeystoneServicePasswordSyncedInitMessage
+	KeystoneServicePasswordSyncedInitMessage = "Service user password not applied"
+
+	// KeystoneServicePasswordSyncedMessage
+	KeystoneServicePasswordSyncedMessage = "Service user password applied"
+
+	// KeystoneServicePasswordSyncedErrorMessage
+	KeystoneServicePasswordSyncedErrorMessage = "Service user password error occured %s"
 )
@@ -17,9 +17,11 @@ package v1beta1
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/url"
 	"reflect"
+	"slices"
 	"time"
 
 	"github.com/gophercloud/gophercloud/v2"
@@ -38,6 +40,11 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 )
 
+// KeystoneAPINameLabel - label to select the KeystoneAPI a KeystoneService,
+// KeystoneEndpoint or any other keystone resource is registered in, when there
+// is more than one KeystoneAPI in the namespace
+const KeystoneAPINameLabel = "keystone.openstack.org/keystoneapi"
+
 // KeystoneAPIStatusChangedPredicate - primary purpose is to return true if
 // the KeystoneAPI Status.APIEndpoints has changed.
 // In addition also returns true if it gets deleted. Used by service operators
@@ -88,7 +95,7 @@ func GetKeystoneAPI(
 	}
 
 	if len(keystoneList.Items) > 1 {
-		return nil, fmt.Errorf("more then one KeystoneAPI object found in namespace %s", namespace)
+		return nil, fmt.Errorf("more then one KeystoneAPI object found in namespace %s, set the %s label to select one", namespace, KeystoneAPINameLabel)
 	}
 
 	if len(keystoneList.Items) == 0 {
@@ -117,6 +124,84 @@ func GetKeystoneAPIByName(
 	return keystoneAPI, nil
 }
 
+// GetKeystoneAPIForObject - get the keystoneAPI object obj uses. This is the
+// one named by the KeystoneAPINameLabel of obj or, without the label, the only
+// one in the namespace of obj.
+func GetKeystoneAPIForObject(
+	ctx context.Context,
+	h *helper.Helper,
+	obj client.Object,
+) (*KeystoneAPI, error) {
+	if name, ok := obj.GetLabels()[KeystoneAPINameLabel]; ok && name != "" {
+		return GetKeystoneAPIByName(ctx, h, name, obj.GetNamespace())
+	}
+
+	return GetKeystoneAPI(ctx, h, obj.GetNamespace(), map[string]string{})
+}
+
+// ErrKeystoneAPIRefNotAllowed - the referenced KeystoneAPI does not allow
+// references from the namespace of the resource
+var ErrKeystoneAPIRefNotAllowed = errors.New("KeystoneAPI does not allow references from the namespace")
+
+// AllowsRefFrom - returns true if resources of namespace may register in the
+// KeystoneAPI, see AllowedNamespaces
+func (instance KeystoneAPI) AllowsRefFrom(namespace string) bool {
+	return namespace == instance.Namespace || slices.Contains(instance.Spec.AllowedNamespaces, namespace)
+}
+
+// GetKeystoneAPIWithRef - get the keystoneAPI object referenced by ref or,
+// without a reference, the one obj uses, see GetKeystoneAPIForObject. A
+// KeystoneAPI of another namespace has to allow references from the namespace
+// of obj, else ErrKeystoneAPIRefNotAllowed gets returned.
+func GetKeystoneAPIWithRef(
+	ctx context.Context,
+	h *helper.Helper,
+	ref *KeystoneAPIRef,
+	obj client.Object,
+) (*KeystoneAPI, error) {
+	if ref != nil {
+		name := ref.GetNamespacedName(obj.GetNamespace())
+		keystoneAPI, err := GetKeystoneAPIByName(ctx, h, name.Name, name.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		if !keystoneAPI.AllowsRefFrom(obj.GetNamespace()) {
+			return nil, fmt.Errorf("%w: KeystoneAPI %s, namespace %s",
+				ErrKeystoneAPIRefNotAllowed, name, obj.GetNamespace())
+		}
+		return keystoneAPI, nil
+	}
+
+	return GetKeystoneAPIForObject(ctx, h, obj)
+}
+
+// getKeystoneAPISecretData - get the data of key from a Secret of the
+// keystoneAPI. The Secret gets read from the namespace of the keystoneAPI, which
+// is not the one of h when referenced from another namespace.
+func getKeystoneAPISecretData(
+	ctx context.Context,
+	h *helper.Helper,
+	keystoneAPI *KeystoneAPI,
+	secretName string,
+	key string,
+) (string, ctrl.Result, error) {
+	s, _, err := secret.GetSecret(ctx, h, secretName, keystoneAPI.Namespace)
+	if err != nil {
+		if k8s_errors.IsNotFound(err) {
+			h.GetLogger().Info(fmt.Sprintf("Secret %s not found, reconcile in 10s", secretName))
+			return "", ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+		}
+		return "", ctrl.Result{}, err
+	}
+
+	val, ok := s.Data[key]
+	if !ok {
+		return "", ctrl.Result{}, fmt.Errorf("%s not found in secret %s", key, secretName)
+	}
+
+	return string(val), ctrl.Result{}, nil
+}
+
 // GetAdminServiceClient - get a system scoped admin serviceClient for the keystoneAPI instance
 func GetAdminServiceClient(
 	ctx context.Context,
@@ -124,11 +209,11 @@ func GetAdminServiceClient(
 	keystoneAPI *KeystoneAPI,
 ) (*openstack.OpenStack, ctrl.Result, error) {
 	// Get the password of the admin user from Spec.Secret using PasswordSelectors.Admin
-	authPassword, ctrlResult, err := secret.GetDataFromSecret(
+	authPassword, ctrlResult, err := getKeystoneAPISecretData(
 		ctx,
 		h,
+		keystoneAPI,
 		keystoneAPI.Spec.Secret,
-		10*time.Second,
 		keystoneAPI.Spec.PasswordSelectors.Admin)
 	if err != nil {
 		return nil, ctrl.Result{}, err
@@ -184,11 +269,11 @@ func GetScopedClient(
 
 	tlsConfig := &openstack.TLSConfig{}
 	if parsedAuthURL.Scheme == "https" && keystoneAPI.Spec.TLS.CaBundleSecretName != "" {
-		caCert, ctrlResult, err := secret.GetDataFromSecret(
+		caCert, ctrlResult, err := getKeystoneAPISecretData(
 			ctx,
 			h,
+			keystoneAPI,
 			keystoneAPI.Spec.TLS.CaBundleSecretName,
-			10*time.Second,
 			tls.CABundleKey)
 		if err != nil {
 			return nil, ctrlResult, err
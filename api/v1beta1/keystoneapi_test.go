@@ -0,0 +1,98 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+func TestMinFernetActiveKeys(t *testing.T) {
+
+	tests := []struct {
+		name         string
+		spec         KeystoneAPISpecCore
+		allowExpired bool
+		want         int
+	}{
+		{
+			name:         "Defaults",
+			spec:         KeystoneAPISpecCore{},
+			allowExpired: true,
+			want:         5,
+		},
+		{
+			name:         "Defaults without allow expired window",
+			spec:         KeystoneAPISpecCore{},
+			allowExpired: false,
+			want:         3,
+		},
+		{
+			name: "Rotation more frequent than the token expiration",
+			spec: KeystoneAPISpecCore{
+				TokenExpiration:    ptr.To(int32(3 * 86400)),
+				AllowExpiredWindow: ptr.To(int32(0)),
+				FernetRotationDays: ptr.To(int32(1)),
+			},
+			allowExpired: true,
+			want:         5,
+		},
+		{
+			name: "Rotation less frequent than the token lifetime",
+			spec: KeystoneAPISpecCore{
+				FernetRotationDays: ptr.To(int32(7)),
+			},
+			allowExpired: true,
+			want:         3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(tt.spec.MinFernetActiveKeys(tt.allowExpired)).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestGetFernetMaxActiveKeys(t *testing.T) {
+	g := NewWithT(t)
+
+	spec := KeystoneAPISpecCore{FernetMaxActiveKeys: ptr.To(int32(7))}
+	g.Expect(spec.GetFernetMaxActiveKeys()).To(Equal(7))
+
+	spec.FernetMaxActiveKeys = ptr.To(int32(0))
+	spec.AllowExpiredWindow = ptr.To(int32(0))
+	g.Expect(spec.GetFernetMaxActiveKeys()).To(Equal(3))
+}
+
+func TestAllowsRefFrom(t *testing.T) {
+	g := NewWithT(t)
+
+	keystoneAPI := KeystoneAPI{
+		ObjectMeta: metav1.ObjectMeta{Name: "keystone", Namespace: "openstack"},
+	}
+	g.Expect(keystoneAPI.AllowsRefFrom("openstack")).To(BeTrue())
+	g.Expect(keystoneAPI.AllowsRefFrom("tenant")).To(BeFalse())
+
+	keystoneAPI.Spec.AllowedNamespaces = []string{"workload"}
+	g.Expect(keystoneAPI.AllowsRefFrom("workload")).To(BeTrue())
+	g.Expect(keystoneAPI.AllowsRefFrom("tenant")).To(BeFalse())
+}
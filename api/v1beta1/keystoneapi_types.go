@@ -42,9 +42,48 @@ const (
 	// BootstrapHash completed
 	BootstrapHash = "bootstrap"
 
+	// CredentialMigrateHash hash
+	CredentialMigrateHash = "credentialmigrate"
+
 	// FernetKeysHash completed
 	FernetKeysHash = "fernetkeys"
 
+	// DbExpandHash hash
+	DbExpandHash = "dbexpand"
+
+	// DbMigrateHash hash
+	DbMigrateHash = "dbmigrate"
+
+	// DbContractHash hash
+	DbContractHash = "dbcontract"
+
+	// UpgradeCheckHash hash
+	UpgradeCheckHash = "upgradecheck"
+
+	// DoctorHash hash
+	DoctorHash = "doctor"
+
+	// DefaultTokenExpiration - default token lifetime in seconds
+	DefaultTokenExpiration = 3600
+
+	// DefaultAllowExpiredWindow - default time in seconds expired tokens can be retrieved
+	DefaultAllowExpiredWindow = 172800
+
+	// DefaultFernetRotationDays - default fernet key rotation frequency
+	DefaultFernetRotationDays = 1
+
+	// TokenProviderFernet - fernet token provider
+	TokenProviderFernet = "fernet"
+
+	// TokenProviderJWS - JSON Web Signature token provider
+	TokenProviderJWS = "jws"
+
+	// RolloutStrategyRolling - changes get rolled out to all replicas
+	RolloutStrategyRolling = "Rolling"
+
+	// RolloutStrategyCanary - changes get verified with a canary replica first
+	RolloutStrategyCanary = "Canary"
+
 	// Container image fall-back defaults
 
 	// KeystoneAPIContainerImage is the fall-back container image for KeystoneAPI
@@ -87,6 +126,14 @@ type KeystoneAPISpecCore struct {
 	// Region - optional region name for the keystone service
 	Region string `json:"region"`
 
+	// +kubebuilder:validation:Optional
+	// AllowedNamespaces - namespaces whose KeystoneServices, KeystoneEndpoints
+	// and KeystoneApplicationCredentials may register in this KeystoneAPI with
+	// their keystoneAPIRef. Resources of the namespace of the KeystoneAPI are
+	// always allowed, the ones of any other namespace get refused unless it is
+	// listed, as they act with the admin credentials of this KeystoneAPI.
+	AllowedNamespaces []string `json:"allowedNamespaces,omitempty"`
+
 	// +kubebuilder:validation:Optional
 	// +kubebuilder:default=admin
 	// AdminProject - admin project name
@@ -137,10 +184,67 @@ type KeystoneAPISpecCore struct {
 
 	// +kubebuilder:validation:Optional
 	// +kubebuilder:default=5
-	// +kubebuilder:validation:Minimum=3
-	// FernetMaxActiveKeys - Maximum number of fernet token keys after rotation
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:XValidation:rule="self == 0 || self >= 3",message="fernetMaxActiveKeys must be 0 or at least 3"
+	// FernetMaxActiveKeys - Maximum number of fernet token keys after rotation.
+	// 0 computes the minimum number of keys which keeps the tokens valid for
+	// TokenExpiration and AllowExpiredWindow.
 	FernetMaxActiveKeys *int32 `json:"fernetMaxActiveKeys"`
 
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=1
+	// +kubebuilder:validation:Minimum=1
+	// ReceiptRotationDays - Rotate the fernet receipt keys, which sign the
+	// auth receipts of multi-factor logins, every X days
+	ReceiptRotationDays *int32 `json:"receiptRotationDays"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=3
+	// +kubebuilder:validation:Minimum=3
+	// ReceiptMaxActiveKeys - Maximum number of fernet receipt keys after rotation
+	ReceiptMaxActiveKeys *int32 `json:"receiptMaxActiveKeys"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=3600
+	// +kubebuilder:validation:Minimum=1
+	// TokenExpiration - Time in seconds a token stays valid
+	TokenExpiration *int32 `json:"tokenExpiration"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=172800
+	// +kubebuilder:validation:Minimum=0
+	// AllowExpiredWindow - Time in seconds an expired token can still be
+	// retrieved by services with allow_expired, e.g. to finish long running
+	// operations
+	AllowExpiredWindow *int32 `json:"allowExpiredWindow"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=fernet
+	// +kubebuilder:validation:Enum=fernet;jws
+	// TokenProvider - Token provider, fernet or jws. The ES256 key pairs jws
+	// tokens are signed with get rotated like the fernet keys, following
+	// FernetRotationDays and FernetMaxActiveKeys.
+	TokenProvider string `json:"tokenProvider"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=0
+	// +kubebuilder:validation:Minimum=0
+	// CredentialRotationDays - Rotate the credential encryption keys every X days
+	// and re-encrypt the stored credentials with the new key, 0 disables the rotation
+	CredentialRotationDays *int32 `json:"credentialRotationDays"`
+
+	// +kubebuilder:validation:Optional
+	// KeyImport - existing fernet and credential keys to import when the
+	// keystone secret holding the keys gets created, e.g. when adopting an
+	// existing keystone deployment. Once the secret exists it is ignored.
+	KeyImport *KeystoneKeyImport `json:"keyImport,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// TokenRevocation - replaces all fernet keys, or the JWS key pairs, which
+	// invalidates every issued token, and restarts the keystone pods. The
+	// revocation runs once for every new nonce.
+	TokenRevocation *KeystoneTokenRevocation `json:"tokenRevocation,omitempty"`
+
 	// +kubebuilder:validation:Optional
 	// +kubebuilder:default={admin: AdminPassword}
 	// PasswordSelectors - Selectors to identify the AdminUser password from the Secret
@@ -155,6 +259,29 @@ type KeystoneAPISpecCore struct {
 	// PreserveJobs - do not delete jobs after they finished e.g. to check logs
 	PreserveJobs bool `json:"preserveJobs"`
 
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=false
+	// IgnoreUpgradeCheckFailures - continue an upgrade to a new ContainerImage
+	// even if `keystone-status upgrade check` or `keystone-manage doctor`
+	// failed with the new image
+	IgnoreUpgradeCheckFailures bool `json:"ignoreUpgradeCheckFailures"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=Rolling
+	// +kubebuilder:validation:Enum=Rolling;Canary
+	// RolloutStrategy - Rolling rolls out changes of the config or the image
+	// to all replicas. Canary first runs a single replica with the change,
+	// which gets promoted once a token could be issued and validated through
+	// it, or rolled back after CanaryTimeout.
+	RolloutStrategy string `json:"rolloutStrategy"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=300
+	// +kubebuilder:validation:Minimum=1
+	// CanaryTimeout - seconds the canary replica has to pass the token check
+	// before it gets rolled back
+	CanaryTimeout *int32 `json:"canaryTimeout"`
+
 	// +kubebuilder:validation:Optional
 	// CustomServiceConfig - customize the service config using this parameter to change service defaults,
 	// or overwrite rendered information using raw OpenStack config format. The content gets added to
@@ -292,6 +419,213 @@ type KeystoneAPIStatus struct {
 
 	// Region - optional region name for the keystone service
 	Region string `json:"region,omitempty"`
+
+	// FernetKeys - rotation state of the fernet token keys
+	FernetKeys *KeyRotationStatus `json:"fernetKeys,omitempty"`
+
+	// CredentialKeys - rotation state of the credential encryption keys
+	CredentialKeys *KeyRotationStatus `json:"credentialKeys,omitempty"`
+
+	// ReceiptKeys - rotation state of the fernet receipt keys
+	ReceiptKeys *KeyRotationStatus `json:"receiptKeys,omitempty"`
+
+	// TokenRevocation - the last revocation of all tokens
+	TokenRevocation *TokenRevocationStatus `json:"tokenRevocation,omitempty"`
+
+	// ContainerImage - the image the database schema got synced for and the
+	// service runs with
+	ContainerImage string `json:"containerImage,omitempty"`
+
+	// DatabaseUpgrade - state of the last rolling upgrade of the database
+	DatabaseUpgrade *DatabaseUpgradeStatus `json:"databaseUpgrade,omitempty"`
+
+	// PromotedRevision - config and image the keystone Deployment runs with
+	// when the Canary rollout strategy is used
+	PromotedRevision *RolloutRevision `json:"promotedRevision,omitempty"`
+
+	// Canary - state of the last canary rollout
+	Canary *CanaryStatus `json:"canary,omitempty"`
+
+	// ResourceName - name the Deployment, the Jobs, the key Secret and the
+	// database are named after. This is the name of the KeystoneAPI, a
+	// KeystoneAPI deployed before the resources got named after it keeps
+	// the keystone names.
+	ResourceName string `json:"resourceName,omitempty"`
+}
+
+// RolloutRevision defines a config and image of the keystone pods
+type RolloutRevision struct {
+	// InputHash - hash of the config inputs
+	InputHash string `json:"inputHash,omitempty"`
+
+	// ContainerImage - the keystone image
+	ContainerImage string `json:"containerImage,omitempty"`
+}
+
+// CanaryPhase - phase of a canary rollout
+type CanaryPhase string
+
+const (
+	// CanaryVerifying - the canary replica runs and gets verified
+	CanaryVerifying CanaryPhase = "Verifying"
+
+	// CanaryPromoted - the canary passed and got rolled out to all replicas
+	CanaryPromoted CanaryPhase = "Promoted"
+
+	// CanaryRolledBack - the canary failed, the replicas keep the promoted revision
+	CanaryRolledBack CanaryPhase = "RolledBack"
+)
+
+// CanaryStatus defines the observed state of a canary rollout
+type CanaryStatus struct {
+	// Revision - the config and image of the canary
+	Revision RolloutRevision `json:"revision"`
+
+	// Phase - Verifying, Promoted or RolledBack
+	Phase CanaryPhase `json:"phase,omitempty"`
+
+	// StartedAt - time the canary got started
+	StartedAt *metav1.Time `json:"startedAt,omitempty"`
+
+	// CompletedAt - time the canary got promoted or rolled back
+	CompletedAt *metav1.Time `json:"completedAt,omitempty"`
+
+	// Message - why the canary got rolled back
+	Message string `json:"message,omitempty"`
+}
+
+// DatabaseUpgradePhase - phase of a rolling upgrade of the database
+type DatabaseUpgradePhase string
+
+const (
+	// DatabaseUpgradeCheck - the pre-upgrade checks run with the new image
+	DatabaseUpgradeCheck DatabaseUpgradePhase = "Check"
+
+	// DatabaseUpgradeExpand - db_sync --expand adds the new schema, the old
+	// pods keep serving
+	DatabaseUpgradeExpand DatabaseUpgradePhase = "Expand"
+
+	// DatabaseUpgradeRollout - the Deployment gets rolled to the new image
+	DatabaseUpgradeRollout DatabaseUpgradePhase = "Rollout"
+
+	// DatabaseUpgradeMigrate - db_sync --migrate migrates the data
+	DatabaseUpgradeMigrate DatabaseUpgradePhase = "Migrate"
+
+	// DatabaseUpgradeContract - db_sync --contract removes the old schema,
+	// once all pods run the new image
+	DatabaseUpgradeContract DatabaseUpgradePhase = "Contract"
+
+	// DatabaseUpgradeCompleted - the upgrade completed
+	DatabaseUpgradeCompleted DatabaseUpgradePhase = "Completed"
+)
+
+// DatabaseUpgradeStatus defines the observed state of a rolling upgrade of
+// the database, triggered by a change of the container image
+type DatabaseUpgradeStatus struct {
+	// Phase - the current phase of the upgrade
+	Phase DatabaseUpgradePhase `json:"phase,omitempty"`
+
+	// SourceImage - the image the upgrade started from
+	SourceImage string `json:"sourceImage,omitempty"`
+
+	// TargetImage - the image the upgrade is for
+	TargetImage string `json:"targetImage,omitempty"`
+
+	// StartedAt - time the upgrade started
+	StartedAt *metav1.Time `json:"startedAt,omitempty"`
+
+	// CompletedAt - time the upgrade completed
+	CompletedAt *metav1.Time `json:"completedAt,omitempty"`
+
+	// Checks - results of the pre-upgrade checks
+	Checks []UpgradeCheckStatus `json:"checks,omitempty"`
+}
+
+// UpgradeCheckResult - result of a pre-upgrade check
+type UpgradeCheckResult string
+
+const (
+	// UpgradeCheckPassed - the check passed, warnings included
+	UpgradeCheckPassed UpgradeCheckResult = "Passed"
+
+	// UpgradeCheckFailed - the check failed
+	UpgradeCheckFailed UpgradeCheckResult = "Failed"
+)
+
+// UpgradeCheckStatus defines the result of a pre-upgrade check
+type UpgradeCheckStatus struct {
+	// Name - the name of the check, upgrade-check or doctor
+	Name string `json:"name"`
+
+	// Result - Passed or Failed
+	Result UpgradeCheckResult `json:"result"`
+
+	// Message - the end of the output of a failed check
+	Message string `json:"message,omitempty"`
+}
+
+// KeystoneTokenRevocation defines a request to revoke all issued tokens
+type KeystoneTokenRevocation struct {
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	// Nonce - a value not used before triggers the revocation
+	Nonce string `json:"nonce"`
+
+	// +kubebuilder:validation:Optional
+	// Reason - why the tokens get revoked, recorded in the status and the Event
+	Reason string `json:"reason,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// RequestedBy - the user who requested the revocation. The webhook sets it
+	// from the user info of the admission request when the nonce changes and
+	// keeps it otherwise, a value provided by the client gets replaced
+	RequestedBy string `json:"requestedBy,omitempty"`
+}
+
+// TokenRevocationStatus defines the observed state of a revocation of all tokens
+type TokenRevocationStatus struct {
+	// Nonce - the nonce of the revocation
+	Nonce string `json:"nonce,omitempty"`
+
+	// Reason - why the tokens got revoked
+	Reason string `json:"reason,omitempty"`
+
+	// RequestedBy - the user who requested the revocation, as authenticated by
+	// the API server
+	RequestedBy string `json:"requestedBy,omitempty"`
+
+	// RevokedAt - time the keys got replaced
+	RevokedAt *metav1.Time `json:"revokedAt,omitempty"`
+}
+
+// KeystoneKeyImport defines the Secrets holding key repositories to import.
+// The keys of the Secrets are the file names of the key repository as created
+// by keystone-manage, e.g. created with
+// `oc create secret generic fernet-keys --from-file=/etc/keystone/fernet-keys/`
+type KeystoneKeyImport struct {
+	// +kubebuilder:validation:Optional
+	// FernetKeysSecret - Secret holding the fernet key repository, with at
+	// least two and at most FernetMaxActiveKeys keys
+	FernetKeysSecret string `json:"fernetKeysSecret,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// CredentialKeysSecret - Secret holding the credential key repository,
+	// with the staged and the primary key. A third key gets migrated away
+	// like during a credential key rotation.
+	CredentialKeysSecret string `json:"credentialKeysSecret,omitempty"`
+}
+
+// KeyRotationStatus defines the observed rotation state of a key repository
+type KeyRotationStatus struct {
+	// LastRotated - time the keys got rotated last
+	LastRotated *metav1.Time `json:"lastRotated,omitempty"`
+
+	// NextRotation - time the next rotation of the keys is due, not set if
+	// the rotation is disabled
+	NextRotation *metav1.Time `json:"nextRotation,omitempty"`
+
+	// ActiveKeys - number of active keys, including the primary and the staged key
+	ActiveKeys int32 `json:"activeKeys,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -398,3 +732,47 @@ func (instance *KeystoneAPISpecCore) ValidateTopology(
 func (instance *KeystoneAPI) GetRegion() string {
 	return instance.Status.Region
 }
+
+// IsDatabaseUpgradeInProgress - returns true if a rolling upgrade of the
+// database got started and did not complete yet
+func (instance KeystoneAPI) IsDatabaseUpgradeInProgress() bool {
+	return instance.Status.DatabaseUpgrade != nil &&
+		instance.Status.DatabaseUpgrade.Phase != DatabaseUpgradeCompleted
+}
+
+// MinFernetActiveKeys - returns the minimum number of fernet keys, so the
+// tokens do not outlive the key they got issued with, as documented in
+// https://docs.openstack.org/keystone/latest/admin/fernet-token-faq.html:
+// (token expiration + allow expired window) / rotation frequency + 2, for the
+// staged and the primary key. Without allowExpired the allow expired window is
+// not taken into account.
+func (spec *KeystoneAPISpecCore) MinFernetActiveKeys(allowExpired bool) int {
+	lifetime := int64(DefaultTokenExpiration)
+	if spec.TokenExpiration != nil {
+		lifetime = int64(*spec.TokenExpiration)
+	}
+	if allowExpired {
+		if spec.AllowExpiredWindow != nil {
+			lifetime += int64(*spec.AllowExpiredWindow)
+		} else {
+			lifetime += DefaultAllowExpiredWindow
+		}
+	}
+	rotationDays := int64(DefaultFernetRotationDays)
+	if spec.FernetRotationDays != nil && *spec.FernetRotationDays > 0 {
+		rotationDays = int64(*spec.FernetRotationDays)
+	}
+	rotation := rotationDays * 24 * 60 * 60
+
+	// keystone requires at least 3 keys
+	return max(3, int((lifetime+rotation-1)/rotation)+2)
+}
+
+// GetFernetMaxActiveKeys - returns the number of active fernet keys, computed
+// with MinFernetActiveKeys if FernetMaxActiveKeys is 0
+func (spec *KeystoneAPISpecCore) GetFernetMaxActiveKeys() int {
+	if spec.FernetMaxActiveKeys != nil && *spec.FernetMaxActiveKeys > 0 {
+		return int(*spec.FernetMaxActiveKeys)
+	}
+	return spec.MinFernetActiveKeys(true)
+}
@@ -131,6 +131,11 @@ func (spec *KeystoneAPISpecCore) ValidateCreate(basePath *field.Path, namespace
 	// Validate external Keystone API configuration
 	allErrs = append(allErrs, spec.ValidateExternalKeystoneAPI(basePath)...)
 
+	// Validate the fernet keys outlive the tokens
+	warnings, errs = spec.ValidateFernetMaxActiveKeys(basePath)
+	allWarns = append(allWarns, warnings...)
+	allErrs = append(allErrs, errs...)
+
 	return allWarns, allErrs
 }
 
@@ -184,6 +189,11 @@ func (spec *KeystoneAPISpecCore) ValidateUpdate(old KeystoneAPISpecCore, basePat
 	// Validate external Keystone API configuration
 	allErrs = append(allErrs, spec.ValidateExternalKeystoneAPI(basePath)...)
 
+	// Validate the fernet keys outlive the tokens
+	warnings, errs = spec.ValidateFernetMaxActiveKeys(basePath)
+	allWarns = append(allWarns, warnings...)
+	allErrs = append(allErrs, errs...)
+
 	return allWarns, allErrs
 }
 
@@ -195,6 +205,35 @@ func (r *KeystoneAPI) ValidateDelete() (admission.Warnings, error) {
 	return nil, nil
 }
 
+// ValidateFernetMaxActiveKeys validates an explicit FernetMaxActiveKeys
+// against the token lifetime. Fewer keys than required for the token
+// expiration invalidate tokens before they expire and get rejected, fewer keys
+// than required for the allow expired window only affect the retrieval of
+// expired tokens and get a warning.
+func (spec *KeystoneAPISpecCore) ValidateFernetMaxActiveKeys(basePath *field.Path) ([]string, field.ErrorList) {
+	var allErrs field.ErrorList
+	var allWarns []string
+
+	if spec.FernetMaxActiveKeys == nil || *spec.FernetMaxActiveKeys == 0 {
+		return allWarns, allErrs
+	}
+	fieldPath := basePath.Child("fernetMaxActiveKeys")
+	keys := int(*spec.FernetMaxActiveKeys)
+
+	if minKeys := spec.MinFernetActiveKeys(false); keys < minKeys {
+		allErrs = append(allErrs, field.Invalid(
+			fieldPath, keys,
+			fmt.Sprintf("tokens outlive the fernet keys, at least %d keys are required for tokenExpiration and fernetRotationDays, "+
+				"or set 0 to compute the number of keys", minKeys)))
+	} else if minKeys := spec.MinFernetActiveKeys(true); keys < minKeys {
+		allWarns = append(allWarns, fmt.Sprintf(
+			"%s: expired tokens outlive the fernet keys, at least %d keys are required for tokenExpiration, "+
+				"allowExpiredWindow and fernetRotationDays", fieldPath.String(), minKeys))
+	}
+
+	return allWarns, allErrs
+}
+
 // ValidateExternalKeystoneAPI validates the external Keystone API configuration
 func (spec *KeystoneAPISpecCore) ValidateExternalKeystoneAPI(basePath *field.Path) field.ErrorList {
 	var allErrs field.ErrorList
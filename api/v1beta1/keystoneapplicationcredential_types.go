@@ -63,6 +63,12 @@ type KeystoneApplicationCredentialSpec struct {
 	// AccessRules defines which services the ApplicationCredential is permitted to access
 	// +kubebuilder:validation:Optional
 	AccessRules []ACRule `json:"accessRules,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// KeystoneAPIRef - KeystoneAPI to register in, by default the one in the
+	// namespace selected by the keystone.openstack.org/keystoneapi label, or
+	// the only one in the namespace
+	KeystoneAPIRef *KeystoneAPIRef `json:"keystoneAPIRef,omitempty"`
 }
 
 // ACRule defines an access rule for an ApplicationCredential
@@ -117,6 +123,10 @@ type KeystoneApplicationCredentialStatus struct {
 	// SecurityHash tracks the hash of security-critical spec fields (roles, accessRules, unrestricted).
 	// Used to detect when these fields change and trigger immediate rotation.
 	SecurityHash string `json:"securityHash,omitempty"`
+
+	// KeystoneAPI - namespace/name of the KeystoneAPI the ApplicationCredential
+	// got created in
+	KeystoneAPI string `json:"keystoneAPI,omitempty"`
 }
 
 //+kubebuilder:object:root=true
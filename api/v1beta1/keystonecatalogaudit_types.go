@@ -0,0 +1,132 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// KeystoneCatalogAuditSpec defines the desired state of KeystoneCatalogAudit
+type KeystoneCatalogAuditSpec struct {
+	// +kubebuilder:validation:Optional
+	// KeystoneAPIRef - the KeystoneAPI whose catalog gets audited. If not set,
+	// the KeystoneAPI of the namespace selected by the
+	// keystone.openstack.org/keystoneapi label, or the only one in the namespace
+	KeystoneAPIRef *KeystoneAPIRef `json:"keystoneAPIRef,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=false
+	// Prune - delete the orphaned services and endpoints from the catalog. If
+	// not set, the orphans only get reported in the status.
+	Prune bool `json:"prune"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=3600
+	// +kubebuilder:validation:Minimum=0
+	// AuditInterval - interval in seconds the catalog gets audited, 0 audits
+	// the catalog only when the spec changes
+	AuditInterval *int32 `json:"auditInterval,omitempty"`
+}
+
+// CatalogAuditService - a service of the catalog no KeystoneService owns
+type CatalogAuditService struct {
+	// ID - the ID of the service in keystone
+	ID string `json:"id"`
+
+	// Name - the name of the service
+	Name string `json:"name,omitempty"`
+
+	// Type - the type of the service
+	Type string `json:"type"`
+}
+
+// CatalogAuditEndpoint - an endpoint of the catalog no KeystoneEndpoint owns
+type CatalogAuditEndpoint struct {
+	// ID - the ID of the endpoint in keystone
+	ID string `json:"id"`
+
+	// ServiceID - the ID of the service of the endpoint
+	ServiceID string `json:"serviceID"`
+
+	// Interface - the interface of the endpoint, admin, internal or public
+	Interface string `json:"interface"`
+
+	// URL - the URL of the endpoint
+	URL string `json:"url"`
+
+	// Region - the region of the endpoint
+	Region string `json:"region,omitempty"`
+}
+
+// KeystoneCatalogAuditStatus defines the observed state of KeystoneCatalogAudit
+type KeystoneCatalogAuditStatus struct {
+	// OrphanedServices - services of the catalog no KeystoneService owns
+	OrphanedServices []CatalogAuditService `json:"orphanedServices,omitempty"`
+
+	// OrphanedEndpoints - endpoints of the catalog no KeystoneEndpoint owns
+	OrphanedEndpoints []CatalogAuditEndpoint `json:"orphanedEndpoints,omitempty"`
+
+	// PrunedServices - orphaned services the last audit deleted
+	PrunedServices []CatalogAuditService `json:"prunedServices,omitempty"`
+
+	// PrunedEndpoints - orphaned endpoints the last audit deleted
+	PrunedEndpoints []CatalogAuditEndpoint `json:"prunedEndpoints,omitempty"`
+
+	// LastAuditTime - time of the last completed audit
+	LastAuditTime *metav1.Time `json:"lastAuditTime,omitempty"`
+
+	// Conditions
+	Conditions condition.Conditions `json:"conditions,omitempty" optional:"true"`
+
+	// ObservedGeneration - the generation of the spec the last completed audit
+	// ran with
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Prune",type="boolean",JSONPath=".spec.prune",description="Prune orphans"
+//+kubebuilder:printcolumn:name="LastAudit",type="date",JSONPath=".status.lastAuditTime",description="Last audit"
+//+kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.conditions[0].status",description="Status"
+//+kubebuilder:printcolumn:name="Message",type="string",JSONPath=".status.conditions[0].message",description="Message"
+
+// KeystoneCatalogAudit is the Schema for the keystonecatalogaudits API
+type KeystoneCatalogAudit struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   KeystoneCatalogAuditSpec   `json:"spec,omitempty"`
+	Status KeystoneCatalogAuditStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// KeystoneCatalogAuditList contains a list of KeystoneCatalogAudit
+type KeystoneCatalogAuditList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []KeystoneCatalogAudit `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&KeystoneCatalogAudit{}, &KeystoneCatalogAuditList{})
+}
+
+// IsReady - returns true if KeystoneCatalogAudit is reconciled successfully
+func (instance KeystoneCatalogAudit) IsReady() bool {
+	return instance.Status.Conditions.IsTrue(condition.ReadyCondition)
+}
@@ -0,0 +1,197 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// KeystoneDomainSpec defines the desired state of KeystoneDomain
+type KeystoneDomainSpec struct {
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern=`^[a-zA-Z0-9_.-]+$`
+	// DomainName - Name of the domain in keystone. It is also used to name the
+	// domain specific config file keystone.<domainName>.conf, therefore it is
+	// restricted to characters valid in a file name.
+	DomainName string `json:"domainName"`
+
+	// +kubebuilder:validation:Optional
+	// Description - Description for the domain.
+	Description string `json:"description,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=true
+	// Enabled - whether or not the domain is enabled.
+	Enabled bool `json:"enabled"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=false
+	// Adopt - take over a domain with the DomainName which already exists in
+	// keystone. Without it the domain is not touched and the KeystoneDomain
+	// reports an error. An adopted domain is never deleted from keystone when
+	// the KeystoneDomain gets deleted.
+	Adopt bool `json:"adopt"`
+
+	// +kubebuilder:validation:Optional
+	// LDAP - configures the domain to use an LDAP identity backend. The rendered
+	// config gets mounted into /etc/keystone/domains of the keystone-api pods.
+	// If not set, the domain uses the default SQL identity backend.
+	LDAP *KeystoneDomainLDAP `json:"ldap,omitempty"`
+}
+
+// KeystoneDomainLDAP defines the [ldap] section of the domain specific config
+type KeystoneDomainLDAP struct {
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	// URL - URL(s) of the LDAP server(s), comma separated, e.g. ldaps://ldap.example.com
+	URL string `json:"url"`
+
+	// +kubebuilder:validation:Optional
+	// User - DN used to bind to the LDAP server
+	User string `json:"user,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Secret containing the password of the bind User
+	Secret string `json:"secret,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=LDAPBindPassword
+	// PasswordSelector - Selector to get the bind password from the Secret
+	PasswordSelector string `json:"passwordSelector"`
+
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	// Suffix - default LDAP suffix, e.g. dc=example,dc=com
+	Suffix string `json:"suffix"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=one;sub
+	// QueryScope - search scope, one level (one) or subtree (sub)
+	QueryScope string `json:"queryScope,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=false
+	// UseTLS - enable StartTLS on the connection to the LDAP server
+	UseTLS bool `json:"useTLS"`
+
+	// +kubebuilder:validation:Optional
+	// UserTreeDN - search base for users, e.g. ou=Users,dc=example,dc=com
+	UserTreeDN string `json:"userTreeDN,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// UserFilter - LDAP search filter for users
+	UserFilter string `json:"userFilter,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// UserObjectClass - LDAP object class for users, e.g. inetOrgPerson
+	UserObjectClass string `json:"userObjectClass,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// UserIDAttribute - LDAP attribute mapped to the user ID, e.g. uid
+	UserIDAttribute string `json:"userIDAttribute,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// UserNameAttribute - LDAP attribute mapped to the user name, e.g. uid
+	UserNameAttribute string `json:"userNameAttribute,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// UserMailAttribute - LDAP attribute mapped to the user email, e.g. mail
+	UserMailAttribute string `json:"userMailAttribute,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// UserEnabledAttribute - LDAP attribute mapped to the user enabled flag
+	UserEnabledAttribute string `json:"userEnabledAttribute,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// GroupTreeDN - search base for groups, e.g. ou=Groups,dc=example,dc=com
+	GroupTreeDN string `json:"groupTreeDN,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// GroupFilter - LDAP search filter for groups
+	GroupFilter string `json:"groupFilter,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// GroupObjectClass - LDAP object class for groups, e.g. groupOfNames
+	GroupObjectClass string `json:"groupObjectClass,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// GroupIDAttribute - LDAP attribute mapped to the group ID, e.g. cn
+	GroupIDAttribute string `json:"groupIDAttribute,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// GroupNameAttribute - LDAP attribute mapped to the group name, e.g. cn
+	GroupNameAttribute string `json:"groupNameAttribute,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// GroupMemberAttribute - LDAP attribute listing the group members, e.g. member
+	GroupMemberAttribute string `json:"groupMemberAttribute,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// CustomConfig - additional options in raw OpenStack config format which get
+	// appended to the rendered domain specific config, e.g. [ldap] tls_cacertfile
+	CustomConfig string `json:"customConfig,omitempty"`
+}
+
+// KeystoneDomainStatus defines the observed state of KeystoneDomain
+type KeystoneDomainStatus struct {
+	// DomainID - the ID of the domain in keystone
+	DomainID string `json:"domainID,omitempty"`
+
+	// Adopted - the domain existed in keystone before and got adopted, it is
+	// left in place when the KeystoneDomain gets deleted
+	Adopted bool `json:"adopted,omitempty"`
+
+	// Conditions
+	Conditions condition.Conditions `json:"conditions,omitempty" optional:"true"`
+
+	// ObservedGeneration - the most recent generation observed for this domain.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Domain",type="string",JSONPath=".spec.domainName",description="Domain name"
+//+kubebuilder:printcolumn:name="DomainID",type="string",JSONPath=".status.domainID",description="Keystone domain ID"
+//+kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.conditions[0].status",description="Status"
+//+kubebuilder:printcolumn:name="Message",type="string",JSONPath=".status.conditions[0].message",description="Message"
+
+// KeystoneDomain is the Schema for the keystonedomains API
+type KeystoneDomain struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   KeystoneDomainSpec   `json:"spec,omitempty"`
+	Status KeystoneDomainStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// KeystoneDomainList contains a list of KeystoneDomain
+type KeystoneDomainList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []KeystoneDomain `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&KeystoneDomain{}, &KeystoneDomainList{})
+}
+
+// IsReady - returns true if KeystoneDomain is reconciled successfully
+func (instance KeystoneDomain) IsReady() bool {
+	return instance.Status.Conditions.IsTrue(condition.ReadyCondition)
+}
@@ -310,3 +310,69 @@ func TestFilterVisibility(t *testing.T) {
 		})
 	}
 }
+
+func TestGetEndpointEntries(t *testing.T) {
+
+	tests := []struct {
+		name string
+		spec KeystoneEndpointSpec
+		want map[string]EndpointEntry
+	}{
+		{
+			name: "No endpoints",
+			spec: KeystoneEndpointSpec{},
+			want: map[string]EndpointEntry{},
+		},
+		{
+			name: "Endpoints only",
+			spec: KeystoneEndpointSpec{
+				Endpoints: map[string]string{
+					"internal": "endpt-internal",
+					"public":   "endpt-public",
+				},
+			},
+			want: map[string]EndpointEntry{
+				"internal": {URL: "endpt-internal"},
+				"public":   {URL: "endpt-public"},
+			},
+		},
+		{
+			name: "EndpointEntries replace Endpoints",
+			spec: KeystoneEndpointSpec{
+				Endpoints: map[string]string{
+					"internal": "endpt-internal",
+					"public":   "endpt-public",
+				},
+				EndpointEntries: map[string]EndpointEntry{
+					"public": {URL: "endpt-public-two", Region: "regionTwo", Enabled: ptr.To(false)},
+					"admin":  {URL: "endpt-admin"},
+				},
+			},
+			want: map[string]EndpointEntry{
+				"internal": {URL: "endpt-internal"},
+				"public":   {URL: "endpt-public-two", Region: "regionTwo", Enabled: ptr.To(false)},
+				"admin":    {URL: "endpt-admin"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(tt.spec.GetEndpointEntries()).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestEndpointEntry(t *testing.T) {
+	g := NewWithT(t)
+
+	entry := EndpointEntry{URL: "endpt-public"}
+	g.Expect(entry.IsEnabled()).To(BeTrue())
+	g.Expect(entry.GetRegion("regionOne")).To(Equal("regionOne"))
+
+	entry = EndpointEntry{URL: "endpt-public", Region: "regionTwo", Enabled: ptr.To(false)}
+	g.Expect(entry.IsEnabled()).To(BeFalse())
+	g.Expect(entry.GetRegion("regionOne")).To(Equal("regionTwo"))
+}
@@ -22,15 +22,93 @@ import (
 )
 
 // KeystoneEndpointSpec defines the desired state of KeystoneEndpoint
+// +kubebuilder:validation:XValidation:rule="has(self.endpoints) || has(self.endpointEntries)",message="endpoints or endpointEntries must be set"
 type KeystoneEndpointSpec struct {
 	// +kubebuilder:validation:Required
 	// ServiceName - Name of the service to create the endpoint for
 	ServiceName string `json:"serviceName"`
-	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Optional
 	// Endpoints - map with service api endpoint URLs with the endpoint type as index
-	Endpoints map[string]string `json:"endpoints"`
+	Endpoints map[string]string `json:"endpoints,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// EndpointEntries - map with service api endpoints with the endpoint type
+	// as index, which can set the region of the endpoint or disable it. An
+	// entry replaces the URL of the same endpoint type in Endpoints.
+	EndpointEntries map[string]EndpointEntry `json:"endpointEntries,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// KeystoneAPIRef - KeystoneAPI to register in, by default the one in the
+	// namespace selected by the keystone.openstack.org/keystoneapi label, or
+	// the only one in the namespace
+	KeystoneAPIRef *KeystoneAPIRef `json:"keystoneAPIRef,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=Correct
+	// +kubebuilder:validation:Enum=Correct;Report
+	// DriftPolicy - whether endpoints changed or deleted in keystone, e.g. with
+	// the openstack CLI, get changed back to match the spec or the drift only
+	// gets reported in the CatalogInSync condition
+	DriftPolicy DriftPolicy `json:"driftPolicy,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=300
+	// +kubebuilder:validation:Minimum=0
+	// ResyncInterval - interval in seconds the endpoints registered in keystone
+	// get compared against the spec, 0 disables the periodic resync
+	ResyncInterval *int32 `json:"resyncInterval,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=Fail
+	// +kubebuilder:validation:Enum=Fail;Dedupe
+	// DuplicatePolicy - what happens when keystone has several endpoints
+	// registered for the service and an interface. Fail stops reconciling
+	// until they got cleaned up manually, Dedupe keeps the endpoint with the
+	// URL of the spec, or else the oldest one, and deletes the others.
+	DuplicatePolicy DuplicatePolicy `json:"duplicatePolicy,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// AdoptServiceID - ID of the existing service in keystone the endpoints
+	// belong to. The endpoints only get registered once the KeystoneService
+	// registered or adopted the service with this ID.
+	AdoptServiceID string `json:"adoptServiceID,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// AdoptEndpointIDs - IDs of existing endpoints in keystone per endpoint
+	// type to take over instead of looking them up by service, interface and
+	// region, e.g. when moving an existing cloud under the operator
+	AdoptEndpointIDs map[string]string `json:"adoptEndpointIDs,omitempty"`
 }
 
+// EndpointEntry - a service api endpoint
+type EndpointEntry struct {
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	// URL - endpoint url
+	URL string `json:"url"`
+
+	// +kubebuilder:validation:Optional
+	// Region - region the endpoint gets registered in, by default the region
+	// of the KeystoneAPI
+	Region string `json:"region,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=true
+	// Enabled - whether the endpoint is enabled
+	Enabled *bool `json:"enabled,omitempty"`
+}
+
+// DuplicatePolicy - defines how duplicate endpoints registered for the
+// service and an interface get resolved
+type DuplicatePolicy string
+
+const (
+	// DuplicatePolicyFail - duplicate endpoints are an error
+	DuplicatePolicyFail DuplicatePolicy = "Fail"
+	// DuplicatePolicyDedupe - duplicate endpoints get deleted
+	DuplicatePolicyDedupe DuplicatePolicy = "Dedupe"
+)
+
 // KeystoneEndpointStatus defines the observed state of KeystoneEndpoint
 type KeystoneEndpointStatus struct {
 	EndpointIDs map[string]string `json:"endpointIDs,omitempty"`
@@ -41,8 +119,24 @@ type KeystoneEndpointStatus struct {
 	// Endpoints - current status of latest configured endpoints for the service
 	Endpoints []Endpoint `json:"endpoints,omitempty"`
 
+	// RemovedDuplicates - the last duplicate endpoints which got deleted with
+	// the Dedupe duplicate policy
+	RemovedDuplicates []Endpoint `json:"removedDuplicates,omitempty"`
+
+	// RemovedDuplicatesCount - the number of duplicate endpoints which got
+	// deleted with the Dedupe duplicate policy
+	RemovedDuplicatesCount int `json:"removedDuplicatesCount,omitempty"`
+
+	// AdoptedEndpointIDs - IDs of the existing endpoints which got adopted
+	// per endpoint type
+	AdoptedEndpointIDs map[string]string `json:"adoptedEndpointIDs,omitempty"`
+
 	//ObservedGeneration - the most recent generation observed for this service. If the observed generation is less than the spec generation, then the controller has not processed the latest changes.
 	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// KeystoneAPI - namespace/name of the KeystoneAPI the endpoints got
+	// registered in
+	KeystoneAPI string `json:"keystoneAPI,omitempty"`
 }
 
 // Endpoint -
@@ -53,6 +147,10 @@ type Endpoint struct {
 	URL string `json:"url"`
 	// ID - endpoint id
 	ID string `json:"id"`
+	// Region - region of the endpoint
+	Region string `json:"region,omitempty"`
+	// Enabled - whether the endpoint is enabled
+	Enabled bool `json:"enabled"`
 }
 
 //+kubebuilder:object:root=true
@@ -86,3 +184,30 @@ func init() {
 func (instance KeystoneEndpoint) IsReady() bool {
 	return instance.Status.Conditions.IsTrue(condition.ReadyCondition)
 }
+
+// GetEndpointEntries - returns the endpoints of Endpoints and EndpointEntries
+// with the endpoint type as index, an entry of EndpointEntries replaces the
+// URL of the same endpoint type in Endpoints
+func (spec KeystoneEndpointSpec) GetEndpointEntries() map[string]EndpointEntry {
+	entries := make(map[string]EndpointEntry, len(spec.Endpoints)+len(spec.EndpointEntries))
+	for endpointType, url := range spec.Endpoints {
+		entries[endpointType] = EndpointEntry{URL: url}
+	}
+	for endpointType, entry := range spec.EndpointEntries {
+		entries[endpointType] = entry
+	}
+	return entries
+}
+
+// IsEnabled - returns true if the endpoint is enabled, which it is by default
+func (entry EndpointEntry) IsEnabled() bool {
+	return entry.Enabled == nil || *entry.Enabled
+}
+
+// GetRegion - returns the region of the endpoint, defaultRegion if not set
+func (entry EndpointEntry) GetRegion(defaultRegion string) string {
+	if entry.Region == "" {
+		return defaultRegion
+	}
+	return entry.Region
+}
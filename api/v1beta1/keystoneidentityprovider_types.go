@@ -0,0 +1,233 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// KeystoneIdentityProviderSpec defines the desired state of KeystoneIdentityProvider
+// +kubebuilder:validation:XValidation:rule="!(has(self.oidc) && has(self.saml))",message="only one of oidc or saml can be set"
+type KeystoneIdentityProviderSpec struct {
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern=`^[a-zA-Z0-9_.-]+$`
+	// IdentityProviderID - ID of the identity provider in keystone. It is part
+	// of the federation URLs and of the generated config file names.
+	IdentityProviderID string `json:"identityProviderID"`
+
+	// +kubebuilder:validation:Optional
+	// Description - Description for the identity provider.
+	Description string `json:"description,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=true
+	// Enabled - whether or not the identity provider is enabled.
+	Enabled bool `json:"enabled"`
+
+	// +kubebuilder:validation:Optional
+	// DomainName - Name of the domain federated users get created in. If not
+	// set, keystone creates a dedicated domain for the identity provider.
+	DomainName string `json:"domainName,omitempty"`
+
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinItems=1
+	// RemoteIDs - IDs the identity provider is known by, the OIDC issuer or
+	// the SAML entityID.
+	RemoteIDs []string `json:"remoteIDs"`
+
+	// +kubebuilder:validation:Optional
+	// OIDC - generates the mod_auth_openidc config for the identity provider
+	OIDC *KeystoneIdentityProviderOIDC `json:"oidc,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// SAML - generates the mod_auth_mellon config for the identity provider
+	SAML *KeystoneIdentityProviderSAML `json:"saml,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=Delete
+	// +kubebuilder:validation:Enum=Delete;Retain
+	// DeletionPolicy - Delete removes the identity provider from keystone when
+	// the CR gets deleted, Retain keeps it.
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy"`
+}
+
+// KeystoneIdentityProviderOIDC defines the mod_auth_openidc config
+type KeystoneIdentityProviderOIDC struct {
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	// ProviderMetadataURL - URL of the OpenID Connect discovery document,
+	// e.g. https://idp.example.com/.well-known/openid-configuration
+	ProviderMetadataURL string `json:"providerMetadataURL"`
+
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	// ClientID - client ID of keystone registered in the identity provider
+	ClientID string `json:"clientID"`
+
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	// Secret containing the client secret and the crypto passphrase
+	Secret string `json:"secret"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=ClientSecret
+	// ClientSecretSelector - Selector to get the client secret from the Secret
+	ClientSecretSelector string `json:"clientSecretSelector"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=CryptoPassphrase
+	// CryptoPassphraseSelector - Selector to get the passphrase mod_auth_openidc
+	// encrypts its state and session cookies with from the Secret
+	CryptoPassphraseSelector string `json:"cryptoPassphraseSelector"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default={"openid","email","profile"}
+	// Scopes - scopes requested from the identity provider
+	Scopes []string `json:"scopes"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=code
+	// ResponseType - OpenID Connect response type
+	ResponseType string `json:"responseType"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=sub
+	// RemoteUserClaim - claim used to set REMOTE_USER
+	RemoteUserClaim string `json:"remoteUserClaim"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=OIDC-
+	// ClaimPrefix - prefix of the claims passed on to keystone
+	ClaimPrefix string `json:"claimPrefix"`
+
+	// +kubebuilder:validation:Optional
+	// IntrospectionEndpoint - OAuth 2.0 token introspection endpoint of the
+	// identity provider. If set, the auth endpoint also accepts bearer tokens,
+	// which is required for CLI access.
+	IntrospectionEndpoint string `json:"introspectionEndpoint,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// CustomConfig - additional httpd directives appended to the generated config
+	CustomConfig string `json:"customConfig,omitempty"`
+}
+
+// KeystoneIdentityProviderSAML defines the mod_auth_mellon config
+type KeystoneIdentityProviderSAML struct {
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	// Secret containing the SP private key and certificate and the metadata
+	// of the identity provider
+	Secret string `json:"secret"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=sp-key.pem
+	// SPPrivateKeySelector - Selector to get the SP private key from the Secret
+	SPPrivateKeySelector string `json:"spPrivateKeySelector"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=sp-cert.pem
+	// SPCertificateSelector - Selector to get the SP certificate from the Secret
+	SPCertificateSelector string `json:"spCertificateSelector"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=idp-metadata.xml
+	// IdPMetadataSelector - Selector to get the identity provider metadata from the Secret
+	IdPMetadataSelector string `json:"idpMetadataSelector"`
+
+	// +kubebuilder:validation:Optional
+	// CustomConfig - additional httpd directives appended to the generated config
+	CustomConfig string `json:"customConfig,omitempty"`
+}
+
+// KeystoneIdentityProviderStatus defines the observed state of KeystoneIdentityProvider
+type KeystoneIdentityProviderStatus struct {
+	// IdentityProviderID - the ID of the identity provider in keystone
+	IdentityProviderID string `json:"identityProviderID,omitempty"`
+
+	// DomainID - the ID of the domain federated users get created in
+	DomainID string `json:"domainID,omitempty"`
+
+	// Conditions
+	Conditions condition.Conditions `json:"conditions,omitempty" optional:"true"`
+
+	// ObservedGeneration - the most recent generation observed for this identity provider.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="IdentityProvider",type="string",JSONPath=".spec.identityProviderID",description="Identity provider ID"
+//+kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.conditions[0].status",description="Status"
+//+kubebuilder:printcolumn:name="Message",type="string",JSONPath=".status.conditions[0].message",description="Message"
+
+// KeystoneIdentityProvider is the Schema for the keystoneidentityproviders API
+type KeystoneIdentityProvider struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   KeystoneIdentityProviderSpec   `json:"spec,omitempty"`
+	Status KeystoneIdentityProviderStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// KeystoneIdentityProviderList contains a list of KeystoneIdentityProvider
+type KeystoneIdentityProviderList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []KeystoneIdentityProvider `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&KeystoneIdentityProvider{}, &KeystoneIdentityProviderList{})
+}
+
+// IsReady - returns true if KeystoneIdentityProvider is reconciled successfully
+func (instance KeystoneIdentityProvider) IsReady() bool {
+	return instance.Status.Conditions.IsTrue(condition.ReadyCondition)
+}
+
+// GetSecretName - returns the name of the Secret referenced by the OIDC or
+// SAML config, empty if none is set
+func (instance KeystoneIdentityProvider) GetSecretName() string {
+	if instance.Spec.OIDC != nil {
+		return instance.Spec.OIDC.Secret
+	}
+	if instance.Spec.SAML != nil {
+		return instance.Spec.SAML.Secret
+	}
+	return ""
+}
+
+// GetSecretSelectors - returns the keys expected in the Secret referenced by
+// the OIDC or SAML config
+func (instance KeystoneIdentityProvider) GetSecretSelectors() []string {
+	if instance.Spec.OIDC != nil {
+		return []string{
+			instance.Spec.OIDC.ClientSecretSelector,
+			instance.Spec.OIDC.CryptoPassphraseSelector,
+		}
+	}
+	if instance.Spec.SAML != nil {
+		return []string{
+			instance.Spec.SAML.SPPrivateKeySelector,
+			instance.Spec.SAML.SPCertificateSelector,
+			instance.Spec.SAML.IdPMetadataSelector,
+		}
+	}
+	return nil
+}
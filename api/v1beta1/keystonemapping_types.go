@@ -0,0 +1,87 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// KeystoneMappingSpec defines the desired state of KeystoneMapping
+type KeystoneMappingSpec struct {
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern=`^[a-zA-Z0-9_.-]+$`
+	// MappingID - ID of the mapping in keystone.
+	MappingID string `json:"mappingID"`
+
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	// Rules - the mapping rules as JSON list, in the format documented in
+	// https://docs.openstack.org/keystone/latest/admin/federation/mapping_combinations.html
+	Rules string `json:"rules"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=Delete
+	// +kubebuilder:validation:Enum=Delete;Retain
+	// DeletionPolicy - Delete removes the mapping from keystone when the CR
+	// gets deleted, Retain keeps it.
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy"`
+}
+
+// KeystoneMappingStatus defines the observed state of KeystoneMapping
+type KeystoneMappingStatus struct {
+	// MappingID - the ID of the mapping in keystone
+	MappingID string `json:"mappingID,omitempty"`
+
+	// Conditions
+	Conditions condition.Conditions `json:"conditions,omitempty" optional:"true"`
+
+	// ObservedGeneration - the most recent generation observed for this mapping.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Mapping",type="string",JSONPath=".spec.mappingID",description="Mapping ID"
+//+kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.conditions[0].status",description="Status"
+//+kubebuilder:printcolumn:name="Message",type="string",JSONPath=".status.conditions[0].message",description="Message"
+
+// KeystoneMapping is the Schema for the keystonemappings API
+type KeystoneMapping struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   KeystoneMappingSpec   `json:"spec,omitempty"`
+	Status KeystoneMappingStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// KeystoneMappingList contains a list of KeystoneMapping
+type KeystoneMappingList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []KeystoneMapping `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&KeystoneMapping{}, &KeystoneMappingList{})
+}
+
+// IsReady - returns true if KeystoneMapping is reconciled successfully
+func (instance KeystoneMapping) IsReady() bool {
+	return instance.Status.Conditions.IsTrue(condition.ReadyCondition)
+}
@@ -0,0 +1,111 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// KeystoneProjectSpec defines the desired state of KeystoneProject
+type KeystoneProjectSpec struct {
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	// ProjectName - Name of the project in keystone.
+	ProjectName string `json:"projectName"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=Default
+	// DomainName - Name of the domain the project belongs to.
+	DomainName string `json:"domainName"`
+
+	// +kubebuilder:validation:Optional
+	// Description - Description for the project.
+	Description string `json:"description,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=true
+	// Enabled - whether or not the project is enabled.
+	Enabled bool `json:"enabled"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=false
+	// Adopt - take over a project with the ProjectName which already exists in
+	// keystone. Without it the project is not touched and the KeystoneProject
+	// reports an error. An adopted project is never deleted from keystone,
+	// regardless of the DeletionPolicy.
+	Adopt bool `json:"adopt"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=Delete
+	// +kubebuilder:validation:Enum=Delete;Retain
+	// DeletionPolicy - Delete removes the project from keystone when the CR gets
+	// deleted, Retain keeps it.
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy"`
+}
+
+// KeystoneProjectStatus defines the observed state of KeystoneProject
+type KeystoneProjectStatus struct {
+	// ProjectID - the ID of the project in keystone
+	ProjectID string `json:"projectID,omitempty"`
+
+	// Adopted - the project existed in keystone before and got adopted, it is
+	// left in place when the KeystoneProject gets deleted
+	Adopted bool `json:"adopted,omitempty"`
+
+	// DomainID - the ID of the domain the project belongs to
+	DomainID string `json:"domainID,omitempty"`
+
+	// Conditions
+	Conditions condition.Conditions `json:"conditions,omitempty" optional:"true"`
+
+	// ObservedGeneration - the most recent generation observed for this project.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Project",type="string",JSONPath=".spec.projectName",description="Project name"
+//+kubebuilder:printcolumn:name="ProjectID",type="string",JSONPath=".status.projectID",description="Keystone project ID"
+//+kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.conditions[0].status",description="Status"
+//+kubebuilder:printcolumn:name="Message",type="string",JSONPath=".status.conditions[0].message",description="Message"
+
+// KeystoneProject is the Schema for the keystoneprojects API
+type KeystoneProject struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   KeystoneProjectSpec   `json:"spec,omitempty"`
+	Status KeystoneProjectStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// KeystoneProjectList contains a list of KeystoneProject
+type KeystoneProjectList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []KeystoneProject `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&KeystoneProject{}, &KeystoneProjectList{})
+}
+
+// IsReady - returns true if KeystoneProject is reconciled successfully
+func (instance KeystoneProject) IsReady() bool {
+	return instance.Status.Conditions.IsTrue(condition.ReadyCondition)
+}
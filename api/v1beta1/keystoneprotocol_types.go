@@ -0,0 +1,101 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// KeystoneProtocolSpec defines the desired state of KeystoneProtocol
+type KeystoneProtocolSpec struct {
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern=`^[a-zA-Z0-9_.-]+$`
+	// ProtocolID - ID of the protocol in keystone, e.g. openid or saml2. It is
+	// also used as auth method and as the name of the keystone.conf section
+	// holding the remote_id_attribute.
+	ProtocolID string `json:"protocolID"`
+
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	// IdentityProvider - name of the KeystoneIdentityProvider CR the protocol
+	// belongs to
+	IdentityProvider string `json:"identityProvider"`
+
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	// Mapping - name of the KeystoneMapping CR used by the protocol
+	Mapping string `json:"mapping"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=Delete
+	// +kubebuilder:validation:Enum=Delete;Retain
+	// DeletionPolicy - Delete removes the protocol from keystone when the CR
+	// gets deleted, Retain keeps it.
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy"`
+}
+
+// KeystoneProtocolStatus defines the observed state of KeystoneProtocol
+type KeystoneProtocolStatus struct {
+	// ProtocolID - the ID of the protocol in keystone
+	ProtocolID string `json:"protocolID,omitempty"`
+
+	// IdentityProviderID - the ID of the identity provider the protocol is registered on
+	IdentityProviderID string `json:"identityProviderID,omitempty"`
+
+	// MappingID - the ID of the mapping used by the protocol
+	MappingID string `json:"mappingID,omitempty"`
+
+	// Conditions
+	Conditions condition.Conditions `json:"conditions,omitempty" optional:"true"`
+
+	// ObservedGeneration - the most recent generation observed for this protocol.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Protocol",type="string",JSONPath=".spec.protocolID",description="Protocol ID"
+//+kubebuilder:printcolumn:name="IdentityProvider",type="string",JSONPath=".status.identityProviderID",description="Identity provider ID"
+//+kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.conditions[0].status",description="Status"
+//+kubebuilder:printcolumn:name="Message",type="string",JSONPath=".status.conditions[0].message",description="Message"
+
+// KeystoneProtocol is the Schema for the keystoneprotocols API
+type KeystoneProtocol struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   KeystoneProtocolSpec   `json:"spec,omitempty"`
+	Status KeystoneProtocolStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// KeystoneProtocolList contains a list of KeystoneProtocol
+type KeystoneProtocolList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []KeystoneProtocol `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&KeystoneProtocol{}, &KeystoneProtocolList{})
+}
+
+// IsReady - returns true if KeystoneProtocol is reconciled successfully
+func (instance KeystoneProtocol) IsReady() bool {
+	return instance.Status.Conditions.IsTrue(condition.ReadyCondition)
+}
@@ -0,0 +1,139 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// SystemScopeAll - the only system scope keystone supports
+	SystemScopeAll = "all"
+)
+
+// KeystoneRoleAssignmentSpec defines the desired state of KeystoneRoleAssignment
+type KeystoneRoleAssignmentSpec struct {
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	// RoleName - Name of the role to assign. The role gets created if it does
+	// not exist.
+	RoleName string `json:"roleName"`
+
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	// UserName - Name of the user the role gets assigned to.
+	UserName string `json:"userName"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=Default
+	// UserDomainName - Name of the domain the user belongs to.
+	UserDomainName string `json:"userDomainName"`
+
+	// +kubebuilder:validation:Required
+	// Scope - the target of the assignment, exactly one of project, domain or
+	// system must be set.
+	Scope KeystoneRoleAssignmentScope `json:"scope"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=Delete
+	// +kubebuilder:validation:Enum=Delete;Retain
+	// DeletionPolicy - Delete revokes the assignment in keystone when the CR gets
+	// deleted, Retain keeps it.
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy"`
+}
+
+// KeystoneRoleAssignmentScope defines the target of a role assignment
+// +kubebuilder:validation:XValidation:rule="[has(self.projectName), has(self.domainName), has(self.system)].filter(x, x).size() == 1",message="exactly one of projectName, domainName or system must be set"
+type KeystoneRoleAssignmentScope struct {
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MinLength=1
+	// ProjectName - Name of the project the role gets assigned on.
+	ProjectName string `json:"projectName,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=Default
+	// ProjectDomainName - Name of the domain the project belongs to.
+	ProjectDomainName string `json:"projectDomainName,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MinLength=1
+	// DomainName - Name of the domain the role gets assigned on.
+	DomainName string `json:"domainName,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=all
+	// System - assign the role on the system scope. The only supported value is all.
+	System string `json:"system,omitempty"`
+}
+
+// KeystoneRoleAssignmentStatus defines the observed state of KeystoneRoleAssignment
+type KeystoneRoleAssignmentStatus struct {
+	// RoleID - the ID of the assigned role
+	RoleID string `json:"roleID,omitempty"`
+
+	// UserID - the ID of the user the role is assigned to
+	UserID string `json:"userID,omitempty"`
+
+	// ProjectID - the ID of the project the role is assigned on
+	ProjectID string `json:"projectID,omitempty"`
+
+	// DomainID - the ID of the domain the role is assigned on
+	DomainID string `json:"domainID,omitempty"`
+
+	// System - the system scope the role is assigned on
+	System string `json:"system,omitempty"`
+
+	// Conditions
+	Conditions condition.Conditions `json:"conditions,omitempty" optional:"true"`
+
+	// ObservedGeneration - the most recent generation observed for this assignment.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Role",type="string",JSONPath=".spec.roleName",description="Role name"
+//+kubebuilder:printcolumn:name="User",type="string",JSONPath=".spec.userName",description="User name"
+//+kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.conditions[0].status",description="Status"
+//+kubebuilder:printcolumn:name="Message",type="string",JSONPath=".status.conditions[0].message",description="Message"
+
+// KeystoneRoleAssignment is the Schema for the keystoneroleassignments API
+type KeystoneRoleAssignment struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   KeystoneRoleAssignmentSpec   `json:"spec,omitempty"`
+	Status KeystoneRoleAssignmentStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// KeystoneRoleAssignmentList contains a list of KeystoneRoleAssignment
+type KeystoneRoleAssignmentList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []KeystoneRoleAssignment `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&KeystoneRoleAssignment{}, &KeystoneRoleAssignmentList{})
+}
+
+// IsReady - returns true if KeystoneRoleAssignment is reconciled successfully
+func (instance KeystoneRoleAssignment) IsReady() bool {
+	return instance.Status.Conditions.IsTrue(condition.ReadyCondition)
+}
@@ -0,0 +1,77 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetAdoptIDs(t *testing.T) {
+
+	tests := []struct {
+		name          string
+		service       KeystoneService
+		wantServiceID string
+		wantUserID    string
+	}{
+		{
+			name:    "Nothing to adopt",
+			service: KeystoneService{},
+		},
+		{
+			name: "IDs from the annotations",
+			service: KeystoneService{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{
+						AdoptServiceIDAnnotation: "annotated-service",
+						AdoptUserIDAnnotation:    "annotated-user",
+					},
+				},
+			},
+			wantServiceID: "annotated-service",
+			wantUserID:    "annotated-user",
+		},
+		{
+			name: "IDs from the spec take precedence",
+			service: KeystoneService{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{
+						AdoptServiceIDAnnotation: "annotated-service",
+						AdoptUserIDAnnotation:    "annotated-user",
+					},
+				},
+				Spec: KeystoneServiceSpec{
+					AdoptServiceID: "service",
+					AdoptUserID:    "user",
+				},
+			},
+			wantServiceID: "service",
+			wantUserID:    "user",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(tt.service.GetAdoptServiceID()).To(Equal(tt.wantServiceID))
+			g.Expect(tt.service.GetAdoptUserID()).To(Equal(tt.wantUserID))
+		})
+	}
+}
@@ -21,6 +21,16 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+const (
+	// AdoptServiceIDAnnotation - annotation of a KeystoneService with the ID
+	// of an existing service in keystone to adopt
+	AdoptServiceIDAnnotation = "keystone.openstack.org/adopt-service-id"
+
+	// AdoptUserIDAnnotation - annotation of a KeystoneService with the ID of
+	// an existing user in keystone to adopt as the service user
+	AdoptUserIDAnnotation = "keystone.openstack.org/adopt-user-id"
+)
+
 // KeystoneServiceSpec defines the desired state of KeystoneService
 type KeystoneServiceSpec struct {
 	// +kubebuilder:validation:Required
@@ -44,6 +54,107 @@ type KeystoneServiceSpec struct {
 	// +kubebuilder:validation:Required
 	// PasswordSelector - Selector to get the ServiceUser password from the Secret, e.g. PlacementPassword
 	PasswordSelector string `json:"passwordSelector"`
+
+	// +kubebuilder:validation:Optional
+	// KeystoneAPIRef - KeystoneAPI to register in, by default the one in the
+	// namespace selected by the keystone.openstack.org/keystoneapi label, or
+	// the only one in the namespace
+	KeystoneAPIRef *KeystoneAPIRef `json:"keystoneAPIRef,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=Correct
+	// +kubebuilder:validation:Enum=Correct;Report
+	// DriftPolicy - whether a service changed in keystone, e.g. with the
+	// openstack CLI, gets changed back to match the spec or the drift only gets
+	// reported in the CatalogInSync condition
+	DriftPolicy DriftPolicy `json:"driftPolicy,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=300
+	// +kubebuilder:validation:Minimum=0
+	// ResyncInterval - interval in seconds the service registered in keystone
+	// gets compared against the spec, 0 disables the periodic resync
+	ResyncInterval *int32 `json:"resyncInterval,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default={admin,service}
+	// Roles - roles the ServiceUser gets assigned on the ProjectName project
+	Roles []string `json:"roles"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=service
+	// +kubebuilder:validation:MinLength=1
+	// ProjectName - project the ServiceUser gets created in and the Roles get
+	// assigned on, created if it does not exist
+	ProjectName string `json:"projectName,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=Default
+	// +kubebuilder:validation:MinLength=1
+	// DomainName - name of the domain of the ServiceUser and the ProjectName
+	// project, the domain must exist
+	DomainName string `json:"domainName,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// SystemRoles - roles the ServiceUser gets assigned on the system scope
+	// all, e.g. reader
+	SystemRoles []string `json:"systemRoles,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=Delete
+	// +kubebuilder:validation:Enum=Delete;Retain;Orphan
+	// DeletionPolicy - what happens to the service, the ServiceUser and its
+	// role assignments in keystone when the CR gets deleted. Delete removes
+	// the service and the user, Retain keeps both but revokes the role
+	// assignments, Orphan leaves all of them untouched.
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// AdoptServiceID - ID of an existing service in keystone to take over
+	// instead of looking it up by type and name, e.g. when moving an existing
+	// cloud under the operator. Takes precedence over the
+	// keystone.openstack.org/adopt-service-id annotation.
+	AdoptServiceID string `json:"adoptServiceID,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// AdoptUserID - ID of an existing user in keystone to take over as the
+	// ServiceUser instead of looking it up by name. The user gets renamed to
+	// the ServiceUser. Takes precedence over the
+	// keystone.openstack.org/adopt-user-id annotation.
+	AdoptUserID string `json:"adoptUserID,omitempty"`
+}
+
+// KeystoneServiceRoleAssignment - a role assigned to the ServiceUser
+type KeystoneServiceRoleAssignment struct {
+	// RoleName - the name of the assigned role
+	RoleName string `json:"roleName"`
+
+	// RoleID - the ID of the assigned role
+	RoleID string `json:"roleID"`
+
+	// UserID - the ID of the user the role is assigned to
+	UserID string `json:"userID"`
+
+	// ProjectID - the ID of the project the role is assigned on
+	ProjectID string `json:"projectID,omitempty"`
+
+	// System - the system scope the role is assigned on
+	System string `json:"system,omitempty"`
+}
+
+// KeystoneServiceObserved - the service as registered in keystone
+type KeystoneServiceObserved struct {
+	// Name - the name of the service
+	Name string `json:"name"`
+
+	// Type - the type of the service
+	Type string `json:"type"`
+
+	// Description - the description of the service
+	Description string `json:"description,omitempty"`
+
+	// Enabled - whether the service is enabled
+	Enabled bool `json:"enabled"`
 }
 
 // KeystoneServiceStatus defines the observed state of KeystoneService
@@ -51,6 +162,42 @@ type KeystoneServiceStatus struct {
 	ServiceID string `json:"serviceID,omitempty"`
 	// Conditions
 	Conditions condition.Conditions `json:"conditions,omitempty" optional:"true"`
+
+	// ObservedGeneration - the most recent generation observed for this service.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// ServiceHash - hash of the spec the service got last registered in
+	// keystone with. Differences of the registered service are a drift while
+	// the spec did not change since.
+	ServiceHash string `json:"serviceHash,omitempty"`
+
+	// RoleAssignments - the roles assigned to the ServiceUser. Assignments
+	// removed from the spec get revoked.
+	RoleAssignments []KeystoneServiceRoleAssignment `json:"roleAssignments,omitempty"`
+
+	// LegacyRoleAssignmentsRevoked - the roles an earlier version assigned to
+	// the ServiceUser without recording them got revoked where no longer in
+	// the spec, they do not get looked up again
+	LegacyRoleAssignmentsRevoked bool `json:"legacyRoleAssignmentsRevoked,omitempty"`
+
+	// PasswordHash - salted hash of the service user password last set in
+	// keystone, the password gets updated in keystone when the secret changes
+	PasswordHash string `json:"passwordHash,omitempty"`
+
+	// Service - the service registered in keystone as last observed, differs
+	// from the spec while a drift only gets reported
+	Service *KeystoneServiceObserved `json:"service,omitempty"`
+
+	// AdoptedServiceID - ID of the existing service which got adopted
+	AdoptedServiceID string `json:"adoptedServiceID,omitempty"`
+
+	// AdoptedUserID - ID of the existing user which got adopted as the
+	// ServiceUser
+	AdoptedUserID string `json:"adoptedUserID,omitempty"`
+
+	// KeystoneAPI - namespace/name of the KeystoneAPI the service got
+	// registered in
+	KeystoneAPI string `json:"keystoneAPI,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -84,3 +231,37 @@ func init() {
 func (instance KeystoneService) IsReady() bool {
 	return instance.Status.Conditions.IsTrue(condition.ReadyCondition)
 }
+
+// GetProjectName - returns the project of the ServiceUser, service if not set
+func (spec KeystoneServiceSpec) GetProjectName() string {
+	if spec.ProjectName == "" {
+		return DefaultServiceProjectName
+	}
+	return spec.ProjectName
+}
+
+// GetDomainName - returns the domain of the ServiceUser, Default if not set
+func (spec KeystoneServiceSpec) GetDomainName() string {
+	if spec.DomainName == "" {
+		return DefaultDomainName
+	}
+	return spec.DomainName
+}
+
+// GetAdoptServiceID - returns the ID of the service to adopt from the spec or
+// else the AdoptServiceIDAnnotation, empty if none should be adopted
+func (instance KeystoneService) GetAdoptServiceID() string {
+	if instance.Spec.AdoptServiceID != "" {
+		return instance.Spec.AdoptServiceID
+	}
+	return instance.GetAnnotations()[AdoptServiceIDAnnotation]
+}
+
+// GetAdoptUserID - returns the ID of the user to adopt from the spec or else
+// the AdoptUserIDAnnotation, empty if none should be adopted
+func (instance KeystoneService) GetAdoptUserID() string {
+	if instance.Spec.AdoptUserID != "" {
+		return instance.Spec.AdoptUserID
+	}
+	return instance.GetAnnotations()[AdoptUserIDAnnotation]
+}
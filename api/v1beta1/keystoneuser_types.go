@@ -0,0 +1,145 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// KeystoneUserSpec defines the desired state of KeystoneUser
+type KeystoneUserSpec struct {
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	// UserName - Name of the user in keystone.
+	UserName string `json:"userName"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=Default
+	// DomainName - Name of the domain the user belongs to.
+	DomainName string `json:"domainName"`
+
+	// +kubebuilder:validation:Optional
+	// DefaultProjectName - Name of the default project of the user, the project
+	// is looked up in the domain of the user.
+	DefaultProjectName string `json:"defaultProjectName,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Description - Description for the user.
+	Description string `json:"description,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=true
+	// Enabled - whether or not the user is enabled.
+	Enabled bool `json:"enabled"`
+
+	// +kubebuilder:validation:Optional
+	// Secret containing the password of the user. If not set, a password gets
+	// generated and stored in the <name>-password Secret owned by this CR.
+	Secret string `json:"secret,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=Password
+	// PasswordSelector - Selector to get the user password from the Secret
+	PasswordSelector string `json:"passwordSelector"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=false
+	// Adopt - take over a user with the UserName which already exists in
+	// keystone. Without it the user is not touched and the KeystoneUser
+	// reports an error. An adopted user is never deleted from keystone,
+	// regardless of the DeletionPolicy.
+	Adopt bool `json:"adopt"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=Delete
+	// +kubebuilder:validation:Enum=Delete;Retain
+	// DeletionPolicy - Delete removes the user from keystone when the CR gets
+	// deleted, Retain keeps it.
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy"`
+}
+
+// KeystoneUserStatus defines the observed state of KeystoneUser
+type KeystoneUserStatus struct {
+	// UserID - the ID of the user in keystone
+	UserID string `json:"userID,omitempty"`
+
+	// Adopted - the user existed in keystone before and got adopted, it is
+	// left in place when the KeystoneUser gets deleted
+	Adopted bool `json:"adopted,omitempty"`
+
+	// DomainID - the ID of the domain the user belongs to
+	DomainID string `json:"domainID,omitempty"`
+
+	// DefaultProjectID - the ID of the default project of the user
+	DefaultProjectID string `json:"defaultProjectID,omitempty"`
+
+	// PasswordSecret - name of the Secret holding the password of the user
+	PasswordSecret string `json:"passwordSecret,omitempty"`
+
+	// PasswordHash - salted hash of the password last set in keystone, used
+	// to detect password changes
+	PasswordHash string `json:"passwordHash,omitempty"`
+
+	// Conditions
+	Conditions condition.Conditions `json:"conditions,omitempty" optional:"true"`
+
+	// ObservedGeneration - the most recent generation observed for this user.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="User",type="string",JSONPath=".spec.userName",description="User name"
+//+kubebuilder:printcolumn:name="UserID",type="string",JSONPath=".status.userID",description="Keystone user ID"
+//+kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.conditions[0].status",description="Status"
+//+kubebuilder:printcolumn:name="Message",type="string",JSONPath=".status.conditions[0].message",description="Message"
+
+// KeystoneUser is the Schema for the keystoneusers API
+type KeystoneUser struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   KeystoneUserSpec   `json:"spec,omitempty"`
+	Status KeystoneUserStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// KeystoneUserList contains a list of KeystoneUser
+type KeystoneUserList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []KeystoneUser `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&KeystoneUser{}, &KeystoneUserList{})
+}
+
+// IsReady - returns true if KeystoneUser is reconciled successfully
+func (instance KeystoneUser) IsReady() bool {
+	return instance.Status.Conditions.IsTrue(condition.ReadyCondition)
+}
+
+// GetPasswordSecretName - returns the name of the Secret holding the password,
+// either the one referenced in the spec or the generated one
+func (instance KeystoneUser) GetPasswordSecretName() string {
+	if instance.Spec.Secret != "" {
+		return instance.Spec.Secret
+	}
+	return instance.Name + "-password"
+}
@@ -81,6 +81,88 @@ func (in *ApplicationCredentialData) DeepCopy() *ApplicationCredentialData {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CanaryStatus) DeepCopyInto(out *CanaryStatus) {
+	*out = *in
+	out.Revision = in.Revision
+	if in.StartedAt != nil {
+		in, out := &in.StartedAt, &out.StartedAt
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletedAt != nil {
+		in, out := &in.CompletedAt, &out.CompletedAt
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CanaryStatus.
+func (in *CanaryStatus) DeepCopy() *CanaryStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CanaryStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CatalogAuditEndpoint) DeepCopyInto(out *CatalogAuditEndpoint) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CatalogAuditEndpoint.
+func (in *CatalogAuditEndpoint) DeepCopy() *CatalogAuditEndpoint {
+	if in == nil {
+		return nil
+	}
+	out := new(CatalogAuditEndpoint)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CatalogAuditService) DeepCopyInto(out *CatalogAuditService) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CatalogAuditService.
+func (in *CatalogAuditService) DeepCopy() *CatalogAuditService {
+	if in == nil {
+		return nil
+	}
+	out := new(CatalogAuditService)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DatabaseUpgradeStatus) DeepCopyInto(out *DatabaseUpgradeStatus) {
+	*out = *in
+	if in.StartedAt != nil {
+		in, out := &in.StartedAt, &out.StartedAt
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletedAt != nil {
+		in, out := &in.CompletedAt, &out.CompletedAt
+		*out = (*in).DeepCopy()
+	}
+	if in.Checks != nil {
+		in, out := &in.Checks, &out.Checks
+		*out = make([]UpgradeCheckStatus, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatabaseUpgradeStatus.
+func (in *DatabaseUpgradeStatus) DeepCopy() *DatabaseUpgradeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DatabaseUpgradeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Endpoint) DeepCopyInto(out *Endpoint) {
 	*out = *in
@@ -96,6 +178,26 @@ func (in *Endpoint) DeepCopy() *Endpoint {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EndpointEntry) DeepCopyInto(out *EndpointEntry) {
+	*out = *in
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EndpointEntry.
+func (in *EndpointEntry) DeepCopy() *EndpointEntry {
+	if in == nil {
+		return nil
+	}
+	out := new(EndpointEntry)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HttpdCustomization) DeepCopyInto(out *HttpdCustomization) {
 	*out = *in
@@ -121,6 +223,29 @@ func (in *HttpdCustomization) DeepCopy() *HttpdCustomization {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeyRotationStatus) DeepCopyInto(out *KeyRotationStatus) {
+	*out = *in
+	if in.LastRotated != nil {
+		in, out := &in.LastRotated, &out.LastRotated
+		*out = (*in).DeepCopy()
+	}
+	if in.NextRotation != nil {
+		in, out := &in.NextRotation, &out.NextRotation
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeyRotationStatus.
+func (in *KeyRotationStatus) DeepCopy() *KeyRotationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(KeyRotationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KeystoneAPI) DeepCopyInto(out *KeystoneAPI) {
 	*out = *in
@@ -195,6 +320,21 @@ func (in *KeystoneAPIList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneAPIRef) DeepCopyInto(out *KeystoneAPIRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneAPIRef.
+func (in *KeystoneAPIRef) DeepCopy() *KeystoneAPIRef {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneAPIRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KeystoneAPISpec) DeepCopyInto(out *KeystoneAPISpec) {
 	*out = *in
@@ -214,6 +354,11 @@ func (in *KeystoneAPISpec) DeepCopy() *KeystoneAPISpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KeystoneAPISpecCore) DeepCopyInto(out *KeystoneAPISpecCore) {
 	*out = *in
+	if in.AllowedNamespaces != nil {
+		in, out := &in.AllowedNamespaces, &out.AllowedNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.Replicas != nil {
 		in, out := &in.Replicas, &out.Replicas
 		*out = new(int32)
@@ -229,6 +374,41 @@ func (in *KeystoneAPISpecCore) DeepCopyInto(out *KeystoneAPISpecCore) {
 		*out = new(int32)
 		**out = **in
 	}
+	if in.ReceiptRotationDays != nil {
+		in, out := &in.ReceiptRotationDays, &out.ReceiptRotationDays
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ReceiptMaxActiveKeys != nil {
+		in, out := &in.ReceiptMaxActiveKeys, &out.ReceiptMaxActiveKeys
+		*out = new(int32)
+		**out = **in
+	}
+	if in.TokenExpiration != nil {
+		in, out := &in.TokenExpiration, &out.TokenExpiration
+		*out = new(int32)
+		**out = **in
+	}
+	if in.AllowExpiredWindow != nil {
+		in, out := &in.AllowExpiredWindow, &out.AllowExpiredWindow
+		*out = new(int32)
+		**out = **in
+	}
+	if in.CredentialRotationDays != nil {
+		in, out := &in.CredentialRotationDays, &out.CredentialRotationDays
+		*out = new(int32)
+		**out = **in
+	}
+	if in.KeyImport != nil {
+		in, out := &in.KeyImport, &out.KeyImport
+		*out = new(KeystoneKeyImport)
+		**out = **in
+	}
+	if in.TokenRevocation != nil {
+		in, out := &in.TokenRevocation, &out.TokenRevocation
+		*out = new(KeystoneTokenRevocation)
+		**out = **in
+	}
 	out.PasswordSelectors = in.PasswordSelectors
 	if in.NodeSelector != nil {
 		in, out := &in.NodeSelector, &out.NodeSelector
@@ -241,6 +421,11 @@ func (in *KeystoneAPISpecCore) DeepCopyInto(out *KeystoneAPISpecCore) {
 			}
 		}
 	}
+	if in.CanaryTimeout != nil {
+		in, out := &in.CanaryTimeout, &out.CanaryTimeout
+		*out = new(int32)
+		**out = **in
+	}
 	if in.DefaultConfigOverwrite != nil {
 		in, out := &in.DefaultConfigOverwrite, &out.DefaultConfigOverwrite
 		*out = make(map[string]string, len(*in))
@@ -331,6 +516,41 @@ func (in *KeystoneAPIStatus) DeepCopyInto(out *KeystoneAPIStatus) {
 		*out = new(topologyv1beta1.TopoRef)
 		**out = **in
 	}
+	if in.FernetKeys != nil {
+		in, out := &in.FernetKeys, &out.FernetKeys
+		*out = new(KeyRotationStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CredentialKeys != nil {
+		in, out := &in.CredentialKeys, &out.CredentialKeys
+		*out = new(KeyRotationStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ReceiptKeys != nil {
+		in, out := &in.ReceiptKeys, &out.ReceiptKeys
+		*out = new(KeyRotationStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TokenRevocation != nil {
+		in, out := &in.TokenRevocation, &out.TokenRevocation
+		*out = new(TokenRevocationStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DatabaseUpgrade != nil {
+		in, out := &in.DatabaseUpgrade, &out.DatabaseUpgrade
+		*out = new(DatabaseUpgradeStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PromotedRevision != nil {
+		in, out := &in.PromotedRevision, &out.PromotedRevision
+		*out = new(RolloutRevision)
+		**out = **in
+	}
+	if in.Canary != nil {
+		in, out := &in.Canary, &out.Canary
+		*out = new(CanaryStatus)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneAPIStatus.
@@ -415,6 +635,11 @@ func (in *KeystoneApplicationCredentialSpec) DeepCopyInto(out *KeystoneApplicati
 		*out = make([]ACRule, len(*in))
 		copy(*out, *in)
 	}
+	if in.KeystoneAPIRef != nil {
+		in, out := &in.KeystoneAPIRef, &out.KeystoneAPIRef
+		*out = new(KeystoneAPIRef)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneApplicationCredentialSpec.
@@ -466,7 +691,7 @@ func (in *KeystoneApplicationCredentialStatus) DeepCopy() *KeystoneApplicationCr
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *KeystoneEndpoint) DeepCopyInto(out *KeystoneEndpoint) {
+func (in *KeystoneCatalogAudit) DeepCopyInto(out *KeystoneCatalogAudit) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
@@ -474,18 +699,18 @@ func (in *KeystoneEndpoint) DeepCopyInto(out *KeystoneEndpoint) {
 	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneEndpoint.
-func (in *KeystoneEndpoint) DeepCopy() *KeystoneEndpoint {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneCatalogAudit.
+func (in *KeystoneCatalogAudit) DeepCopy() *KeystoneCatalogAudit {
 	if in == nil {
 		return nil
 	}
-	out := new(KeystoneEndpoint)
+	out := new(KeystoneCatalogAudit)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *KeystoneEndpoint) DeepCopyObject() runtime.Object {
+func (in *KeystoneCatalogAudit) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -493,65 +718,31 @@ func (in *KeystoneEndpoint) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *KeystoneEndpointHelper) DeepCopyInto(out *KeystoneEndpointHelper) {
-	*out = *in
-	if in.endpoint != nil {
-		in, out := &in.endpoint, &out.endpoint
-		*out = new(KeystoneEndpoint)
-		(*in).DeepCopyInto(*out)
-	}
-	if in.labels != nil {
-		in, out := &in.labels, &out.labels
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
-	}
-	if in.id != nil {
-		in, out := &in.id, &out.id
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
-	}
-}
-
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneEndpointHelper.
-func (in *KeystoneEndpointHelper) DeepCopy() *KeystoneEndpointHelper {
-	if in == nil {
-		return nil
-	}
-	out := new(KeystoneEndpointHelper)
-	in.DeepCopyInto(out)
-	return out
-}
-
-// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *KeystoneEndpointList) DeepCopyInto(out *KeystoneEndpointList) {
+func (in *KeystoneCatalogAuditList) DeepCopyInto(out *KeystoneCatalogAuditList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]KeystoneEndpoint, len(*in))
+		*out = make([]KeystoneCatalogAudit, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneEndpointList.
-func (in *KeystoneEndpointList) DeepCopy() *KeystoneEndpointList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneCatalogAuditList.
+func (in *KeystoneCatalogAuditList) DeepCopy() *KeystoneCatalogAuditList {
 	if in == nil {
 		return nil
 	}
-	out := new(KeystoneEndpointList)
+	out := new(KeystoneCatalogAuditList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *KeystoneEndpointList) DeepCopyObject() runtime.Object {
+func (in *KeystoneCatalogAuditList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -559,104 +750,97 @@ func (in *KeystoneEndpointList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *KeystoneEndpointSpec) DeepCopyInto(out *KeystoneEndpointSpec) {
+func (in *KeystoneCatalogAuditSpec) DeepCopyInto(out *KeystoneCatalogAuditSpec) {
 	*out = *in
-	if in.Endpoints != nil {
-		in, out := &in.Endpoints, &out.Endpoints
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
+	if in.KeystoneAPIRef != nil {
+		in, out := &in.KeystoneAPIRef, &out.KeystoneAPIRef
+		*out = new(KeystoneAPIRef)
+		**out = **in
+	}
+	if in.AuditInterval != nil {
+		in, out := &in.AuditInterval, &out.AuditInterval
+		*out = new(int32)
+		**out = **in
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneEndpointSpec.
-func (in *KeystoneEndpointSpec) DeepCopy() *KeystoneEndpointSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneCatalogAuditSpec.
+func (in *KeystoneCatalogAuditSpec) DeepCopy() *KeystoneCatalogAuditSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(KeystoneEndpointSpec)
+	out := new(KeystoneCatalogAuditSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *KeystoneEndpointStatus) DeepCopyInto(out *KeystoneEndpointStatus) {
+func (in *KeystoneCatalogAuditStatus) DeepCopyInto(out *KeystoneCatalogAuditStatus) {
 	*out = *in
-	if in.EndpointIDs != nil {
-		in, out := &in.EndpointIDs, &out.EndpointIDs
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
+	if in.OrphanedServices != nil {
+		in, out := &in.OrphanedServices, &out.OrphanedServices
+		*out = make([]CatalogAuditService, len(*in))
+		copy(*out, *in)
 	}
-	if in.Conditions != nil {
-		in, out := &in.Conditions, &out.Conditions
-		*out = make(condition.Conditions, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
+	if in.OrphanedEndpoints != nil {
+		in, out := &in.OrphanedEndpoints, &out.OrphanedEndpoints
+		*out = make([]CatalogAuditEndpoint, len(*in))
+		copy(*out, *in)
 	}
-	if in.Endpoints != nil {
-		in, out := &in.Endpoints, &out.Endpoints
-		*out = make([]Endpoint, len(*in))
+	if in.PrunedServices != nil {
+		in, out := &in.PrunedServices, &out.PrunedServices
+		*out = make([]CatalogAuditService, len(*in))
 		copy(*out, *in)
 	}
-}
-
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneEndpointStatus.
-func (in *KeystoneEndpointStatus) DeepCopy() *KeystoneEndpointStatus {
-	if in == nil {
-		return nil
+	if in.PrunedEndpoints != nil {
+		in, out := &in.PrunedEndpoints, &out.PrunedEndpoints
+		*out = make([]CatalogAuditEndpoint, len(*in))
+		copy(*out, *in)
 	}
-	out := new(KeystoneEndpointStatus)
-	in.DeepCopyInto(out)
-	return out
-}
-
-// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *KeystoneExtraMounts) DeepCopyInto(out *KeystoneExtraMounts) {
-	*out = *in
-	if in.VolMounts != nil {
-		in, out := &in.VolMounts, &out.VolMounts
-		*out = make([]storage.VolMounts, len(*in))
+	if in.LastAuditTime != nil {
+		in, out := &in.LastAuditTime, &out.LastAuditTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(condition.Conditions, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneExtraMounts.
-func (in *KeystoneExtraMounts) DeepCopy() *KeystoneExtraMounts {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneCatalogAuditStatus.
+func (in *KeystoneCatalogAuditStatus) DeepCopy() *KeystoneCatalogAuditStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(KeystoneExtraMounts)
+	out := new(KeystoneCatalogAuditStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *KeystoneService) DeepCopyInto(out *KeystoneService) {
+func (in *KeystoneDomain) DeepCopyInto(out *KeystoneDomain) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneService.
-func (in *KeystoneService) DeepCopy() *KeystoneService {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneDomain.
+func (in *KeystoneDomain) DeepCopy() *KeystoneDomain {
 	if in == nil {
 		return nil
 	}
-	out := new(KeystoneService)
+	out := new(KeystoneDomain)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *KeystoneService) DeepCopyObject() runtime.Object {
+func (in *KeystoneDomain) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -664,14 +848,898 @@ func (in *KeystoneService) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *KeystoneServiceHelper) DeepCopyInto(out *KeystoneServiceHelper) {
+func (in *KeystoneDomainLDAP) DeepCopyInto(out *KeystoneDomainLDAP) {
 	*out = *in
-	if in.service != nil {
-		in, out := &in.service, &out.service
-		*out = new(KeystoneService)
-		(*in).DeepCopyInto(*out)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneDomainLDAP.
+func (in *KeystoneDomainLDAP) DeepCopy() *KeystoneDomainLDAP {
+	if in == nil {
+		return nil
 	}
-	if in.labels != nil {
+	out := new(KeystoneDomainLDAP)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneDomainList) DeepCopyInto(out *KeystoneDomainList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]KeystoneDomain, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneDomainList.
+func (in *KeystoneDomainList) DeepCopy() *KeystoneDomainList {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneDomainList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KeystoneDomainList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneDomainSpec) DeepCopyInto(out *KeystoneDomainSpec) {
+	*out = *in
+	if in.LDAP != nil {
+		in, out := &in.LDAP, &out.LDAP
+		*out = new(KeystoneDomainLDAP)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneDomainSpec.
+func (in *KeystoneDomainSpec) DeepCopy() *KeystoneDomainSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneDomainSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneDomainStatus) DeepCopyInto(out *KeystoneDomainStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(condition.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneDomainStatus.
+func (in *KeystoneDomainStatus) DeepCopy() *KeystoneDomainStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneDomainStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneEndpoint) DeepCopyInto(out *KeystoneEndpoint) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneEndpoint.
+func (in *KeystoneEndpoint) DeepCopy() *KeystoneEndpoint {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneEndpoint)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KeystoneEndpoint) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneEndpointHelper) DeepCopyInto(out *KeystoneEndpointHelper) {
+	*out = *in
+	if in.endpoint != nil {
+		in, out := &in.endpoint, &out.endpoint
+		*out = new(KeystoneEndpoint)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.labels != nil {
+		in, out := &in.labels, &out.labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.id != nil {
+		in, out := &in.id, &out.id
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneEndpointHelper.
+func (in *KeystoneEndpointHelper) DeepCopy() *KeystoneEndpointHelper {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneEndpointHelper)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneEndpointList) DeepCopyInto(out *KeystoneEndpointList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]KeystoneEndpoint, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneEndpointList.
+func (in *KeystoneEndpointList) DeepCopy() *KeystoneEndpointList {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneEndpointList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KeystoneEndpointList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneEndpointSpec) DeepCopyInto(out *KeystoneEndpointSpec) {
+	*out = *in
+	if in.Endpoints != nil {
+		in, out := &in.Endpoints, &out.Endpoints
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.EndpointEntries != nil {
+		in, out := &in.EndpointEntries, &out.EndpointEntries
+		*out = make(map[string]EndpointEntry, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.KeystoneAPIRef != nil {
+		in, out := &in.KeystoneAPIRef, &out.KeystoneAPIRef
+		*out = new(KeystoneAPIRef)
+		**out = **in
+	}
+	if in.ResyncInterval != nil {
+		in, out := &in.ResyncInterval, &out.ResyncInterval
+		*out = new(int32)
+		**out = **in
+	}
+	if in.AdoptEndpointIDs != nil {
+		in, out := &in.AdoptEndpointIDs, &out.AdoptEndpointIDs
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneEndpointSpec.
+func (in *KeystoneEndpointSpec) DeepCopy() *KeystoneEndpointSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneEndpointSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneEndpointStatus) DeepCopyInto(out *KeystoneEndpointStatus) {
+	*out = *in
+	if in.EndpointIDs != nil {
+		in, out := &in.EndpointIDs, &out.EndpointIDs
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(condition.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Endpoints != nil {
+		in, out := &in.Endpoints, &out.Endpoints
+		*out = make([]Endpoint, len(*in))
+		copy(*out, *in)
+	}
+	if in.RemovedDuplicates != nil {
+		in, out := &in.RemovedDuplicates, &out.RemovedDuplicates
+		*out = make([]Endpoint, len(*in))
+		copy(*out, *in)
+	}
+	if in.AdoptedEndpointIDs != nil {
+		in, out := &in.AdoptedEndpointIDs, &out.AdoptedEndpointIDs
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneEndpointStatus.
+func (in *KeystoneEndpointStatus) DeepCopy() *KeystoneEndpointStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneEndpointStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneExtraMounts) DeepCopyInto(out *KeystoneExtraMounts) {
+	*out = *in
+	if in.VolMounts != nil {
+		in, out := &in.VolMounts, &out.VolMounts
+		*out = make([]storage.VolMounts, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneExtraMounts.
+func (in *KeystoneExtraMounts) DeepCopy() *KeystoneExtraMounts {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneExtraMounts)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneIdentityProvider) DeepCopyInto(out *KeystoneIdentityProvider) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneIdentityProvider.
+func (in *KeystoneIdentityProvider) DeepCopy() *KeystoneIdentityProvider {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneIdentityProvider)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KeystoneIdentityProvider) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneIdentityProviderList) DeepCopyInto(out *KeystoneIdentityProviderList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]KeystoneIdentityProvider, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneIdentityProviderList.
+func (in *KeystoneIdentityProviderList) DeepCopy() *KeystoneIdentityProviderList {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneIdentityProviderList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KeystoneIdentityProviderList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneIdentityProviderOIDC) DeepCopyInto(out *KeystoneIdentityProviderOIDC) {
+	*out = *in
+	if in.Scopes != nil {
+		in, out := &in.Scopes, &out.Scopes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneIdentityProviderOIDC.
+func (in *KeystoneIdentityProviderOIDC) DeepCopy() *KeystoneIdentityProviderOIDC {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneIdentityProviderOIDC)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneIdentityProviderSAML) DeepCopyInto(out *KeystoneIdentityProviderSAML) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneIdentityProviderSAML.
+func (in *KeystoneIdentityProviderSAML) DeepCopy() *KeystoneIdentityProviderSAML {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneIdentityProviderSAML)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneIdentityProviderSpec) DeepCopyInto(out *KeystoneIdentityProviderSpec) {
+	*out = *in
+	if in.RemoteIDs != nil {
+		in, out := &in.RemoteIDs, &out.RemoteIDs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.OIDC != nil {
+		in, out := &in.OIDC, &out.OIDC
+		*out = new(KeystoneIdentityProviderOIDC)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SAML != nil {
+		in, out := &in.SAML, &out.SAML
+		*out = new(KeystoneIdentityProviderSAML)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneIdentityProviderSpec.
+func (in *KeystoneIdentityProviderSpec) DeepCopy() *KeystoneIdentityProviderSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneIdentityProviderSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneIdentityProviderStatus) DeepCopyInto(out *KeystoneIdentityProviderStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(condition.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneIdentityProviderStatus.
+func (in *KeystoneIdentityProviderStatus) DeepCopy() *KeystoneIdentityProviderStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneIdentityProviderStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneKeyImport) DeepCopyInto(out *KeystoneKeyImport) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneKeyImport.
+func (in *KeystoneKeyImport) DeepCopy() *KeystoneKeyImport {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneKeyImport)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneMapping) DeepCopyInto(out *KeystoneMapping) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneMapping.
+func (in *KeystoneMapping) DeepCopy() *KeystoneMapping {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneMapping)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KeystoneMapping) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneMappingList) DeepCopyInto(out *KeystoneMappingList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]KeystoneMapping, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneMappingList.
+func (in *KeystoneMappingList) DeepCopy() *KeystoneMappingList {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneMappingList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KeystoneMappingList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneMappingSpec) DeepCopyInto(out *KeystoneMappingSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneMappingSpec.
+func (in *KeystoneMappingSpec) DeepCopy() *KeystoneMappingSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneMappingSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneMappingStatus) DeepCopyInto(out *KeystoneMappingStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(condition.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneMappingStatus.
+func (in *KeystoneMappingStatus) DeepCopy() *KeystoneMappingStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneMappingStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneProject) DeepCopyInto(out *KeystoneProject) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneProject.
+func (in *KeystoneProject) DeepCopy() *KeystoneProject {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneProject)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KeystoneProject) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneProjectList) DeepCopyInto(out *KeystoneProjectList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]KeystoneProject, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneProjectList.
+func (in *KeystoneProjectList) DeepCopy() *KeystoneProjectList {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneProjectList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KeystoneProjectList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneProjectSpec) DeepCopyInto(out *KeystoneProjectSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneProjectSpec.
+func (in *KeystoneProjectSpec) DeepCopy() *KeystoneProjectSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneProjectSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneProjectStatus) DeepCopyInto(out *KeystoneProjectStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(condition.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneProjectStatus.
+func (in *KeystoneProjectStatus) DeepCopy() *KeystoneProjectStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneProjectStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneProtocol) DeepCopyInto(out *KeystoneProtocol) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneProtocol.
+func (in *KeystoneProtocol) DeepCopy() *KeystoneProtocol {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneProtocol)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KeystoneProtocol) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneProtocolList) DeepCopyInto(out *KeystoneProtocolList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]KeystoneProtocol, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneProtocolList.
+func (in *KeystoneProtocolList) DeepCopy() *KeystoneProtocolList {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneProtocolList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KeystoneProtocolList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneProtocolSpec) DeepCopyInto(out *KeystoneProtocolSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneProtocolSpec.
+func (in *KeystoneProtocolSpec) DeepCopy() *KeystoneProtocolSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneProtocolSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneProtocolStatus) DeepCopyInto(out *KeystoneProtocolStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(condition.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneProtocolStatus.
+func (in *KeystoneProtocolStatus) DeepCopy() *KeystoneProtocolStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneProtocolStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneRoleAssignment) DeepCopyInto(out *KeystoneRoleAssignment) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneRoleAssignment.
+func (in *KeystoneRoleAssignment) DeepCopy() *KeystoneRoleAssignment {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneRoleAssignment)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KeystoneRoleAssignment) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneRoleAssignmentList) DeepCopyInto(out *KeystoneRoleAssignmentList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]KeystoneRoleAssignment, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneRoleAssignmentList.
+func (in *KeystoneRoleAssignmentList) DeepCopy() *KeystoneRoleAssignmentList {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneRoleAssignmentList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KeystoneRoleAssignmentList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneRoleAssignmentScope) DeepCopyInto(out *KeystoneRoleAssignmentScope) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneRoleAssignmentScope.
+func (in *KeystoneRoleAssignmentScope) DeepCopy() *KeystoneRoleAssignmentScope {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneRoleAssignmentScope)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneRoleAssignmentSpec) DeepCopyInto(out *KeystoneRoleAssignmentSpec) {
+	*out = *in
+	out.Scope = in.Scope
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneRoleAssignmentSpec.
+func (in *KeystoneRoleAssignmentSpec) DeepCopy() *KeystoneRoleAssignmentSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneRoleAssignmentSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneRoleAssignmentStatus) DeepCopyInto(out *KeystoneRoleAssignmentStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(condition.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneRoleAssignmentStatus.
+func (in *KeystoneRoleAssignmentStatus) DeepCopy() *KeystoneRoleAssignmentStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneRoleAssignmentStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneService) DeepCopyInto(out *KeystoneService) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneService.
+func (in *KeystoneService) DeepCopy() *KeystoneService {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneService)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KeystoneService) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneServiceHelper) DeepCopyInto(out *KeystoneServiceHelper) {
+	*out = *in
+	if in.service != nil {
+		in, out := &in.service, &out.service
+		*out = new(KeystoneService)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.labels != nil {
 		in, out := &in.labels, &out.labels
 		*out = make(map[string]string, len(*in))
 		for key, val := range *in {
@@ -722,9 +1790,59 @@ func (in *KeystoneServiceList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneServiceObserved) DeepCopyInto(out *KeystoneServiceObserved) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneServiceObserved.
+func (in *KeystoneServiceObserved) DeepCopy() *KeystoneServiceObserved {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneServiceObserved)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneServiceRoleAssignment) DeepCopyInto(out *KeystoneServiceRoleAssignment) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneServiceRoleAssignment.
+func (in *KeystoneServiceRoleAssignment) DeepCopy() *KeystoneServiceRoleAssignment {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneServiceRoleAssignment)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KeystoneServiceSpec) DeepCopyInto(out *KeystoneServiceSpec) {
 	*out = *in
+	if in.KeystoneAPIRef != nil {
+		in, out := &in.KeystoneAPIRef, &out.KeystoneAPIRef
+		*out = new(KeystoneAPIRef)
+		**out = **in
+	}
+	if in.ResyncInterval != nil {
+		in, out := &in.ResyncInterval, &out.ResyncInterval
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Roles != nil {
+		in, out := &in.Roles, &out.Roles
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.SystemRoles != nil {
+		in, out := &in.SystemRoles, &out.SystemRoles
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneServiceSpec.
@@ -747,6 +1865,16 @@ func (in *KeystoneServiceStatus) DeepCopyInto(out *KeystoneServiceStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.RoleAssignments != nil {
+		in, out := &in.RoleAssignments, &out.RoleAssignments
+		*out = make([]KeystoneServiceRoleAssignment, len(*in))
+		copy(*out, *in)
+	}
+	if in.Service != nil {
+		in, out := &in.Service, &out.Service
+		*out = new(KeystoneServiceObserved)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneServiceStatus.
@@ -759,6 +1887,117 @@ func (in *KeystoneServiceStatus) DeepCopy() *KeystoneServiceStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneTokenRevocation) DeepCopyInto(out *KeystoneTokenRevocation) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneTokenRevocation.
+func (in *KeystoneTokenRevocation) DeepCopy() *KeystoneTokenRevocation {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneTokenRevocation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneUser) DeepCopyInto(out *KeystoneUser) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneUser.
+func (in *KeystoneUser) DeepCopy() *KeystoneUser {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneUser)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KeystoneUser) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneUserList) DeepCopyInto(out *KeystoneUserList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]KeystoneUser, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneUserList.
+func (in *KeystoneUserList) DeepCopy() *KeystoneUserList {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneUserList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KeystoneUserList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneUserSpec) DeepCopyInto(out *KeystoneUserSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneUserSpec.
+func (in *KeystoneUserSpec) DeepCopy() *KeystoneUserSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneUserSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeystoneUserStatus) DeepCopyInto(out *KeystoneUserStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(condition.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneUserStatus.
+func (in *KeystoneUserStatus) DeepCopy() *KeystoneUserStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(KeystoneUserStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PasswordSelector) DeepCopyInto(out *PasswordSelector) {
 	*out = *in
@@ -773,3 +2012,52 @@ func (in *PasswordSelector) DeepCopy() *PasswordSelector {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RolloutRevision) DeepCopyInto(out *RolloutRevision) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RolloutRevision.
+func (in *RolloutRevision) DeepCopy() *RolloutRevision {
+	if in == nil {
+		return nil
+	}
+	out := new(RolloutRevision)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TokenRevocationStatus) DeepCopyInto(out *TokenRevocationStatus) {
+	*out = *in
+	if in.RevokedAt != nil {
+		in, out := &in.RevokedAt, &out.RevokedAt
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TokenRevocationStatus.
+func (in *TokenRevocationStatus) DeepCopy() *TokenRevocationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(TokenRevocationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpgradeCheckStatus) DeepCopyInto(out *UpgradeCheckStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpgradeCheckStatus.
+func (in *UpgradeCheckStatus) DeepCopy() *UpgradeCheckStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(UpgradeCheckStatus)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -253,27 +253,95 @@ func main() {
 	keystonev1.SetupDefaults()
 
 	if err := (&controller.KeystoneAPIReconciler{
-		Client:  mgr.GetClient(),
-		Scheme:  mgr.GetScheme(),
-		Kclient: kclient,
+		Client:        mgr.GetClient(),
+		Scheme:        mgr.GetScheme(),
+		Kclient:       kclient,
+		RestConfig:    mgr.GetConfig(),
+		EventRecorder: mgr.GetEventRecorderFor("keystoneapi-controller"),
 	}).SetupWithManager(context.Background(), mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "KeystoneAPI")
 		os.Exit(1)
 	}
 	if err := (&controller.KeystoneServiceReconciler{
+		Client:        mgr.GetClient(),
+		Scheme:        mgr.GetScheme(),
+		Kclient:       kclient,
+		EventRecorder: mgr.GetEventRecorderFor("keystoneservice-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "KeystoneService")
+		os.Exit(1)
+	}
+	if err := (&controller.KeystoneEndpointReconciler{
+		Client:        mgr.GetClient(),
+		Scheme:        mgr.GetScheme(),
+		Kclient:       kclient,
+		EventRecorder: mgr.GetEventRecorderFor("keystoneendpoint-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "KeystoneEndpoint")
+		os.Exit(1)
+	}
+	if err := (&controller.KeystoneDomainReconciler{
 		Client:  mgr.GetClient(),
 		Scheme:  mgr.GetScheme(),
 		Kclient: kclient,
 	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "KeystoneService")
+		setupLog.Error(err, "unable to create controller", "controller", "KeystoneDomain")
 		os.Exit(1)
 	}
-	if err := (&controller.KeystoneEndpointReconciler{
+	if err := (&controller.KeystoneCatalogAuditReconciler{
 		Client:  mgr.GetClient(),
 		Scheme:  mgr.GetScheme(),
 		Kclient: kclient,
 	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "KeystoneEndpoint")
+		setupLog.Error(err, "unable to create controller", "controller", "KeystoneCatalogAudit")
+		os.Exit(1)
+	}
+	if err := (&controller.KeystoneProjectReconciler{
+		Client:  mgr.GetClient(),
+		Scheme:  mgr.GetScheme(),
+		Kclient: kclient,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "KeystoneProject")
+		os.Exit(1)
+	}
+	if err := (&controller.KeystoneUserReconciler{
+		Client:  mgr.GetClient(),
+		Scheme:  mgr.GetScheme(),
+		Kclient: kclient,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "KeystoneUser")
+		os.Exit(1)
+	}
+	if err := (&controller.KeystoneRoleAssignmentReconciler{
+		Client:  mgr.GetClient(),
+		Scheme:  mgr.GetScheme(),
+		Kclient: kclient,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "KeystoneRoleAssignment")
+		os.Exit(1)
+	}
+	if err := (&controller.KeystoneIdentityProviderReconciler{
+		Client:  mgr.GetClient(),
+		Scheme:  mgr.GetScheme(),
+		Kclient: kclient,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "KeystoneIdentityProvider")
+		os.Exit(1)
+	}
+	if err := (&controller.KeystoneMappingReconciler{
+		Client:  mgr.GetClient(),
+		Scheme:  mgr.GetScheme(),
+		Kclient: kclient,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "KeystoneMapping")
+		os.Exit(1)
+	}
+	if err := (&controller.KeystoneProtocolReconciler{
+		Client:  mgr.GetClient(),
+		Scheme:  mgr.GetScheme(),
+		Kclient: kclient,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "KeystoneProtocol")
 		os.Exit(1)
 	}
 	if err := (&controller.ApplicationCredentialReconciler{
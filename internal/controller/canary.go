@@ -0,0 +1,89 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2"
+	gophercloud_openstack "github.com/gophercloud/gophercloud/v2/openstack"
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/tokens"
+)
+
+// ErrCanaryNotReady - the canary has no ready pod to verify
+var ErrCanaryNotReady = errors.New("canary pod is not ready")
+
+// ErrCanaryTokenInvalid - the token issued by the canary did not validate
+var ErrCanaryTokenInvalid = errors.New("token issued by the canary did not validate")
+
+// canaryRequestTimeout - timeout of the requests against the canary
+const canaryRequestTimeout = 10 * time.Second
+
+// checkTokenRoundTrip - issues a token against the keystone API at endpoint
+// and validates it there. With TLS the certificate of the endpoint gets
+// verified for serverName, as the canary is addressed by its pod IP.
+func checkTokenRoundTrip(
+	ctx context.Context,
+	endpoint string,
+	serverName string,
+	caCert string,
+	authOpts tokens.AuthOptions,
+) error {
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: serverName,
+	}
+	if caCert != "" {
+		caCertPool := x509.NewCertPool()
+		caCertPool.AppendCertsFromPEM([]byte(caCert))
+		tlsConfig.RootCAs = caCertPool
+	}
+
+	provider, err := gophercloud_openstack.NewClient(endpoint)
+	if err != nil {
+		return err
+	}
+	provider.HTTPClient = http.Client{
+		Transport: &http.Transport{TLSClientConfig: tlsConfig},
+		Timeout:   canaryRequestTimeout,
+	}
+	identityClient := &gophercloud.ServiceClient{
+		ProviderClient: provider,
+		Endpoint:       gophercloud.NormalizeURL(endpoint) + "v3/",
+	}
+
+	tokenID, err := tokens.Create(ctx, identityClient, &authOpts).ExtractTokenID()
+	if err != nil {
+		return fmt.Errorf("issuing a token failed: %w", err)
+	}
+
+	provider.SetToken(tokenID)
+	valid, err := tokens.Validate(ctx, identityClient, tokenID)
+	if err != nil {
+		return fmt.Errorf("validating the token failed: %w", err)
+	}
+	if !valid {
+		return ErrCanaryTokenInvalid
+	}
+
+	return nil
+}
@@ -0,0 +1,86 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/tokens"
+)
+
+func newFakeKeystone(t *testing.T, validateStatus int) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v3/auth/tokens", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			w.Header().Set("X-Subject-Token", "canary-token")
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"token": {"expires_at": "2030-01-01T00:00:00.000000Z"}}`))
+		case http.MethodHead:
+			if r.Header.Get("X-Subject-Token") != "canary-token" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.WriteHeader(validateStatus)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestCheckTokenRoundTrip(t *testing.T) {
+	authOpts := tokens.AuthOptions{
+		Username:   "admin",
+		Password:   "password",
+		DomainName: "Default",
+		Scope: tokens.Scope{
+			System: true,
+		},
+	}
+
+	server := newFakeKeystone(t, http.StatusOK)
+	err := checkTokenRoundTrip(context.Background(), server.URL+"/", "", "", authOpts)
+	if err != nil {
+		t.Fatalf("expected the token round trip to pass, got %v", err)
+	}
+
+	server = newFakeKeystone(t, http.StatusNoContent)
+	err = checkTokenRoundTrip(context.Background(), server.URL+"/", "", "", authOpts)
+	if err != nil {
+		t.Fatalf("expected the token round trip to pass, got %v", err)
+	}
+}
+
+func TestCheckTokenRoundTripInvalidToken(t *testing.T) {
+	server := newFakeKeystone(t, http.StatusNotFound)
+	err := checkTokenRoundTrip(context.Background(), server.URL+"/", "", "", tokens.AuthOptions{
+		Username:   "admin",
+		Password:   "password",
+		DomainName: "Default",
+	})
+	if !errors.Is(err, ErrCanaryTokenInvalid) {
+		t.Fatalf("expected %v, got %v", ErrCanaryTokenInvalid, err)
+	}
+}
+
+func TestCheckTokenRoundTripUnreachable(t *testing.T) {
+	server := newFakeKeystone(t, http.StatusOK)
+	endpoint := server.URL + "/"
+	server.Close()
+
+	err := checkTokenRoundTrip(context.Background(), endpoint, "", "", tokens.AuthOptions{
+		Username:   "admin",
+		Password:   "password",
+		DomainName: "Default",
+	})
+	if err == nil || errors.Is(err, ErrCanaryTokenInvalid) {
+		t.Fatalf("expected a request error, got %v", err)
+	}
+}
@@ -0,0 +1,527 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/endpoints"
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/services"
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	keystone "github.com/openstack-k8s-operators/keystone-operator/internal/keystone"
+	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	util "github.com/openstack-k8s-operators/lib-common/modules/common/util"
+	openstack "github.com/openstack-k8s-operators/lib-common/modules/openstack"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+var (
+	// ErrAdoptNotFound - the object to adopt does not exist in keystone
+	ErrAdoptNotFound = errors.New("object to adopt not found")
+
+	// ErrAdoptServiceMismatch - the object to adopt belongs to another service
+	ErrAdoptServiceMismatch = errors.New("object to adopt belongs to another service")
+
+	// ErrAdoptEndpointMismatch - the endpoint to adopt is registered for
+	// another interface or in another region
+	ErrAdoptEndpointMismatch = errors.New("endpoint to adopt is registered for another interface or region")
+)
+
+// regionEndpoint - openstack.Endpoint with the region and the enabled flag of
+// the endpoint, lib-common registers endpoints enabled in the region of the
+// admin client
+type regionEndpoint struct {
+	openstack.Endpoint
+	Region  string
+	Enabled bool
+}
+
+// endpointCreateOpts - gophercloud endpoints.CreateOpts has no enabled flag
+type endpointCreateOpts struct {
+	Availability gophercloud.Availability `json:"interface" required:"true"`
+	Name         string                   `json:"name" required:"true"`
+	Region       string                   `json:"region,omitempty"`
+	URL          string                   `json:"url" required:"true"`
+	ServiceID    string                   `json:"service_id" required:"true"`
+	Enabled      bool                     `json:"enabled"`
+}
+
+// ToEndpointCreateMap - implements endpoints.CreateOptsBuilder
+func (opts endpointCreateOpts) ToEndpointCreateMap() (map[string]any, error) {
+	return gophercloud.BuildRequestBody(opts, "endpoint")
+}
+
+var _ endpoints.CreateOptsBuilder = endpointCreateOpts{}
+
+// endpointUpdateOpts - gophercloud endpoints.UpdateOpts has no enabled flag
+type endpointUpdateOpts struct {
+	Availability gophercloud.Availability `json:"interface,omitempty"`
+	Region       string                   `json:"region,omitempty"`
+	URL          string                   `json:"url,omitempty"`
+	Enabled      *bool                    `json:"enabled,omitempty"`
+}
+
+// ToEndpointUpdateMap - implements endpoints.UpdateOptsBuilder
+func (opts endpointUpdateOpts) ToEndpointUpdateMap() (map[string]any, error) {
+	return gophercloud.BuildRequestBody(opts, "endpoint")
+}
+
+var _ endpoints.UpdateOptsBuilder = endpointUpdateOpts{}
+
+// getEndpoint - returns the endpoint, nil if it does not exist
+func getEndpoint(
+	ctx context.Context,
+	client *gophercloud.ServiceClient,
+	endpointID string,
+) (*endpoints.Endpoint, error) {
+	endpoint, err := endpoints.Get(ctx, client, endpointID).Extract()
+	if err != nil {
+		if gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return endpoint, nil
+}
+
+// getRegionEndpoints - returns the endpoints of the service registered for
+// the availability in the region
+func getRegionEndpoints(
+	ctx context.Context,
+	client *gophercloud.ServiceClient,
+	serviceID string,
+	availability gophercloud.Availability,
+	region string,
+) ([]endpoints.Endpoint, error) {
+	allPages, err := endpoints.List(client, endpoints.ListOpts{
+		Availability: availability,
+		ServiceID:    serviceID,
+		RegionID:     region,
+	}).AllPages(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return endpoints.ExtractEndpoints(allPages)
+}
+
+// createRegionEndpoint - registers the endpoint, returns its ID
+func createRegionEndpoint(
+	ctx context.Context,
+	client *gophercloud.ServiceClient,
+	e regionEndpoint,
+) (string, error) {
+	endpoint, err := endpoints.Create(ctx, client, endpointCreateOpts{
+		Availability: e.Availability,
+		Name:         e.Name,
+		Region:       e.Region,
+		URL:          e.URL,
+		ServiceID:    e.ServiceID,
+		Enabled:      e.Enabled,
+	}).Extract()
+	if err != nil {
+		return "", err
+	}
+
+	return endpoint.ID, nil
+}
+
+// updateRegionEndpoint - updates interface, region, URL and enabled flag of
+// the endpoint
+func updateRegionEndpoint(
+	ctx context.Context,
+	client *gophercloud.ServiceClient,
+	e regionEndpoint,
+	endpointID string,
+) error {
+	_, err := endpoints.Update(ctx, client, endpointID, endpointUpdateOpts{
+		Availability: e.Availability,
+		Region:       e.Region,
+		URL:          e.URL,
+		Enabled:      &e.Enabled,
+	}).Extract()
+	return err
+}
+
+// deleteRegionEndpoints - deletes the endpoints of the service registered for
+// the availability in the region
+func deleteRegionEndpoints(
+	ctx context.Context,
+	client *gophercloud.ServiceClient,
+	serviceID string,
+	availability gophercloud.Availability,
+	region string,
+) error {
+	allEndpoints, err := getRegionEndpoints(ctx, client, serviceID, availability, region)
+	if err != nil {
+		return err
+	}
+
+	for _, endpoint := range allEndpoints {
+		err = endpoints.Delete(ctx, client, endpoint.ID).ExtractErr()
+		if err != nil && !gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// createService - creates the service of the spec, returns the service as
+// registered in keystone
+func createService(
+	ctx context.Context,
+	client *gophercloud.ServiceClient,
+	spec keystonev1.KeystoneServiceSpec,
+) (*services.Service, error) {
+	return services.Create(ctx, client, services.CreateOpts{
+		Type:    spec.ServiceType,
+		Enabled: &spec.Enabled,
+		Extra: map[string]any{
+			"name":        spec.ServiceName,
+			"description": spec.ServiceDescription,
+		},
+	}).Extract()
+}
+
+// updateService - updates the service to the spec, returns the service as
+// registered in keystone
+func updateService(
+	ctx context.Context,
+	client *gophercloud.ServiceClient,
+	spec keystonev1.KeystoneServiceSpec,
+	serviceID string,
+) (*services.Service, error) {
+	return services.Update(ctx, client, serviceID, services.UpdateOpts{
+		Type:    spec.ServiceType,
+		Enabled: &spec.Enabled,
+		Extra: map[string]any{
+			"name":        spec.ServiceName,
+			"description": spec.ServiceDescription,
+		},
+	}).Extract()
+}
+
+// getService - returns the service, nil if it does not exist
+func getService(
+	ctx context.Context,
+	client *gophercloud.ServiceClient,
+	serviceID string,
+) (*services.Service, error) {
+	service, err := services.Get(ctx, client, serviceID).Extract()
+	if err != nil {
+		if gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return service, nil
+}
+
+// endpointDrift - returns how the registered endpoint differs from the
+// expected one, a nil endpoint got deleted
+func endpointDrift(
+	endpoint *endpoints.Endpoint,
+	expected regionEndpoint,
+) []string {
+	if endpoint == nil {
+		return []string{"deleted"}
+	}
+
+	drift := []string{}
+	if endpoint.URL != expected.URL {
+		drift = append(drift, fmt.Sprintf("url %s", endpoint.URL))
+	}
+	if endpoint.Availability != expected.Availability {
+		drift = append(drift, fmt.Sprintf("interface %s", endpoint.Availability))
+	}
+	if expected.Region != "" && endpoint.Region != expected.Region {
+		drift = append(drift, fmt.Sprintf("region %s", endpoint.Region))
+	}
+	if endpoint.Enabled != expected.Enabled {
+		drift = append(drift, fmt.Sprintf("enabled %t", endpoint.Enabled))
+	}
+	return drift
+}
+
+// endpointStatusMatches - returns true if the endpoint got registered with the
+// URL, region and enabled flag of the spec. Status entries without region
+// were registered before the region got recorded in the status.
+func endpointStatusMatches(status keystonev1.Endpoint, expected regionEndpoint) bool {
+	if status.URL != expected.URL {
+		return false
+	}
+	if status.Region == "" {
+		return true
+	}
+	return status.Region == expected.Region && status.Enabled == expected.Enabled
+}
+
+// serviceDrift - returns how the registered service differs from the spec,
+// a nil service got deleted
+func serviceDrift(
+	service *services.Service,
+	spec keystonev1.KeystoneServiceSpec,
+) []string {
+	if service == nil {
+		return []string{"deleted"}
+	}
+
+	drift := []string{}
+	if name, _ := service.Extra["name"].(string); name != spec.ServiceName {
+		drift = append(drift, fmt.Sprintf("name %s", name))
+	}
+	if service.Type != spec.ServiceType {
+		drift = append(drift, fmt.Sprintf("type %s", service.Type))
+	}
+	if service.Enabled != spec.Enabled {
+		drift = append(drift, fmt.Sprintf("enabled %t", service.Enabled))
+	}
+	if description, _ := service.Extra["description"].(string); description != spec.ServiceDescription {
+		drift = append(drift, fmt.Sprintf("description %q", description))
+	}
+	return drift
+}
+
+// observedService - returns the status of the service registered in keystone,
+// nil if it got deleted
+func observedService(service *services.Service) *keystonev1.KeystoneServiceObserved {
+	if service == nil {
+		return nil
+	}
+	name, _ := service.Extra["name"].(string)
+	description, _ := service.Extra["description"].(string)
+	return &keystonev1.KeystoneServiceObserved{
+		Name:        name,
+		Type:        service.Type,
+		Description: description,
+		Enabled:     service.Enabled,
+	}
+}
+
+// serviceSpecHash - returns the hash of the spec fields the service gets
+// registered in keystone with
+func serviceSpecHash(spec keystonev1.KeystoneServiceSpec) (string, error) {
+	return util.ObjectHash(keystonev1.KeystoneServiceObserved{
+		Name:        spec.ServiceName,
+		Type:        spec.ServiceType,
+		Description: spec.ServiceDescription,
+		Enabled:     spec.Enabled,
+	})
+}
+
+// setCatalogInSyncCondition - sets the CatalogInSync condition from the drift
+// found comparing the catalog against the spec
+func setCatalogInSyncCondition(
+	conditions *condition.Conditions,
+	policy keystonev1.DriftPolicy,
+	drift []string,
+) {
+	switch {
+	case len(drift) == 0:
+		conditions.MarkTrue(
+			keystonev1.KeystoneCatalogInSyncCondition,
+			keystonev1.KeystoneCatalogInSyncMessage)
+	case policy == keystonev1.DriftPolicyReport:
+		conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneCatalogInSyncCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.KeystoneCatalogInSyncDriftedMessage,
+			strings.Join(drift, "; ")))
+	default:
+		conditions.MarkTrue(
+			keystonev1.KeystoneCatalogInSyncCondition,
+			keystonev1.KeystoneCatalogInSyncCorrectedMessage,
+			strings.Join(drift, "; "))
+	}
+}
+
+// catalogResyncResult - requeues to compare the catalog against the spec
+// again after interval seconds
+func catalogResyncResult(interval *int32) ctrl.Result {
+	if interval == nil || *interval == 0 {
+		return ctrl.Result{}
+	}
+	return ctrl.Result{RequeueAfter: time.Duration(*interval) * time.Second}
+}
+
+// findCatalogOrphans - returns the services and endpoints of the catalog no
+// KeystoneService or KeystoneEndpoint owns. The identity service registered by
+// the keystone bootstrap and its endpoints are owned by the KeystoneAPI.
+// Endpoints of other regions are not audited, neither are services which
+// still have endpoints in other regions, they might be owned by the
+// deployment of the other region.
+func findCatalogOrphans(
+	catalogServices []services.Service,
+	catalogEndpoints []endpoints.Endpoint,
+	ksServices []keystonev1.KeystoneService,
+	ksEndpoints []keystonev1.KeystoneEndpoint,
+	region string,
+) ([]keystonev1.CatalogAuditService, []keystonev1.CatalogAuditEndpoint) {
+	serviceNames := map[string]string{}
+	identityServices := map[string]bool{}
+	ownedServices := map[string]bool{}
+	for _, service := range catalogServices {
+		name, _ := service.Extra["name"].(string)
+		serviceNames[service.ID] = name
+		if service.Type == "identity" && name == keystone.ServiceName {
+			identityServices[service.ID] = true
+			ownedServices[service.ID] = true
+		}
+		// a KeystoneService might not have stored the ID of the service
+		// it just created in its status yet
+		for _, ksService := range ksServices {
+			if ksService.Spec.ServiceType == service.Type && ksService.Spec.ServiceName == name {
+				ownedServices[service.ID] = true
+			}
+		}
+	}
+	for _, ksService := range ksServices {
+		if ksService.Status.ServiceID != "" {
+			ownedServices[ksService.Status.ServiceID] = true
+		}
+	}
+
+	ownedEndpoints := map[string]bool{}
+	for _, ksEndpoint := range ksEndpoints {
+		for _, endpointID := range ksEndpoint.Status.EndpointIDs {
+			ownedEndpoints[endpointID] = true
+		}
+	}
+
+	orphanedEndpoints := []keystonev1.CatalogAuditEndpoint{}
+	otherRegionServices := map[string]bool{}
+	for _, endpoint := range catalogEndpoints {
+		if region != "" && endpoint.Region != region {
+			otherRegionServices[endpoint.ServiceID] = true
+			continue
+		}
+		if ownedEndpoints[endpoint.ID] || identityServices[endpoint.ServiceID] {
+			continue
+		}
+		owned := false
+		for _, ksEndpoint := range ksEndpoints {
+			// a KeystoneEndpoint might not have stored the ID of the
+			// endpoint it just created in its status yet
+			entry, ok := ksEndpoint.Spec.GetEndpointEntries()[string(endpoint.Availability)]
+			if ok && ksEndpoint.Spec.ServiceName == serviceNames[endpoint.ServiceID] &&
+				entry.URL == endpoint.URL {
+				owned = true
+				break
+			}
+		}
+		if owned {
+			continue
+		}
+		orphanedEndpoints = append(orphanedEndpoints, keystonev1.CatalogAuditEndpoint{
+			ID:        endpoint.ID,
+			ServiceID: endpoint.ServiceID,
+			Interface: string(endpoint.Availability),
+			URL:       endpoint.URL,
+			Region:    endpoint.Region,
+		})
+	}
+
+	orphanedServices := []keystonev1.CatalogAuditService{}
+	for _, service := range catalogServices {
+		if ownedServices[service.ID] || otherRegionServices[service.ID] {
+			continue
+		}
+		orphanedServices = append(orphanedServices, keystonev1.CatalogAuditService{
+			ID:   service.ID,
+			Name: serviceNames[service.ID],
+			Type: service.Type,
+		})
+	}
+
+	return orphanedServices, orphanedEndpoints
+}
+
+// selectDuplicateEndpoint - returns the endpoint to keep of the duplicate
+// endpoints registered for an interface, and the ones to delete. It keeps the
+// endpoint with the URL of the spec, or else the oldest one. As keystone does
+// not expose when an endpoint got created, the oldest one is the one recorded
+// in the status, or else the first one keystone lists.
+func selectDuplicateEndpoint(
+	allEndpoints []endpoints.Endpoint,
+	url string,
+	registeredID string,
+) (endpoints.Endpoint, []endpoints.Endpoint) {
+	keep := 0
+	if idx := slices.IndexFunc(allEndpoints, func(e endpoints.Endpoint) bool {
+		return e.URL == url
+	}); idx >= 0 {
+		keep = idx
+	} else if idx := slices.IndexFunc(allEndpoints, func(e endpoints.Endpoint) bool {
+		return e.ID == registeredID
+	}); idx >= 0 {
+		keep = idx
+	}
+
+	duplicates := slices.Concat(allEndpoints[:keep], allEndpoints[keep+1:])
+	return allEndpoints[keep], duplicates
+}
+
+// removedDuplicatesLimit - the number of removed duplicate endpoints kept in
+// the status
+const removedDuplicatesLimit = 10
+
+// recordRemovedDuplicate - records a removed duplicate endpoint in the status,
+// which only keeps the last removedDuplicatesLimit ones next to the count of
+// all removed duplicates
+func recordRemovedDuplicate(status *keystonev1.KeystoneEndpointStatus, endpoint keystonev1.Endpoint) {
+	status.RemovedDuplicatesCount++
+	status.RemovedDuplicates = append(status.RemovedDuplicates, endpoint)
+	if excess := len(status.RemovedDuplicates) - removedDuplicatesLimit; excess > 0 {
+		status.RemovedDuplicates = slices.Clone(status.RemovedDuplicates[excess:])
+	}
+}
+
+// verifyAdoptedEndpoint - checks the endpoint to adopt exists and is the
+// expected endpoint of the service, with the same interface and region
+func verifyAdoptedEndpoint(
+	endpoint *endpoints.Endpoint,
+	endpointID string,
+	expected regionEndpoint,
+) error {
+	if endpoint == nil {
+		return fmt.Errorf("%w: endpoint %s", ErrAdoptNotFound, endpointID)
+	}
+	if endpoint.ServiceID != expected.ServiceID {
+		return fmt.Errorf("%w: endpoint %s is registered for service %s, not %s",
+			ErrAdoptServiceMismatch, endpointID, endpoint.ServiceID, expected.ServiceID)
+	}
+	if endpoint.Availability != expected.Availability {
+		return fmt.Errorf("%w: endpoint %s is a %s endpoint, not %s",
+			ErrAdoptEndpointMismatch, endpointID, endpoint.Availability, expected.Availability)
+	}
+	if endpoint.Region != expected.Region {
+		return fmt.Errorf("%w: endpoint %s is registered in region %s, not %s",
+			ErrAdoptEndpointMismatch, endpointID, endpoint.Region, expected.Region)
+	}
+
+	return nil
+}
@@ -0,0 +1,402 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/endpoints"
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/services"
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	openstack "github.com/openstack-k8s-operators/lib-common/modules/openstack"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+func TestEndpointDrift(t *testing.T) {
+	expected := regionEndpoint{
+		Endpoint: openstack.Endpoint{
+			Availability: gophercloud.AvailabilityPublic,
+			URL:          "https://nova.example.com",
+		},
+		Region:  "regionOne",
+		Enabled: true,
+	}
+	endpoint := &endpoints.Endpoint{
+		ID:           "1",
+		Availability: gophercloud.AvailabilityPublic,
+		Region:       "regionOne",
+		URL:          "https://nova.example.com",
+		Enabled:      true,
+	}
+	if drift := endpointDrift(endpoint, expected); len(drift) != 0 {
+		t.Errorf("expected no drift, got %v", drift)
+	}
+	// an unknown region is not compared
+	noRegion := expected
+	noRegion.Region = ""
+	if drift := endpointDrift(endpoint, noRegion); len(drift) != 0 {
+		t.Errorf("expected no drift without region, got %v", drift)
+	}
+
+	changed := &endpoints.Endpoint{
+		ID:           "1",
+		Availability: gophercloud.AvailabilityInternal,
+		Region:       "regionTwo",
+		URL:          "https://changed.example.com",
+		Enabled:      false,
+	}
+	drift := endpointDrift(changed, expected)
+	want := []string{"url https://changed.example.com", "interface internal", "region regionTwo", "enabled false"}
+	if len(drift) != len(want) {
+		t.Fatalf("expected drift %v, got %v", want, drift)
+	}
+	for i := range want {
+		if drift[i] != want[i] {
+			t.Errorf("expected drift %q, got %q", want[i], drift[i])
+		}
+	}
+
+	// a disabled endpoint of the spec
+	disabled := expected
+	disabled.Enabled = false
+	if drift := endpointDrift(endpoint, disabled); len(drift) != 1 || drift[0] != "enabled true" {
+		t.Errorf("expected the enabled endpoint to drift, got %v", drift)
+	}
+
+	if drift := endpointDrift(nil, expected); len(drift) != 1 || drift[0] != "deleted" {
+		t.Errorf("expected a deleted endpoint, got %v", drift)
+	}
+}
+
+func TestEndpointStatusMatches(t *testing.T) {
+	expected := regionEndpoint{
+		Endpoint: openstack.Endpoint{URL: "https://nova"},
+		Region:   "regionTwo",
+		Enabled:  false,
+	}
+
+	if !endpointStatusMatches(keystonev1.Endpoint{URL: "https://nova", Region: "regionTwo", Enabled: false}, expected) {
+		t.Errorf("expected the status to match the spec")
+	}
+	// recorded before region and enabled flag got recorded
+	if !endpointStatusMatches(keystonev1.Endpoint{URL: "https://nova"}, expected) {
+		t.Errorf("expected the status without region to match the spec")
+	}
+	if endpointStatusMatches(keystonev1.Endpoint{URL: "https://nova.old", Region: "regionTwo"}, expected) {
+		t.Errorf("expected a changed URL not to match")
+	}
+	if endpointStatusMatches(keystonev1.Endpoint{URL: "https://nova", Region: "regionOne"}, expected) {
+		t.Errorf("expected a changed region not to match")
+	}
+	if endpointStatusMatches(keystonev1.Endpoint{URL: "https://nova", Region: "regionTwo", Enabled: true}, expected) {
+		t.Errorf("expected a changed enabled flag not to match")
+	}
+}
+
+func TestServiceDrift(t *testing.T) {
+	spec := keystonev1.KeystoneServiceSpec{
+		ServiceName:        "nova",
+		ServiceType:        "compute",
+		ServiceDescription: "Nova Compute Service",
+		Enabled:            true,
+	}
+
+	service := &services.Service{}
+	err := json.Unmarshal([]byte(`{
+		"id": "1",
+		"type": "compute",
+		"enabled": true,
+		"name": "nova",
+		"description": "Nova Compute Service"
+	}`), service)
+	if err != nil {
+		t.Fatalf("failed to unmarshal the service: %v", err)
+	}
+	if drift := serviceDrift(service, spec); len(drift) != 0 {
+		t.Errorf("expected no drift, got %v", drift)
+	}
+
+	service.Enabled = false
+	service.Extra["description"] = "changed"
+	drift := serviceDrift(service, spec)
+	want := []string{"enabled false", `description "changed"`}
+	if len(drift) != len(want) {
+		t.Fatalf("expected drift %v, got %v", want, drift)
+	}
+	for i := range want {
+		if drift[i] != want[i] {
+			t.Errorf("expected drift %q, got %q", want[i], drift[i])
+		}
+	}
+
+	if drift := serviceDrift(nil, spec); len(drift) != 1 || drift[0] != "deleted" {
+		t.Errorf("expected a deleted service, got %v", drift)
+	}
+}
+
+func TestObservedService(t *testing.T) {
+	expected := keystonev1.KeystoneServiceObserved{
+		Name:        "nova",
+		Type:        "compute",
+		Description: "Nova Compute Service",
+		Enabled:     false,
+	}
+
+	service := &services.Service{}
+	err := json.Unmarshal([]byte(`{
+		"id": "1",
+		"type": "compute",
+		"enabled": false,
+		"name": "nova",
+		"description": "Nova Compute Service"
+	}`), service)
+	if err != nil {
+		t.Fatalf("failed to unmarshal the service: %v", err)
+	}
+	if observed := observedService(service); *observed != expected {
+		t.Errorf("expected the observed service %v, got %v", expected, *observed)
+	}
+
+	service.Enabled = true
+	if observed := observedService(service); !observed.Enabled {
+		t.Errorf("expected the observed service to be enabled, got %v", *observed)
+	}
+
+	if observed := observedService(nil); observed != nil {
+		t.Errorf("expected no deleted service, got %v", *observed)
+	}
+}
+
+func TestServiceSpecHash(t *testing.T) {
+	spec := keystonev1.KeystoneServiceSpec{
+		ServiceName: "nova",
+		ServiceType: "compute",
+		Enabled:     true,
+		Roles:       []string{"admin", "service"},
+	}
+	hash, err := serviceSpecHash(spec)
+	if err != nil {
+		t.Fatalf("failed to hash the spec: %v", err)
+	}
+
+	// fields which are not registered with the service do not change it
+	other := spec
+	other.Roles = []string{"service"}
+	if otherHash, _ := serviceSpecHash(other); otherHash != hash {
+		t.Errorf("expected the hash to ignore the roles, got %s and %s", hash, otherHash)
+	}
+
+	other = spec
+	other.ServiceDescription = "Nova Compute Service"
+	if otherHash, _ := serviceSpecHash(other); otherHash == hash {
+		t.Errorf("expected the hash to change with the description, got %s", otherHash)
+	}
+}
+
+func TestSetCatalogInSyncCondition(t *testing.T) {
+	conditions := condition.Conditions{}
+
+	setCatalogInSyncCondition(&conditions, keystonev1.DriftPolicyCorrect, []string{})
+	c := conditions.Get(keystonev1.KeystoneCatalogInSyncCondition)
+	if c.Status != corev1.ConditionTrue || c.Message != keystonev1.KeystoneCatalogInSyncMessage {
+		t.Errorf("expected the catalog in sync, got %s: %s", c.Status, c.Message)
+	}
+
+	setCatalogInSyncCondition(&conditions, keystonev1.DriftPolicyCorrect, []string{"public endpoint deleted"})
+	c = conditions.Get(keystonev1.KeystoneCatalogInSyncCondition)
+	if c.Status != corev1.ConditionTrue || c.Message != "Catalog in sync, corrected drift: public endpoint deleted" {
+		t.Errorf("expected the drift corrected, got %s: %s", c.Status, c.Message)
+	}
+
+	setCatalogInSyncCondition(&conditions, keystonev1.DriftPolicyReport, []string{"public endpoint deleted", "service disabled"})
+	c = conditions.Get(keystonev1.KeystoneCatalogInSyncCondition)
+	if c.Status != corev1.ConditionFalse || c.Message != "Catalog drifted from the spec: public endpoint deleted; service disabled" {
+		t.Errorf("expected the drift reported, got %s: %s", c.Status, c.Message)
+	}
+}
+
+func TestCatalogResyncResult(t *testing.T) {
+	if result := catalogResyncResult(nil); result.RequeueAfter != 0 {
+		t.Errorf("expected no resync without interval, got %v", result.RequeueAfter)
+	}
+	if result := catalogResyncResult(ptr.To[int32](0)); result.RequeueAfter != 0 {
+		t.Errorf("expected the resync disabled, got %v", result.RequeueAfter)
+	}
+	if result := catalogResyncResult(ptr.To[int32](300)); result.RequeueAfter != 5*time.Minute {
+		t.Errorf("expected a resync after 5m, got %v", result.RequeueAfter)
+	}
+}
+
+func makeCatalogService(t *testing.T, id, serviceType, name string) services.Service {
+	t.Helper()
+	service := services.Service{}
+	err := json.Unmarshal([]byte(`{"id": "`+id+`", "type": "`+serviceType+`", "name": "`+name+`", "enabled": true}`), &service)
+	if err != nil {
+		t.Fatalf("failed to unmarshal the service: %v", err)
+	}
+	return service
+}
+
+func TestFindCatalogOrphans(t *testing.T) {
+	catalogServices := []services.Service{
+		makeCatalogService(t, "keystone-id", "identity", "keystone"),
+		makeCatalogService(t, "nova-id", "compute", "nova"),
+		// KeystoneService did not store the ID yet
+		makeCatalogService(t, "glance-id", "image", "glance"),
+		makeCatalogService(t, "old-id", "volume", "cinder"),
+		// has endpoints in another region only
+		makeCatalogService(t, "remote-id", "object-store", "swift"),
+	}
+	catalogEndpoints := []endpoints.Endpoint{
+		{ID: "keystone-public", ServiceID: "keystone-id", Availability: gophercloud.AvailabilityPublic, Region: "regionOne", URL: "https://keystone"},
+		{ID: "nova-public", ServiceID: "nova-id", Availability: gophercloud.AvailabilityPublic, Region: "regionOne", URL: "https://nova"},
+		// KeystoneEndpoint did not store the ID yet
+		{ID: "nova-internal", ServiceID: "nova-id", Availability: gophercloud.AvailabilityInternal, Region: "regionOne", URL: "http://nova.internal"},
+		// URL no KeystoneEndpoint registers
+		{ID: "nova-old", ServiceID: "nova-id", Availability: gophercloud.AvailabilityInternal, Region: "regionOne", URL: "http://nova.old"},
+		{ID: "cinder-public", ServiceID: "old-id", Availability: gophercloud.AvailabilityPublic, Region: "regionOne", URL: "https://cinder"},
+		{ID: "swift-public", ServiceID: "remote-id", Availability: gophercloud.AvailabilityPublic, Region: "regionTwo", URL: "https://swift"},
+	}
+	ksServices := []keystonev1.KeystoneService{
+		{
+			Spec:   keystonev1.KeystoneServiceSpec{ServiceName: "nova", ServiceType: "compute"},
+			Status: keystonev1.KeystoneServiceStatus{ServiceID: "nova-id"},
+		},
+		{
+			Spec: keystonev1.KeystoneServiceSpec{ServiceName: "glance", ServiceType: "image"},
+		},
+	}
+	ksEndpoints := []keystonev1.KeystoneEndpoint{
+		{
+			Spec: keystonev1.KeystoneEndpointSpec{
+				ServiceName: "nova",
+				Endpoints: map[string]string{
+					"public":   "https://nova",
+					"internal": "http://nova.internal",
+				},
+			},
+			Status: keystonev1.KeystoneEndpointStatus{
+				EndpointIDs: map[string]string{"public": "nova-public"},
+			},
+		},
+	}
+
+	orphanedServices, orphanedEndpoints := findCatalogOrphans(catalogServices, catalogEndpoints, ksServices, ksEndpoints, "regionOne")
+
+	if len(orphanedServices) != 1 || orphanedServices[0] != (keystonev1.CatalogAuditService{ID: "old-id", Name: "cinder", Type: "volume"}) {
+		t.Errorf("expected the cinder service orphaned, got %v", orphanedServices)
+	}
+	if len(orphanedEndpoints) != 2 || orphanedEndpoints[0].ID != "nova-old" || orphanedEndpoints[1].ID != "cinder-public" {
+		t.Errorf("expected the old nova and the cinder endpoint orphaned, got %v", orphanedEndpoints)
+	}
+}
+
+func TestNextCatalogAudit(t *testing.T) {
+	instance := &keystonev1.KeystoneCatalogAudit{
+		Spec: keystonev1.KeystoneCatalogAuditSpec{AuditInterval: ptr.To[int32](3600)},
+	}
+	if next := nextCatalogAudit(instance); next != 0 {
+		t.Errorf("expected the first audit due, got %v", next)
+	}
+
+	instance.Status.LastAuditTime = &metav1.Time{Time: time.Now().Add(-2 * time.Hour)}
+	if next := nextCatalogAudit(instance); next != 0 {
+		t.Errorf("expected an audit due, got %v", next)
+	}
+
+	instance.Status.LastAuditTime = &metav1.Time{Time: time.Now().Add(-30 * time.Minute)}
+	if next := nextCatalogAudit(instance); next <= 29*time.Minute || next > 30*time.Minute {
+		t.Errorf("expected the next audit in 30m, got %v", next)
+	}
+
+	instance.Spec.AuditInterval = ptr.To[int32](0)
+	if next := nextCatalogAudit(instance); next >= 0 {
+		t.Errorf("expected no periodic audit, got %v", next)
+	}
+}
+
+func TestSelectDuplicateEndpoint(t *testing.T) {
+	allEndpoints := []endpoints.Endpoint{
+		{ID: "1", URL: "https://nova.old"},
+		{ID: "2", URL: "https://nova"},
+		{ID: "3", URL: "https://nova.other"},
+	}
+
+	// the endpoint with the URL of the spec
+	keep, duplicates := selectDuplicateEndpoint(allEndpoints, "https://nova", "3")
+	if keep.ID != "2" || len(duplicates) != 2 || duplicates[0].ID != "1" || duplicates[1].ID != "3" {
+		t.Errorf("expected to keep the endpoint with the spec URL, kept %s and removed %v", keep.ID, duplicates)
+	}
+
+	// the endpoint recorded in the status
+	keep, duplicates = selectDuplicateEndpoint(allEndpoints, "https://nova.new", "3")
+	if keep.ID != "3" || len(duplicates) != 2 || duplicates[0].ID != "1" || duplicates[1].ID != "2" {
+		t.Errorf("expected to keep the registered endpoint, kept %s and removed %v", keep.ID, duplicates)
+	}
+
+	// the first one keystone lists
+	keep, duplicates = selectDuplicateEndpoint(allEndpoints, "https://nova.new", "")
+	if keep.ID != "1" || len(duplicates) != 2 || duplicates[0].ID != "2" || duplicates[1].ID != "3" {
+		t.Errorf("expected to keep the first endpoint, kept %s and removed %v", keep.ID, duplicates)
+	}
+	if len(allEndpoints) != 3 || allEndpoints[1].ID != "2" {
+		t.Errorf("expected the listed endpoints unchanged, got %v", allEndpoints)
+	}
+}
+
+func TestRecordRemovedDuplicate(t *testing.T) {
+	status := &keystonev1.KeystoneEndpointStatus{}
+	for i := range removedDuplicatesLimit + 5 {
+		recordRemovedDuplicate(status, keystonev1.Endpoint{ID: fmt.Sprintf("%d", i)})
+	}
+
+	if status.RemovedDuplicatesCount != removedDuplicatesLimit+5 {
+		t.Errorf("expected %d removed duplicates, got %d", removedDuplicatesLimit+5, status.RemovedDuplicatesCount)
+	}
+	if len(status.RemovedDuplicates) != removedDuplicatesLimit {
+		t.Fatalf("expected the last %d removed duplicates, got %d", removedDuplicatesLimit, len(status.RemovedDuplicates))
+	}
+	if status.RemovedDuplicates[0].ID != "5" || status.RemovedDuplicates[removedDuplicatesLimit-1].ID != "14" {
+		t.Errorf("expected the last removed duplicates, got %v", status.RemovedDuplicates)
+	}
+}
+
+func TestVerifyAdoptedEndpoint(t *testing.T) {
+	endpoint := &endpoints.Endpoint{ID: "1", ServiceID: "nova", Availability: gophercloud.AvailabilityPublic, Region: "regionOne"}
+	expected := regionEndpoint{
+		Endpoint: openstack.Endpoint{ServiceID: "nova", Availability: gophercloud.AvailabilityPublic},
+		Region:   "regionOne",
+	}
+
+	if err := verifyAdoptedEndpoint(endpoint, "1", expected); err != nil {
+		t.Errorf("expected the endpoint to be adoptable, got %v", err)
+	}
+	if err := verifyAdoptedEndpoint(nil, "1", expected); !errors.Is(err, ErrAdoptNotFound) {
+		t.Errorf("expected %v, got %v", ErrAdoptNotFound, err)
+	}
+
+	otherService := expected
+	otherService.ServiceID = "cinder"
+	if err := verifyAdoptedEndpoint(endpoint, "1", otherService); !errors.Is(err, ErrAdoptServiceMismatch) {
+		t.Errorf("expected %v, got %v", ErrAdoptServiceMismatch, err)
+	}
+
+	// the public endpoint does not get adopted as the internal one
+	internal := expected
+	internal.Availability = gophercloud.AvailabilityInternal
+	if err := verifyAdoptedEndpoint(endpoint, "1", internal); !errors.Is(err, ErrAdoptEndpointMismatch) {
+		t.Errorf("expected %v, got %v", ErrAdoptEndpointMismatch, err)
+	}
+
+	otherRegion := expected
+	otherRegion.Region = "regionTwo"
+	if err := verifyAdoptedEndpoint(endpoint, "1", otherRegion); !errors.Is(err, ErrAdoptEndpointMismatch) {
+		t.Errorf("expected %v, got %v", ErrAdoptEndpointMismatch, err)
+	}
+}
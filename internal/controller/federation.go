@@ -0,0 +1,194 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gophercloud/gophercloud/v2"
+)
+
+// ErrFederationConfig - the identity providers can not be rendered into a
+// valid httpd config
+var ErrFederationConfig = errors.New("invalid federation config")
+
+// gophercloud only supports federation mappings, identity providers and
+// protocols are managed with plain requests against the OS-FEDERATION API.
+
+// identityProvider - OS-FEDERATION identity provider
+type identityProvider struct {
+	ID          string   `json:"id,omitempty"`
+	DomainID    string   `json:"domain_id,omitempty"`
+	Description string   `json:"description"`
+	Enabled     bool     `json:"enabled"`
+	RemoteIDs   []string `json:"remote_ids"`
+}
+
+// federationProtocol - OS-FEDERATION protocol of an identity provider
+type federationProtocol struct {
+	ID        string `json:"id,omitempty"`
+	MappingID string `json:"mapping_id"`
+}
+
+func identityProviderURL(client *gophercloud.ServiceClient, idpID string) string {
+	return client.ServiceURL("OS-FEDERATION", "identity_providers", idpID)
+}
+
+func federationProtocolURL(client *gophercloud.ServiceClient, idpID string, protocolID string) string {
+	return client.ServiceURL("OS-FEDERATION", "identity_providers", idpID, "protocols", protocolID)
+}
+
+// getIdentityProvider - returns the identity provider, nil if it does not exist
+func getIdentityProvider(
+	ctx context.Context,
+	client *gophercloud.ServiceClient,
+	idpID string,
+) (*identityProvider, error) {
+	var body struct {
+		IdentityProvider identityProvider `json:"identity_provider"`
+	}
+	_, err := client.Get(ctx, identityProviderURL(client, idpID), &body, nil)
+	if err != nil {
+		if gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &body.IdentityProvider, nil
+}
+
+// createIdentityProvider - registers the identity provider
+func createIdentityProvider(
+	ctx context.Context,
+	client *gophercloud.ServiceClient,
+	idp identityProvider,
+) (*identityProvider, error) {
+	var body struct {
+		IdentityProvider identityProvider `json:"identity_provider"`
+	}
+	idpID := idp.ID
+	idp.ID = ""
+	_, err := client.Put(ctx, identityProviderURL(client, idpID), map[string]any{"identity_provider": idp}, &body, &gophercloud.RequestOpts{
+		OkCodes: []int{http.StatusCreated},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &body.IdentityProvider, nil
+}
+
+// updateIdentityProvider - updates description, enabled and remote IDs of the
+// identity provider, the domain can not be changed
+func updateIdentityProvider(
+	ctx context.Context,
+	client *gophercloud.ServiceClient,
+	idp identityProvider,
+) error {
+	_, err := client.Patch(ctx, identityProviderURL(client, idp.ID), map[string]any{
+		"identity_provider": map[string]any{
+			"description": idp.Description,
+			"enabled":     idp.Enabled,
+			"remote_ids":  idp.RemoteIDs,
+		},
+	}, nil, &gophercloud.RequestOpts{
+		OkCodes: []int{http.StatusOK},
+	})
+	return err
+}
+
+// deleteIdentityProvider - deletes the identity provider together with its
+// protocols, an already deleted identity provider is not an error
+func deleteIdentityProvider(
+	ctx context.Context,
+	client *gophercloud.ServiceClient,
+	idpID string,
+) error {
+	_, err := client.Delete(ctx, identityProviderURL(client, idpID), nil)
+	if err != nil && !gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+		return err
+	}
+	return nil
+}
+
+// getFederationProtocol - returns the protocol, nil if it does not exist
+func getFederationProtocol(
+	ctx context.Context,
+	client *gophercloud.ServiceClient,
+	idpID string,
+	protocolID string,
+) (*federationProtocol, error) {
+	var body struct {
+		Protocol federationProtocol `json:"protocol"`
+	}
+	_, err := client.Get(ctx, federationProtocolURL(client, idpID, protocolID), &body, nil)
+	if err != nil {
+		if gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &body.Protocol, nil
+}
+
+// ensureFederationProtocol - registers the protocol or updates its mapping
+func ensureFederationProtocol(
+	ctx context.Context,
+	client *gophercloud.ServiceClient,
+	idpID string,
+	protocolID string,
+	mappingID string,
+) error {
+	protocol, err := getFederationProtocol(ctx, client, idpID, protocolID)
+	if err != nil {
+		return err
+	}
+
+	body := map[string]any{"protocol": federationProtocol{MappingID: mappingID}}
+	if protocol == nil {
+		_, err = client.Put(ctx, federationProtocolURL(client, idpID, protocolID), body, nil, &gophercloud.RequestOpts{
+			OkCodes: []int{http.StatusCreated},
+		})
+		return err
+	}
+	if protocol.MappingID != mappingID {
+		_, err = client.Patch(ctx, federationProtocolURL(client, idpID, protocolID), body, nil, &gophercloud.RequestOpts{
+			OkCodes: []int{http.StatusOK},
+		})
+		return err
+	}
+
+	return nil
+}
+
+// deleteFederationProtocol - deletes the protocol, an already deleted
+// protocol is not an error
+func deleteFederationProtocol(
+	ctx context.Context,
+	client *gophercloud.ServiceClient,
+	idpID string,
+	protocolID string,
+) error {
+	_, err := client.Delete(ctx, federationProtocolURL(client, idpID, protocolID), nil)
+	if err != nil && !gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+		return err
+	}
+	return nil
+}
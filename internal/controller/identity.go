@@ -0,0 +1,215 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/domains"
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/roles"
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	util "github.com/openstack-k8s-operators/lib-common/modules/common/util"
+	openstack "github.com/openstack-k8s-operators/lib-common/modules/openstack"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ErrDomainNotFound - the referenced domain does not exist in keystone
+var ErrDomainNotFound = errors.New("domain not found")
+
+// ErrNotAdopted - an object with the same name exists in keystone, which was
+// not created by the operator and adopting it was not requested
+var ErrNotAdopted = errors.New("already exists in keystone and adopt is not set")
+
+// getDomainID - returns the ID of the domain with domainName
+func getDomainID(
+	ctx context.Context,
+	os *openstack.OpenStack,
+	domainName string,
+) (string, error) {
+	allPages, err := domains.List(os.GetOSClient(), domains.ListOpts{Name: domainName}).AllPages(ctx)
+	if err != nil {
+		return "", err
+	}
+	allDomains, err := domains.ExtractDomains(allPages)
+	if err != nil {
+		return "", err
+	}
+	if len(allDomains) == 0 {
+		return "", fmt.Errorf("%w: %s", ErrDomainNotFound, domainName)
+	}
+
+	return allDomains[0].ID, nil
+}
+
+// persistCreatedID - patches the ID of an object just created in keystone,
+// set by setID, into the status of instance right away. If only the deferred
+// status patch at the end of the reconcile recorded it and that got lost, the
+// next reconcile would find the object by name and refuse it as not adopted.
+// instance itself is left as is, the deferred patch still records the ID and
+// the other status changes of the reconcile.
+func persistCreatedID[T client.Object](
+	ctx context.Context,
+	c client.Client,
+	instance T,
+	setID func(T),
+) error {
+	created, ok := instance.DeepCopyObject().(T)
+	if !ok {
+		return fmt.Errorf("unexpected type %T", instance)
+	}
+	setID(created)
+	return c.Status().Patch(ctx, created, client.MergeFrom(instance))
+}
+
+// hashPassword - hash of a password kept in the status to detect changes of
+// the Secret, salted with the UID of the instance so the same password does
+// not give the same hash across instances
+func hashPassword(password string, uid types.UID) (string, error) {
+	return util.ObjectHash(struct {
+		Password string
+		Salt     types.UID
+	}{
+		Password: password,
+		Salt:     uid,
+	})
+}
+
+// systemRoleURL - gophercloud has no support for system role assignments,
+// this returns /system/users/{user_id}/roles/{role_id}
+func systemRoleURL(client *gophercloud.ServiceClient, userID string, roleID string) string {
+	return client.ServiceURL("system", "users", userID, "roles", roleID)
+}
+
+// assignRole - assigns the role to the user on the project, domain or system
+// referenced in the assignment
+func assignRole(
+	ctx context.Context,
+	os *openstack.OpenStack,
+	assignment keystonev1.KeystoneRoleAssignmentStatus,
+) error {
+	client := os.GetOSClient()
+	if assignment.System != "" {
+		_, err := client.Put(ctx, systemRoleURL(client, assignment.UserID, assignment.RoleID), nil, nil, &gophercloud.RequestOpts{
+			OkCodes: []int{http.StatusNoContent},
+		})
+		return err
+	}
+
+	return roles.Assign(ctx, client, assignment.RoleID, roles.AssignOpts{
+		UserID:    assignment.UserID,
+		ProjectID: assignment.ProjectID,
+		DomainID:  assignment.DomainID,
+	}).ExtractErr()
+}
+
+// unassignRole - revokes the role assignment, an already revoked assignment
+// is not an error
+func unassignRole(
+	ctx context.Context,
+	os *openstack.OpenStack,
+	assignment keystonev1.KeystoneRoleAssignmentStatus,
+) error {
+	client := os.GetOSClient()
+	var err error
+	if assignment.System != "" {
+		_, err = client.Delete(ctx, systemRoleURL(client, assignment.UserID, assignment.RoleID), &gophercloud.RequestOpts{
+			OkCodes: []int{http.StatusNoContent},
+		})
+	} else {
+		err = roles.Unassign(ctx, client, assignment.RoleID, roles.UnassignOpts{
+			UserID:    assignment.UserID,
+			ProjectID: assignment.ProjectID,
+			DomainID:  assignment.DomainID,
+		}).ExtractErr()
+	}
+	if err != nil && !gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+		return err
+	}
+
+	return nil
+}
+
+// serviceRoleAssignment - returns the role assignment of the service user in
+// the form the assignRole and unassignRole helpers take
+func serviceRoleAssignment(assignment keystonev1.KeystoneServiceRoleAssignment) keystonev1.KeystoneRoleAssignmentStatus {
+	return keystonev1.KeystoneRoleAssignmentStatus{
+		RoleID:    assignment.RoleID,
+		UserID:    assignment.UserID,
+		ProjectID: assignment.ProjectID,
+		System:    assignment.System,
+	}
+}
+
+// legacyServiceRoles - the roles service users got assigned on the service
+// project of the default domain before the roles became configurable
+var legacyServiceRoles = []string{"admin", "service"}
+
+// needsLegacyRoleAssignments - whether the service user may have role
+// assignments of an earlier version, which did not record them in the status,
+// left to revoke. Once revoked they are not looked up again, also when the
+// spec has no roles and the status records none.
+func needsLegacyRoleAssignments(status keystonev1.KeystoneServiceStatus) bool {
+	return status.RoleAssignments == nil &&
+		status.AdoptedUserID == "" &&
+		!status.LegacyRoleAssignmentsRevoked
+}
+
+// legacyRoleAssignments - returns the role assignments an earlier version made
+// for the service user, which did not record them in the status. roleIDs holds
+// the IDs of the legacyServiceRoles which exist in keystone.
+func legacyRoleAssignments(
+	userID string,
+	projectID string,
+	roleIDs map[string]string,
+) []keystonev1.KeystoneServiceRoleAssignment {
+	assignments := []keystonev1.KeystoneServiceRoleAssignment{}
+	for _, roleName := range legacyServiceRoles {
+		roleID, ok := roleIDs[roleName]
+		if !ok {
+			continue
+		}
+		assignments = append(assignments, keystonev1.KeystoneServiceRoleAssignment{
+			RoleName:  roleName,
+			RoleID:    roleID,
+			UserID:    userID,
+			ProjectID: projectID,
+		})
+	}
+
+	return assignments
+}
+
+// staleRoleAssignments - returns the previous role assignments which are not
+// desired anymore
+func staleRoleAssignments(
+	previous []keystonev1.KeystoneServiceRoleAssignment,
+	desired []keystonev1.KeystoneServiceRoleAssignment,
+) []keystonev1.KeystoneServiceRoleAssignment {
+	stale := []keystonev1.KeystoneServiceRoleAssignment{}
+	for _, p := range previous {
+		if !slices.Contains(desired, p) {
+			stale = append(stale, p)
+		}
+	}
+
+	return stale
+}
@@ -0,0 +1,143 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestStaleRoleAssignments(t *testing.T) {
+	admin := keystonev1.KeystoneServiceRoleAssignment{RoleName: "admin", RoleID: "a", UserID: "u", ProjectID: "p"}
+	service := keystonev1.KeystoneServiceRoleAssignment{RoleName: "service", RoleID: "s", UserID: "u", ProjectID: "p"}
+	reader := keystonev1.KeystoneServiceRoleAssignment{RoleName: "reader", RoleID: "r", UserID: "u", System: keystonev1.SystemScopeAll}
+
+	if stale := staleRoleAssignments(nil, []keystonev1.KeystoneServiceRoleAssignment{admin}); len(stale) != 0 {
+		t.Errorf("expected nothing to revoke without previous assignments, got %v", stale)
+	}
+
+	stale := staleRoleAssignments(
+		[]keystonev1.KeystoneServiceRoleAssignment{admin, service},
+		[]keystonev1.KeystoneServiceRoleAssignment{service, reader})
+	if len(stale) != 1 || stale[0] != admin {
+		t.Errorf("expected admin to be revoked, got %v", stale)
+	}
+
+	// the same role on another project is another assignment
+	moved := service
+	moved.ProjectID = "other"
+	stale = staleRoleAssignments(
+		[]keystonev1.KeystoneServiceRoleAssignment{service},
+		[]keystonev1.KeystoneServiceRoleAssignment{moved})
+	if len(stale) != 1 || stale[0] != service {
+		t.Errorf("expected the assignment on the previous project to be revoked, got %v", stale)
+	}
+}
+
+func TestStaleLegacyRoleAssignments(t *testing.T) {
+	// a service user of an earlier version has no role assignments in the
+	// status, it got admin and service assigned on the service project
+	legacy := legacyRoleAssignments("u", "p", map[string]string{"admin": "a", "service": "s"})
+	admin := keystonev1.KeystoneServiceRoleAssignment{RoleName: "admin", RoleID: "a", UserID: "u", ProjectID: "p"}
+	service := keystonev1.KeystoneServiceRoleAssignment{RoleName: "service", RoleID: "s", UserID: "u", ProjectID: "p"}
+	if len(legacy) != 2 || legacy[0] != admin || legacy[1] != service {
+		t.Fatalf("expected the admin and service roles on the service project, got %v", legacy)
+	}
+
+	if stale := staleRoleAssignments(legacy, []keystonev1.KeystoneServiceRoleAssignment{admin, service}); len(stale) != 0 {
+		t.Errorf("expected nothing to revoke with the default roles, got %v", stale)
+	}
+
+	stale := staleRoleAssignments(legacy, []keystonev1.KeystoneServiceRoleAssignment{service})
+	if len(stale) != 1 || stale[0] != admin {
+		t.Errorf("expected the legacy admin role to be revoked, got %v", stale)
+	}
+
+	// a legacy role which does not exist in keystone is not assigned
+	legacy = legacyRoleAssignments("u", "p", map[string]string{"service": "s"})
+	if len(legacy) != 1 || legacy[0] != service {
+		t.Errorf("expected only the service role, got %v", legacy)
+	}
+}
+
+func TestNeedsLegacyRoleAssignments(t *testing.T) {
+	// a service user of an earlier version
+	if !needsLegacyRoleAssignments(keystonev1.KeystoneServiceStatus{}) {
+		t.Errorf("expected the legacy role assignments to be looked up")
+	}
+
+	// the spec has no roles, nothing gets recorded, the lookup is done once
+	status := keystonev1.KeystoneServiceStatus{LegacyRoleAssignmentsRevoked: true}
+	if needsLegacyRoleAssignments(status) {
+		t.Errorf("expected no lookup once the legacy role assignments got revoked")
+	}
+
+	status = keystonev1.KeystoneServiceStatus{RoleAssignments: []keystonev1.KeystoneServiceRoleAssignment{{RoleName: "service"}}}
+	if needsLegacyRoleAssignments(status) {
+		t.Errorf("expected no lookup with recorded role assignments")
+	}
+
+	status = keystonev1.KeystoneServiceStatus{AdoptedUserID: "u"}
+	if needsLegacyRoleAssignments(status) {
+		t.Errorf("expected no lookup for an adopted user")
+	}
+}
+
+func TestPersistCreatedID(t *testing.T) {
+	domain := &keystonev1.KeystoneDomain{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "openstack"},
+	}
+	c := fake.NewClientBuilder().
+		WithScheme(newTestScheme()).
+		WithObjects(domain).
+		WithStatusSubresource(domain).
+		Build()
+
+	instance := &keystonev1.KeystoneDomain{}
+	if err := c.Get(context.Background(), types.NamespacedName{Name: "test", Namespace: "openstack"}, instance); err != nil {
+		t.Fatal(err)
+	}
+	// a status change of the reconcile, left to the deferred patch
+	instance.Status.Conditions.Set(condition.TrueCondition(condition.ReadyCondition, condition.ReadyMessage))
+
+	err := persistCreatedID(context.Background(), c, instance, func(created *keystonev1.KeystoneDomain) {
+		created.Status.DomainID = "d"
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stored := &keystonev1.KeystoneDomain{}
+	if err := c.Get(context.Background(), types.NamespacedName{Name: "test", Namespace: "openstack"}, stored); err != nil {
+		t.Fatal(err)
+	}
+	if stored.Status.DomainID != "d" {
+		t.Errorf("expected the domain ID to be stored, got %q", stored.Status.DomainID)
+	}
+	if len(stored.Status.Conditions) != 0 {
+		t.Errorf("expected only the domain ID to be patched, got conditions %v", stored.Status.Conditions)
+	}
+	if instance.Status.DomainID != "" || len(instance.Status.Conditions) != 1 {
+		t.Errorf("expected the instance to be left as is, got %+v", instance.Status)
+	}
+}
+
+func TestHashPassword(t *testing.T) {
+	hash, err := hashPassword("secret", "uid-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := hashPassword("secret", "uid-a"); again != hash {
+		t.Errorf("expected the same hash for the same password and instance, got %s and %s", hash, again)
+	}
+	if changed, _ := hashPassword("changed", "uid-a"); changed == hash {
+		t.Errorf("expected another hash for a changed password")
+	}
+	if other, _ := hashPassword("secret", "uid-b"); other == hash {
+		t.Errorf("expected another hash for the same password of another instance")
+	}
+}
@@ -17,14 +17,19 @@ limitations under the License.
 package controller
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"maps"
+	"net"
+	"net/url"
 	"slices"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/tokens"
 	networkv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
 	memcachedv1 "github.com/openstack-k8s-operators/infra-operator/apis/memcached/v1beta1"
 	rabbitmqv1 "github.com/openstack-k8s-operators/infra-operator/apis/rabbitmq/v1beta1"
@@ -42,7 +47,9 @@ import (
 	job "github.com/openstack-k8s-operators/lib-common/modules/common/job"
 	labels "github.com/openstack-k8s-operators/lib-common/modules/common/labels"
 	nad "github.com/openstack-k8s-operators/lib-common/modules/common/networkattachment"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/pod"
 	common_rbac "github.com/openstack-k8s-operators/lib-common/modules/common/rbac"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/rsh"
 	oko_secret "github.com/openstack-k8s-operators/lib-common/modules/common/secret"
 	"github.com/openstack-k8s-operators/lib-common/modules/common/service"
 	"github.com/openstack-k8s-operators/lib-common/modules/common/tls"
@@ -63,6 +70,8 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -96,13 +105,18 @@ func (r *KeystoneAPIReconciler) GetLogger(ctx context.Context) logr.Logger {
 // KeystoneAPIReconciler reconciles a KeystoneAPI object
 type KeystoneAPIReconciler struct {
 	client.Client
-	Kclient kubernetes.Interface
-	Scheme  *runtime.Scheme
+	Kclient       kubernetes.Interface
+	Scheme        *runtime.Scheme
+	RestConfig    *rest.Config
+	EventRecorder record.EventRecorder
 }
 
 // +kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneapis,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneapis/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneapis/finalizers,verbs=update;patch
+// +kubebuilder:rbac:groups=keystone.openstack.org,resources=keystonedomains,verbs=get;list;watch
+// +kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneidentityproviders,verbs=get;list;watch
+// +kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneprotocols,verbs=get;list;watch
 // +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;update;patch;delete;
 // +kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch;create;update;patch;delete;
 // +kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch;create;update;patch;delete;
@@ -126,6 +140,8 @@ type KeystoneAPIReconciler struct {
 // keystone service account permissions that are needed to grant permission to the above
 // +kubebuilder:rbac:groups="security.openshift.io",resourceNames=anyuid,resources=securitycontextconstraints,verbs=use
 // +kubebuilder:rbac:groups="",resources=pods,verbs=create;delete;get;list;patch;update;watch
+// +kubebuilder:rbac:groups="",resources=pods/exec,verbs=create
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 // Reconcile reconcile keystone API requests
 func (r *KeystoneAPIReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, _err error) {
@@ -215,12 +231,22 @@ func (r *KeystoneAPIReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		cl.Set(condition.UnknownCondition(condition.DeploymentReadyCondition, condition.InitReason, condition.DeploymentReadyInitMessage))
 		cl.Set(condition.UnknownCondition(condition.NetworkAttachmentsReadyCondition, condition.InitReason, condition.NetworkAttachmentsReadyInitMessage))
 		cl.Set(condition.UnknownCondition(condition.CronJobReadyCondition, condition.InitReason, condition.CronJobReadyInitMessage))
+		cl.Set(condition.UnknownCondition(keystonev1.KeystoneAPIFernetKeysReadyCondition, condition.InitReason, keystonev1.KeystoneAPIFernetKeysReadyInitMessage))
+		cl.Set(condition.UnknownCondition(keystonev1.KeystoneAPICredentialKeysReadyCondition, condition.InitReason, keystonev1.KeystoneAPICredentialKeysReadyInitMessage))
+		cl.Set(condition.UnknownCondition(keystonev1.KeystoneAPIReceiptKeysReadyCondition, condition.InitReason, keystonev1.KeystoneAPIReceiptKeysReadyInitMessage))
+		cl.Set(condition.UnknownCondition(keystonev1.KeystoneAPIDatabaseUpgradeReadyCondition, condition.InitReason, keystonev1.KeystoneAPIDatabaseUpgradeReadyInitMessage))
+		cl.Set(condition.UnknownCondition(keystonev1.KeystoneAPIUpgradeReadinessCondition, condition.InitReason, keystonev1.KeystoneAPIUpgradeReadinessInitMessage))
 		// service account, role, rolebinding conditions
 		cl.Set(condition.UnknownCondition(condition.ServiceAccountReadyCondition, condition.InitReason, condition.ServiceAccountReadyInitMessage))
 		cl.Set(condition.UnknownCondition(condition.RoleReadyCondition, condition.InitReason, condition.RoleReadyInitMessage))
 		cl.Set(condition.UnknownCondition(condition.RoleBindingReadyCondition, condition.InitReason, condition.RoleBindingReadyInitMessage))
 	}
 
+	// Init Canary condition if the canary rollout strategy is used
+	if !instance.Spec.ExternalKeystoneAPI && instance.Spec.RolloutStrategy == keystonev1.RolloutStrategyCanary {
+		cl.Set(condition.UnknownCondition(keystonev1.KeystoneAPICanaryReadyCondition, condition.InitReason, keystonev1.KeystoneAPICanaryReadyInitMessage))
+	}
+
 	// Init Topology condition if there's a reference
 	if instance.Spec.TopologyRef != nil {
 		cl.Set(condition.UnknownCondition(condition.TopologyReadyCondition, condition.InitReason, condition.TopologyReadyInitMessage))
@@ -234,6 +260,17 @@ func (r *KeystoneAPIReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return ctrl.Result{}, nil
 	}
 
+	// record the name the resources of the instance are named after, before
+	// the first hash gets added
+	if instance.Status.ResourceName == "" {
+		keystoneAPIs := &keystonev1.KeystoneAPIList{}
+		err = r.Client.List(ctx, keystoneAPIs, client.InNamespace(instance.Namespace))
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		instance.Status.ResourceName = keystone.UniqueResourceName(instance, keystoneAPIs.Items)
+	}
+
 	if instance.Status.Hash == nil {
 		instance.Status.Hash = map[string]string{}
 	}
@@ -255,7 +292,11 @@ func (r *KeystoneAPIReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	}
 
 	// Handle non-deleted clusters
-	return r.reconcileNormal(ctx, instance, helper)
+	result, err = r.reconcileNormal(ctx, instance, helper)
+	if err != nil {
+		return result, err
+	}
+	return requeueForKeyRotation(instance, result), nil
 }
 
 // fields to index to reconcile when change
@@ -269,6 +310,12 @@ const (
 	federatedRealmConfigField           = ".spec.federatedRealmConfig"                         // #nosec G101
 )
 
+// domainLDAPSecretField - KeystoneDomain field to index the LDAP bind password secret
+const domainLDAPSecretField = ".spec.ldap.secret" // #nosec G101
+
+// federationSecretField - KeystoneIdentityProvider field to index the OIDC/SAML secret
+const federationSecretField = ".spec.federation.secret" // #nosec G101
+
 var allWatchFields = []string{
 	passwordSecretField,
 	caBundleSecretNameField,
@@ -367,6 +414,30 @@ func (r *KeystoneAPIReconciler) SetupWithManager(ctx context.Context, mgr ctrl.M
 		return err
 	}
 
+	// index domainLDAPSecretField
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &keystonev1.KeystoneDomain{}, domainLDAPSecretField, func(rawObj client.Object) []string {
+		// Extract the secret name from the spec, if one is provided
+		cr := rawObj.(*keystonev1.KeystoneDomain)
+		if cr.Spec.LDAP == nil || cr.Spec.LDAP.Secret == "" {
+			return nil
+		}
+		return []string{cr.Spec.LDAP.Secret}
+	}); err != nil {
+		return err
+	}
+
+	// index federationSecretField
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &keystonev1.KeystoneIdentityProvider{}, federationSecretField, func(rawObj client.Object) []string {
+		// Extract the secret name from the spec, if one is provided
+		cr := rawObj.(*keystonev1.KeystoneIdentityProvider)
+		if cr.GetSecretName() == "" {
+			return nil
+		}
+		return []string{cr.GetSecretName()}
+	}); err != nil {
+		return err
+	}
+
 	memcachedFn := func(ctx context.Context, o client.Object) []reconcile.Request {
 		result := []reconcile.Request{}
 
@@ -420,9 +491,109 @@ func (r *KeystoneAPIReconciler) SetupWithManager(ctx context.Context, mgr ctrl.M
 		Watches(&topologyv1.Topology{},
 			handler.EnqueueRequestsFromMapFunc(r.findObjectsForSrc),
 			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&keystonev1.KeystoneDomain{},
+			handler.EnqueueRequestsFromMapFunc(r.findObjectsInNamespace)).
+		Watches(
+			&corev1.Secret{},
+			handler.EnqueueRequestsFromMapFunc(r.findObjectsForDomainSecret),
+			builder.WithPredicates(predicate.ResourceVersionChangedPredicate{}),
+		).
+		Watches(&keystonev1.KeystoneIdentityProvider{},
+			handler.EnqueueRequestsFromMapFunc(r.findObjectsInNamespace)).
+		Watches(&keystonev1.KeystoneProtocol{},
+			handler.EnqueueRequestsFromMapFunc(r.findObjectsInNamespace)).
+		Watches(
+			&corev1.Secret{},
+			handler.EnqueueRequestsFromMapFunc(r.findObjectsForFederationSecret),
+			builder.WithPredicates(predicate.ResourceVersionChangedPredicate{}),
+		).
 		Complete(r)
 }
 
+// findObjectsInNamespace - the domain specific and federation configs get
+// rendered by the KeystoneAPI, reconcile the KeystoneAPI CR the changed
+// KeystoneDomain, KeystoneIdentityProvider or KeystoneProtocol uses. This is
+// the one named by its KeystoneAPINameLabel or, without the label, the only
+// one in the namespace.
+func (r *KeystoneAPIReconciler) findObjectsInNamespace(ctx context.Context, src client.Object) []reconcile.Request {
+	requests := []reconcile.Request{}
+
+	Log := r.GetLogger(ctx)
+
+	crList := &keystonev1.KeystoneAPIList{}
+	err := r.List(ctx, crList, client.InNamespace(src.GetNamespace()))
+	if err != nil {
+		Log.Error(err, fmt.Sprintf("listing %s - %s", crList.GroupVersionKind().Kind, src.GetNamespace()))
+		return requests
+	}
+
+	for _, item := range crList.Items {
+		if !usesKeystoneAPI(src, &item, len(crList.Items) == 1) {
+			continue
+		}
+		Log.Info(fmt.Sprintf("%T %s changed, reconcile: %s - %s", src, src.GetName(), item.GetName(), item.GetNamespace()))
+
+		requests = append(requests,
+			reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      item.GetName(),
+					Namespace: item.GetNamespace(),
+				},
+			},
+		)
+	}
+
+	return requests
+}
+
+// findObjectsForDomainSecret - reconcile the KeystoneAPI CRs when the LDAP
+// bind password secret of a KeystoneDomain changes
+func (r *KeystoneAPIReconciler) findObjectsForDomainSecret(ctx context.Context, src client.Object) []reconcile.Request {
+	Log := r.GetLogger(ctx)
+
+	domainList := &keystonev1.KeystoneDomainList{}
+	listOps := &client.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector(domainLDAPSecretField, src.GetName()),
+		Namespace:     src.GetNamespace(),
+	}
+	err := r.List(ctx, domainList, listOps)
+	if err != nil {
+		Log.Error(err, fmt.Sprintf("listing %s for field: %s - %s", domainList.GroupVersionKind().Kind, domainLDAPSecretField, src.GetNamespace()))
+		return []reconcile.Request{}
+	}
+
+	requests := []reconcile.Request{}
+	for _, item := range domainList.Items {
+		requests = append(requests, r.findObjectsInNamespace(ctx, &item)...)
+	}
+
+	return requests
+}
+
+// findObjectsForFederationSecret - reconcile the KeystoneAPI CRs when the
+// OIDC or SAML secret of a KeystoneIdentityProvider changes
+func (r *KeystoneAPIReconciler) findObjectsForFederationSecret(ctx context.Context, src client.Object) []reconcile.Request {
+	Log := r.GetLogger(ctx)
+
+	idpList := &keystonev1.KeystoneIdentityProviderList{}
+	listOps := &client.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector(federationSecretField, src.GetName()),
+		Namespace:     src.GetNamespace(),
+	}
+	err := r.List(ctx, idpList, listOps)
+	if err != nil {
+		Log.Error(err, fmt.Sprintf("listing %s for field: %s - %s", idpList.GroupVersionKind().Kind, federationSecretField, src.GetNamespace()))
+		return []reconcile.Request{}
+	}
+
+	requests := []reconcile.Request{}
+	for _, item := range idpList.Items {
+		requests = append(requests, r.findObjectsInNamespace(ctx, &item)...)
+	}
+
+	return requests
+}
+
 func (r *KeystoneAPIReconciler) findObjectsForSrc(ctx context.Context, src client.Object) []reconcile.Request {
 	requests := []reconcile.Request{}
 
@@ -511,7 +682,7 @@ func (r *KeystoneAPIReconciler) reconcileDelete(ctx context.Context, instance *k
 	}
 
 	// remove db finalizer before the keystone one
-	db, err := mariadbv1.GetDatabaseByNameAndAccount(ctx, helper, keystone.DatabaseCRName, instance.Spec.DatabaseAccount, instance.Namespace)
+	db, err := mariadbv1.GetDatabaseByNameAndAccount(ctx, helper, keystone.DatabaseCRName(instance), instance.Spec.DatabaseAccount, instance.Namespace)
 	if err != nil && !k8s_errors.IsNotFound(err) {
 		return ctrl.Result{}, err
 	}
@@ -563,43 +734,46 @@ func (r *KeystoneAPIReconciler) reconcileInit(
 	}
 
 	//
-	// run keystone db sync
+	// run keystone db sync, during an upgrade of the database the schema gets
+	// synced by the phases of reconcileUpgrade
 	//
-	dbSyncHash := instance.Status.Hash[keystonev1.DbSyncHash]
-	jobDef := keystone.DbSyncJob(instance, serviceLabels, serviceAnnotations)
-	dbSyncjob := job.NewJob(
-		jobDef,
-		keystonev1.DbSyncHash,
-		instance.Spec.PreserveJobs,
-		5*time.Second,
-		dbSyncHash,
-	)
-	ctrlResult, err := dbSyncjob.DoJob(
-		ctx,
-		helper,
-	)
-	if (ctrlResult != ctrl.Result{}) {
-		instance.Status.Conditions.Set(condition.FalseCondition(
-			condition.DBSyncReadyCondition,
-			condition.RequestedReason,
-			condition.SeverityInfo,
-			condition.DBSyncReadyRunningMessage))
-		return ctrlResult, nil
-	}
-	if err != nil {
-		instance.Status.Conditions.Set(condition.FalseCondition(
-			condition.DBSyncReadyCondition,
-			condition.ErrorReason,
-			condition.SeverityWarning,
-			condition.DBSyncReadyErrorMessage,
-			err.Error()))
-		return ctrl.Result{}, err
-	}
-	if dbSyncjob.HasChanged() {
-		instance.Status.Hash[keystonev1.DbSyncHash] = dbSyncjob.GetHash()
-		Log.Info(fmt.Sprintf("Job %s hash added - %s", jobDef.Name, instance.Status.Hash[keystonev1.DbSyncHash]))
+	if !instance.IsDatabaseUpgradeInProgress() {
+		dbSyncHash := instance.Status.Hash[keystonev1.DbSyncHash]
+		jobDef := keystone.DbSyncJob(instance, serviceLabels, serviceAnnotations)
+		dbSyncjob := job.NewJob(
+			jobDef,
+			keystonev1.DbSyncHash,
+			instance.Spec.PreserveJobs,
+			5*time.Second,
+			dbSyncHash,
+		)
+		ctrlResult, err := dbSyncjob.DoJob(
+			ctx,
+			helper,
+		)
+		if (ctrlResult != ctrl.Result{}) {
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				condition.DBSyncReadyCondition,
+				condition.RequestedReason,
+				condition.SeverityInfo,
+				condition.DBSyncReadyRunningMessage))
+			return ctrlResult, nil
+		}
+		if err != nil {
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				condition.DBSyncReadyCondition,
+				condition.ErrorReason,
+				condition.SeverityWarning,
+				condition.DBSyncReadyErrorMessage,
+				err.Error()))
+			return ctrl.Result{}, err
+		}
+		if dbSyncjob.HasChanged() {
+			instance.Status.Hash[keystonev1.DbSyncHash] = dbSyncjob.GetHash()
+			Log.Info(fmt.Sprintf("Job %s hash added - %s", jobDef.Name, instance.Status.Hash[keystonev1.DbSyncHash]))
+		}
+		instance.Status.Conditions.MarkTrue(condition.DBSyncReadyCondition, condition.DBSyncReadyMessage)
 	}
-	instance.Status.Conditions.MarkTrue(condition.DBSyncReadyCondition, condition.DBSyncReadyMessage)
 
 	// run keystone db sync - end
 
@@ -725,7 +899,7 @@ func (r *KeystoneAPIReconciler) reconcileInit(
 	//
 	// BootStrap Job
 	//
-	jobDef = keystone.BootstrapJob(instance, serviceLabels, serviceAnnotations, instance.Status.APIEndpoints, memcached)
+	jobDef := keystone.BootstrapJob(instance, serviceLabels, serviceAnnotations, instance.Status.APIEndpoints, memcached)
 	bootstrapjob := job.NewJob(
 		jobDef,
 		keystonev1.BootstrapHash,
@@ -733,7 +907,7 @@ func (r *KeystoneAPIReconciler) reconcileInit(
 		5*time.Second,
 		instance.Status.Hash[keystonev1.BootstrapHash],
 	)
-	ctrlResult, err = bootstrapjob.DoJob(
+	ctrlResult, err := bootstrapjob.DoJob(
 		ctx,
 		helper,
 	)
@@ -1000,81 +1174,727 @@ func (r *KeystoneAPIReconciler) reconcileUpdate(ctx context.Context) (ctrl.Resul
 	return ctrl.Result{}, nil
 }
 
-func (r *KeystoneAPIReconciler) reconcileUpgrade(ctx context.Context) (ctrl.Result, error) {
+// reconcileUpgrade - upgrades the database when the container image changes,
+// with the expand, migrate and contract phases of keystone-manage db_sync:
+// - the pre-upgrade checks run with the new image, failures block the upgrade
+// - expand adds the new schema while the old pods keep serving
+// - the Deployment gets rolled to the new image
+// - migrate migrates the data
+// - contract removes the old schema once all pods run the new image
+// Before the rollout a change of the image restarts the upgrade for the new
+// image, or cancels it when the image is set back to the source image.
+// The phase is tracked in the status, so the upgrade resumes where it stopped
+// after a restart of the operator.
+func (r *KeystoneAPIReconciler) reconcileUpgrade(
+	ctx context.Context,
+	instance *keystonev1.KeystoneAPI,
+	helper *helper.Helper,
+	serviceLabels map[string]string,
+	serviceAnnotations map[string]string,
+) (ctrl.Result, error) {
 	Log := r.GetLogger(ctx)
 	Log.Info("Reconciling Service upgrade")
 
-	// TODO: should have major version upgrade tasks
-	// -delete dbsync hash from status to rerun it?
+	if !instance.IsDatabaseUpgradeInProgress() {
+		currentImage := instance.Status.ContainerImage
+		if currentImage == "" {
+			// the image is not tracked yet, use the one of the running pods
+			deploy, err := deployment.GetDeploymentWithName(ctx, helper, keystone.ResourceName(instance), instance.Namespace)
+			if err != nil && !k8s_errors.IsNotFound(err) {
+				return ctrl.Result{}, err
+			}
+			if err == nil {
+				currentImage = keystone.DeploymentImage(deploy)
+			}
+		}
+
+		if currentImage == "" || currentImage == instance.Spec.ContainerImage {
+			instance.Status.ContainerImage = instance.Spec.ContainerImage
+			instance.Status.Conditions.MarkTrue(
+				keystonev1.KeystoneAPIDatabaseUpgradeReadyCondition,
+				keystonev1.KeystoneAPIDatabaseUpgradeReadyMessage)
+			instance.Status.Conditions.MarkTrue(
+				keystonev1.KeystoneAPIUpgradeReadinessCondition,
+				keystonev1.KeystoneAPIUpgradeReadinessNotRequiredMessage)
+			Log.Info("Reconciled Service upgrade successfully")
+			return ctrl.Result{}, nil
+		}
+
+		instance.Status.DatabaseUpgrade = &keystonev1.DatabaseUpgradeStatus{
+			Phase:       keystonev1.DatabaseUpgradeCheck,
+			SourceImage: currentImage,
+			TargetImage: instance.Spec.ContainerImage,
+			StartedAt:   ptr.To(metav1.Now()),
+		}
+		r.EventRecorder.Event(
+			instance,
+			corev1.EventTypeNormal,
+			"DatabaseUpgradeStarted",
+			fmt.Sprintf("Upgrading the database from %s to %s", currentImage, instance.Spec.ContainerImage),
+		)
+	}
+
+	upgrade := instance.Status.DatabaseUpgrade
+	// until the Deployment gets rolled the old pods keep serving, a change of
+	// the image restarts the upgrade for the new image, going back to the
+	// source image cancels it
+	if (upgrade.Phase == keystonev1.DatabaseUpgradeCheck || upgrade.Phase == keystonev1.DatabaseUpgradeExpand) &&
+		instance.Spec.ContainerImage != upgrade.TargetImage {
+		for _, hashKey := range []string{keystonev1.UpgradeCheckHash, keystonev1.DoctorHash, keystonev1.DbExpandHash} {
+			delete(instance.Status.Hash, hashKey)
+		}
+
+		if instance.Spec.ContainerImage == upgrade.SourceImage {
+			Log.Info(fmt.Sprintf("Database upgrade to %s cancelled", upgrade.TargetImage))
+			r.EventRecorder.Event(
+				instance,
+				corev1.EventTypeNormal,
+				"DatabaseUpgradeCancelled",
+				fmt.Sprintf("Cancelled the database upgrade to %s, staying on %s", upgrade.TargetImage, upgrade.SourceImage),
+			)
+			instance.Status.DatabaseUpgrade = nil
+			instance.Status.ContainerImage = upgrade.SourceImage
+			instance.Status.Conditions.MarkTrue(
+				keystonev1.KeystoneAPIDatabaseUpgradeReadyCondition,
+				keystonev1.KeystoneAPIDatabaseUpgradeReadyMessage)
+			instance.Status.Conditions.MarkTrue(
+				keystonev1.KeystoneAPIUpgradeReadinessCondition,
+				keystonev1.KeystoneAPIUpgradeReadinessNotRequiredMessage)
+			return ctrl.Result{}, nil
+		}
+
+		r.EventRecorder.Event(
+			instance,
+			corev1.EventTypeNormal,
+			"DatabaseUpgradeRestarted",
+			fmt.Sprintf("Restarting the database upgrade from %s to %s instead of %s",
+				upgrade.SourceImage, instance.Spec.ContainerImage, upgrade.TargetImage),
+		)
+		upgrade.Phase = keystonev1.DatabaseUpgradeCheck
+		upgrade.TargetImage = instance.Spec.ContainerImage
+		upgrade.Checks = nil
+		upgrade.StartedAt = ptr.To(metav1.Now())
+	}
+
+	Log.Info(fmt.Sprintf("Database upgrade to %s in phase %s", upgrade.TargetImage, upgrade.Phase))
+	instance.Status.Conditions.Set(condition.FalseCondition(
+		keystonev1.KeystoneAPIDatabaseUpgradeReadyCondition,
+		condition.RequestedReason,
+		condition.SeverityInfo,
+		keystonev1.KeystoneAPIDatabaseUpgradeReadyRunningMessage,
+		upgrade.TargetImage,
+		upgrade.Phase))
+
+	if upgrade.Phase == keystonev1.DatabaseUpgradeCheck {
+		ctrlResult, err := r.runUpgradeChecks(ctx, instance, helper, serviceLabels, serviceAnnotations)
+		if err != nil || (ctrlResult != ctrl.Result{}) {
+			return ctrlResult, err
+		}
+		upgrade.Phase = keystonev1.DatabaseUpgradeExpand
+	}
+	setUpgradeReadiness(instance)
+
+	if upgrade.Phase == keystonev1.DatabaseUpgradeExpand {
+		ctrlResult, err := r.runDbUpgradeJob(ctx, instance, helper, serviceLabels, serviceAnnotations, keystonev1.DbExpandHash)
+		if err != nil || (ctrlResult != ctrl.Result{}) {
+			return ctrlResult, err
+		}
+		upgrade.Phase = keystonev1.DatabaseUpgradeRollout
+	}
+
+	if upgrade.Phase == keystonev1.DatabaseUpgradeRollout {
+		// the Deployment gets rolled to the target image by reconcileNormal,
+		// continue once all pods run it
+		deploy, err := deployment.GetDeploymentWithName(ctx, helper, keystone.ResourceName(instance), instance.Namespace)
+		if err != nil && !k8s_errors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+		if err != nil || keystone.DeploymentImage(deploy) != upgrade.TargetImage || !deployment.IsReady(*deploy) {
+			return ctrl.Result{}, nil
+		}
+		upgrade.Phase = keystonev1.DatabaseUpgradeMigrate
+	}
+
+	if upgrade.Phase == keystonev1.DatabaseUpgradeMigrate {
+		ctrlResult, err := r.runDbUpgradeJob(ctx, instance, helper, serviceLabels, serviceAnnotations, keystonev1.DbMigrateHash)
+		if err != nil || (ctrlResult != ctrl.Result{}) {
+			return ctrlResult, err
+		}
+		upgrade.Phase = keystonev1.DatabaseUpgradeContract
+	}
+
+	if upgrade.Phase == keystonev1.DatabaseUpgradeContract {
+		ctrlResult, err := r.runDbUpgradeJob(ctx, instance, helper, serviceLabels, serviceAnnotations, keystonev1.DbContractHash)
+		if err != nil || (ctrlResult != ctrl.Result{}) {
+			return ctrlResult, err
+		}
+
+		// the db sync of reconcileInit is skipped during the upgrade, record
+		// it as done for the target image so it does not run again
+		dbSyncHash, err := util.ObjectHash(keystone.DbSyncJob(instance, serviceLabels, serviceAnnotations).Spec.Template.Spec)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		instance.Status.Hash[keystonev1.DbSyncHash] = dbSyncHash
+
+		upgrade.Phase = keystonev1.DatabaseUpgradeCompleted
+		upgrade.CompletedAt = ptr.To(metav1.Now())
+		instance.Status.ContainerImage = upgrade.TargetImage
+		r.EventRecorder.Event(
+			instance,
+			corev1.EventTypeNormal,
+			"DatabaseUpgradeCompleted",
+			fmt.Sprintf("Upgraded the database from %s to %s", upgrade.SourceImage, upgrade.TargetImage),
+		)
+		instance.Status.Conditions.MarkTrue(
+			keystonev1.KeystoneAPIDatabaseUpgradeReadyCondition,
+			keystonev1.KeystoneAPIDatabaseUpgradeReadyMessage)
+	}
 
 	Log.Info("Reconciled Service upgrade successfully")
 	return ctrl.Result{}, nil
 }
 
-func (r *KeystoneAPIReconciler) reconcileNormal(
+// runUpgradeChecks - runs keystone-status upgrade check and keystone-manage
+// doctor with the new image and records their results. Failed checks block
+// the upgrade unless IgnoreUpgradeCheckFailures is set, returns a non empty
+// result while the checks run or block the upgrade.
+func (r *KeystoneAPIReconciler) runUpgradeChecks(
 	ctx context.Context,
 	instance *keystonev1.KeystoneAPI,
 	helper *helper.Helper,
+	serviceLabels map[string]string,
+	serviceAnnotations map[string]string,
 ) (ctrl.Result, error) {
 	Log := r.GetLogger(ctx)
-	Log.Info("Reconciling Service")
-
-	serviceLabels := map[string]string{
-		common.AppSelector:   keystone.ServiceName,
-		common.OwnerSelector: instance.Name,
+	upgrade := instance.Status.DatabaseUpgrade
+
+	checks := []struct {
+		name    string
+		hashKey string
+		command string
+	}{
+		{keystone.UpgradeCheckName, keystonev1.UpgradeCheckHash, keystone.UpgradeCheckCommand},
+		{keystone.DoctorName, keystonev1.DoctorHash, keystone.DoctorCommand},
+	}
+
+	running := false
+	results := []keystonev1.UpgradeCheckStatus{}
+	for _, check := range checks {
+		jobDef := keystone.UpgradeCheckJob(instance, serviceLabels, serviceAnnotations, check.name, check.command)
+		checkJob := job.NewJob(
+			jobDef,
+			check.hashKey,
+			instance.Spec.PreserveJobs,
+			5*time.Second,
+			instance.Status.Hash[check.hashKey],
+		)
+		ctrlResult, err := checkJob.DoJob(
+			ctx,
+			helper,
+		)
+		switch {
+		case (ctrlResult != ctrl.Result{}):
+			running = true
+		case err != nil && checkJob.GetTotalFailedAttempts() > 0:
+			// the check failed, the job does not get retried
+			message, err := r.getJobTerminationMessage(ctx, helper, jobDef)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			results = append(results, keystonev1.UpgradeCheckStatus{
+				Name:    check.name,
+				Result:  keystonev1.UpgradeCheckFailed,
+				Message: message,
+			})
+		case err != nil:
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				keystonev1.KeystoneAPIUpgradeReadinessCondition,
+				condition.ErrorReason,
+				condition.SeverityWarning,
+				keystonev1.KeystoneAPIUpgradeReadinessErrorMessage,
+				err.Error()))
+			return ctrl.Result{}, err
+		default:
+			if checkJob.HasChanged() {
+				instance.Status.Hash[check.hashKey] = checkJob.GetHash()
+				Log.Info(fmt.Sprintf("Job %s hash added - %s", jobDef.Name, instance.Status.Hash[check.hashKey]))
+			}
+			results = append(results, keystonev1.UpgradeCheckStatus{
+				Name:   check.name,
+				Result: keystonev1.UpgradeCheckPassed,
+			})
+		}
 	}
 
-	// ConfigMap
-	configMapVars := make(map[string]env.Setter)
+	if running {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneAPIUpgradeReadinessCondition,
+			condition.RequestedReason,
+			condition.SeverityInfo,
+			keystonev1.KeystoneAPIUpgradeReadinessRunningMessage,
+			upgrade.TargetImage))
+		return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+	}
 
-	//
-	// check for required OpenStack secret holding passwords for service/admin user and add hash to the vars map
-	//
-	ctrlResult, err := r.verifySecret(ctx, instance, helper, configMapVars)
-	if err != nil {
-		return ctrlResult, err
-	} else if (ctrlResult != ctrl.Result{}) {
-		return ctrlResult, nil
+	upgrade.Checks = results
+	failed := getFailedUpgradeChecks(upgrade.Checks)
+	if len(failed) > 0 && !instance.Spec.IgnoreUpgradeCheckFailures {
+		Log.Info(fmt.Sprintf("Upgrade to %s blocked by failed checks %s", upgrade.TargetImage, failed))
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneAPIUpgradeReadinessCondition,
+			condition.ErrorReason,
+			condition.SeverityError,
+			keystonev1.KeystoneAPIUpgradeReadinessFailedMessage,
+			strings.Join(failed, ", "),
+			upgrade.TargetImage))
+		return ctrl.Result{RequeueAfter: upgradeCheckRetryInterval}, nil
 	}
 
-	instance.Status.Conditions.MarkTrue(condition.InputReadyCondition, condition.InputReadyMessage)
+	return ctrl.Result{}, nil
+}
 
-	// run check OpenStack secret - end
+// setUpgradeReadiness - marks the pre-upgrade checks of the running upgrade
+// as passed, or as ignored if some failed
+func setUpgradeReadiness(instance *keystonev1.KeystoneAPI) {
+	upgrade := instance.Status.DatabaseUpgrade
+	failed := getFailedUpgradeChecks(upgrade.Checks)
+	if len(failed) > 0 {
+		instance.Status.Conditions.MarkTrue(
+			keystonev1.KeystoneAPIUpgradeReadinessCondition,
+			keystonev1.KeystoneAPIUpgradeReadinessIgnoredMessage,
+			strings.Join(failed, ", "),
+			upgrade.TargetImage)
+		return
+	}
+	instance.Status.Conditions.MarkTrue(
+		keystonev1.KeystoneAPIUpgradeReadinessCondition,
+		keystonev1.KeystoneAPIUpgradeReadinessMessage,
+		upgrade.TargetImage)
+}
 
-	//
-	// create service DB instance
-	//
-	db, result, err := r.ensureDB(ctx, helper, instance)
-	if err != nil {
-		return ctrl.Result{}, err
-	} else if (result != ctrl.Result{}) {
-		return result, nil
+// getFailedUpgradeChecks - returns the names of the failed checks
+func getFailedUpgradeChecks(checks []keystonev1.UpgradeCheckStatus) []string {
+	failed := []string{}
+	for _, check := range checks {
+		if check.Result == keystonev1.UpgradeCheckFailed {
+			failed = append(failed, check.Name)
+		}
 	}
-	// create service DB - end
+	return failed
+}
 
-	//
-	// create RabbitMQ transportURL CR and get the actual URL from the associated secret that is created
-	//
-	transportURL, op, err := r.transportURLCreateOrUpdate(ctx, instance, serviceLabels)
+// getJobTerminationMessage - returns the termination message of the failed
+// pod of a job, the end of its output
+func (r *KeystoneAPIReconciler) getJobTerminationMessage(
+	ctx context.Context,
+	helper *helper.Helper,
+	jobDef *batchv1.Job,
+) (string, error) {
+	pods := &corev1.PodList{}
+	err := helper.GetClient().List(ctx, pods,
+		client.InNamespace(jobDef.Namespace),
+		client.MatchingLabels{"job-name": jobDef.Name},
+	)
 	if err != nil {
-		instance.Status.Conditions.Set(condition.FalseCondition(
-			condition.RabbitMqTransportURLReadyCondition,
-			condition.ErrorReason,
-			condition.SeverityWarning,
-			condition.RabbitMqTransportURLReadyErrorMessage,
-			err.Error()))
-		return ctrl.Result{}, err
+		return "", err
 	}
-	if op != controllerutil.OperationResultNone {
-		Log.Info(fmt.Sprintf("TransportURL %s successfully reconciled - operation: %s", transportURL.Name, string(op)))
+
+	for _, pod := range pods.Items {
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.State.Terminated != nil && status.State.Terminated.ExitCode != 0 {
+				return strings.TrimSpace(status.State.Terminated.Message), nil
+			}
+		}
 	}
+	return "", nil
+}
 
-	instance.Status.TransportURLSecret = transportURL.Status.SecretName
+// runDbUpgradeJob - runs the job of the current phase of the database
+// upgrade, returns a non empty result while the job is running
+func (r *KeystoneAPIReconciler) runDbUpgradeJob(
+	ctx context.Context,
+	instance *keystonev1.KeystoneAPI,
+	helper *helper.Helper,
+	serviceLabels map[string]string,
+	serviceAnnotations map[string]string,
+	hashKey string,
+) (ctrl.Result, error) {
+	Log := r.GetLogger(ctx)
 
-	if instance.Status.TransportURLSecret == "" {
-		// Since the TransportURL secret is automatically created by the Infra operator,
-		// we treat this as an info (because the user is not responsible for manually creating it).
-		Log.Info(fmt.Sprintf("Waiting for TransportURL %s secret to be created", transportURL.Name))
+	jobDef := keystone.DbUpgradeJob(instance, serviceLabels, serviceAnnotations, instance.Status.DatabaseUpgrade.Phase)
+	upgradeJob := job.NewJob(
+		jobDef,
+		hashKey,
+		instance.Spec.PreserveJobs,
+		5*time.Second,
+		instance.Status.Hash[hashKey],
+	)
+	ctrlResult, err := upgradeJob.DoJob(
+		ctx,
+		helper,
+	)
+	if (ctrlResult != ctrl.Result{}) {
+		return ctrlResult, nil
+	}
+	if err != nil {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneAPIDatabaseUpgradeReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.KeystoneAPIDatabaseUpgradeReadyErrorMessage,
+			err.Error()))
+		return ctrl.Result{}, err
+	}
+	if upgradeJob.HasChanged() {
+		instance.Status.Hash[hashKey] = upgradeJob.GetHash()
+		Log.Info(fmt.Sprintf("Job %s hash added - %s", jobDef.Name, instance.Status.Hash[hashKey]))
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// reconcileCanary - with the Canary rollout strategy a change of the config
+// or the image gets rolled out to a single canary replica first. Once a token
+// could be issued and validated through it, the change gets promoted to the
+// keystone Deployment, if it does not pass within CanaryTimeout it gets
+// rolled back. The image of a database upgrade gets promoted without a canary.
+// Returns a non empty result while the canary gets verified.
+func (r *KeystoneAPIReconciler) reconcileCanary(
+	ctx context.Context,
+	instance *keystonev1.KeystoneAPI,
+	helper *helper.Helper,
+	deplDef *appsv1.Deployment,
+	inputHash string,
+) (ctrl.Result, error) {
+	Log := r.GetLogger(ctx)
+
+	revision := keystonev1.RolloutRevision{
+		InputHash:      inputHash,
+		ContainerImage: keystone.DeploymentImage(deplDef),
+	}
+	canary := deployment.NewDeployment(
+		keystone.CanaryDeployment(instance, deplDef),
+		5*time.Second,
+	)
+
+	// switching to the canary strategy, there is nothing to verify against
+	if instance.Status.PromotedRevision == nil {
+		return ctrl.Result{}, r.promoteRevision(ctx, instance, helper, revision)
+	}
+
+	if *instance.Status.PromotedRevision == revision {
+		instance.Status.Conditions.MarkTrue(
+			keystonev1.KeystoneAPICanaryReadyCondition,
+			keystonev1.KeystoneAPICanaryReadyMessage)
+		return ctrl.Result{}, canary.Delete(ctx, helper)
+	}
+
+	// the image of a database upgrade does not go through the canary, it
+	// passed the upgrade checks already and once the database got expanded a
+	// rollback would leave the upgrade waiting for the rollout forever
+	if instance.IsDatabaseUpgradeInProgress() &&
+		revision.ContainerImage == instance.Status.DatabaseUpgrade.TargetImage &&
+		instance.Status.PromotedRevision.ContainerImage != revision.ContainerImage {
+		Log.Info(fmt.Sprintf("Skipping the canary for the database upgrade to %s", revision.ContainerImage))
+		err := r.promoteRevision(ctx, instance, helper, revision)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		instance.Status.Canary = nil
+		r.EventRecorder.Event(
+			instance,
+			corev1.EventTypeNormal,
+			"CanarySkipped",
+			fmt.Sprintf("Rolling out config %s and image %s of the database upgrade without a canary", revision.InputHash, revision.ContainerImage),
+		)
+		instance.Status.Conditions.MarkTrue(
+			keystonev1.KeystoneAPICanaryReadyCondition,
+			keystonev1.KeystoneAPICanaryReadyMessage)
+		return ctrl.Result{}, canary.Delete(ctx, helper)
+	}
+
+	status := instance.Status.Canary
+	if status == nil || status.Revision != revision {
+		status = &keystonev1.CanaryStatus{
+			Revision:  revision,
+			Phase:     keystonev1.CanaryVerifying,
+			StartedAt: ptr.To(metav1.Now()),
+		}
+		instance.Status.Canary = status
+		r.EventRecorder.Event(
+			instance,
+			corev1.EventTypeNormal,
+			"CanaryStarted",
+			fmt.Sprintf("Started a canary with config %s and image %s", revision.InputHash, revision.ContainerImage),
+		)
+	}
+
+	// a rolled back revision is not retried, the keystone Deployment keeps
+	// the promoted one until the config or the image changes again
+	if status.Phase == keystonev1.CanaryRolledBack {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneAPICanaryReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.KeystoneAPICanaryReadyRolledBackMessage,
+			status.Message))
+		return ctrl.Result{}, canary.Delete(ctx, helper)
+	}
+
+	ctrlResult, err := canary.CreateOrPatch(ctx, helper)
+	if err != nil {
+		return ctrlResult, err
+	}
+
+	verifyErr := ErrCanaryNotReady
+	canaryDepl := canary.GetDeployment()
+	if (ctrlResult == ctrl.Result{}) && deployment.IsReady(canaryDepl) {
+		verifyErr = r.verifyCanary(ctx, instance, helper, &canaryDepl)
+	}
+
+	if verifyErr == nil {
+		err = r.promoteRevision(ctx, instance, helper, revision)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		status.Phase = keystonev1.CanaryPromoted
+		status.CompletedAt = ptr.To(metav1.Now())
+		r.EventRecorder.Event(
+			instance,
+			corev1.EventTypeNormal,
+			"CanaryPromoted",
+			fmt.Sprintf("Canary passed the token check, rolling out config %s and image %s", revision.InputHash, revision.ContainerImage),
+		)
+		instance.Status.Conditions.MarkTrue(
+			keystonev1.KeystoneAPICanaryReadyCondition,
+			keystonev1.KeystoneAPICanaryReadyMessage)
+		return ctrl.Result{}, canary.Delete(ctx, helper)
+	}
+
+	timeout := time.Duration(*instance.Spec.CanaryTimeout) * time.Second
+	if time.Since(status.StartedAt.Time) > timeout {
+		Log.Info(fmt.Sprintf("Canary rolled back: %s", verifyErr))
+		status.Phase = keystonev1.CanaryRolledBack
+		status.CompletedAt = ptr.To(metav1.Now())
+		status.Message = verifyErr.Error()
+		r.EventRecorder.Event(
+			instance,
+			corev1.EventTypeWarning,
+			"CanaryRolledBack",
+			fmt.Sprintf("Canary did not pass the token check within %s, keeping config %s and image %s: %s",
+				timeout, instance.Status.PromotedRevision.InputHash, instance.Status.PromotedRevision.ContainerImage, verifyErr),
+		)
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneAPICanaryReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.KeystoneAPICanaryReadyRolledBackMessage,
+			status.Message))
+		return ctrl.Result{}, canary.Delete(ctx, helper)
+	}
+
+	instance.Status.Conditions.Set(condition.FalseCondition(
+		keystonev1.KeystoneAPICanaryReadyCondition,
+		condition.RequestedReason,
+		condition.SeverityInfo,
+		keystonev1.KeystoneAPICanaryReadyVerifyingMessage,
+		verifyErr.Error()))
+	return ctrl.Result{RequeueAfter: canaryRetryInterval}, nil
+}
+
+// promoteRevision - copies the current config to the Secret the keystone
+// Deployment runs with and records the promoted revision
+func (r *KeystoneAPIReconciler) promoteRevision(
+	ctx context.Context,
+	instance *keystonev1.KeystoneAPI,
+	helper *helper.Helper,
+	revision keystonev1.RolloutRevision,
+) error {
+	configSecret, _, err := oko_secret.GetSecret(ctx, helper, fmt.Sprintf("%s-config-data", instance.Name), instance.Namespace)
+	if err != nil {
+		return err
+	}
+
+	promotedSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      keystone.PromotedConfigSecretName(instance),
+			Namespace: instance.Namespace,
+			Labels:    configSecret.Labels,
+		},
+		Data: configSecret.Data,
+	}
+	_, _, err = oko_secret.CreateOrPatchSecret(ctx, helper, instance, promotedSecret)
+	if err != nil {
+		return err
+	}
+
+	instance.Status.PromotedRevision = &revision
+	return nil
+}
+
+// deleteCanary - removes the canary Deployment and the promoted config when
+// the Rolling rollout strategy is used
+func (r *KeystoneAPIReconciler) deleteCanary(
+	ctx context.Context,
+	instance *keystonev1.KeystoneAPI,
+	helper *helper.Helper,
+) error {
+	canary := &appsv1.Deployment{}
+	canary.Name = keystone.CanaryName(instance)
+	canary.Namespace = instance.Namespace
+	err := deployment.NewDeployment(canary, 5*time.Second).Delete(ctx, helper)
+	if err != nil {
+		return err
+	}
+
+	err = oko_secret.DeleteSecretsWithName(ctx, helper, keystone.PromotedConfigSecretName(instance), instance.Namespace)
+	if err != nil {
+		return err
+	}
+
+	instance.Status.PromotedRevision = nil
+	return nil
+}
+
+// verifyCanary - issues and validates a token through the keystone API of
+// the ready canary pod
+func (r *KeystoneAPIReconciler) verifyCanary(
+	ctx context.Context,
+	instance *keystonev1.KeystoneAPI,
+	helper *helper.Helper,
+	canary *appsv1.Deployment,
+) error {
+	pods := &corev1.PodList{}
+	err := helper.GetClient().List(ctx, pods,
+		client.InNamespace(instance.Namespace),
+		client.MatchingLabels(canary.Spec.Selector.MatchLabels),
+	)
+	if err != nil {
+		return err
+	}
+	podIP := ""
+	for i := range pods.Items {
+		if isPodReady(&pods.Items[i]) && pods.Items[i].Status.PodIP != "" {
+			podIP = pods.Items[i].Status.PodIP
+			break
+		}
+	}
+	if podIP == "" {
+		return ErrCanaryNotReady
+	}
+
+	// the canary serves the certificate of the internal endpoint
+	scheme := "http"
+	serverName := ""
+	if instance.Spec.TLS.API.Enabled(service.EndpointInternal) {
+		scheme = "https"
+		internalURL, err := url.Parse(instance.Status.APIEndpoints[string(service.EndpointInternal)])
+		if err != nil {
+			return err
+		}
+		serverName = internalURL.Hostname()
+	}
+	caCert := ""
+	if instance.Spec.TLS.CaBundleSecretName != "" {
+		caCert, _, err = oko_secret.GetDataFromSecret(ctx, helper, instance.Spec.TLS.CaBundleSecretName, 10*time.Second, tls.CABundleKey)
+		if err != nil {
+			return err
+		}
+	}
+	password, _, err := oko_secret.GetDataFromSecret(ctx, helper, instance.Spec.Secret, 10*time.Second, instance.Spec.PasswordSelectors.Admin)
+	if err != nil {
+		return err
+	}
+
+	endpoint := fmt.Sprintf("%s://%s/", scheme, net.JoinHostPort(podIP, strconv.Itoa(int(keystone.KeystoneInternalPort))))
+	return checkTokenRoundTrip(ctx, endpoint, serverName, caCert, tokens.AuthOptions{
+		Username:   instance.Spec.AdminUser,
+		Password:   password,
+		DomainName: "Default",
+		Scope: tokens.Scope{
+			System: true,
+		},
+	})
+}
+
+func (r *KeystoneAPIReconciler) reconcileNormal(
+	ctx context.Context,
+	instance *keystonev1.KeystoneAPI,
+	helper *helper.Helper,
+) (ctrl.Result, error) {
+	Log := r.GetLogger(ctx)
+	Log.Info("Reconciling Service")
+
+	// flag an overdue fernet key rotation, e.g. when the reconcile does not
+	// get to the rotation because of an unavailable dependency. Rotating the
+	// keys marks the condition ready again.
+	if instance.Status.FernetKeys != nil && instance.Status.FernetKeys.NextRotation != nil &&
+		instance.Status.FernetKeys.NextRotation.Before(ptr.To(metav1.Now())) {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneAPIFernetKeysReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.KeystoneAPIFernetKeysReadyOverdueMessage,
+			instance.Status.FernetKeys.NextRotation.Format(time.RFC3339)))
+	}
+
+	serviceLabels := map[string]string{
+		common.AppSelector:   keystone.ServiceName,
+		common.OwnerSelector: instance.Name,
+	}
+
+	// ConfigMap
+	configMapVars := make(map[string]env.Setter)
+
+	//
+	// check for required OpenStack secret holding passwords for service/admin user and add hash to the vars map
+	//
+	ctrlResult, err := r.verifySecret(ctx, instance, helper, configMapVars)
+	if err != nil {
+		return ctrlResult, err
+	} else if (ctrlResult != ctrl.Result{}) {
+		return ctrlResult, nil
+	}
+
+	instance.Status.Conditions.MarkTrue(condition.InputReadyCondition, condition.InputReadyMessage)
+
+	// run check OpenStack secret - end
+
+	//
+	// create service DB instance
+	//
+	db, result, err := r.ensureDB(ctx, helper, instance)
+	if err != nil {
+		return ctrl.Result{}, err
+	} else if (result != ctrl.Result{}) {
+		return result, nil
+	}
+	// create service DB - end
+
+	//
+	// create RabbitMQ transportURL CR and get the actual URL from the associated secret that is created
+	//
+	transportURL, op, err := r.transportURLCreateOrUpdate(ctx, instance, serviceLabels)
+	if err != nil {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			condition.RabbitMqTransportURLReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			condition.RabbitMqTransportURLReadyErrorMessage,
+			err.Error()))
+		return ctrl.Result{}, err
+	}
+	if op != controllerutil.OperationResultNone {
+		Log.Info(fmt.Sprintf("TransportURL %s successfully reconciled - operation: %s", transportURL.Name, string(op)))
+	}
+
+	instance.Status.TransportURLSecret = transportURL.Status.SecretName
+
+	if instance.Status.TransportURLSecret == "" {
+		// Since the TransportURL secret is automatically created by the Infra operator,
+		// we treat this as an info (because the user is not responsible for manually creating it).
+		Log.Info(fmt.Sprintf("Waiting for TransportURL %s secret to be created", transportURL.Name))
 		instance.Status.Conditions.Set(condition.FalseCondition(
 			condition.RabbitMqTransportURLReadyCondition,
 			condition.RequestedReason,
@@ -1146,13 +1966,42 @@ func (r *KeystoneAPIReconciler) reconcileNormal(
 	// Create ConfigMaps and Secrets required as input for the Service and calculate an overall hash of hashes
 	//
 
+	//
+	// render domain specific configs of the KeystoneDomain CRs
+	//
+	domainConfigs, err := r.getDomainConfigs(ctx, instance, helper)
+	if err != nil {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			condition.ServiceConfigReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			condition.ServiceConfigReadyErrorMessage,
+			err.Error()))
+		return ctrl.Result{}, err
+	}
+	domainFilenames := slices.Sorted(maps.Keys(domainConfigs))
+
+	//
+	// render the httpd federation configs of the KeystoneIdentityProvider CRs
+	//
+	federationConfig, err := r.getFederationConfig(ctx, instance, helper)
+	if err != nil {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			condition.ServiceConfigReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			condition.ServiceConfigReadyErrorMessage,
+			err.Error()))
+		return ctrl.Result{}, err
+	}
+
 	//
 	// create Configmap required for keystone input
 	// - %-scripts configmap holding scripts to e.g. bootstrap the service
 	// - %-config configmap holding minimal keystone config required to get the service up, user can add additional files to be added to the service
 	// - parameters which has passwords gets added from the OpenStack secret via the init container
 	//
-	err = r.generateServiceConfigMaps(ctx, instance, helper, &configMapVars, memcached, db)
+	err = r.generateServiceConfigMaps(ctx, instance, helper, &configMapVars, memcached, db, domainConfigs, federationConfig)
 	if err != nil {
 		instance.Status.Conditions.Set(condition.FalseCondition(
 			condition.ServiceConfigReadyCondition,
@@ -1166,16 +2015,56 @@ func (r *KeystoneAPIReconciler) reconcileNormal(
 	//
 	// Create secret holding fernet keys (for token and credential)
 	//
-	err = r.ensureFernetKeys(ctx, instance, helper, &configMapVars)
+	pendingPods, err := r.ensureFernetKeys(ctx, instance, helper, &configMapVars)
 	if err != nil {
 		instance.Status.Conditions.Set(condition.FalseCondition(
-			condition.ServiceConfigReadyCondition,
+			keystonev1.KeystoneAPIFernetKeysReadyCondition,
 			condition.ErrorReason,
 			condition.SeverityWarning,
-			condition.ServiceConfigReadyErrorMessage,
+			keystonev1.KeystoneAPIFernetKeysReadyErrorMessage,
+			err.Error()))
+		return ctrl.Result{}, err
+	}
+	if len(pendingPods) > 0 {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneAPIFernetKeysReadyCondition,
+			condition.RequestedReason,
+			condition.SeverityInfo,
+			keystonev1.KeystoneAPIFernetKeysReadyRotationHeldMessage,
+			strings.Join(pendingPods, ", ")))
+	} else {
+		instance.Status.Conditions.MarkTrue(
+			keystonev1.KeystoneAPIFernetKeysReadyCondition,
+			keystonev1.KeystoneAPIFernetKeysReadyMessage,
+			instance.Status.FernetKeys.NextRotation.Format(time.RFC3339))
+	}
+
+	//
+	// Add the fernet receipt keys to the secret
+	//
+	pendingPods, err = r.ensureReceiptKeys(ctx, instance, helper)
+	if err != nil {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneAPIReceiptKeysReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.KeystoneAPIReceiptKeysReadyErrorMessage,
 			err.Error()))
 		return ctrl.Result{}, err
 	}
+	if len(pendingPods) > 0 {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneAPIReceiptKeysReadyCondition,
+			condition.RequestedReason,
+			condition.SeverityInfo,
+			keystonev1.KeystoneAPIReceiptKeysReadyRotationHeldMessage,
+			strings.Join(pendingPods, ", ")))
+	} else {
+		instance.Status.Conditions.MarkTrue(
+			keystonev1.KeystoneAPIReceiptKeysReadyCondition,
+			keystonev1.KeystoneAPIReceiptKeysReadyMessage,
+			instance.Status.ReceiptKeys.NextRotation.Format(time.RFC3339))
+	}
 
 	//
 	// Create secret holding federation realm config (for multiple realms)
@@ -1287,24 +2176,24 @@ func (r *KeystoneAPIReconciler) reconcileNormal(
 			instance.Spec.NetworkAttachments, err)
 	}
 
-	// Handle service init
-	ctrlResult, err = r.reconcileInit(ctx, instance, helper, serviceLabels, serviceAnnotations, memcached)
+	// Handle service upgrade, before the init runs jobs with a new image
+	ctrlResult, err = r.reconcileUpgrade(ctx, instance, helper, serviceLabels, serviceAnnotations)
 	if err != nil {
 		return ctrlResult, err
 	} else if (ctrlResult != ctrl.Result{}) {
 		return ctrlResult, nil
 	}
 
-	// Handle service update
-	ctrlResult, err = r.reconcileUpdate(ctx)
+	// Handle service init
+	ctrlResult, err = r.reconcileInit(ctx, instance, helper, serviceLabels, serviceAnnotations, memcached)
 	if err != nil {
 		return ctrlResult, err
 	} else if (ctrlResult != ctrl.Result{}) {
 		return ctrlResult, nil
 	}
 
-	// Handle service upgrade
-	ctrlResult, err = r.reconcileUpgrade(ctx)
+	// Handle service update
+	ctrlResult, err = r.reconcileUpdate(ctx)
 	if err != nil {
 		return ctrlResult, err
 	} else if (ctrlResult != ctrl.Result{}) {
@@ -1351,7 +2240,7 @@ func (r *KeystoneAPIReconciler) reconcileNormal(
 	//
 
 	// Define a new Deployment object
-	deplDef, err := keystone.Deployment(instance, inputHash, serviceLabels, serviceAnnotations, topology, federationFilenames, memcached)
+	deplDef, err := keystone.Deployment(instance, inputHash, serviceLabels, serviceAnnotations, topology, federationFilenames, domainFilenames, memcached)
 	if err != nil {
 		instance.Status.Conditions.Set(condition.FalseCondition(
 			condition.DeploymentReadyCondition,
@@ -1361,12 +2250,47 @@ func (r *KeystoneAPIReconciler) reconcileNormal(
 			err.Error()))
 		return ctrl.Result{}, err
 	}
-	depl := deployment.NewDeployment(
-		deplDef,
-		5*time.Second,
-	)
 
-	ctrlResult, err = depl.CreateOrPatch(ctx, helper)
+	// with the Canary rollout strategy the keystone Deployment keeps the
+	// promoted config and image until the canary with a change passed
+	canaryResult := ctrl.Result{}
+	if instance.Spec.RolloutStrategy == keystonev1.RolloutStrategyCanary {
+		canaryResult, err = r.reconcileCanary(ctx, instance, helper, deplDef, inputHash)
+		if err != nil {
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				keystonev1.KeystoneAPICanaryReadyCondition,
+				condition.ErrorReason,
+				condition.SeverityWarning,
+				keystonev1.KeystoneAPICanaryReadyErrorMessage,
+				err.Error()))
+			return ctrl.Result{}, err
+		}
+
+		promoted := instance.Status.PromotedRevision
+		deplDef, err = keystone.Deployment(instance, promoted.InputHash, serviceLabels, serviceAnnotations, topology, federationFilenames, domainFilenames, memcached)
+		if err != nil {
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				condition.DeploymentReadyCondition,
+				condition.ErrorReason,
+				condition.SeverityWarning,
+				condition.DeploymentReadyErrorMessage,
+				err.Error()))
+			return ctrl.Result{}, err
+		}
+		keystone.PinDeployment(deplDef, promoted.ContainerImage, keystone.PromotedConfigSecretName(instance))
+	} else {
+		err = r.deleteCanary(ctx, instance, helper)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	depl := deployment.NewDeployment(
+		deplDef,
+		5*time.Second,
+	)
+
+	ctrlResult, err = depl.CreateOrPatch(ctx, helper)
 	if err != nil {
 		instance.Status.Conditions.Set(condition.FalseCondition(
 			condition.DeploymentReadyCondition,
@@ -1433,7 +2357,7 @@ func (r *KeystoneAPIReconciler) reconcileNormal(
 
 	if instance.Status.ReadyCount == *instance.Spec.Replicas {
 		// remove finalizers from unused MariaDBAccount records
-		err = mariadbv1.DeleteUnusedMariaDBAccountFinalizers(ctx, helper, keystone.DatabaseName, instance.Spec.DatabaseAccount, instance.Namespace)
+		err = mariadbv1.DeleteUnusedMariaDBAccountFinalizers(ctx, helper, keystone.DatabaseCRName(instance), instance.Spec.DatabaseAccount, instance.Namespace)
 		if err != nil {
 			return ctrl.Result{}, err
 		}
@@ -1468,8 +2392,24 @@ func (r *KeystoneAPIReconciler) reconcileNormal(
 		return ctrl.Result{}, err
 	}
 
+	//
+	// rotate the credential keys and re-encrypt the credentials
+	//
+	ctrlResult, err = r.ensureCredentialKeys(ctx, instance, helper, serviceLabels, serviceAnnotations)
+	if err != nil {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneAPICredentialKeysReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.KeystoneAPICredentialKeysReadyErrorMessage,
+			err.Error()))
+		return ctrlResult, err
+	} else if (ctrlResult != ctrl.Result{}) {
+		return ctrlResult, nil
+	}
+
 	Log.Info("Reconciled Service successfully")
-	return ctrl.Result{}, nil
+	return canaryResult, nil
 }
 
 func (r *KeystoneAPIReconciler) transportURLCreateOrUpdate(
@@ -1520,6 +2460,8 @@ func (r *KeystoneAPIReconciler) generateServiceConfigMaps(
 	envVars *map[string]env.Setter,
 	mc *memcachedv1.Memcached,
 	db *mariadbv1.Database,
+	domainConfigs map[string]string,
+	federationConfig *keystone.FederationConfig,
 ) error {
 	//
 	// create Configmap/Secret required for keystone input
@@ -1544,6 +2486,10 @@ func (r *KeystoneAPIReconciler) generateServiceConfigMaps(
 		"my.cnf":                           db.GetDatabaseClientConfig(tlsCfg), //(mschuppert) for now just get the default my.cnf
 	}
 	maps.Copy(customData, instance.Spec.DefaultConfigOverwrite)
+	// domain specific configs get mounted to /etc/keystone/domains
+	maps.Copy(customData, domainConfigs)
+	// httpd configs and keys of the federated identity providers
+	maps.Copy(customData, federationConfig.Files)
 
 	transportURLSecret, _, err := oko_secret.GetSecret(ctx, h, instance.Status.TransportURLSecret, instance.Namespace)
 	if err != nil {
@@ -1562,11 +2508,29 @@ func (r *KeystoneAPIReconciler) generateServiceConfigMaps(
 			databaseAccount.Spec.UserName,
 			string(dbSecret.Data[mariadbv1.DatabasePasswordSelector]),
 			instance.Status.DatabaseHostname,
-			keystone.DatabaseName,
+			keystone.DatabaseName(instance),
 		),
-		"ProcessNumber":       instance.Spec.HttpdCustomization.ProcessNumber,
-		"EnableSecureRBAC":    instance.Spec.EnableSecureRBAC,
-		"FernetMaxActiveKeys": instance.Spec.FernetMaxActiveKeys,
+		"ProcessNumber":        instance.Spec.HttpdCustomization.ProcessNumber,
+		"EnableSecureRBAC":     instance.Spec.EnableSecureRBAC,
+		"FernetMaxActiveKeys":  instance.Spec.GetFernetMaxActiveKeys(),
+		"TokenExpiration":      instance.Spec.TokenExpiration,
+		"AllowExpiredWindow":   instance.Spec.AllowExpiredWindow,
+		"DomainConfigDir":      keystone.DomainConfigMountPath,
+		"TokenProvider":        instance.Spec.TokenProvider,
+		"ReceiptKeysPath":      keystone.ReceiptKeysPath,
+		"ReceiptMaxActiveKeys": instance.Spec.ReceiptMaxActiveKeys,
+		"JWSPublicKeysPath":    keystone.JWSPublicKeysPath,
+		"JWSPrivateKeysPath":   keystone.JWSPrivateKeysPath,
+	}
+
+	templateParameters["DomainSpecificDrivers"] = len(domainConfigs) > 0
+
+	templateParameters["FederationAuthMethods"] = ""
+	templateParameters["FederationRemoteIDAttributes"] = federationConfig.RemoteIDAttributes
+	if len(federationConfig.RemoteIDAttributes) > 0 {
+		templateParameters["FederationAuthMethods"] = strings.Join(append(
+			slices.Clone(keystone.DefaultAuthMethods),
+			slices.Sorted(maps.Keys(federationConfig.RemoteIDAttributes))...), ",")
 	}
 
 	templateParameters["KeystoneEndpointPublic"], _ = instance.GetEndpoint(endpoint.EndpointPublic)
@@ -1613,6 +2577,10 @@ func (r *KeystoneAPIReconciler) generateServiceConfigMaps(
 				}
 			}
 		}
+		// the federation configs get included in the public vhost only
+		if endpt == service.EndpointPublic && len(federationConfig.RemoteIDAttributes) > 0 {
+			endptConfig["Override"] = true
+		}
 		httpdVhostConfig[endpt.String()] = endptConfig
 	}
 	templateParameters["VHosts"] = httpdVhostConfig
@@ -1684,7 +2652,7 @@ func (r *KeystoneAPIReconciler) reconcileCloudConfig(
 
 	cms := []util.Template{
 		{
-			Name:          "openstack-config",
+			Name:          keystone.GetOpenStackConfigName(instance),
 			Namespace:     instance.Namespace,
 			Type:          util.TemplateTypeNone,
 			InstanceType:  instance.Kind,
@@ -1727,7 +2695,7 @@ func (r *KeystoneAPIReconciler) reconcileCloudConfig(
 
 	secrets := []util.Template{
 		{
-			Name:          "openstack-config-secret",
+			Name:          keystone.GetOpenStackConfigSecretName(instance),
 			Namespace:     instance.Namespace,
 			Type:          util.TemplateTypeNone,
 			InstanceType:  instance.Kind,
@@ -1740,41 +2708,79 @@ func (r *KeystoneAPIReconciler) reconcileCloudConfig(
 	return oko_secret.EnsureSecrets(ctx, h, instance, secrets, nil)
 }
 
-// ensureFernetKeys - creates secret with fernet keys, rotates the keys
+// ensureFernetKeys - creates secret with fernet keys, rotates the keys and
+// records the rotation state in the status
 func (r *KeystoneAPIReconciler) ensureFernetKeys(
 	ctx context.Context,
 	instance *keystonev1.KeystoneAPI,
 	helper *helper.Helper,
 	envVars *map[string]env.Setter,
-) error {
+) ([]string, error) {
 	logger := r.GetLogger(ctx)
 	fernetAnnotation := labels.GetGroupLabel(keystone.ServiceName) + "/rotatedat"
 	labels := labels.GetLabels(instance, labels.GetGroupLabel(keystone.ServiceName), map[string]string{})
-	now := time.Now().UTC()
+	now := time.Now().UTC().Truncate(time.Second)
 
 	//
 	// check if secret already exist
 	//
-	secretName := keystone.ServiceName
-	var numberKeys int
-	if instance.Spec.FernetMaxActiveKeys == nil {
-		numberKeys = keystone.DefaultFernetMaxActiveKeys
+	secretName := keystone.ResourceName(instance)
+	numberKeys := instance.Spec.GetFernetMaxActiveKeys()
+
+	var duration int
+	if instance.Spec.FernetRotationDays == nil {
+		duration = keystone.DefaultFernetRotationDays
 	} else {
-		numberKeys = int(*instance.Spec.FernetMaxActiveKeys)
+		duration = int(*instance.Spec.FernetRotationDays)
+	}
+	rotatedAt := now
+	pendingPods := []string{}
+	generateFernetKey := func() []byte {
+		return []byte(keystone.GenerateFernetKey(logger))
 	}
+	generateJWSKey := func() []byte {
+		return keystone.GenerateJWSKey(logger)
+	}
+	jws := instance.Spec.TokenProvider == keystonev1.TokenProviderJWS
+	revocation := instance.Spec.TokenRevocation
+	revoke := revocation != nil &&
+		(instance.Status.TokenRevocation == nil || instance.Status.TokenRevocation.Nonce != revocation.Nonce)
 
 	secret, _, err := oko_secret.GetSecret(ctx, helper, secretName, instance.Namespace)
 
 	if err != nil && !k8s_errors.IsNotFound(err) {
-		return err
+		return nil, err
 	} else if k8s_errors.IsNotFound(err) {
-		fernetKeys := map[string]string{
-			"CredentialKeys0": keystone.GenerateFernetKey(logger),
-			"CredentialKeys1": keystone.GenerateFernetKey(logger),
+		fernetKeys, err := r.importKeys(ctx, instance, helper, numberKeys)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, exists := fernetKeys["CredentialKeys0"]; !exists {
+			fernetKeys["CredentialKeys0"] = keystone.GenerateFernetKey(logger)
+			fernetKeys["CredentialKeys1"] = keystone.GenerateFernetKey(logger)
 		}
 
-		for i := 0; i < numberKeys; i++ {
-			fernetKeys[fmt.Sprintf("FernetKeys%d", i)] = keystone.GenerateFernetKey(logger)
+		// missing keys of an imported repository get added by the next
+		// reconcile, like when FernetMaxActiveKeys changes
+		if _, exists := fernetKeys["FernetKeys0"]; !exists {
+			for i := 0; i < numberKeys; i++ {
+				fernetKeys[fmt.Sprintf("FernetKeys%d", i)] = keystone.GenerateFernetKey(logger)
+			}
+		}
+
+		if jws {
+			jwsKeys := map[string][]byte{}
+			for i := 0; i < numberKeys; i++ {
+				jwsKeys[fmt.Sprintf("%s%d", keystone.JWSKeysPrefix, i)] = generateJWSKey()
+			}
+			_, err = keystone.UpdateJWSPublicKeys(jwsKeys)
+			if err != nil {
+				return nil, err
+			}
+			for key, value := range jwsKeys {
+				fernetKeys[key] = string(value)
+			}
 		}
 
 		annotations := map[string]string{
@@ -1790,9 +2796,9 @@ func (r *KeystoneAPIReconciler) ensureFernetKeys(
 				Annotations: annotations,
 			},
 		}
-		err := oko_secret.EnsureSecrets(ctx, helper, instance, tmpl, envVars)
+		err = oko_secret.EnsureSecrets(ctx, helper, instance, tmpl, envVars)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	} else {
 		// DON'T add hash to envVars to prevent pod restarts when keys rotate
@@ -1801,93 +2807,508 @@ func (r *KeystoneAPIReconciler) ensureFernetKeys(
 
 		changedKeys := false
 
-		extraKey := fmt.Sprintf("FernetKeys%d", numberKeys)
-
 		//
 		// Fernet Key rotation
 		//
 		if secret.Annotations == nil {
 			secret.Annotations = map[string]string{}
 		}
-		rotatedAt, err := time.Parse(time.RFC3339, secret.Annotations[fernetAnnotation])
-
-		var duration int
-		if instance.Spec.FernetRotationDays == nil {
-			duration = keystone.DefaultFernetRotationDays
-		} else {
-			duration = int(*instance.Spec.FernetRotationDays)
+		lastRotated, err := time.Parse(time.RFC3339, secret.Annotations[fernetAnnotation])
+
+		if revoke {
+			// replace all keys, they get generated again below
+			logger.Info(fmt.Sprintf("Revoking all tokens, requested by %s: %s", revocation.RequestedBy, revocation.Reason))
+			keystone.RemoveKeyRepository(secret.Data, keystone.FernetKeysPrefix)
+			keystone.RemoveKeyRepository(secret.Data, keystone.JWSKeysPrefix)
+			keystone.RemoveKeyRepository(secret.Data, keystone.JWSPublicKeysPrefix)
+			changedKeys = true
+		} else if err != nil {
+			changedKeys = true
+		} else if !now.Before(lastRotated.AddDate(0, 0, duration)) {
+			// the staged key 0 becomes the primary key, only promote it once
+			// all keystone pods have it, otherwise the pods which did not
+			// yet get it reject the tokens issued with it
+			pendingPods, err = r.getKeyRepositoryPendingPods(ctx, instance, helper, keystone.FernetKeysPath,
+				keystone.KeyRepositoryChecksums(secret.Data, keystone.FernetKeysPrefix))
+			if err != nil {
+				return nil, err
+			}
+			// same for the public key of the staged JWS key pair
+			if jws && len(pendingPods) == 0 {
+				pendingPods, err = r.getKeyRepositoryPendingPods(ctx, instance, helper, keystone.JWSPublicKeysPath,
+					keystone.KeyRepositoryChecksums(secret.Data, keystone.JWSPublicKeysPrefix))
+				if err != nil {
+					return nil, err
+				}
+			}
+			if len(pendingPods) > 0 {
+				logger.Info(fmt.Sprintf("Holding back fernet key rotation, pods %v did not yet observe the current keys", pendingPods))
+			} else {
+				logger.Info(fmt.Sprintf("Rotating fernet keys, last rotation at %s", lastRotated.Format(time.RFC3339)))
+				keystone.RotateKeyRepository(secret.Data, keystone.FernetKeysPrefix, numberKeys, generateFernetKey)
+				if jws {
+					keystone.RotateKeyRepository(secret.Data, keystone.JWSKeysPrefix, numberKeys, generateJWSKey)
+				}
+			}
 		}
 
-		if err != nil {
+		//
+		// Remove or add keys when FernetMaxActiveKeys changes
+		//
+		if keystone.ResizeKeyRepository(secret.Data, keystone.FernetKeysPrefix, numberKeys, generateFernetKey) {
 			changedKeys = true
-		} else if rotatedAt.AddDate(0, 0, duration).Before(now) {
-			secret.Data[extraKey] = secret.Data["FernetKeys0"]
-			secret.Data["FernetKeys0"] = []byte(keystone.GenerateFernetKey(logger))
 		}
 
 		//
-		// Remove extra keys when FernetMaxActiveKeys changes
+		// JWS key pairs, also generated when the token provider changes to jws
 		//
-		for {
-			_, exists := secret.Data[extraKey]
-			if !exists {
-				break
+		if jws {
+			if keystone.ResizeKeyRepository(secret.Data, keystone.JWSKeysPrefix, numberKeys, generateJWSKey) {
+				changedKeys = true
 			}
-			changedKeys = true
-			i := 1
-			for {
-				key := fmt.Sprintf("FernetKeys%d", i)
-				i++
-				nextKey := fmt.Sprintf("FernetKeys%d", i)
-				_, exists = secret.Data[nextKey]
-				if !exists {
-					break
-				}
-				secret.Data[key] = secret.Data[nextKey]
-				delete(secret.Data, nextKey)
+			changedPublicKeys, err := keystone.UpdateJWSPublicKeys(secret.Data)
+			if err != nil {
+				return nil, err
 			}
+			changedKeys = changedKeys || changedPublicKeys
 		}
 
-		//
-		// Add extra keys when FernetMaxActiveKeys changes
-		//
-		lastKey := fmt.Sprintf("FernetKeys%d", numberKeys-1)
-		for {
-			_, exists := secret.Data[lastKey]
-			if exists {
-				break
+		if changedKeys {
+			// keep the rotation due while it is held back
+			if len(pendingPods) > 0 {
+				rotatedAt = lastRotated
 			}
-			changedKeys = true
-			i := 1
-			nextKeyValue := []byte(keystone.GenerateFernetKey(logger))
-			for {
-				key := fmt.Sprintf("FernetKeys%d", i)
-				i++
-				keyValue, exists := secret.Data[key]
-				secret.Data[key] = nextKeyValue
-				nextKeyValue = keyValue
-				if !exists {
-					break
-				}
+			secret.Annotations[fernetAnnotation] = rotatedAt.Format(time.RFC3339)
+
+			// use update to apply changes to the secret, since EnsureSecrets
+			// does not handle annotation updates, also CreateOrPatchSecret would
+			// preserve the existing annotation
+			err = helper.GetClient().Update(ctx, secret, &client.UpdateOptions{})
+			if err != nil {
+				return nil, err
 			}
+		} else {
+			rotatedAt = lastRotated
 		}
+	}
 
-		if !changedKeys {
-			return nil
+	if revoke {
+		instance.Status.TokenRevocation = &keystonev1.TokenRevocationStatus{
+			Nonce:       revocation.Nonce,
+			Reason:      revocation.Reason,
+			RequestedBy: revocation.RequestedBy,
+			RevokedAt:   ptr.To(metav1.NewTime(now)),
 		}
+		r.EventRecorder.Event(
+			instance,
+			corev1.EventTypeWarning,
+			"TokensRevoked",
+			fmt.Sprintf("Replaced all token keys, all issued tokens are invalid. Requested by %s: %s",
+				revocation.RequestedBy, revocation.Reason),
+		)
+	}
+	// roll out the keystone pods with the new keys, instead of waiting for
+	// the kubelet to update the mounted keys
+	if instance.Status.TokenRevocation != nil {
+		(*envVars)["TokenRevocation"] = env.SetValue(instance.Status.TokenRevocation.Nonce)
+	}
+
+	instance.Status.FernetKeys = &keystonev1.KeyRotationStatus{
+		LastRotated:  ptr.To(metav1.NewTime(rotatedAt)),
+		NextRotation: ptr.To(metav1.NewTime(rotatedAt.AddDate(0, 0, duration))),
+		ActiveKeys:   int32(numberKeys), // #nosec G115
+	}
+
+	return pendingPods, nil
+}
 
-		secret.Annotations[fernetAnnotation] = now.Format(time.RFC3339)
+// ensureReceiptKeys - adds the fernet receipt keys to the keystone secret,
+// rotates them like the fernet token keys and records the rotation state in
+// the status
+func (r *KeystoneAPIReconciler) ensureReceiptKeys(
+	ctx context.Context,
+	instance *keystonev1.KeystoneAPI,
+	helper *helper.Helper,
+) ([]string, error) {
+	logger := r.GetLogger(ctx)
+	rotationAnnotation := labels.GetGroupLabel(keystone.ServiceName) + "/receiptrotatedat"
+	now := time.Now().UTC().Truncate(time.Second)
 
-		// use update to apply changes to the secret, since EnsureSecrets
-		// does not handle annotation updates, also CreateOrPatchSecret would
-		// preserve the existing annotation
+	numberKeys := keystone.DefaultReceiptMaxActiveKeys
+	if instance.Spec.ReceiptMaxActiveKeys != nil {
+		numberKeys = int(*instance.Spec.ReceiptMaxActiveKeys)
+	}
+	duration := keystone.DefaultReceiptRotationDays
+	if instance.Spec.ReceiptRotationDays != nil {
+		duration = int(*instance.Spec.ReceiptRotationDays)
+	}
+	generateKey := func() []byte {
+		return []byte(keystone.GenerateFernetKey(logger))
+	}
+	rotatedAt := now
+	pendingPods := []string{}
+
+	secret, _, err := oko_secret.GetSecret(ctx, helper, keystone.ResourceName(instance), instance.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	if secret.Annotations == nil {
+		secret.Annotations = map[string]string{}
+	}
+
+	changedKeys := false
+	lastRotated, err := time.Parse(time.RFC3339, secret.Annotations[rotationAnnotation])
+	if err != nil {
+		changedKeys = true
+	} else if !now.Before(lastRotated.AddDate(0, 0, duration)) {
+		pendingPods, err = r.getKeyRepositoryPendingPods(ctx, instance, helper, keystone.ReceiptKeysPath,
+			keystone.KeyRepositoryChecksums(secret.Data, keystone.ReceiptKeysPrefix))
+		if err != nil {
+			return nil, err
+		}
+		if len(pendingPods) > 0 {
+			logger.Info(fmt.Sprintf("Holding back receipt key rotation, pods %v did not yet observe the current keys", pendingPods))
+		} else {
+			logger.Info(fmt.Sprintf("Rotating receipt keys, last rotation at %s", lastRotated.Format(time.RFC3339)))
+			keystone.RotateKeyRepository(secret.Data, keystone.ReceiptKeysPrefix, numberKeys, generateKey)
+		}
+	}
+
+	// also generates the keys of a secret created before the receipt keys
+	// were added
+	if keystone.ResizeKeyRepository(secret.Data, keystone.ReceiptKeysPrefix, numberKeys, generateKey) {
+		changedKeys = true
+	}
+
+	if changedKeys {
+		// keep the rotation due while it is held back
+		if len(pendingPods) > 0 {
+			rotatedAt = lastRotated
+		}
+		secret.Annotations[rotationAnnotation] = rotatedAt.Format(time.RFC3339)
 		err = helper.GetClient().Update(ctx, secret, &client.UpdateOptions{})
 		if err != nil {
-			return err
+			return nil, err
 		}
+	} else {
+		rotatedAt = lastRotated
 	}
 
-	return nil
+	instance.Status.ReceiptKeys = &keystonev1.KeyRotationStatus{
+		LastRotated:  ptr.To(metav1.NewTime(rotatedAt)),
+		NextRotation: ptr.To(metav1.NewTime(rotatedAt.AddDate(0, 0, duration))),
+		ActiveKeys:   int32(numberKeys), // #nosec G115
+	}
+
+	return pendingPods, nil
+}
+
+// importKeys - returns the fernet and credential keys of the key repositories
+// referenced by KeyImport, in the format of the keystone secret
+func (r *KeystoneAPIReconciler) importKeys(
+	ctx context.Context,
+	instance *keystonev1.KeystoneAPI,
+	helper *helper.Helper,
+	numberKeys int,
+) (map[string]string, error) {
+	logger := r.GetLogger(ctx)
+	keys := map[string]string{}
+	if instance.Spec.KeyImport == nil {
+		return keys, nil
+	}
+
+	repositories := []struct {
+		secretName string
+		prefix     string
+		minKeys    int
+		maxKeys    int
+	}{
+		{instance.Spec.KeyImport.FernetKeysSecret, keystone.FernetKeysPrefix, 2, numberKeys},
+		{instance.Spec.KeyImport.CredentialKeysSecret, keystone.CredentialKeysPrefix, 2, 3},
+	}
+	for _, repository := range repositories {
+		if repository.secretName == "" {
+			continue
+		}
+		secret, _, err := oko_secret.GetSecret(ctx, helper, repository.secretName, instance.Namespace)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get the key import secret %s: %w", repository.secretName, err)
+		}
+		imported, err := keystone.ImportKeyRepository(secret.Data, repository.prefix, repository.minKeys, repository.maxKeys)
+		if err != nil {
+			return nil, fmt.Errorf("unable to import the keys of secret %s: %w", repository.secretName, err)
+		}
+		logger.Info(fmt.Sprintf("Importing %d keys from secret %s", len(imported), repository.secretName))
+		maps.Copy(keys, imported)
+	}
+
+	return keys, nil
+}
+
+// ensureCredentialKeys - rotates the credential keys. Other than the fernet
+// token keys, the credential keys encrypt data stored in the database, which
+// gets re-encrypted with the new primary key using credential_migrate before
+// the old keys are removed. The phases are tracked in the keystone secret:
+//
+//  1. the new primary key is staged as CredentialKeys2 once all pods observe the current keys
+//  2. the credentials get re-encrypted once all pods observe the new primary key
+//  3. the new primary key becomes CredentialKeys1, replacing the old one
+func (r *KeystoneAPIReconciler) ensureCredentialKeys(
+	ctx context.Context,
+	instance *keystonev1.KeystoneAPI,
+	helper *helper.Helper,
+	serviceLabels map[string]string,
+	serviceAnnotations map[string]string,
+) (ctrl.Result, error) {
+	logger := r.GetLogger(ctx)
+	rotationAnnotation := labels.GetGroupLabel(keystone.ServiceName) + "/credentialrotatedat"
+	now := time.Now().UTC().Truncate(time.Second)
+
+	var duration int
+	if instance.Spec.CredentialRotationDays != nil {
+		duration = int(*instance.Spec.CredentialRotationDays)
+	}
+
+	secret, _, err := oko_secret.GetSecret(ctx, helper, keystone.ResourceName(instance), instance.Namespace)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if secret.Annotations == nil {
+		secret.Annotations = map[string]string{}
+	}
+
+	lastRotated, err := time.Parse(time.RFC3339, secret.Annotations[rotationAnnotation])
+	if err != nil {
+		// keys which were never rotated, start the rotation period now
+		lastRotated = now
+		secret.Annotations[rotationAnnotation] = now.Format(time.RFC3339)
+		err = helper.GetClient().Update(ctx, secret, &client.UpdateOptions{})
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	primaryKey := fmt.Sprintf("%s%d", keystone.CredentialKeysPrefix, 2)
+	_, staged := secret.Data[primaryKey]
+
+	instance.Status.CredentialKeys = &keystonev1.KeyRotationStatus{
+		LastRotated: ptr.To(metav1.NewTime(lastRotated)),
+		ActiveKeys:  2,
+	}
+	if duration > 0 {
+		instance.Status.CredentialKeys.NextRotation = ptr.To(metav1.NewTime(lastRotated.AddDate(0, 0, duration)))
+	}
+
+	if !staged {
+		if duration == 0 {
+			instance.Status.Conditions.MarkTrue(
+				keystonev1.KeystoneAPICredentialKeysReadyCondition,
+				keystonev1.KeystoneAPICredentialKeysReadyRotationDisabledMessage)
+			return ctrl.Result{}, nil
+		}
+		if now.Before(lastRotated.AddDate(0, 0, duration)) {
+			instance.Status.Conditions.MarkTrue(
+				keystonev1.KeystoneAPICredentialKeysReadyCondition,
+				keystonev1.KeystoneAPICredentialKeysReadyMessage,
+				instance.Status.CredentialKeys.NextRotation.Format(time.RFC3339))
+			return ctrl.Result{}, nil
+		}
+
+		pendingPods, err := r.getKeyRepositoryPendingPods(ctx, instance, helper, keystone.CredentialKeysPath,
+			keystone.KeyRepositoryChecksums(secret.Data, keystone.CredentialKeysPrefix))
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if len(pendingPods) > 0 {
+			logger.Info(fmt.Sprintf("Holding back credential key rotation, pods %v did not yet observe the current keys", pendingPods))
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				keystonev1.KeystoneAPICredentialKeysReadyCondition,
+				condition.RequestedReason,
+				condition.SeverityInfo,
+				keystonev1.KeystoneAPICredentialKeysReadyRotationHeldMessage,
+				strings.Join(pendingPods, ", ")))
+			return ctrl.Result{RequeueAfter: fernetRotationRetryInterval}, nil
+		}
+
+		// the staged key 0 becomes the new primary key, the old primary
+		// key 1 stays to decrypt the credentials until they got migrated
+		logger.Info(fmt.Sprintf("Rotating credential keys, last rotation at %s", lastRotated.Format(time.RFC3339)))
+		secret.Data[primaryKey] = secret.Data[keystone.CredentialKeysPrefix+"0"]
+		secret.Data[keystone.CredentialKeysPrefix+"0"] = []byte(keystone.GenerateFernetKey(logger))
+		err = helper.GetClient().Update(ctx, secret, &client.UpdateOptions{})
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	instance.Status.CredentialKeys.ActiveKeys = 3
+
+	// all pods have to encrypt with the new primary key before the
+	// credentials get migrated, otherwise credentials created meanwhile
+	// would still use the old one
+	checksums := keystone.KeyRepositoryChecksums(secret.Data, keystone.CredentialKeysPrefix)
+	pendingPods, err := r.getKeyRepositoryPendingPods(ctx, instance, helper, keystone.CredentialKeysPath, checksums)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if len(pendingPods) > 0 {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneAPICredentialKeysReadyCondition,
+			condition.RequestedReason,
+			condition.SeverityInfo,
+			keystonev1.KeystoneAPICredentialKeysReadyStagedMessage,
+			strings.Join(pendingPods, ", ")))
+		return ctrl.Result{RequeueAfter: fernetRotationRetryInterval}, nil
+	}
+
+	jobDef := keystone.CredentialMigrateJob(instance, serviceLabels, serviceAnnotations, checksums["2"])
+	migrateJob := job.NewJob(
+		jobDef,
+		keystonev1.CredentialMigrateHash,
+		instance.Spec.PreserveJobs,
+		5*time.Second,
+		instance.Status.Hash[keystonev1.CredentialMigrateHash],
+	)
+	ctrlResult, err := migrateJob.DoJob(ctx, helper)
+	if (ctrlResult != ctrl.Result{}) {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneAPICredentialKeysReadyCondition,
+			condition.RequestedReason,
+			condition.SeverityInfo,
+			keystonev1.KeystoneAPICredentialKeysReadyMigratingMessage))
+		return ctrlResult, nil
+	}
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if migrateJob.HasChanged() {
+		instance.Status.Hash[keystonev1.CredentialMigrateHash] = migrateJob.GetHash()
+		logger.Info(fmt.Sprintf("Job %s hash added - %s", jobDef.Name, instance.Status.Hash[keystonev1.CredentialMigrateHash]))
+	}
+
+	// all credentials use the new primary key, drop the old one
+	secret.Data[keystone.CredentialKeysPrefix+"1"] = secret.Data[primaryKey]
+	delete(secret.Data, primaryKey)
+	secret.Annotations[rotationAnnotation] = now.Format(time.RFC3339)
+	err = helper.GetClient().Update(ctx, secret, &client.UpdateOptions{})
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	instance.Status.CredentialKeys = &keystonev1.KeyRotationStatus{
+		LastRotated: ptr.To(metav1.NewTime(now)),
+		ActiveKeys:  2,
+	}
+	if duration > 0 {
+		instance.Status.CredentialKeys.NextRotation = ptr.To(metav1.NewTime(now.AddDate(0, 0, duration)))
+		instance.Status.Conditions.MarkTrue(
+			keystonev1.KeystoneAPICredentialKeysReadyCondition,
+			keystonev1.KeystoneAPICredentialKeysReadyMessage,
+			instance.Status.CredentialKeys.NextRotation.Format(time.RFC3339))
+	} else {
+		instance.Status.Conditions.MarkTrue(
+			keystonev1.KeystoneAPICredentialKeysReadyCondition,
+			keystonev1.KeystoneAPICredentialKeysReadyRotationDisabledMessage)
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// getKeyRepositoryPendingPods - returns the ready keystone pods which did not
+// yet observe the keys with the given checksums in the key repository at path.
+// Secret updates reach the pods with the kubelet sync, which can take a while.
+func (r *KeystoneAPIReconciler) getKeyRepositoryPendingPods(
+	ctx context.Context,
+	instance *keystonev1.KeystoneAPI,
+	helper *helper.Helper,
+	path string,
+	checksums map[string]string,
+) ([]string, error) {
+	logger := r.GetLogger(ctx)
+
+	podList, err := pod.GetPodListWithLabel(ctx, helper, instance.Namespace, map[string]string{
+		common.AppSelector:   keystone.ServiceName,
+		common.OwnerSelector: instance.Name,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	pendingPods := []string{}
+	for _, p := range podList.Items {
+		if !p.DeletionTimestamp.IsZero() || !isPodReady(&p) {
+			continue
+		}
+
+		observed := map[string]string{}
+		err := rsh.ExecInPod(ctx, helper.GetKClient(), r.RestConfig,
+			types.NamespacedName{Namespace: p.Namespace, Name: p.Name},
+			keystone.ServiceName+"-api",
+			keystone.KeyRepositoryChecksumCommand(path),
+			func(stdout *bytes.Buffer, _ *bytes.Buffer) error {
+				observed = keystone.ParseKeyRepositoryChecksums(stdout.String())
+				return nil
+			},
+		)
+		if err != nil {
+			// e.g. the pod just went away, check again with the next reconcile
+			logger.Info(fmt.Sprintf("Unable to check the keys in %s of pod %s: %s", path, p.Name, err))
+		}
+		if err != nil || !maps.Equal(observed, checksums) {
+			pendingPods = append(pendingPods, p.Name)
+		}
+	}
+
+	return pendingPods, nil
+}
+
+// isPodReady - returns true if the pod has the Ready condition set
+func isPodReady(p *corev1.Pod) bool {
+	for _, c := range p.Status.Conditions {
+		if c.Type == corev1.PodReady {
+			return c.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// fernetRotationRetryInterval - interval the keystone pods get checked in
+// while a key rotation is held back
+const fernetRotationRetryInterval = 10 * time.Second
+
+// canaryRetryInterval - interval the canary gets verified in
+const canaryRetryInterval = 10 * time.Second
+
+// upgradeCheckRetryInterval - interval failed pre-upgrade checks get
+// evaluated again in, e.g. after their jobs got deleted to run them again
+const upgradeCheckRetryInterval = time.Minute
+
+// requeueForKeyRotation - the fernet, credential and receipt keys get rotated
+// by the reconcile loop, make sure it runs when the next rotation is due, also
+// on a cluster where nothing else triggers a reconcile
+func requeueForKeyRotation(instance *keystonev1.KeystoneAPI, result ctrl.Result) ctrl.Result {
+	// an immediate requeue is already pending
+	if result.Requeue && result.RequeueAfter == 0 {
+		return result
+	}
+
+	for _, status := range []*keystonev1.KeyRotationStatus{
+		instance.Status.FernetKeys,
+		instance.Status.CredentialKeys,
+		instance.Status.ReceiptKeys,
+	} {
+		if status == nil || status.NextRotation == nil {
+			continue
+		}
+		requeueAfter := time.Until(status.NextRotation.Time)
+		if requeueAfter <= 0 {
+			// the rotation is held back, check the pods again shortly
+			requeueAfter = fernetRotationRetryInterval
+		}
+		if result.RequeueAfter == 0 || requeueAfter < result.RequeueAfter {
+			result.RequeueAfter = requeueAfter
+		}
+	}
+	return result
 }
 
 // ensureFederationRealmConfig - create secret with federation realm config
@@ -1958,7 +3379,7 @@ func (r *KeystoneAPIReconciler) ensureFederationRealmConfig(
 	cmLabels := labels.GetLabels(instance, labels.GetGroupLabel(keystone.ServiceName), map[string]string{})
 	newSecret := []util.Template{
 		{
-			Name:          keystone.FederationMultiRealmSecret,
+			Name:          keystone.FederationMultiRealmSecretName(instance),
 			Namespace:     instance.Namespace,
 			Type:          util.TemplateTypeNone,
 			InstanceType:  instance.Kind,
@@ -1976,6 +3397,165 @@ func (r *KeystoneAPIReconciler) ensureFederationRealmConfig(
 	return sortedFilenames, nil
 }
 
+// isOnlyKeystoneAPI - returns true if instance is the only KeystoneAPI in its
+// namespace, resources without the KeystoneAPINameLabel use it then
+func (r *KeystoneAPIReconciler) isOnlyKeystoneAPI(
+	ctx context.Context,
+	instance *keystonev1.KeystoneAPI,
+) (bool, error) {
+	keystoneAPIs := &keystonev1.KeystoneAPIList{}
+	err := r.List(ctx, keystoneAPIs, client.InNamespace(instance.Namespace))
+	if err != nil {
+		return false, err
+	}
+	return len(keystoneAPIs.Items) == 1, nil
+}
+
+// getDomainConfigs - renders the domain specific configs of all LDAP backed
+// KeystoneDomain CRs using instance which are registered in keystone.
+// Returns a map of config filename to content.
+func (r *KeystoneAPIReconciler) getDomainConfigs(
+	ctx context.Context,
+	instance *keystonev1.KeystoneAPI,
+	helper *helper.Helper,
+) (map[string]string, error) {
+	domainConfigs := map[string]string{}
+
+	onlyKeystoneAPI, err := r.isOnlyKeystoneAPI(ctx, instance)
+	if err != nil {
+		return nil, err
+	}
+
+	domains := &keystonev1.KeystoneDomainList{}
+	err = r.List(ctx, domains, client.InNamespace(instance.Namespace))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, domain := range domains.Items {
+		if !usesKeystoneAPI(&domain, instance, onlyKeystoneAPI) {
+			continue
+		}
+		// only render the config once the domain exists in keystone, keystone
+		// ignores config files of unknown domains
+		if domain.Spec.LDAP == nil || domain.Status.DomainID == "" || !domain.DeletionTimestamp.IsZero() {
+			continue
+		}
+
+		password := ""
+		if domain.Spec.LDAP.Secret != "" {
+			ldapSecret, _, err := oko_secret.GetSecret(ctx, helper, domain.Spec.LDAP.Secret, instance.Namespace)
+			if err != nil {
+				return nil, err
+			}
+			data, ok := ldapSecret.Data[domain.Spec.LDAP.PasswordSelector]
+			if !ok {
+				return nil, fmt.Errorf("key %s not found in secret %s: %w", domain.Spec.LDAP.PasswordSelector, domain.Spec.LDAP.Secret, util.ErrFieldNotFound)
+			}
+			password = string(data)
+		}
+
+		domainConfig, err := keystone.RenderDomainLDAPConfig(domain.Spec.LDAP, password)
+		if err != nil {
+			return nil, err
+		}
+		domainConfigs[keystone.DomainConfigFileName(domain.Spec.DomainName)] = domainConfig
+	}
+
+	return domainConfigs, nil
+}
+
+// getFederationConfig - renders the httpd configs of all KeystoneIdentityProvider
+// CRs using instance which are registered in keystone and have at least one
+// registered KeystoneProtocol.
+func (r *KeystoneAPIReconciler) getFederationConfig(
+	ctx context.Context,
+	instance *keystonev1.KeystoneAPI,
+	helper *helper.Helper,
+) (*keystone.FederationConfig, error) {
+	federationConfig := &keystone.FederationConfig{
+		Files:              map[string]string{},
+		RemoteIDAttributes: map[string]string{},
+	}
+
+	onlyKeystoneAPI, err := r.isOnlyKeystoneAPI(ctx, instance)
+	if err != nil {
+		return nil, err
+	}
+
+	idps := &keystonev1.KeystoneIdentityProviderList{}
+	err = r.List(ctx, idps, client.InNamespace(instance.Namespace))
+	if err != nil {
+		return nil, err
+	}
+
+	protocols := &keystonev1.KeystoneProtocolList{}
+	err = r.List(ctx, protocols, client.InNamespace(instance.Namespace))
+	if err != nil {
+		return nil, err
+	}
+
+	keystoneEndpoint, _ := instance.GetEndpoint(endpoint.EndpointPublic)
+
+	oidcIdP := ""
+	for _, idp := range idps.Items {
+		if !usesKeystoneAPI(&idp, instance, onlyKeystoneAPI) {
+			continue
+		}
+		if idp.Spec.OIDC == nil && idp.Spec.SAML == nil {
+			continue
+		}
+		if idp.Status.IdentityProviderID == "" || !idp.DeletionTimestamp.IsZero() {
+			continue
+		}
+
+		idpProtocols := []string{}
+		for _, protocol := range protocols.Items {
+			if protocol.Spec.IdentityProvider != idp.Name || !usesKeystoneAPI(&protocol, instance, onlyKeystoneAPI) ||
+				protocol.Status.ProtocolID == "" || !protocol.DeletionTimestamp.IsZero() {
+				continue
+			}
+			idpProtocols = append(idpProtocols, protocol.Spec.ProtocolID)
+		}
+		if len(idpProtocols) == 0 {
+			continue
+		}
+		slices.Sort(idpProtocols)
+
+		// mod_auth_openidc only supports a single provider per vhost when
+		// configured through OIDCProviderMetadataURL
+		if idp.Spec.OIDC != nil {
+			if oidcIdP != "" {
+				return nil, fmt.Errorf("%w: only one OIDC identity provider supported, found %s and %s",
+					ErrFederationConfig, oidcIdP, idp.Name)
+			}
+			oidcIdP = idp.Name
+		}
+
+		idpSecret, _, err := oko_secret.GetSecret(ctx, helper, idp.GetSecretName(), instance.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		for _, selector := range idp.GetSecretSelectors() {
+			if _, ok := idpSecret.Data[selector]; !ok {
+				return nil, fmt.Errorf("key %s not found in secret %s: %w", selector, idp.GetSecretName(), util.ErrFieldNotFound)
+			}
+		}
+
+		files, err := keystone.RenderFederationConfig(&idp, idpProtocols, keystoneEndpoint, idpSecret.Data)
+		if err != nil {
+			return nil, err
+		}
+		maps.Copy(federationConfig.Files, files)
+
+		for _, protocol := range idpProtocols {
+			federationConfig.RemoteIDAttributes[protocol] = keystone.RemoteIDAttribute(&idp)
+		}
+	}
+
+	return federationConfig, nil
+}
+
 // createHashOfInputHashes - creates a hash of hashes which gets added to the resources which requires a restart
 // if any of the input resources change, like configs, passwords, ...
 //
@@ -2035,11 +3615,11 @@ func (r *KeystoneAPIReconciler) ensureDB(
 	// create service DB instance
 	//
 	db := mariadbv1.NewDatabaseForAccount(
-		instance.Spec.DatabaseInstance, // mariadb/galera service to target
-		keystone.DatabaseName,          // name used in CREATE DATABASE in mariadb
-		keystone.DatabaseCRName,        // CR name for MariaDBDatabase
-		instance.Spec.DatabaseAccount,  // CR name for MariaDBAccount
-		instance.Namespace,             // namespace
+		instance.Spec.DatabaseInstance,    // mariadb/galera service to target
+		keystone.DatabaseName(instance),   // name used in CREATE DATABASE in mariadb
+		keystone.DatabaseCRName(instance), // CR name for MariaDBDatabase
+		instance.Spec.DatabaseAccount,     // CR name for MariaDBAccount
+		instance.Namespace,                // namespace
 	)
 
 	// create or patch the DB
@@ -0,0 +1,214 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	helper "github.com/openstack-k8s-operators/lib-common/modules/common/helper"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// keystoneAPIRefField - field to index the KeystoneAPI referenced by a
+// KeystoneService, KeystoneEndpoint or KeystoneApplicationCredential
+const keystoneAPIRefField = ".spec.keystoneAPIRef"
+
+// keystoneAPIRefIndexValue - index value of ref, the namespace/name of the
+// referenced KeystoneAPI
+func keystoneAPIRefIndexValue(ref *keystonev1.KeystoneAPIRef, namespace string) []string {
+	if ref == nil {
+		return nil
+	}
+	return []string{ref.GetNamespacedName(namespace).String()}
+}
+
+// keystoneAPIRefPredicate - passes the changes of a KeystoneAPI the referencing
+// resources care about, its endpoints, its readiness, the namespaces it allows
+// references from and its deletion
+var keystoneAPIRefPredicate = predicate.Or(
+	keystonev1.KeystoneAPIStatusChangedPredicate,
+	predicate.Funcs{
+		CreateFunc: func(_ event.CreateEvent) bool { return true },
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldAPI, okOld := e.ObjectOld.(*keystonev1.KeystoneAPI)
+			newAPI, okNew := e.ObjectNew.(*keystonev1.KeystoneAPI)
+			if !okOld || !okNew {
+				return false
+			}
+			return oldAPI.IsReady() != newAPI.IsReady() ||
+				!slices.Equal(oldAPI.Spec.AllowedNamespaces, newAPI.Spec.AllowedNamespaces)
+		},
+		DeleteFunc:  func(_ event.DeleteEvent) bool { return false },
+		GenericFunc: func(_ event.GenericEvent) bool { return false },
+	},
+)
+
+// findObjectsForKeystoneAPIRef - reconcile the objects of list which reference
+// the changed KeystoneAPI in their keystoneAPIRef, in any namespace
+func findObjectsForKeystoneAPIRef(
+	ctx context.Context,
+	c client.Client,
+	list client.ObjectList,
+	src client.Object,
+) []reconcile.Request {
+	Log := log.FromContext(ctx)
+	requests := []reconcile.Request{}
+
+	key := types.NamespacedName{Name: src.GetName(), Namespace: src.GetNamespace()}.String()
+	err := c.List(ctx, list, &client.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector(keystoneAPIRefField, key),
+	})
+	if err != nil {
+		Log.Error(err, fmt.Sprintf("listing %T for field: %s - %s", list, keystoneAPIRefField, key))
+		return requests
+	}
+
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		Log.Error(err, fmt.Sprintf("extracting %T", list))
+		return requests
+	}
+	for _, item := range items {
+		obj, ok := item.(client.Object)
+		if !ok {
+			continue
+		}
+		Log.Info(fmt.Sprintf("KeystoneAPI %s changed, reconcile: %s - %s", key, obj.GetName(), obj.GetNamespace()))
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{
+				Name:      obj.GetName(),
+				Namespace: obj.GetNamespace(),
+			},
+		})
+	}
+
+	return requests
+}
+
+// keystoneAPINotFoundCondition - KeystoneAPIReady condition of a resource
+// whose KeystoneAPI does not exist, naming the KeystoneAPI of ref if set
+func keystoneAPINotFoundCondition(ref *keystonev1.KeystoneAPIRef, namespace string) *condition.Condition {
+	if ref != nil {
+		return condition.FalseCondition(
+			keystonev1.KeystoneAPIReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.KeystoneAPIReadyRefNotFoundMessage,
+			ref.GetNamespacedName(namespace).String())
+	}
+	return condition.FalseCondition(
+		keystonev1.KeystoneAPIReadyCondition,
+		condition.ErrorReason,
+		condition.SeverityWarning,
+		keystonev1.KeystoneAPIReadyNotFoundMessage)
+}
+
+// keystoneAPIWaitingCondition - KeystoneAPIReady condition of a resource
+// whose KeystoneAPI is not ready, naming the KeystoneAPI of ref if set
+func keystoneAPIWaitingCondition(ref *keystonev1.KeystoneAPIRef, namespace string) *condition.Condition {
+	if ref != nil {
+		return condition.FalseCondition(
+			keystonev1.KeystoneAPIReadyCondition,
+			condition.RequestedReason,
+			condition.SeverityInfo,
+			keystonev1.KeystoneAPIReadyRefWaitingMessage,
+			ref.GetNamespacedName(namespace).String())
+	}
+	return condition.FalseCondition(
+		keystonev1.KeystoneAPIReadyCondition,
+		condition.RequestedReason,
+		condition.SeverityInfo,
+		keystonev1.KeystoneAPIReadyWaitingMessage)
+}
+
+// keystoneAPIFinalizer - finalizer a resource adds to the KeystoneAPI it is
+// registered in. A resource of another namespace adds its namespace, so same
+// named resources of different namespaces do not share the finalizer.
+func keystoneAPIFinalizer(h *helper.Helper, instance client.Object, keystoneAPI *keystonev1.KeystoneAPI) string {
+	if instance.GetNamespace() != keystoneAPI.Namespace {
+		return fmt.Sprintf("%s-%s-%s", h.GetFinalizer(), instance.GetNamespace(), instance.GetName())
+	}
+	return fmt.Sprintf("%s-%s", h.GetFinalizer(), instance.GetName())
+}
+
+// usesKeystoneAPI - returns true if obj uses keystoneAPI, see
+// keystonev1.GetKeystoneAPIForObject. Without the KeystoneAPINameLabel obj
+// uses keystoneAPI only if it is the only one in the namespace.
+func usesKeystoneAPI(obj client.Object, keystoneAPI *keystonev1.KeystoneAPI, onlyKeystoneAPI bool) bool {
+	if name, ok := obj.GetLabels()[keystonev1.KeystoneAPINameLabel]; ok && name != "" {
+		return name == keystoneAPI.Name
+	}
+	return onlyKeystoneAPI
+}
+
+// switchKeystoneAPI - returns true if a resource registered in the KeystoneAPI
+// previous, the namespace/name recorded in its status, now uses keystoneAPI,
+// e.g. after its keystoneAPIRef or its keystone.openstack.org/keystoneapi label
+// changed. cleanup removes the previous registration from the keystone of the
+// previous KeystoneAPI, unless it is gone or being deleted, before the
+// finalizer of the resource gets removed from it. The caller resets the IDs of
+// the previous registration.
+func switchKeystoneAPI(
+	ctx context.Context,
+	h *helper.Helper,
+	instance client.Object,
+	previous string,
+	keystoneAPI *keystonev1.KeystoneAPI,
+	cleanup func(previousAPI *keystonev1.KeystoneAPI) error,
+) (bool, error) {
+	if previous == "" || previous == client.ObjectKeyFromObject(keystoneAPI).String() {
+		return false, nil
+	}
+
+	namespace, name, _ := strings.Cut(previous, "/")
+	previousAPI := &keystonev1.KeystoneAPI{}
+	err := h.GetClient().Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, previousAPI)
+	if err != nil {
+		if k8s_errors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	}
+
+	if previousAPI.DeletionTimestamp.IsZero() {
+		err = cleanup(previousAPI)
+		if err != nil {
+			return false, err
+		}
+	}
+	if controllerutil.RemoveFinalizer(previousAPI, keystoneAPIFinalizer(h, instance, previousAPI)) {
+		err = h.GetClient().Update(ctx, previousAPI)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
@@ -0,0 +1,202 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-logr/logr"
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/helper"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func makeKeystoneService(name, namespace string, ref *keystonev1.KeystoneAPIRef) *keystonev1.KeystoneService {
+	return &keystonev1.KeystoneService{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: keystonev1.KeystoneServiceSpec{
+			ServiceName:    name,
+			KeystoneAPIRef: ref,
+		},
+	}
+}
+
+func TestFindObjectsForKeystoneAPIRef(t *testing.T) {
+	c := fake.NewClientBuilder().
+		WithScheme(newTestScheme()).
+		WithIndex(&keystonev1.KeystoneService{}, keystoneAPIRefField, func(rawObj client.Object) []string {
+			cr := rawObj.(*keystonev1.KeystoneService)
+			return keystoneAPIRefIndexValue(cr.Spec.KeystoneAPIRef, cr.Namespace)
+		}).
+		WithObjects(
+			// references keystone in its own namespace
+			makeKeystoneService("nova", "openstack", &keystonev1.KeystoneAPIRef{Name: "keystone"}),
+			// references keystone of the openstack namespace
+			makeKeystoneService("glance", "workload", &keystonev1.KeystoneAPIRef{Name: "keystone", Namespace: "openstack"}),
+			// references keystone of its own namespace
+			makeKeystoneService("cinder", "workload", &keystonev1.KeystoneAPIRef{Name: "keystone"}),
+			// references another KeystoneAPI
+			makeKeystoneService("swift", "openstack", &keystonev1.KeystoneAPIRef{Name: "keystone-b"}),
+			// no reference
+			makeKeystoneService("placement", "openstack", nil),
+		).
+		Build()
+
+	src := &keystonev1.KeystoneAPI{
+		ObjectMeta: metav1.ObjectMeta{Name: "keystone", Namespace: "openstack"},
+	}
+	requests := findObjectsForKeystoneAPIRef(context.Background(), c, &keystonev1.KeystoneServiceList{}, src)
+
+	got := map[types.NamespacedName]bool{}
+	for _, req := range requests {
+		got[req.NamespacedName] = true
+	}
+	want := []types.NamespacedName{
+		{Name: "nova", Namespace: "openstack"},
+		{Name: "glance", Namespace: "workload"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected requests for %v, got %v", want, requests)
+	}
+	for _, name := range want {
+		if !got[name] {
+			t.Errorf("expected a request for %s, got %v", name, requests)
+		}
+	}
+}
+
+func TestKeystoneAPIFinalizer(t *testing.T) {
+	keystoneAPI := &keystonev1.KeystoneAPI{
+		ObjectMeta: metav1.ObjectMeta{Name: "keystone", Namespace: "openstack"},
+	}
+	s := newTestScheme()
+	instance := makeKeystoneService("nova", "openstack", nil)
+	h, err := helper.NewHelper(instance, fake.NewClientBuilder().WithScheme(s).Build(), k8sfake.NewSimpleClientset(), s, logr.Discard())
+	if err != nil {
+		t.Fatalf("failed to create helper: %v", err)
+	}
+
+	finalizer := keystoneAPIFinalizer(h, instance, keystoneAPI)
+	if finalizer != h.GetFinalizer()+"-nova" {
+		t.Errorf("unexpected finalizer %s in the namespace of the KeystoneAPI", finalizer)
+	}
+
+	finalizer = keystoneAPIFinalizer(h, makeKeystoneService("nova", "workload", nil), keystoneAPI)
+	if finalizer != h.GetFinalizer()+"-workload-nova" {
+		t.Errorf("unexpected finalizer %s from another namespace", finalizer)
+	}
+}
+
+func TestUsesKeystoneAPI(t *testing.T) {
+	keystoneAPI := &keystonev1.KeystoneAPI{
+		ObjectMeta: metav1.ObjectMeta{Name: "keystone", Namespace: "openstack"},
+	}
+	labeled := func(name string) client.Object {
+		return &keystonev1.KeystoneDomain{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "corp",
+				Namespace: "openstack",
+				Labels:    map[string]string{keystonev1.KeystoneAPINameLabel: name},
+			},
+		}
+	}
+	unlabeled := &keystonev1.KeystoneDomain{
+		ObjectMeta: metav1.ObjectMeta{Name: "corp", Namespace: "openstack"},
+	}
+
+	tests := []struct {
+		name            string
+		obj             client.Object
+		onlyKeystoneAPI bool
+		want            bool
+	}{
+		{"labeled with the KeystoneAPI", labeled("keystone"), false, true},
+		{"labeled with another KeystoneAPI", labeled("keystone-b"), true, false},
+		{"unlabeled with a single KeystoneAPI", unlabeled, true, true},
+		{"unlabeled with several KeystoneAPIs", unlabeled, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := usesKeystoneAPI(tt.obj, keystoneAPI, tt.onlyKeystoneAPI); got != tt.want {
+				t.Errorf("usesKeystoneAPI() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSwitchKeystoneAPI(t *testing.T) {
+	s := newTestScheme()
+	instance := makeKeystoneService("nova", "openstack", nil)
+	previousAPI := &keystonev1.KeystoneAPI{
+		ObjectMeta: metav1.ObjectMeta{Name: "keystone", Namespace: "openstack"},
+	}
+	keystoneAPI := &keystonev1.KeystoneAPI{
+		ObjectMeta: metav1.ObjectMeta{Name: "keystone-b", Namespace: "openstack"},
+	}
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(previousAPI, keystoneAPI).Build()
+	h, err := helper.NewHelper(instance, c, k8sfake.NewSimpleClientset(), s, logr.Discard())
+	if err != nil {
+		t.Fatalf("failed to create helper: %v", err)
+	}
+	previousAPI.Finalizers = []string{keystoneAPIFinalizer(h, instance, previousAPI)}
+	if err := c.Update(context.Background(), previousAPI); err != nil {
+		t.Fatalf("failed to add the finalizer: %v", err)
+	}
+
+	cleanedUp := []string{}
+	cleanup := func(previousAPI *keystonev1.KeystoneAPI) error {
+		cleanedUp = append(cleanedUp, previousAPI.Name)
+		return nil
+	}
+
+	for _, previous := range []string{"", "openstack/keystone-b"} {
+		switched, err := switchKeystoneAPI(context.Background(), h, instance, previous, keystoneAPI, cleanup)
+		if err != nil || switched {
+			t.Errorf("expected no switch from %q, got %v, %v", previous, switched, err)
+		}
+	}
+	if len(cleanedUp) != 0 {
+		t.Errorf("expected no cleanup without a switch, got %v", cleanedUp)
+	}
+
+	// a failed cleanup keeps the finalizer, the switch gets retried
+	_, err = switchKeystoneAPI(context.Background(), h, instance, "openstack/keystone", keystoneAPI,
+		func(_ *keystonev1.KeystoneAPI) error { return errors.New("keystone unavailable") })
+	if err == nil {
+		t.Errorf("expected the cleanup error")
+	}
+	updated := &keystonev1.KeystoneAPI{}
+	if err := c.Get(context.Background(), types.NamespacedName{Name: "keystone", Namespace: "openstack"}, updated); err != nil {
+		t.Fatalf("failed to get the previous KeystoneAPI: %v", err)
+	}
+	if len(updated.Finalizers) != 1 {
+		t.Errorf("expected the finalizer to be kept on a failed cleanup, got %v", updated.Finalizers)
+	}
+
+	switched, err := switchKeystoneAPI(context.Background(), h, instance, "openstack/keystone", keystoneAPI, cleanup)
+	if err != nil || !switched {
+		t.Fatalf("expected a switch from openstack/keystone, got %v, %v", switched, err)
+	}
+	if len(cleanedUp) != 1 || cleanedUp[0] != "keystone" {
+		t.Errorf("expected the cleanup in the previous KeystoneAPI, got %v", cleanedUp)
+	}
+	if err := c.Get(context.Background(), types.NamespacedName{Name: "keystone", Namespace: "openstack"}, updated); err != nil {
+		t.Fatalf("failed to get the previous KeystoneAPI: %v", err)
+	}
+	if len(updated.Finalizers) != 0 {
+		t.Errorf("expected the finalizer to be removed from the previous KeystoneAPI, got %v", updated.Finalizers)
+	}
+
+	// the previous KeystoneAPI is gone already
+	switched, err = switchKeystoneAPI(context.Background(), h, instance, "openstack/keystone-c", keystoneAPI, cleanup)
+	if err != nil || !switched {
+		t.Errorf("expected a switch from a deleted KeystoneAPI, got %v, %v", switched, err)
+	}
+	if len(cleanedUp) != 1 {
+		t.Errorf("expected no cleanup in a deleted KeystoneAPI, got %v", cleanedUp)
+	}
+}
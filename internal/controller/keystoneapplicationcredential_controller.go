@@ -147,7 +147,7 @@ func (r *ApplicationCredentialReconciler) Reconcile(ctx context.Context, req ctr
 	//
 	// Validate that keystoneAPI is up
 	//
-	keystoneAPI, err := keystonev1.GetKeystoneAPI(ctx, helperObj, instance.Namespace, nil)
+	keystoneAPI, err := keystonev1.GetKeystoneAPIWithRef(ctx, helperObj, instance.Spec.KeystoneAPIRef, instance)
 	if err != nil {
 		if k8s_errors.IsNotFound(err) {
 			// If this KeystoneApplicationCredential CR is being deleted and it has not created
@@ -157,12 +157,7 @@ func (r *ApplicationCredentialReconciler) Reconcile(ctx context.Context, req ctr
 				return r.reconcileDelete(ctx, instance, helperObj, nil)
 			}
 
-			instance.Status.Conditions.Set(condition.FalseCondition(
-				keystonev1.KeystoneAPIReadyCondition,
-				condition.ErrorReason,
-				condition.SeverityWarning,
-				keystonev1.KeystoneAPIReadyNotFoundMessage,
-			))
+			instance.Status.Conditions.Set(keystoneAPINotFoundCondition(instance.Spec.KeystoneAPIRef, instance.Namespace))
 			logger.Info("KeystoneAPI not found!")
 
 			return ctrl.Result{RequeueAfter: time.Second * 5}, nil
@@ -184,12 +179,7 @@ func (r *ApplicationCredentialReconciler) Reconcile(ctx context.Context, req ctr
 	}
 
 	if !keystoneAPI.IsReady() {
-		instance.Status.Conditions.Set(condition.FalseCondition(
-			keystonev1.KeystoneAPIReadyCondition,
-			condition.RequestedReason,
-			condition.SeverityInfo,
-			keystonev1.KeystoneAPIReadyWaitingMessage,
-		))
+		instance.Status.Conditions.Set(keystoneAPIWaitingCondition(instance.Spec.KeystoneAPIRef, instance.Namespace))
 		logger.Info("KeystoneAPI not yet ready!")
 
 		return ctrl.Result{RequeueAfter: time.Second * 5}, nil
@@ -211,6 +201,24 @@ func (r *ApplicationCredentialReconciler) reconcileNormal(
 ) (ctrl.Result, error) {
 	logger := r.GetLogger(ctx)
 
+	// the ApplicationCredential gets created again when it moved to another
+	// KeystoneAPI, and revoked in the keystone of the previous one
+	switched, err := switchKeystoneAPI(ctx, helperObj, instance, instance.Status.KeystoneAPI, keystoneAPI,
+		func(previousAPI *keystonev1.KeystoneAPI) error {
+			if instance.Status.ACID == "" {
+				return nil
+			}
+			return r.revokePreviousAC(ctx, instance, helperObj, previousAPI)
+		})
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if switched {
+		logger.Info(fmt.Sprintf("Moving the ApplicationCredential from KeystoneAPI %s to %s", instance.Status.KeystoneAPI, keystoneAPI.Name))
+		instance.Status.ACID = ""
+	}
+	instance.Status.KeystoneAPI = client.ObjectKeyFromObject(keystoneAPI).String()
+
 	// Decide if we need to create or rotate
 	doRotate, msg, err := needsRotation(instance)
 	if err != nil {
@@ -547,6 +555,40 @@ func (r *ApplicationCredentialReconciler) reconcileDelete(
 	return ctrl.Result{}, nil
 }
 
+// revokePreviousAC revokes the current AC in the keystone of the KeystoneAPI
+// the AC CR moved away from. Like on delete the revocation is best-effort.
+func (r *ApplicationCredentialReconciler) revokePreviousAC(
+	ctx context.Context,
+	instance *keystonev1.KeystoneApplicationCredential,
+	helperObj *helper.Helper,
+	previousAPI *keystonev1.KeystoneAPI,
+) error {
+	logger := r.GetLogger(ctx)
+
+	userOS, userRes, err := keystonev1.GetUserServiceClient(
+		ctx, helperObj, previousAPI,
+		instance.Spec.UserName,
+		instance.Spec.Secret,
+		instance.Spec.PasswordSelector,
+	)
+	if err != nil || userRes != (ctrl.Result{}) {
+		logger.Info("Could not build Keystone client, skipping revocation in the previous KeystoneAPI", "error", err)
+		return nil
+	}
+	userID, err := r.getUserIDFromToken(ctx, userOS.GetOSClient(), instance.Spec.UserName)
+	if err != nil {
+		logger.Info("Could not get user ID, skipping revocation in the previous KeystoneAPI", "error", err)
+		return nil
+	}
+	if err := revokeKeystoneAC(ctx, userOS.GetOSClient(), userID, instance.Status.ACID); err != nil {
+		logger.Info("Keystone revocation failed in the previous KeystoneAPI, continuing", "ACID", instance.Status.ACID, "error", err)
+		return nil
+	}
+	logger.Info("Revoked AC in the previous KeystoneAPI", "ACID", instance.Status.ACID, "KeystoneAPI", previousAPI.Name)
+
+	return nil
+}
+
 // revokeKeystoneAC deletes one application credential in Keystone
 // 404 is ignored (already revoked)
 func revokeKeystoneAC(
@@ -838,6 +880,14 @@ func needsRotation(ac *keystonev1.KeystoneApplicationCredential) (bool, string,
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *ApplicationCredentialReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// index keystoneAPIRefField
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &keystonev1.KeystoneApplicationCredential{}, keystoneAPIRefField, func(rawObj client.Object) []string {
+		cr := rawObj.(*keystonev1.KeystoneApplicationCredential)
+		return keystoneAPIRefIndexValue(cr.Spec.KeystoneAPIRef, cr.Namespace)
+	}); err != nil {
+		return err
+	}
+
 	b := ctrl.NewControllerManagedBy(mgr).
 		For(&keystonev1.KeystoneApplicationCredential{}).
 		// Suppress Create events on owned secrets to prevent a race condition:
@@ -847,7 +897,12 @@ func (r *ApplicationCredentialReconciler) SetupWithManager(mgr ctrl.Manager) err
 		// duplicate the AC+secret creation. Update and Delete events still trigger reconciles.
 		Owns(&corev1.Secret{}, builder.WithPredicates(predicate.Funcs{
 			CreateFunc: func(_ event.CreateEvent) bool { return false },
-		}))
+		})).
+		Watches(
+			&keystonev1.KeystoneAPI{},
+			handler.EnqueueRequestsFromMapFunc(r.findObjectsForKeystoneAPI),
+			builder.WithPredicates(keystoneAPIRefPredicate),
+		)
 
 	// Only register the NodeSet watch if the dataplane CRD is installed.
 	// Without this guard the informer sync times out and the AC controller
@@ -863,6 +918,12 @@ func (r *ApplicationCredentialReconciler) SetupWithManager(mgr ctrl.Manager) err
 	return b.Complete(r)
 }
 
+// findObjectsForKeystoneAPI - reconcile the KeystoneApplicationCredentials
+// referencing the changed KeystoneAPI
+func (r *ApplicationCredentialReconciler) findObjectsForKeystoneAPI(ctx context.Context, src client.Object) []reconcile.Request {
+	return findObjectsForKeystoneAPIRef(ctx, r.Client, &keystonev1.KeystoneApplicationCredentialList{}, src)
+}
+
 // nodesetToACMapFunc maps any NodeSet change to reconcile requests for all
 // KeystoneApplicationCredential CRs in the same namespace. This allows the
 // controller to re-evaluate cleanup eligibility when EDPM deploys complete.
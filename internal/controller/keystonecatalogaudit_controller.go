@@ -0,0 +1,367 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/endpoints"
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/services"
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	helper "github.com/openstack-k8s-operators/lib-common/modules/common/helper"
+	openstack "github.com/openstack-k8s-operators/lib-common/modules/openstack"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// GetClient -
+func (r *KeystoneCatalogAuditReconciler) GetClient() client.Client {
+	return r.Client
+}
+
+// GetKClient -
+func (r *KeystoneCatalogAuditReconciler) GetKClient() kubernetes.Interface {
+	return r.Kclient
+}
+
+// GetScheme -
+func (r *KeystoneCatalogAuditReconciler) GetScheme() *runtime.Scheme {
+	return r.Scheme
+}
+
+// KeystoneCatalogAuditReconciler reconciles a KeystoneCatalogAudit object
+type KeystoneCatalogAuditReconciler struct {
+	client.Client
+	Kclient kubernetes.Interface
+	Scheme  *runtime.Scheme
+}
+
+// GetLogger returns a logger object with a logging prefix of "controller.name" and additional controller context fields
+func (r *KeystoneCatalogAuditReconciler) GetLogger(ctx context.Context) logr.Logger {
+	return log.FromContext(ctx).WithName("Controllers").WithName("KeystoneCatalogAudit")
+}
+
+// +kubebuilder:rbac:groups=keystone.openstack.org,resources=keystonecatalogaudits,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=keystone.openstack.org,resources=keystonecatalogaudits/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=keystone.openstack.org,resources=keystonecatalogaudits/finalizers,verbs=update;patch
+// +kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneapis,verbs=get;list
+// +kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneservices,verbs=get;list
+// +kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneendpoints,verbs=get;list
+
+// Reconcile keystone catalog audit requests
+func (r *KeystoneCatalogAuditReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, _err error) {
+	log := r.GetLogger(ctx)
+
+	// Fetch the KeystoneCatalogAudit instance
+	instance := &keystonev1.KeystoneCatalogAudit{}
+	err := r.Get(ctx, req.NamespacedName, instance)
+	if err != nil {
+		if k8s_errors.IsNotFound(err) {
+			// Request object not found, could have been deleted after reconcile request.
+			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
+			// Return and don't requeue
+			return ctrl.Result{}, nil
+		}
+		// Error reading the object - requeue the request.
+		return ctrl.Result{}, err
+	}
+
+	helper, err := helper.NewHelper(
+		instance,
+		r.Client,
+		r.Kclient,
+		r.Scheme,
+		log,
+	)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// Always patch the instance status when exiting this function so we can persist any changes.
+	defer func() {
+		// Don't update the status, if Reconciler Panics
+		if r := recover(); r != nil {
+			log.Info(fmt.Sprintf("Panic during reconcile %v\n", r))
+			panic(r)
+		}
+		// update the Ready condition based on the sub conditions
+		if instance.Status.Conditions.AllSubConditionIsTrue() {
+			instance.Status.Conditions.MarkTrue(
+				condition.ReadyCondition, condition.ReadyMessage)
+		} else {
+			// something is not ready so reset the Ready condition
+			instance.Status.Conditions.MarkUnknown(
+				condition.ReadyCondition, condition.InitReason, condition.ReadyInitMessage)
+			// and recalculate it based on the state of the rest of the conditions
+			instance.Status.Conditions.Set(
+				instance.Status.Conditions.Mirror(condition.ReadyCondition))
+		}
+		err := helper.PatchInstance(ctx, instance)
+		if err != nil {
+			_err = err
+			return
+		}
+	}()
+
+	//
+	// initialize status
+	//
+	if instance.Status.Conditions == nil {
+		instance.Status.Conditions = condition.Conditions{}
+		cl := condition.CreateList(
+			condition.UnknownCondition(keystonev1.KeystoneAPIReadyCondition, condition.InitReason, keystonev1.KeystoneAPIReadyInitMessage),
+			condition.UnknownCondition(keystonev1.AdminServiceClientReadyCondition, condition.InitReason, keystonev1.AdminServiceClientReadyInitMessage),
+			condition.UnknownCondition(keystonev1.KeystoneCatalogAuditReadyCondition, condition.InitReason, keystonev1.KeystoneCatalogAuditReadyInitMessage))
+		instance.Status.Conditions.Init(&cl)
+
+		// Register overall status immediately to have an early feedback e.g. in the cli
+		return ctrl.Result{}, nil
+	}
+
+	// The audit creates nothing, there is nothing to clean up on delete
+	if !instance.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	// Don't audit before the interval passed, unless the spec changed
+	if instance.Generation == instance.Status.ObservedGeneration {
+		if next := nextCatalogAudit(instance); next > 0 {
+			return ctrl.Result{RequeueAfter: next}, nil
+		} else if next < 0 {
+			return ctrl.Result{}, nil
+		}
+	}
+
+	//
+	// Validate that keystoneAPI is up
+	//
+	keystoneAPI, err := keystonev1.GetKeystoneAPIWithRef(ctx, helper, instance.Spec.KeystoneAPIRef, instance)
+	if err != nil {
+		if k8s_errors.IsNotFound(err) {
+			instance.Status.Conditions.Set(keystoneAPINotFoundCondition(instance.Spec.KeystoneAPIRef, instance.Namespace))
+			log.Info("KeystoneAPI not found!")
+			return ctrl.Result{RequeueAfter: time.Second * 5}, nil
+		}
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneAPIReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.KeystoneAPIReadyErrorMessage,
+			err.Error()))
+		return ctrl.Result{}, err
+	}
+
+	if !keystoneAPI.IsReady() {
+		instance.Status.Conditions.Set(keystoneAPIWaitingCondition(instance.Spec.KeystoneAPIRef, instance.Namespace))
+		log.Info("KeystoneAPI not yet ready")
+		return ctrl.Result{RequeueAfter: time.Second * 5}, nil
+	}
+	instance.Status.Conditions.MarkTrue(keystonev1.KeystoneAPIReadyCondition, keystonev1.KeystoneAPIReadyMessage)
+
+	//
+	// get admin authentication OpenStack
+	//
+	os, ctrlResult, err := keystonev1.GetAdminServiceClient(
+		ctx,
+		helper,
+		keystoneAPI,
+	)
+	if err != nil {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.AdminServiceClientReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.AdminServiceClientReadyErrorMessage,
+			err.Error()))
+		return ctrl.Result{}, err
+	}
+	if (ctrlResult != ctrl.Result{}) {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.AdminServiceClientReadyCondition,
+			condition.RequestedReason,
+			condition.SeverityInfo,
+			keystonev1.AdminServiceClientReadyWaitingMessage))
+		return ctrlResult, nil
+	}
+	instance.Status.Conditions.MarkTrue(keystonev1.AdminServiceClientReadyCondition, keystonev1.AdminServiceClientReadyMessage)
+
+	return r.reconcileNormal(ctx, instance, helper, os)
+}
+
+// SetupWithManager -
+func (r *KeystoneCatalogAuditReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// status updates of the audit must not trigger another audit, the next
+	// one runs after the audit interval
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&keystonev1.KeystoneCatalogAudit{},
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Complete(r)
+}
+
+func (r *KeystoneCatalogAuditReconciler) reconcileNormal(
+	ctx context.Context,
+	instance *keystonev1.KeystoneCatalogAudit,
+	helper *helper.Helper,
+	os *openstack.OpenStack,
+) (ctrl.Result, error) {
+	log := r.GetLogger(ctx)
+	log.Info("Reconciling Catalog audit")
+
+	err := r.auditCatalog(ctx, instance, helper, os)
+	if err != nil {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneCatalogAuditReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.KeystoneCatalogAuditReadyErrorMessage,
+			err.Error()))
+		return ctrl.Result{}, err
+	}
+
+	if instance.Spec.Prune {
+		instance.Status.Conditions.MarkTrue(
+			keystonev1.KeystoneCatalogAuditReadyCondition,
+			keystonev1.KeystoneCatalogAuditReadyPrunedMessage,
+			len(instance.Status.PrunedServices),
+			len(instance.Status.PrunedEndpoints))
+	} else {
+		instance.Status.Conditions.MarkTrue(
+			keystonev1.KeystoneCatalogAuditReadyCondition,
+			keystonev1.KeystoneCatalogAuditReadyMessage,
+			len(instance.Status.OrphanedServices),
+			len(instance.Status.OrphanedEndpoints))
+	}
+	instance.Status.LastAuditTime = &metav1.Time{Time: time.Now().UTC()}
+	instance.Status.ObservedGeneration = instance.Generation
+
+	log.Info("Reconciled Catalog audit successfully")
+	return catalogResyncResult(instance.Spec.AuditInterval), nil
+}
+
+// auditCatalog - lists the services and endpoints of the catalog and compares
+// them against the KeystoneService and KeystoneEndpoint CRs of all namespaces.
+// With prune set, the orphans get deleted.
+func (r *KeystoneCatalogAuditReconciler) auditCatalog(
+	ctx context.Context,
+	instance *keystonev1.KeystoneCatalogAudit,
+	helper *helper.Helper,
+	os *openstack.OpenStack,
+) error {
+	log := r.GetLogger(ctx)
+
+	allPages, err := services.List(os.GetOSClient(), services.ListOpts{}).AllPages(ctx)
+	if err != nil {
+		return err
+	}
+	catalogServices, err := services.ExtractServices(allPages)
+	if err != nil {
+		return err
+	}
+
+	allPages, err = endpoints.List(os.GetOSClient(), endpoints.ListOpts{}).AllPages(ctx)
+	if err != nil {
+		return err
+	}
+	catalogEndpoints, err := endpoints.ExtractEndpoints(allPages)
+	if err != nil {
+		return err
+	}
+
+	// KeystoneServices and KeystoneEndpoints of other namespaces can be
+	// registered in this keystone with their keystoneAPIRef
+	ksServices := &keystonev1.KeystoneServiceList{}
+	if err := r.List(ctx, ksServices); err != nil {
+		return err
+	}
+	ksEndpoints, err := keystonev1.GetKeystoneEndpointList(ctx, helper, "")
+	if err != nil {
+		return err
+	}
+
+	orphanedServices, orphanedEndpoints := findCatalogOrphans(
+		catalogServices,
+		catalogEndpoints,
+		ksServices.Items,
+		ksEndpoints.Items,
+		os.GetRegion(),
+	)
+	instance.Status.OrphanedServices = orphanedServices
+	instance.Status.OrphanedEndpoints = orphanedEndpoints
+	instance.Status.PrunedServices = nil
+	instance.Status.PrunedEndpoints = nil
+	log.Info(fmt.Sprintf("Catalog audit found %d orphaned services and %d orphaned endpoints",
+		len(orphanedServices), len(orphanedEndpoints)))
+
+	if !instance.Spec.Prune {
+		return nil
+	}
+
+	// delete the endpoints first, the orphaned services might still
+	// reference them
+	for len(instance.Status.OrphanedEndpoints) > 0 {
+		endpoint := instance.Status.OrphanedEndpoints[0]
+		err := endpoints.Delete(ctx, os.GetOSClient(), endpoint.ID).ExtractErr()
+		if err != nil && !gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+			return err
+		}
+		log.Info(fmt.Sprintf("Pruned orphaned %s endpoint %s - %s", endpoint.Interface, endpoint.URL, endpoint.ID))
+		instance.Status.OrphanedEndpoints = instance.Status.OrphanedEndpoints[1:]
+		instance.Status.PrunedEndpoints = append(instance.Status.PrunedEndpoints, endpoint)
+	}
+	for len(instance.Status.OrphanedServices) > 0 {
+		service := instance.Status.OrphanedServices[0]
+		err := services.Delete(ctx, os.GetOSClient(), service.ID).ExtractErr()
+		if err != nil && !gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+			return err
+		}
+		log.Info(fmt.Sprintf("Pruned orphaned %s service %s - %s", service.Type, service.Name, service.ID))
+		instance.Status.OrphanedServices = instance.Status.OrphanedServices[1:]
+		instance.Status.PrunedServices = append(instance.Status.PrunedServices, service)
+	}
+
+	return nil
+}
+
+// nextCatalogAudit - returns the time until the next audit of the catalog is
+// due, 0 if it is due and a negative duration if the catalog only gets audited
+// on spec changes
+func nextCatalogAudit(instance *keystonev1.KeystoneCatalogAudit) time.Duration {
+	if instance.Status.LastAuditTime == nil {
+		return 0
+	}
+	interval := catalogResyncResult(instance.Spec.AuditInterval).RequeueAfter
+	if interval == 0 {
+		return -1
+	}
+	next := time.Until(instance.Status.LastAuditTime.Add(interval))
+	if next < 0 {
+		return 0
+	}
+	return next
+}
@@ -0,0 +1,476 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/domains"
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	helper "github.com/openstack-k8s-operators/lib-common/modules/common/helper"
+	secret "github.com/openstack-k8s-operators/lib-common/modules/common/secret"
+
+	openstack "github.com/openstack-k8s-operators/lib-common/modules/openstack"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// GetClient -
+func (r *KeystoneDomainReconciler) GetClient() client.Client {
+	return r.Client
+}
+
+// GetKClient -
+func (r *KeystoneDomainReconciler) GetKClient() kubernetes.Interface {
+	return r.Kclient
+}
+
+// GetScheme -
+func (r *KeystoneDomainReconciler) GetScheme() *runtime.Scheme {
+	return r.Scheme
+}
+
+// KeystoneDomainReconciler reconciles a KeystoneDomain object
+type KeystoneDomainReconciler struct {
+	client.Client
+	Kclient kubernetes.Interface
+	Scheme  *runtime.Scheme
+}
+
+// GetLogger returns a logger object with a logging prefix of "controller.name" and additional controller context fields
+func (r *KeystoneDomainReconciler) GetLogger(ctx context.Context) logr.Logger {
+	return log.FromContext(ctx).WithName("Controllers").WithName("KeystoneDomain")
+}
+
+// +kubebuilder:rbac:groups=keystone.openstack.org,resources=keystonedomains,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=keystone.openstack.org,resources=keystonedomains/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=keystone.openstack.org,resources=keystonedomains/finalizers,verbs=update;patch
+// +kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneapis,verbs=get;list;update;patch
+// +kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneapis/finalizers,verbs=update;patch
+
+// Reconcile keystone domain requests
+func (r *KeystoneDomainReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, _err error) {
+	log := r.GetLogger(ctx)
+
+	// Fetch the KeystoneDomain instance
+	instance := &keystonev1.KeystoneDomain{}
+	err := r.Get(ctx, req.NamespacedName, instance)
+	if err != nil {
+		if k8s_errors.IsNotFound(err) {
+			// Request object not found, could have been deleted after reconcile request.
+			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
+			// Return and don't requeue
+			return ctrl.Result{}, nil
+		}
+		// Error reading the object - requeue the request.
+		return ctrl.Result{}, err
+	}
+
+	helper, err := helper.NewHelper(
+		instance,
+		r.Client,
+		r.Kclient,
+		r.Scheme,
+		log,
+	)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// Always patch the instance status when exiting this function so we can persist any changes.
+	defer func() {
+		// Don't update the status, if Reconciler Panics
+		if r := recover(); r != nil {
+			log.Info(fmt.Sprintf("Panic during reconcile %v\n", r))
+			panic(r)
+		}
+		// update the Ready condition based on the sub conditions
+		if instance.Status.Conditions.AllSubConditionIsTrue() {
+			instance.Status.Conditions.MarkTrue(
+				condition.ReadyCondition, condition.ReadyMessage)
+		} else {
+			// something is not ready so reset the Ready condition
+			instance.Status.Conditions.MarkUnknown(
+				condition.ReadyCondition, condition.InitReason, condition.ReadyInitMessage)
+			// and recalculate it based on the state of the rest of the conditions
+			instance.Status.Conditions.Set(
+				instance.Status.Conditions.Mirror(condition.ReadyCondition))
+		}
+		err := helper.PatchInstance(ctx, instance)
+		if err != nil {
+			_err = err
+			return
+		}
+	}()
+
+	//
+	// initialize status
+	//
+	if instance.Status.Conditions == nil {
+		instance.Status.Conditions = condition.Conditions{}
+		cl := condition.CreateList(
+			condition.UnknownCondition(condition.InputReadyCondition, condition.InitReason, condition.InputReadyInitMessage),
+			condition.UnknownCondition(keystonev1.KeystoneAPIReadyCondition, condition.InitReason, keystonev1.KeystoneAPIReadyInitMessage),
+			condition.UnknownCondition(keystonev1.AdminServiceClientReadyCondition, condition.InitReason, keystonev1.AdminServiceClientReadyInitMessage),
+			condition.UnknownCondition(keystonev1.KeystoneDomainOSDomainReadyCondition, condition.InitReason, keystonev1.KeystoneDomainOSDomainReadyInitMessage))
+		instance.Status.Conditions.Init(&cl)
+
+		// Register overall status immediately to have an early feedback e.g. in the cli
+		return ctrl.Result{}, nil
+	}
+	instance.Status.ObservedGeneration = instance.Generation
+
+	// If we're not deleting this and the domain object doesn't have our finalizer, add it.
+	if instance.DeletionTimestamp.IsZero() && controllerutil.AddFinalizer(instance, helper.GetFinalizer()) {
+		return ctrl.Result{}, err
+	}
+
+	//
+	// Validate that keystoneAPI is up
+	//
+	keystoneAPI, err := keystonev1.GetKeystoneAPIForObject(ctx, helper, instance)
+	if err != nil {
+		if k8s_errors.IsNotFound(err) {
+			// There is nothing to clean up on the OpenStack side if the domain
+			// never got registered, don't wait for a KeystoneAPI to appear.
+			if !instance.DeletionTimestamp.IsZero() && instance.Status.DomainID == "" {
+				return r.reconcileDelete(ctx, instance, helper, nil, nil)
+			}
+
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				keystonev1.KeystoneAPIReadyCondition,
+				condition.ErrorReason,
+				condition.SeverityWarning,
+				keystonev1.KeystoneAPIReadyNotFoundMessage,
+			))
+			log.Info("KeystoneAPI not found!")
+			return ctrl.Result{RequeueAfter: time.Second * 5}, nil
+		}
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneAPIReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.KeystoneAPIReadyErrorMessage,
+			err.Error()))
+		return ctrl.Result{}, err
+	}
+
+	// If both the domain and the KeystoneAPI are deleted we can skip the
+	// cleanup on the OpenStack side, the DB is going away as well.
+	if !instance.DeletionTimestamp.IsZero() && !keystoneAPI.DeletionTimestamp.IsZero() {
+		return r.reconcileDeleteFinalizersOnly(ctx, instance, helper, keystoneAPI)
+	}
+
+	if !instance.DeletionTimestamp.IsZero() && instance.Status.DomainID == "" {
+		return r.reconcileDelete(ctx, instance, helper, nil, keystoneAPI)
+	}
+
+	if !keystoneAPI.IsReady() {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneAPIReadyCondition,
+			condition.RequestedReason,
+			condition.SeverityInfo,
+			keystonev1.KeystoneAPIReadyWaitingMessage))
+		log.Info("KeystoneAPI not yet ready")
+		return ctrl.Result{RequeueAfter: time.Second * 5}, nil
+	}
+	instance.Status.Conditions.MarkTrue(keystonev1.KeystoneAPIReadyCondition, keystonev1.KeystoneAPIReadyMessage)
+
+	//
+	// get admin authentication OpenStack
+	//
+	os, ctrlResult, err := keystonev1.GetAdminServiceClient(
+		ctx,
+		helper,
+		keystoneAPI,
+	)
+	if err != nil {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.AdminServiceClientReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.AdminServiceClientReadyErrorMessage,
+			err.Error()))
+		return ctrl.Result{}, err
+	}
+	if (ctrlResult != ctrl.Result{}) {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.AdminServiceClientReadyCondition,
+			condition.RequestedReason,
+			condition.SeverityInfo,
+			keystonev1.AdminServiceClientReadyWaitingMessage))
+		return ctrlResult, nil
+	}
+	instance.Status.Conditions.MarkTrue(keystonev1.AdminServiceClientReadyCondition, keystonev1.AdminServiceClientReadyMessage)
+
+	// Handle normal domain delete
+	if !instance.DeletionTimestamp.IsZero() {
+		return r.reconcileDelete(ctx, instance, helper, os, keystoneAPI)
+	}
+
+	// Handle non-deleted domains
+	return r.reconcileNormal(ctx, instance, helper, os, keystoneAPI)
+}
+
+// SetupWithManager -
+func (r *KeystoneDomainReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&keystonev1.KeystoneDomain{}).
+		Complete(r)
+}
+
+func (r *KeystoneDomainReconciler) reconcileDelete(
+	ctx context.Context,
+	instance *keystonev1.KeystoneDomain,
+	helper *helper.Helper,
+	os *openstack.OpenStack,
+	keystoneAPI *keystonev1.KeystoneAPI,
+) (ctrl.Result, error) {
+	log := r.GetLogger(ctx)
+	log.Info("Reconciling Domain delete")
+
+	// only cleanup the domain if there is the DomainID reference in the
+	// object status and if we have an OpenStack backend to use
+	if instance.Status.DomainID != "" && os != nil {
+		if instance.Status.Adopted {
+			log.Info("Not deleting adopted domain", "KeystoneDomain", instance.Spec.DomainName)
+		} else {
+			// keystone refuses to delete an enabled domain
+			enabled := false
+			_, err := domains.Update(ctx, os.GetOSClient(), instance.Status.DomainID, domains.UpdateOpts{
+				Enabled: &enabled,
+			}).Extract()
+			if err != nil && !gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+				return ctrl.Result{}, err
+			}
+
+			err = domains.Delete(ctx, os.GetOSClient(), instance.Status.DomainID).ExtractErr()
+			if err != nil && !gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+				return ctrl.Result{}, err
+			}
+			log.Info("Deleted domain", "KeystoneDomain", instance.Spec.DomainName)
+		}
+
+		// Clear the domain ID so that any potential requeues after this reconcile
+		// will know that there is nothing left on the OpenStack side
+		instance.Status.DomainID = ""
+	} else {
+		log.Info("Not deleting domain as there is no stored domain ID", "KeystoneDomain", instance.Spec.DomainName)
+	}
+
+	// There are certain deletion scenarios where we might not have the keystoneAPI
+	if keystoneAPI != nil {
+		// Remove the finalizer for this domain from the KeystoneAPI
+		if controllerutil.RemoveFinalizer(keystoneAPI, fmt.Sprintf("%s-%s", helper.GetFinalizer(), instance.Name)) {
+			err := r.Update(ctx, keystoneAPI)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+	}
+
+	// Domain is deleted so remove the finalizer.
+	controllerutil.RemoveFinalizer(instance, helper.GetFinalizer())
+	log.Info("Reconciled Domain delete successfully")
+
+	return ctrl.Result{}, nil
+}
+
+func (r *KeystoneDomainReconciler) reconcileDeleteFinalizersOnly(
+	ctx context.Context,
+	instance *keystonev1.KeystoneDomain,
+	helper *helper.Helper,
+	keystoneAPI *keystonev1.KeystoneAPI,
+) (ctrl.Result, error) {
+	log := r.GetLogger(ctx)
+	log.Info("Reconciling Domain delete while KeystoneAPI is being deleted")
+
+	if controllerutil.RemoveFinalizer(keystoneAPI, fmt.Sprintf("%s-%s", helper.GetFinalizer(), instance.Name)) {
+		err := r.Update(ctx, keystoneAPI)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	controllerutil.RemoveFinalizer(instance, helper.GetFinalizer())
+	log.Info("Reconciled Domain delete successfully")
+
+	return ctrl.Result{}, nil
+}
+
+func (r *KeystoneDomainReconciler) reconcileNormal(
+	ctx context.Context,
+	instance *keystonev1.KeystoneDomain,
+	helper *helper.Helper,
+	os *openstack.OpenStack,
+	keystoneAPI *keystonev1.KeystoneAPI,
+) (ctrl.Result, error) {
+	log := r.GetLogger(ctx)
+	log.Info("Reconciling Domain")
+
+	//
+	// Add a finalizer to the KeystoneAPI for this domain instance, so that the
+	// domain can be removed from keystone before the KeystoneAPI goes away
+	//
+	if controllerutil.AddFinalizer(keystoneAPI, fmt.Sprintf("%s-%s", helper.GetFinalizer(), instance.Name)) {
+		err := r.Update(ctx, keystoneAPI)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	//
+	// check the LDAP bind password secret, the domain specific config itself
+	// gets rendered by the KeystoneAPI controller
+	//
+	if instance.Spec.LDAP != nil && instance.Spec.LDAP.Secret != "" {
+		_, ctrlResult, err := secret.GetDataFromSecret(
+			ctx,
+			helper,
+			instance.Spec.LDAP.Secret,
+			10*time.Second,
+			instance.Spec.LDAP.PasswordSelector)
+		if err != nil {
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				condition.InputReadyCondition,
+				condition.ErrorReason,
+				condition.SeverityWarning,
+				condition.InputReadyErrorMessage,
+				err.Error()))
+			return ctrl.Result{}, err
+		}
+		if (ctrlResult != ctrl.Result{}) {
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				condition.InputReadyCondition,
+				condition.RequestedReason,
+				condition.SeverityInfo,
+				condition.InputReadyWaitingMessage))
+			return ctrlResult, nil
+		}
+	}
+	instance.Status.Conditions.MarkTrue(condition.InputReadyCondition, condition.InputReadyMessage)
+
+	//
+	// create or update the domain
+	//
+	err := r.reconcileDomain(ctx, instance, os)
+	if err != nil {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneDomainOSDomainReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.KeystoneDomainOSDomainReadyErrorMessage,
+			err.Error()))
+		return ctrl.Result{}, err
+	}
+	instance.Status.Conditions.MarkTrue(
+		keystonev1.KeystoneDomainOSDomainReadyCondition,
+		keystonev1.KeystoneDomainOSDomainReadyMessage,
+		instance.Spec.DomainName,
+		instance.Status.DomainID,
+	)
+
+	log.Info("Reconciled Domain successfully")
+	return ctrl.Result{}, nil
+}
+
+func (r *KeystoneDomainReconciler) reconcileDomain(
+	ctx context.Context,
+	instance *keystonev1.KeystoneDomain,
+	os *openstack.OpenStack,
+) error {
+	log := r.GetLogger(ctx)
+	log.Info("Reconciling Domain", "KeystoneDomain", instance.Spec.DomainName)
+
+	var domain *domains.Domain
+	var err error
+	if instance.Status.DomainID != "" {
+		domain, err = domains.Get(ctx, os.GetOSClient(), instance.Status.DomainID).Extract()
+		if err != nil && !gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+			return err
+		}
+	}
+
+	if domain == nil {
+		domainID, err := getDomainID(ctx, os, instance.Spec.DomainName)
+		switch {
+		case err == nil:
+			// the domain was not created by this KeystoneDomain, only take it
+			// over if requested
+			if !instance.Spec.Adopt {
+				return fmt.Errorf("domain %s %w", instance.Spec.DomainName, ErrNotAdopted)
+			}
+			log.Info("Adopting existing domain", "KeystoneDomain", instance.Spec.DomainName, "DomainID", domainID)
+			instance.Status.Adopted = true
+		case errors.Is(err, ErrDomainNotFound):
+			domainID, err = os.CreateDomain(
+				ctx,
+				log,
+				openstack.Domain{
+					Name:        instance.Spec.DomainName,
+					Description: instance.Spec.Description,
+				})
+			if err != nil {
+				return err
+			}
+			err = persistCreatedID(ctx, r.Client, instance, func(created *keystonev1.KeystoneDomain) {
+				created.Status.DomainID = domainID
+				created.Status.Adopted = false
+			})
+			if err != nil {
+				return err
+			}
+			instance.Status.Adopted = false
+		default:
+			return err
+		}
+
+		domain, err = domains.Get(ctx, os.GetOSClient(), domainID).Extract()
+		if err != nil {
+			return err
+		}
+	}
+	instance.Status.DomainID = domain.ID
+
+	if domain.Name != instance.Spec.DomainName ||
+		domain.Enabled != instance.Spec.Enabled ||
+		domain.Description != instance.Spec.Description {
+		// update the domain ONLY if Name, Enabled or Description changed.
+		_, err = domains.Update(ctx, os.GetOSClient(), domain.ID, domains.UpdateOpts{
+			Name:        instance.Spec.DomainName,
+			Description: &instance.Spec.Description,
+			Enabled:     &instance.Spec.Enabled,
+		}).Extract()
+		if err != nil {
+			return err
+		}
+	}
+
+	log.Info("Reconciled Domain successfully")
+	return nil
+}
@@ -20,17 +20,26 @@ import (
 	"context"
 	"fmt"
 	"maps"
+	"net/http"
 	"slices"
+	"strings"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	"github.com/go-logr/logr"
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/endpoints"
 	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
 	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
 	helper "github.com/openstack-k8s-operators/lib-common/modules/common/helper"
@@ -42,8 +51,9 @@ import (
 // KeystoneEndpointReconciler reconciles a KeystoneEndpoint object
 type KeystoneEndpointReconciler struct {
 	client.Client
-	Kclient kubernetes.Interface
-	Scheme  *runtime.Scheme
+	Kclient       kubernetes.Interface
+	Scheme        *runtime.Scheme
+	EventRecorder record.EventRecorder
 }
 
 // GetLogger returns a logger object with a logging prefix of "controller.name" and additional controller context fields
@@ -58,6 +68,7 @@ func (r *KeystoneEndpointReconciler) GetLogger(ctx context.Context) logr.Logger
 //+kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneapis/finalizers,verbs=update;patch
 //+kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneservices,verbs=get;list;update;patch
 //+kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneservices/finalizers,verbs=update;patch
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 // Reconcile keystone endpoint requests
 func (r *KeystoneEndpointReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, _err error) {
@@ -124,6 +135,7 @@ func (r *KeystoneEndpointReconciler) Reconcile(ctx context.Context, req ctrl.Req
 			condition.UnknownCondition(keystonev1.KeystoneAPIReadyCondition, condition.InitReason, keystonev1.KeystoneAPIReadyInitMessage),
 			condition.UnknownCondition(keystonev1.AdminServiceClientReadyCondition, condition.InitReason, keystonev1.AdminServiceClientReadyInitMessage),
 			condition.UnknownCondition(keystonev1.KeystoneServiceOSEndpointsReadyCondition, condition.InitReason, keystonev1.KeystoneServiceOSEndpointsReadyInitMessage),
+			condition.UnknownCondition(keystonev1.KeystoneCatalogInSyncCondition, condition.InitReason, keystonev1.KeystoneCatalogInSyncInitMessage),
 			// right now we have no dedicated KeystoneServiceReadyInitMessage
 			condition.UnknownCondition(condition.KeystoneServiceReadyCondition, condition.InitReason, ""),
 		)
@@ -150,7 +162,7 @@ func (r *KeystoneEndpointReconciler) Reconcile(ctx context.Context, req ctrl.Req
 	//
 	// Validate that keystoneAPI is up
 	//
-	keystoneAPI, err := keystonev1.GetKeystoneAPI(ctx, helper, instance.Namespace, map[string]string{})
+	keystoneAPI, err := keystonev1.GetKeystoneAPIWithRef(ctx, helper, instance.Spec.KeystoneAPIRef, instance)
 	if err != nil {
 		if k8s_errors.IsNotFound(err) {
 			// If this KeystoneEndpoint CR is being deleted and it has not registered any actual
@@ -161,12 +173,7 @@ func (r *KeystoneEndpointReconciler) Reconcile(ctx context.Context, req ctrl.Req
 				return r.reconcileDelete(ctx, instance, helper, nil, nil)
 			}
 
-			instance.Status.Conditions.Set(condition.FalseCondition(
-				keystonev1.KeystoneAPIReadyCondition,
-				condition.ErrorReason,
-				condition.SeverityWarning,
-				keystonev1.KeystoneAPIReadyNotFoundMessage,
-			))
+			instance.Status.Conditions.Set(keystoneAPINotFoundCondition(instance.Spec.KeystoneAPIRef, instance.Namespace))
 			Log.Info("KeystoneAPI not found!")
 
 			return ctrl.Result{RequeueAfter: time.Second * 5}, nil
@@ -199,11 +206,7 @@ func (r *KeystoneEndpointReconciler) Reconcile(ctx context.Context, req ctrl.Req
 	}
 
 	if !keystoneAPI.IsReady() {
-		instance.Status.Conditions.Set(condition.FalseCondition(
-			keystonev1.KeystoneAPIReadyCondition,
-			condition.RequestedReason,
-			condition.SeverityInfo,
-			keystonev1.KeystoneAPIReadyWaitingMessage))
+		instance.Status.Conditions.Set(keystoneAPIWaitingCondition(instance.Spec.KeystoneAPIRef, instance.Namespace))
 		Log.Info("KeystoneAPI not yet ready!")
 
 		return ctrl.Result{RequeueAfter: time.Second * 5}, nil
@@ -248,11 +251,30 @@ func (r *KeystoneEndpointReconciler) Reconcile(ctx context.Context, req ctrl.Req
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *KeystoneEndpointReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// index keystoneAPIRefField
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &keystonev1.KeystoneEndpoint{}, keystoneAPIRefField, func(rawObj client.Object) []string {
+		cr := rawObj.(*keystonev1.KeystoneEndpoint)
+		return keystoneAPIRefIndexValue(cr.Spec.KeystoneAPIRef, cr.Namespace)
+	}); err != nil {
+		return err
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&keystonev1.KeystoneEndpoint{}).
+		Watches(
+			&keystonev1.KeystoneAPI{},
+			handler.EnqueueRequestsFromMapFunc(r.findObjectsForKeystoneAPI),
+			builder.WithPredicates(keystoneAPIRefPredicate),
+		).
 		Complete(r)
 }
 
+// findObjectsForKeystoneAPI - reconcile the KeystoneEndpoints referencing the
+// changed KeystoneAPI
+func (r *KeystoneEndpointReconciler) findObjectsForKeystoneAPI(ctx context.Context, src client.Object) []reconcile.Request {
+	return findObjectsForKeystoneAPIRef(ctx, r.Client, &keystonev1.KeystoneEndpointList{}, src)
+}
+
 func (r *KeystoneEndpointReconciler) reconcileDelete(
 	ctx context.Context,
 	instance *keystonev1.KeystoneEndpoint,
@@ -266,27 +288,9 @@ func (r *KeystoneEndpointReconciler) reconcileDelete(
 
 	// We might not have an OpenStack backend to use in certain situations
 	if os != nil {
-		// Delete Endpoints -  it is ok to call delete on non existing Endpoints
-		// therefore always call delete for the spec.
-		for _, endpointType := range slices.Sorted(maps.Keys(instance.Spec.Endpoints)) {
-			// get the gopher availability mapping for the endpointInterface
-			availability, err := openstack.GetAvailability(endpointType)
-			if err != nil {
-				return ctrl.Result{}, err
-			}
-
-			err = os.DeleteEndpoint(
-				ctx,
-				Log,
-				openstack.Endpoint{
-					Name:         instance.Spec.ServiceName,
-					ServiceID:    instance.Status.ServiceID,
-					Availability: availability,
-				},
-			)
-			if err != nil {
-				return ctrl.Result{}, err
-			}
+		err := r.deleteEndpoints(ctx, instance, os)
+		if err != nil {
+			return ctrl.Result{}, err
 		}
 	}
 
@@ -310,7 +314,7 @@ func (r *KeystoneEndpointReconciler) reconcileDelete(
 	// There are certain deletion scenarios where we might not have the keystoneAPI
 	if keystoneAPI != nil {
 		// Remove the finalizer for this endpoint from the KeystoneAPI
-		if controllerutil.RemoveFinalizer(keystoneAPI, fmt.Sprintf("%s-%s", helper.GetFinalizer(), instance.Name)) {
+		if controllerutil.RemoveFinalizer(keystoneAPI, keystoneAPIFinalizer(helper, instance, keystoneAPI)) {
 			err := r.Update(ctx, keystoneAPI)
 
 			if err != nil {
@@ -326,6 +330,44 @@ func (r *KeystoneEndpointReconciler) reconcileDelete(
 	return ctrl.Result{}, nil
 }
 
+// deleteEndpoints - deletes the endpoints of the instance from keystone. It is
+// ok to call delete on non existing Endpoints therefore always call delete for
+// the spec, and for the status in case the region changed.
+func (r *KeystoneEndpointReconciler) deleteEndpoints(
+	ctx context.Context,
+	instance *keystonev1.KeystoneEndpoint,
+	os *openstack.OpenStack,
+) error {
+	Log := r.GetLogger(ctx)
+
+	regions := map[string]map[string]bool{}
+	for endpointType, entry := range instance.Spec.GetEndpointEntries() {
+		regions[endpointType] = map[string]bool{entry.GetRegion(os.GetRegion()): true}
+	}
+	for _, endpoint := range instance.Status.Endpoints {
+		if regions[endpoint.Interface] != nil && endpoint.Region != "" {
+			regions[endpoint.Interface][endpoint.Region] = true
+		}
+	}
+	for _, endpointType := range slices.Sorted(maps.Keys(regions)) {
+		// get the gopher availability mapping for the endpointInterface
+		availability, err := openstack.GetAvailability(endpointType)
+		if err != nil {
+			return err
+		}
+
+		for _, region := range slices.Sorted(maps.Keys(regions[endpointType])) {
+			err = deleteRegionEndpoints(ctx, os.GetOSClient(), instance.Status.ServiceID, availability, region)
+			if err != nil {
+				return err
+			}
+			Log.Info(fmt.Sprintf("Deleted %s endpoints of %s in region %s", endpointType, instance.Spec.ServiceName, region))
+		}
+	}
+
+	return nil
+}
+
 func (r *KeystoneEndpointReconciler) reconcileDeleteFinalizersOnly(
 	ctx context.Context,
 	instance *keystonev1.KeystoneEndpoint,
@@ -349,7 +391,7 @@ func (r *KeystoneEndpointReconciler) reconcileDeleteFinalizersOnly(
 		return ctrl.Result{}, err
 	}
 
-	if controllerutil.RemoveFinalizer(keystoneAPI, fmt.Sprintf("%s-%s", helper.GetFinalizer(), instance.Name)) {
+	if controllerutil.RemoveFinalizer(keystoneAPI, keystoneAPIFinalizer(helper, instance, keystoneAPI)) {
 		err := r.Update(ctx, keystoneAPI)
 
 		if err != nil {
@@ -373,6 +415,32 @@ func (r *KeystoneEndpointReconciler) reconcileNormal(
 	Log := r.GetLogger(ctx)
 	Log.Info("Reconciling Endpoint normal")
 
+	// the endpoints get registered again when they moved to another
+	// KeystoneAPI, and deleted from the keystone of the previous one
+	switched, err := switchKeystoneAPI(ctx, helper, instance, instance.Status.KeystoneAPI, keystoneAPI,
+		func(previousAPI *keystonev1.KeystoneAPI) error {
+			if instance.Status.ServiceID == "" {
+				return nil
+			}
+			previousOS, _, err := keystonev1.GetAdminServiceClient(ctx, helper, previousAPI)
+			if err != nil {
+				return err
+			}
+			return r.deleteEndpoints(ctx, instance, previousOS)
+		})
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if switched {
+		Log.Info(fmt.Sprintf("Moving the endpoints from KeystoneAPI %s to %s", instance.Status.KeystoneAPI, keystoneAPI.Name))
+		instance.Status.EndpointIDs = map[string]string{}
+		instance.Status.Endpoints = nil
+		instance.Status.AdoptedEndpointIDs = nil
+		instance.Status.RemovedDuplicates = nil
+		instance.Status.RemovedDuplicatesCount = 0
+	}
+	instance.Status.KeystoneAPI = client.ObjectKeyFromObject(keystoneAPI).String()
+
 	//
 	// Wait for KeystoneService is Ready and get the ServiceID from the object
 	//
@@ -398,6 +466,34 @@ func (r *KeystoneEndpointReconciler) reconcileNormal(
 		return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
 	}
 
+	// the service ID is only valid in the keystone the service got registered
+	// in, which differs while one of them moves to another KeystoneAPI
+	if ksSvc.Status.KeystoneAPI != instance.Status.KeystoneAPI {
+		Log.Info("KeystoneService registered in another KeystoneAPI, waiting to create endpoints",
+			"KeystoneService", instance.Spec.ServiceName, "KeystoneAPI", ksSvc.Status.KeystoneAPI)
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneServiceOSEndpointsReadyCondition,
+			condition.RequestedReason,
+			condition.SeverityInfo,
+			keystonev1.KeystoneServiceOSEndpointsReadyKeystoneAPIMessage,
+			ksSvc.Name,
+			ksSvc.Status.KeystoneAPI,
+			instance.Status.KeystoneAPI))
+		return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+	}
+
+	// the endpoints to adopt belong to the service to adopt
+	if instance.Spec.AdoptServiceID != "" && ksSvc.Status.ServiceID != instance.Spec.AdoptServiceID {
+		err := fmt.Errorf("%w: KeystoneService %s registered service %s, not %s",
+			ErrAdoptServiceMismatch, ksSvc.Name, ksSvc.Status.ServiceID, instance.Spec.AdoptServiceID)
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneServiceOSEndpointsReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.KeystoneServiceOSEndpointsReadyErrorMessage,
+			err.Error()))
+		return ctrl.Result{}, err
+	}
 	instance.Status.ServiceID = ksSvc.Status.ServiceID
 
 	//
@@ -406,7 +502,7 @@ func (r *KeystoneEndpointReconciler) reconcileNormal(
 	// (so that we can properly remove the endpoint from the Keystone database on the OpenStack
 	// side)
 	//
-	if controllerutil.AddFinalizer(keystoneAPI, fmt.Sprintf("%s-%s", helper.GetFinalizer(), instance.Name)) {
+	if controllerutil.AddFinalizer(keystoneAPI, keystoneAPIFinalizer(helper, instance, keystoneAPI)) {
 		err := r.Update(ctx, keystoneAPI)
 
 		if err != nil {
@@ -429,7 +525,7 @@ func (r *KeystoneEndpointReconciler) reconcileNormal(
 	//
 	// create/update endpoints
 	//
-	err = r.reconcileEndpoints(
+	drift, err := r.reconcileEndpoints(
 		ctx,
 		instance,
 		os)
@@ -443,56 +539,64 @@ func (r *KeystoneEndpointReconciler) reconcileNormal(
 		return ctrl.Result{}, err
 	}
 	// Build sorted endpoint list for deterministic condition message
-	endpointStrs := make([]string, 0, len(instance.Spec.Endpoints))
-	for _, k := range slices.Sorted(maps.Keys(instance.Spec.Endpoints)) {
-		endpointStrs = append(endpointStrs, k+":"+instance.Spec.Endpoints[k])
+	entries := instance.Spec.GetEndpointEntries()
+	endpointStrs := make([]string, 0, len(entries))
+	for _, k := range slices.Sorted(maps.Keys(entries)) {
+		endpointStrs = append(endpointStrs, k+":"+entries[k].URL)
 	}
 	instance.Status.Conditions.MarkTrue(
 		keystonev1.KeystoneServiceOSEndpointsReadyCondition,
 		keystonev1.KeystoneServiceOSEndpointsReadyMessage,
 		endpointStrs,
 	)
+	setCatalogInSyncCondition(&instance.Status.Conditions, instance.Spec.DriftPolicy, drift)
 
 	Log.Info("Reconciled Endpoint normal successfully")
 
-	return ctrl.Result{}, nil
+	// compare the registered endpoints against the spec again after the
+	// resync interval, they might get changed or deleted outside of the
+	// operator
+	return catalogResyncResult(instance.Spec.ResyncInterval), nil
 }
 
+// reconcileEndpoints - creates, updates and deletes the endpoints of the spec.
+// Endpoints registered before get compared against the spec, changes which
+// were not made in the spec are returned as drift and only get corrected with
+// the Correct drift policy.
 func (r *KeystoneEndpointReconciler) reconcileEndpoints(
 	ctx context.Context,
 	instance *keystonev1.KeystoneEndpoint,
 	os *openstack.OpenStack,
-) error {
+) ([]string, error) {
 	Log := r.GetLogger(ctx)
 	Log.Info("Reconciling Endpoints")
+	drift := []string{}
+	entries := instance.Spec.GetEndpointEntries()
 
-	// delete endpoint if it does no longer exist in Spec.Endpoints
+	// delete endpoint if it does no longer exist in the spec
 	// but has a reference in Status.EndpointIDs
 	if instance.Status.EndpointIDs != nil {
 		for _, endpointType := range slices.Sorted(maps.Keys(instance.Status.EndpointIDs)) {
-			if _, ok := instance.Spec.Endpoints[endpointType]; !ok {
+			if _, ok := entries[endpointType]; !ok {
 				// get the gopher availability mapping for the endpointInterface
 				availability, err := openstack.GetAvailability(endpointType)
 				if err != nil {
-					return err
+					return drift, err
 				}
 
-				err = os.DeleteEndpoint(
-					ctx,
-					Log,
-					openstack.Endpoint{
-						Name:         instance.Spec.ServiceName,
-						ServiceID:    instance.Status.ServiceID,
-						Availability: availability,
-					},
-				)
+				// delete the endpoint in the region it got registered in
+				idx := getEndpointIdx(endpointType, instance.Status.Endpoints)
+				region := os.GetRegion()
+				if idx >= 0 && instance.Status.Endpoints[idx].Region != "" {
+					region = instance.Status.Endpoints[idx].Region
+				}
+				err = deleteRegionEndpoints(ctx, os.GetOSClient(), instance.Status.ServiceID, availability, region)
 				if err != nil {
-					return err
+					return drift, err
 				}
 
 				// remove endpoint reference from status
 				delete(instance.Status.EndpointIDs, endpointType)
-				idx := getEndpointIdx(endpointType, instance.Status.Endpoints)
 				if idx >= 0 {
 					instance.Status.Endpoints = append(instance.Status.Endpoints[:idx],
 						instance.Status.Endpoints[idx+1:]...)
@@ -502,85 +606,134 @@ func (r *KeystoneEndpointReconciler) reconcileEndpoints(
 	}
 
 	// create / update endpoints
-	for _, endpointType := range slices.Sorted(maps.Keys(instance.Spec.Endpoints)) {
-		endpointURL := instance.Spec.Endpoints[endpointType]
+	for _, endpointType := range slices.Sorted(maps.Keys(entries)) {
+		entry := entries[endpointType]
 
 		// get the gopher availability mapping for the endpointType
 		availability, err := openstack.GetAvailability(endpointType)
 		if err != nil {
-			return err
+			return drift, err
 		}
 
-		// get registered endpoints for the service and endpointType
-		allEndpoints, err := os.GetEndpoints(
-			ctx,
-			Log,
-			instance.Status.ServiceID,
-			endpointType)
-		if err != nil {
-			return err
+		expected := regionEndpoint{
+			Endpoint: openstack.Endpoint{
+				Name:         instance.Spec.ServiceName,
+				ServiceID:    instance.Status.ServiceID,
+				Availability: availability,
+				URL:          entry.URL,
+			},
+			Region:  entry.GetRegion(os.GetRegion()),
+			Enabled: entry.IsEnabled(),
+		}
+
+		adoptID := instance.Spec.AdoptEndpointIDs[endpointType]
+		if adoptID != "" && instance.Status.AdoptedEndpointIDs[endpointType] != adoptID {
+			err = r.adoptEndpoint(ctx, instance, os, endpointType, adoptID, expected)
+			if err != nil {
+				return drift, err
+			}
 		}
 
 		endpointID := ""
-		if len(allEndpoints) == 0 {
-			// Create the endpoint
-			endpointID, err = os.CreateEndpoint(
+		if registeredID, ok := instance.Status.EndpointIDs[endpointType]; ok {
+			//
+			// compare the registered endpoint against the spec
+			//
+			endpoint, err := getEndpoint(ctx, os.GetOSClient(), registeredID)
+			if err != nil {
+				return drift, err
+			}
+
+			diff := endpointDrift(endpoint, expected)
+			if len(diff) > 0 {
+				// a different URL, region or enabled flag in the status is
+				// a change of the spec and no drift
+				idx := getEndpointIdx(endpointType, instance.Status.Endpoints)
+				if idx >= 0 && endpointStatusMatches(instance.Status.Endpoints[idx], expected) {
+					drift = append(drift, fmt.Sprintf("%s endpoint %s", endpointType, strings.Join(diff, ", ")))
+					if instance.Spec.DriftPolicy == keystonev1.DriftPolicyReport {
+						Log.Info(fmt.Sprintf("Endpoint %s drifted: %s", endpointType, strings.Join(diff, ", ")))
+						continue
+					}
+				}
+			}
+
+			if endpoint != nil {
+				endpointID = endpoint.ID
+				if len(diff) > 0 {
+					err = updateRegionEndpoint(ctx, os.GetOSClient(), expected, endpoint.ID)
+					if err != nil {
+						return drift, err
+					}
+					Log.Info(fmt.Sprintf("Endpoint %s updated: %s", endpointType, strings.Join(diff, ", ")))
+				}
+			}
+		}
+
+		if endpointID == "" {
+			// get registered endpoints for the service and endpointType
+			allEndpoints, err := getRegionEndpoints(
 				ctx,
-				Log,
-				openstack.Endpoint{
-					Name:         instance.Spec.ServiceName,
-					ServiceID:    instance.Status.ServiceID,
-					Availability: availability,
-					URL:          endpointURL,
-				},
-			)
+				os.GetOSClient(),
+				instance.Status.ServiceID,
+				availability,
+				expected.Region)
 			if err != nil {
-				return err
+				return drift, err
 			}
-		} else if len(allEndpoints) == 1 {
-			// Update the endpoint if URL changed
-			endpoint := allEndpoints[0]
-			endpointID = endpoint.ID
-			if endpointURL != endpoint.URL {
-				endpointID, err = os.UpdateEndpoint(
-					ctx,
-					Log,
-					openstack.Endpoint{
-						Name:         endpoint.Name,
-						ServiceID:    endpoint.ServiceID,
-						Availability: availability,
-						URL:          endpointURL,
-					},
-					endpoint.ID,
-				)
+
+			if len(allEndpoints) == 0 {
+				// Create the endpoint
+				endpointID, err = createRegionEndpoint(ctx, os.GetOSClient(), expected)
 				if err != nil {
-					return err
+					return drift, err
+				}
+				Log.Info(fmt.Sprintf("Endpoint %s created in region %s - %s", endpointType, expected.Region, endpointID))
+			} else if len(allEndpoints) == 1 || instance.Spec.DuplicatePolicy == keystonev1.DuplicatePolicyDedupe {
+				endpoint := allEndpoints[0]
+				if len(allEndpoints) > 1 {
+					endpoint, err = r.removeDuplicateEndpoints(ctx, instance, os, endpointType, entry.URL, allEndpoints)
+					if err != nil {
+						return drift, err
+					}
 				}
+
+				// Update the endpoint if URL or enabled flag changed
+				endpointID = endpoint.ID
+				if entry.URL != endpoint.URL || expected.Enabled != endpoint.Enabled {
+					err = updateRegionEndpoint(ctx, os.GetOSClient(), expected, endpoint.ID)
+					if err != nil {
+						return drift, err
+					}
+					Log.Info(fmt.Sprintf("Endpoint %s updated in region %s - %s", endpointType, expected.Region, endpointID))
+				}
+			} else {
+				// If there are multiple endpoints for the service and endpoint type log it as an error
+				// as manual check is required, unless the Dedupe duplicate policy is set
+				return drift, util.WrapErrorForObject(
+					fmt.Sprintf("multiple endpoints registered for service:%s type: %s",
+						instance.Spec.ServiceName, endpointType),
+					instance, err)
 			}
-		} else {
-			// If there are multiple endpoints for the service and endpoint type log it as an error
-			// as manual check is required
-			return util.WrapErrorForObject(
-				fmt.Sprintf("multiple endpoints registered for service:%s type: %s",
-					instance.Spec.ServiceName, endpointType),
-				instance, err)
 		}
 
 		if endpointID != "" {
-			if _, ok := instance.Spec.Endpoints[endpointType]; ok {
-				instance.Status.EndpointIDs[endpointType] = endpointID
-			}
+			instance.Status.EndpointIDs[endpointType] = endpointID
 			// validate if endpoint is already in the endpoint status list
 			idx := getEndpointIdx(endpointType, instance.Status.Endpoints)
 			if idx >= 0 {
 				instance.Status.Endpoints[idx].ID = endpointID
-				instance.Status.Endpoints[idx].URL = endpointURL
+				instance.Status.Endpoints[idx].URL = entry.URL
+				instance.Status.Endpoints[idx].Region = expected.Region
+				instance.Status.Endpoints[idx].Enabled = expected.Enabled
 			} else {
 				instance.Status.Endpoints = append(instance.Status.Endpoints,
 					keystonev1.Endpoint{
 						Interface: endpointType,
-						URL:       endpointURL,
+						URL:       entry.URL,
 						ID:        endpointID,
+						Region:    expected.Region,
+						Enabled:   expected.Enabled,
 					})
 			}
 		}
@@ -588,9 +741,106 @@ func (r *KeystoneEndpointReconciler) reconcileEndpoints(
 
 	Log.Info("Reconciled Endpoints successfully")
 
+	return drift, nil
+}
+
+// adoptEndpoint - verifies the endpoint to adopt exists and is the expected
+// endpoint of the service, and records it as the endpoint of the endpointType. An endpoint
+// registered before for the endpointType gets deleted.
+func (r *KeystoneEndpointReconciler) adoptEndpoint(
+	ctx context.Context,
+	instance *keystonev1.KeystoneEndpoint,
+	os *openstack.OpenStack,
+	endpointType string,
+	adoptID string,
+	expected regionEndpoint,
+) error {
+	Log := r.GetLogger(ctx)
+
+	endpoint, err := getEndpoint(ctx, os.GetOSClient(), adoptID)
+	if err != nil {
+		return err
+	}
+	err = verifyAdoptedEndpoint(endpoint, adoptID, expected)
+	if err != nil {
+		return err
+	}
+
+	if registeredID := instance.Status.EndpointIDs[endpointType]; registeredID != "" && registeredID != adoptID {
+		err = endpoints.Delete(ctx, os.GetOSClient(), registeredID).ExtractErr()
+		if err != nil && !gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+			return err
+		}
+		Log.Info(fmt.Sprintf("Deleted %s endpoint %s replaced by the adopted endpoint %s", endpointType, registeredID, adoptID))
+	}
+
+	instance.Status.EndpointIDs[endpointType] = adoptID
+	if instance.Status.AdoptedEndpointIDs == nil {
+		instance.Status.AdoptedEndpointIDs = map[string]string{}
+	}
+	instance.Status.AdoptedEndpointIDs[endpointType] = adoptID
+	// differences of the adopted endpoint get changed to match the spec and
+	// are no drift
+	if idx := getEndpointIdx(endpointType, instance.Status.Endpoints); idx >= 0 {
+		instance.Status.Endpoints = append(instance.Status.Endpoints[:idx],
+			instance.Status.Endpoints[idx+1:]...)
+	}
+
+	Log.Info(fmt.Sprintf("Adopted %s endpoint %s", endpointType, adoptID))
+	r.EventRecorder.Event(
+		instance,
+		corev1.EventTypeNormal,
+		"EndpointAdopted",
+		fmt.Sprintf("Adopted %s endpoint %s of service %s", endpointType, adoptID, instance.Spec.ServiceName),
+	)
+
 	return nil
 }
 
+// removeDuplicateEndpoints - deletes the duplicate endpoints registered for
+// the endpointType, except the one with the URL of the spec or else the oldest
+// one, which gets returned. The removed endpoints get recorded in the status
+// and an Event.
+func (r *KeystoneEndpointReconciler) removeDuplicateEndpoints(
+	ctx context.Context,
+	instance *keystonev1.KeystoneEndpoint,
+	os *openstack.OpenStack,
+	endpointType string,
+	endpointURL string,
+	allEndpoints []endpoints.Endpoint,
+) (endpoints.Endpoint, error) {
+	Log := r.GetLogger(ctx)
+
+	endpoint, duplicates := selectDuplicateEndpoint(allEndpoints, endpointURL, instance.Status.EndpointIDs[endpointType])
+	removedIDs := []string{}
+	for _, duplicate := range duplicates {
+		err := endpoints.Delete(ctx, os.GetOSClient(), duplicate.ID).ExtractErr()
+		if err != nil && !gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+			return endpoint, err
+		}
+		Log.Info(fmt.Sprintf("Removed duplicate %s endpoint %s - %s", endpointType, duplicate.URL, duplicate.ID))
+
+		removedIDs = append(removedIDs, duplicate.ID)
+		recordRemovedDuplicate(&instance.Status, keystonev1.Endpoint{
+			Interface: endpointType,
+			URL:       duplicate.URL,
+			ID:        duplicate.ID,
+			Region:    duplicate.Region,
+			Enabled:   duplicate.Enabled,
+		})
+	}
+
+	r.EventRecorder.Event(
+		instance,
+		corev1.EventTypeNormal,
+		"DuplicateEndpointsRemoved",
+		fmt.Sprintf("Removed duplicate %s endpoints %s of service %s, kept %s",
+			endpointType, strings.Join(removedIDs, ", "), instance.Spec.ServiceName, endpoint.ID),
+	)
+
+	return endpoint, nil
+}
+
 // getEndpointIdx - returns the index of the endpointType from a list of Endpoints
 // if not found -1 is returnd
 func getEndpointIdx(endpointType string, endpoints []keystonev1.Endpoint) int {
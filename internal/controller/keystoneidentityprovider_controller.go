@@ -0,0 +1,508 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/go-logr/logr"
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	helper "github.com/openstack-k8s-operators/lib-common/modules/common/helper"
+	secret "github.com/openstack-k8s-operators/lib-common/modules/common/secret"
+
+	openstack "github.com/openstack-k8s-operators/lib-common/modules/openstack"
+	corev1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// GetClient -
+func (r *KeystoneIdentityProviderReconciler) GetClient() client.Client {
+	return r.Client
+}
+
+// GetKClient -
+func (r *KeystoneIdentityProviderReconciler) GetKClient() kubernetes.Interface {
+	return r.Kclient
+}
+
+// GetScheme -
+func (r *KeystoneIdentityProviderReconciler) GetScheme() *runtime.Scheme {
+	return r.Scheme
+}
+
+// KeystoneIdentityProviderReconciler reconciles a KeystoneIdentityProvider object
+type KeystoneIdentityProviderReconciler struct {
+	client.Client
+	Kclient kubernetes.Interface
+	Scheme  *runtime.Scheme
+}
+
+// GetLogger returns a logger object with a logging prefix of "controller.name" and additional controller context fields
+func (r *KeystoneIdentityProviderReconciler) GetLogger(ctx context.Context) logr.Logger {
+	return log.FromContext(ctx).WithName("Controllers").WithName("KeystoneIdentityProvider")
+}
+
+// +kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneidentityproviders,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneidentityproviders/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneidentityproviders/finalizers,verbs=update;patch
+// +kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneapis,verbs=get;list;update;patch
+// +kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneapis/finalizers,verbs=update;patch
+
+// Reconcile keystone identity provider requests
+func (r *KeystoneIdentityProviderReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, _err error) {
+	log := r.GetLogger(ctx)
+
+	// Fetch the KeystoneIdentityProvider instance
+	instance := &keystonev1.KeystoneIdentityProvider{}
+	err := r.Get(ctx, req.NamespacedName, instance)
+	if err != nil {
+		if k8s_errors.IsNotFound(err) {
+			// Request object not found, could have been deleted after reconcile request.
+			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
+			// Return and don't requeue
+			return ctrl.Result{}, nil
+		}
+		// Error reading the object - requeue the request.
+		return ctrl.Result{}, err
+	}
+
+	helper, err := helper.NewHelper(
+		instance,
+		r.Client,
+		r.Kclient,
+		r.Scheme,
+		log,
+	)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// Always patch the instance status when exiting this function so we can persist any changes.
+	defer func() {
+		// Don't update the status, if Reconciler Panics
+		if r := recover(); r != nil {
+			log.Info(fmt.Sprintf("Panic during reconcile %v\n", r))
+			panic(r)
+		}
+		// update the Ready condition based on the sub conditions
+		if instance.Status.Conditions.AllSubConditionIsTrue() {
+			instance.Status.Conditions.MarkTrue(
+				condition.ReadyCondition, condition.ReadyMessage)
+		} else {
+			// something is not ready so reset the Ready condition
+			instance.Status.Conditions.MarkUnknown(
+				condition.ReadyCondition, condition.InitReason, condition.ReadyInitMessage)
+			// and recalculate it based on the state of the rest of the conditions
+			instance.Status.Conditions.Set(
+				instance.Status.Conditions.Mirror(condition.ReadyCondition))
+		}
+		err := helper.PatchInstance(ctx, instance)
+		if err != nil {
+			_err = err
+			return
+		}
+	}()
+
+	//
+	// initialize status
+	//
+	if instance.Status.Conditions == nil {
+		instance.Status.Conditions = condition.Conditions{}
+		cl := condition.CreateList(
+			condition.UnknownCondition(condition.InputReadyCondition, condition.InitReason, condition.InputReadyInitMessage),
+			condition.UnknownCondition(keystonev1.KeystoneAPIReadyCondition, condition.InitReason, keystonev1.KeystoneAPIReadyInitMessage),
+			condition.UnknownCondition(keystonev1.AdminServiceClientReadyCondition, condition.InitReason, keystonev1.AdminServiceClientReadyInitMessage),
+			condition.UnknownCondition(keystonev1.KeystoneIdentityProviderOSIdentityProviderReadyCondition, condition.InitReason, keystonev1.KeystoneIdentityProviderOSIdentityProviderReadyInitMessage))
+		instance.Status.Conditions.Init(&cl)
+
+		// Register overall status immediately to have an early feedback e.g. in the cli
+		return ctrl.Result{}, nil
+	}
+	instance.Status.ObservedGeneration = instance.Generation
+
+	// If we're not deleting this and the identity provider object doesn't have our finalizer, add it.
+	if instance.DeletionTimestamp.IsZero() && controllerutil.AddFinalizer(instance, helper.GetFinalizer()) {
+		return ctrl.Result{}, err
+	}
+
+	//
+	// Validate that keystoneAPI is up
+	//
+	keystoneAPI, err := keystonev1.GetKeystoneAPIForObject(ctx, helper, instance)
+	if err != nil {
+		if k8s_errors.IsNotFound(err) {
+			// There is nothing to clean up on the OpenStack side if the identity provider
+			// never got registered, don't wait for a KeystoneAPI to appear.
+			if !instance.DeletionTimestamp.IsZero() && instance.Status.IdentityProviderID == "" {
+				return r.reconcileDelete(ctx, instance, helper, nil, nil)
+			}
+
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				keystonev1.KeystoneAPIReadyCondition,
+				condition.ErrorReason,
+				condition.SeverityWarning,
+				keystonev1.KeystoneAPIReadyNotFoundMessage,
+			))
+			log.Info("KeystoneAPI not found!")
+			return ctrl.Result{RequeueAfter: time.Second * 5}, nil
+		}
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneAPIReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.KeystoneAPIReadyErrorMessage,
+			err.Error()))
+		return ctrl.Result{}, err
+	}
+
+	// If both the identity provider and the KeystoneAPI are deleted we can skip the
+	// cleanup on the OpenStack side, the DB is going away as well.
+	if !instance.DeletionTimestamp.IsZero() && !keystoneAPI.DeletionTimestamp.IsZero() {
+		return r.reconcileDeleteFinalizersOnly(ctx, instance, helper, keystoneAPI)
+	}
+
+	if !instance.DeletionTimestamp.IsZero() && instance.Status.IdentityProviderID == "" {
+		return r.reconcileDelete(ctx, instance, helper, nil, keystoneAPI)
+	}
+
+	if !keystoneAPI.IsReady() {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneAPIReadyCondition,
+			condition.RequestedReason,
+			condition.SeverityInfo,
+			keystonev1.KeystoneAPIReadyWaitingMessage))
+		log.Info("KeystoneAPI not yet ready")
+		return ctrl.Result{RequeueAfter: time.Second * 5}, nil
+	}
+	instance.Status.Conditions.MarkTrue(keystonev1.KeystoneAPIReadyCondition, keystonev1.KeystoneAPIReadyMessage)
+
+	//
+	// get admin authentication OpenStack
+	//
+	os, ctrlResult, err := keystonev1.GetAdminServiceClient(
+		ctx,
+		helper,
+		keystoneAPI,
+	)
+	if err != nil {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.AdminServiceClientReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.AdminServiceClientReadyErrorMessage,
+			err.Error()))
+		return ctrl.Result{}, err
+	}
+	if (ctrlResult != ctrl.Result{}) {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.AdminServiceClientReadyCondition,
+			condition.RequestedReason,
+			condition.SeverityInfo,
+			keystonev1.AdminServiceClientReadyWaitingMessage))
+		return ctrlResult, nil
+	}
+	instance.Status.Conditions.MarkTrue(keystonev1.AdminServiceClientReadyCondition, keystonev1.AdminServiceClientReadyMessage)
+
+	// Handle normal identity provider delete
+	if !instance.DeletionTimestamp.IsZero() {
+		return r.reconcileDelete(ctx, instance, helper, os, keystoneAPI)
+	}
+
+	// Handle non-deleted identity providers
+	return r.reconcileNormal(ctx, instance, helper, os, keystoneAPI)
+}
+
+// identityProviderSecretField - KeystoneIdentityProvider field to index the OIDC/SAML secret
+const identityProviderSecretField = ".spec.secret" // #nosec G101
+
+// SetupWithManager -
+func (r *KeystoneIdentityProviderReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// index identityProviderSecretField
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &keystonev1.KeystoneIdentityProvider{}, identityProviderSecretField, func(rawObj client.Object) []string {
+		// Extract the secret name from the spec, if one is provided
+		cr := rawObj.(*keystonev1.KeystoneIdentityProvider)
+		if cr.GetSecretName() == "" {
+			return nil
+		}
+		return []string{cr.GetSecretName()}
+	}); err != nil {
+		return err
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&keystonev1.KeystoneIdentityProvider{}).
+		Watches(
+			&corev1.Secret{},
+			handler.EnqueueRequestsFromMapFunc(r.findObjectsForSecret),
+			builder.WithPredicates(predicate.ResourceVersionChangedPredicate{}),
+		).
+		Complete(r)
+}
+
+func (r *KeystoneIdentityProviderReconciler) findObjectsForSecret(ctx context.Context, src client.Object) []reconcile.Request {
+	requests := []reconcile.Request{}
+
+	Log := r.GetLogger(ctx)
+
+	crList := &keystonev1.KeystoneIdentityProviderList{}
+	listOps := &client.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector(identityProviderSecretField, src.GetName()),
+		Namespace:     src.GetNamespace(),
+	}
+	err := r.List(ctx, crList, listOps)
+	if err != nil {
+		Log.Error(err, fmt.Sprintf("listing %s for field: %s - %s", crList.GroupVersionKind().Kind, identityProviderSecretField, src.GetNamespace()))
+		return requests
+	}
+
+	for _, item := range crList.Items {
+		Log.Info(fmt.Sprintf("input source %s changed, reconcile: %s - %s", src.GetName(), item.GetName(), item.GetNamespace()))
+
+		requests = append(requests,
+			reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      item.GetName(),
+					Namespace: item.GetNamespace(),
+				},
+			},
+		)
+	}
+
+	return requests
+}
+
+func (r *KeystoneIdentityProviderReconciler) reconcileDelete(
+	ctx context.Context,
+	instance *keystonev1.KeystoneIdentityProvider,
+	helper *helper.Helper,
+	os *openstack.OpenStack,
+	keystoneAPI *keystonev1.KeystoneAPI,
+) (ctrl.Result, error) {
+	log := r.GetLogger(ctx)
+	log.Info("Reconciling Identity provider delete")
+
+	// only cleanup the identity provider if there is the ID reference in the
+	// object status and if we have an OpenStack backend to use
+	if instance.Status.IdentityProviderID != "" && os != nil {
+		if instance.Spec.DeletionPolicy == keystonev1.DeletionPolicyRetain {
+			log.Info("Retaining identity provider as requested by the deletion policy", "KeystoneIdentityProvider", instance.Spec.IdentityProviderID)
+		} else {
+			err := deleteIdentityProvider(ctx, os.GetOSClient(), instance.Status.IdentityProviderID)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			log.Info("Deleted identity provider", "KeystoneIdentityProvider", instance.Spec.IdentityProviderID)
+		}
+
+		// Clear the ID so that any potential requeues after this reconcile
+		// will know that there is nothing left on the OpenStack side
+		instance.Status.IdentityProviderID = ""
+	} else {
+		log.Info("Not deleting identity provider as there is no stored ID", "KeystoneIdentityProvider", instance.Spec.IdentityProviderID)
+	}
+
+	// There are certain deletion scenarios where we might not have the keystoneAPI
+	if keystoneAPI != nil {
+		// Remove the finalizer for this identity provider from the KeystoneAPI
+		if controllerutil.RemoveFinalizer(keystoneAPI, fmt.Sprintf("%s-%s", helper.GetFinalizer(), instance.Name)) {
+			err := r.Update(ctx, keystoneAPI)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+	}
+
+	// Identity provider is deleted so remove the finalizer.
+	controllerutil.RemoveFinalizer(instance, helper.GetFinalizer())
+	log.Info("Reconciled Identity provider delete successfully")
+
+	return ctrl.Result{}, nil
+}
+
+func (r *KeystoneIdentityProviderReconciler) reconcileDeleteFinalizersOnly(
+	ctx context.Context,
+	instance *keystonev1.KeystoneIdentityProvider,
+	helper *helper.Helper,
+	keystoneAPI *keystonev1.KeystoneAPI,
+) (ctrl.Result, error) {
+	log := r.GetLogger(ctx)
+	log.Info("Reconciling Identity provider delete while KeystoneAPI is being deleted")
+
+	if controllerutil.RemoveFinalizer(keystoneAPI, fmt.Sprintf("%s-%s", helper.GetFinalizer(), instance.Name)) {
+		err := r.Update(ctx, keystoneAPI)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	controllerutil.RemoveFinalizer(instance, helper.GetFinalizer())
+	log.Info("Reconciled Identity provider delete successfully")
+
+	return ctrl.Result{}, nil
+}
+
+func (r *KeystoneIdentityProviderReconciler) reconcileNormal(
+	ctx context.Context,
+	instance *keystonev1.KeystoneIdentityProvider,
+	helper *helper.Helper,
+	os *openstack.OpenStack,
+	keystoneAPI *keystonev1.KeystoneAPI,
+) (ctrl.Result, error) {
+	log := r.GetLogger(ctx)
+	log.Info("Reconciling Identity provider")
+
+	//
+	// Add a finalizer to the KeystoneAPI for this identity provider instance,
+	// so that it can be removed from keystone before the KeystoneAPI goes away
+	//
+	if controllerutil.AddFinalizer(keystoneAPI, fmt.Sprintf("%s-%s", helper.GetFinalizer(), instance.Name)) {
+		err := r.Update(ctx, keystoneAPI)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	//
+	// check the OIDC/SAML secret, the httpd config itself gets rendered by
+	// the KeystoneAPI controller
+	//
+	if instance.GetSecretName() != "" {
+		_, ctrlResult, err := secret.VerifySecret(
+			ctx,
+			types.NamespacedName{
+				Name:      instance.GetSecretName(),
+				Namespace: instance.Namespace,
+			},
+			instance.GetSecretSelectors(),
+			helper.GetClient(),
+			10*time.Second)
+		if err != nil {
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				condition.InputReadyCondition,
+				condition.ErrorReason,
+				condition.SeverityWarning,
+				condition.InputReadyErrorMessage,
+				err.Error()))
+			return ctrl.Result{}, err
+		}
+		if (ctrlResult != ctrl.Result{}) {
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				condition.InputReadyCondition,
+				condition.RequestedReason,
+				condition.SeverityInfo,
+				condition.InputReadyWaitingMessage))
+			return ctrlResult, nil
+		}
+	}
+	instance.Status.Conditions.MarkTrue(condition.InputReadyCondition, condition.InputReadyMessage)
+
+	//
+	// create or update the identity provider
+	//
+	err := r.reconcileIdentityProvider(ctx, instance, os)
+	if err != nil {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneIdentityProviderOSIdentityProviderReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.KeystoneIdentityProviderOSIdentityProviderReadyErrorMessage,
+			err.Error()))
+		return ctrl.Result{}, err
+	}
+	instance.Status.Conditions.MarkTrue(
+		keystonev1.KeystoneIdentityProviderOSIdentityProviderReadyCondition,
+		keystonev1.KeystoneIdentityProviderOSIdentityProviderReadyMessage,
+		instance.Status.IdentityProviderID,
+	)
+
+	log.Info("Reconciled Identity provider successfully")
+	return ctrl.Result{}, nil
+}
+
+func (r *KeystoneIdentityProviderReconciler) reconcileIdentityProvider(
+	ctx context.Context,
+	instance *keystonev1.KeystoneIdentityProvider,
+	os *openstack.OpenStack,
+) error {
+	log := r.GetLogger(ctx)
+	log.Info("Reconciling Identity provider", "KeystoneIdentityProvider", instance.Spec.IdentityProviderID)
+
+	desired := identityProvider{
+		ID:          instance.Spec.IdentityProviderID,
+		Description: instance.Spec.Description,
+		Enabled:     instance.Spec.Enabled,
+		RemoteIDs:   instance.Spec.RemoteIDs,
+	}
+	if instance.Spec.DomainName != "" {
+		domainID, err := getDomainID(ctx, os, instance.Spec.DomainName)
+		if err != nil {
+			return err
+		}
+		desired.DomainID = domainID
+	}
+
+	// the identity provider ID can change in the spec, remove the one
+	// registered before
+	if instance.Status.IdentityProviderID != "" && instance.Status.IdentityProviderID != desired.ID {
+		err := deleteIdentityProvider(ctx, os.GetOSClient(), instance.Status.IdentityProviderID)
+		if err != nil {
+			return err
+		}
+		log.Info("Deleted previous identity provider", "KeystoneIdentityProvider", instance.Status.IdentityProviderID)
+		instance.Status.IdentityProviderID = ""
+	}
+
+	idp, err := getIdentityProvider(ctx, os.GetOSClient(), desired.ID)
+	if err != nil {
+		return err
+	}
+	if idp == nil {
+		idp, err = createIdentityProvider(ctx, os.GetOSClient(), desired)
+		if err != nil {
+			return err
+		}
+		log.Info("Created identity provider", "KeystoneIdentityProvider", desired.ID)
+	} else if idp.Description != desired.Description ||
+		idp.Enabled != desired.Enabled ||
+		!slices.Equal(idp.RemoteIDs, desired.RemoteIDs) {
+		// update the identity provider ONLY if Description, Enabled or RemoteIDs changed.
+		err = updateIdentityProvider(ctx, os.GetOSClient(), desired)
+		if err != nil {
+			return err
+		}
+	}
+	instance.Status.IdentityProviderID = desired.ID
+	instance.Status.DomainID = idp.DomainID
+
+	log.Info("Reconciled Identity provider successfully")
+	return nil
+}
@@ -0,0 +1,409 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/federation"
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	helper "github.com/openstack-k8s-operators/lib-common/modules/common/helper"
+
+	openstack "github.com/openstack-k8s-operators/lib-common/modules/openstack"
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// GetClient -
+func (r *KeystoneMappingReconciler) GetClient() client.Client {
+	return r.Client
+}
+
+// GetKClient -
+func (r *KeystoneMappingReconciler) GetKClient() kubernetes.Interface {
+	return r.Kclient
+}
+
+// GetScheme -
+func (r *KeystoneMappingReconciler) GetScheme() *runtime.Scheme {
+	return r.Scheme
+}
+
+// KeystoneMappingReconciler reconciles a KeystoneMapping object
+type KeystoneMappingReconciler struct {
+	client.Client
+	Kclient kubernetes.Interface
+	Scheme  *runtime.Scheme
+}
+
+// GetLogger returns a logger object with a logging prefix of "controller.name" and additional controller context fields
+func (r *KeystoneMappingReconciler) GetLogger(ctx context.Context) logr.Logger {
+	return log.FromContext(ctx).WithName("Controllers").WithName("KeystoneMapping")
+}
+
+// +kubebuilder:rbac:groups=keystone.openstack.org,resources=keystonemappings,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=keystone.openstack.org,resources=keystonemappings/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=keystone.openstack.org,resources=keystonemappings/finalizers,verbs=update;patch
+// +kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneapis,verbs=get;list;update;patch
+// +kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneapis/finalizers,verbs=update;patch
+
+// Reconcile keystone mapping requests
+func (r *KeystoneMappingReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, _err error) {
+	log := r.GetLogger(ctx)
+
+	// Fetch the KeystoneMapping instance
+	instance := &keystonev1.KeystoneMapping{}
+	err := r.Get(ctx, req.NamespacedName, instance)
+	if err != nil {
+		if k8s_errors.IsNotFound(err) {
+			// Request object not found, could have been deleted after reconcile request.
+			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
+			// Return and don't requeue
+			return ctrl.Result{}, nil
+		}
+		// Error reading the object - requeue the request.
+		return ctrl.Result{}, err
+	}
+
+	helper, err := helper.NewHelper(
+		instance,
+		r.Client,
+		r.Kclient,
+		r.Scheme,
+		log,
+	)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// Always patch the instance status when exiting this function so we can persist any changes.
+	defer func() {
+		// Don't update the status, if Reconciler Panics
+		if r := recover(); r != nil {
+			log.Info(fmt.Sprintf("Panic during reconcile %v\n", r))
+			panic(r)
+		}
+		// update the Ready condition based on the sub conditions
+		if instance.Status.Conditions.AllSubConditionIsTrue() {
+			instance.Status.Conditions.MarkTrue(
+				condition.ReadyCondition, condition.ReadyMessage)
+		} else {
+			// something is not ready so reset the Ready condition
+			instance.Status.Conditions.MarkUnknown(
+				condition.ReadyCondition, condition.InitReason, condition.ReadyInitMessage)
+			// and recalculate it based on the state of the rest of the conditions
+			instance.Status.Conditions.Set(
+				instance.Status.Conditions.Mirror(condition.ReadyCondition))
+		}
+		err := helper.PatchInstance(ctx, instance)
+		if err != nil {
+			_err = err
+			return
+		}
+	}()
+
+	//
+	// initialize status
+	//
+	if instance.Status.Conditions == nil {
+		instance.Status.Conditions = condition.Conditions{}
+		cl := condition.CreateList(
+			condition.UnknownCondition(keystonev1.KeystoneAPIReadyCondition, condition.InitReason, keystonev1.KeystoneAPIReadyInitMessage),
+			condition.UnknownCondition(keystonev1.AdminServiceClientReadyCondition, condition.InitReason, keystonev1.AdminServiceClientReadyInitMessage),
+			condition.UnknownCondition(keystonev1.KeystoneMappingOSMappingReadyCondition, condition.InitReason, keystonev1.KeystoneMappingOSMappingReadyInitMessage))
+		instance.Status.Conditions.Init(&cl)
+
+		// Register overall status immediately to have an early feedback e.g. in the cli
+		return ctrl.Result{}, nil
+	}
+	instance.Status.ObservedGeneration = instance.Generation
+
+	// If we're not deleting this and the mapping object doesn't have our finalizer, add it.
+	if instance.DeletionTimestamp.IsZero() && controllerutil.AddFinalizer(instance, helper.GetFinalizer()) {
+		return ctrl.Result{}, err
+	}
+
+	//
+	// Validate that keystoneAPI is up
+	//
+	keystoneAPI, err := keystonev1.GetKeystoneAPIForObject(ctx, helper, instance)
+	if err != nil {
+		if k8s_errors.IsNotFound(err) {
+			// There is nothing to clean up on the OpenStack side if the mapping
+			// never got registered, don't wait for a KeystoneAPI to appear.
+			if !instance.DeletionTimestamp.IsZero() && instance.Status.MappingID == "" {
+				return r.reconcileDelete(ctx, instance, helper, nil, nil)
+			}
+
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				keystonev1.KeystoneAPIReadyCondition,
+				condition.ErrorReason,
+				condition.SeverityWarning,
+				keystonev1.KeystoneAPIReadyNotFoundMessage,
+			))
+			log.Info("KeystoneAPI not found!")
+			return ctrl.Result{RequeueAfter: time.Second * 5}, nil
+		}
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneAPIReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.KeystoneAPIReadyErrorMessage,
+			err.Error()))
+		return ctrl.Result{}, err
+	}
+
+	// If both the mapping and the KeystoneAPI are deleted we can skip the
+	// cleanup on the OpenStack side, the DB is going away as well.
+	if !instance.DeletionTimestamp.IsZero() && !keystoneAPI.DeletionTimestamp.IsZero() {
+		return r.reconcileDeleteFinalizersOnly(ctx, instance, helper, keystoneAPI)
+	}
+
+	if !instance.DeletionTimestamp.IsZero() && instance.Status.MappingID == "" {
+		return r.reconcileDelete(ctx, instance, helper, nil, keystoneAPI)
+	}
+
+	if !keystoneAPI.IsReady() {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneAPIReadyCondition,
+			condition.RequestedReason,
+			condition.SeverityInfo,
+			keystonev1.KeystoneAPIReadyWaitingMessage))
+		log.Info("KeystoneAPI not yet ready")
+		return ctrl.Result{RequeueAfter: time.Second * 5}, nil
+	}
+	instance.Status.Conditions.MarkTrue(keystonev1.KeystoneAPIReadyCondition, keystonev1.KeystoneAPIReadyMessage)
+
+	//
+	// get admin authentication OpenStack
+	//
+	os, ctrlResult, err := keystonev1.GetAdminServiceClient(
+		ctx,
+		helper,
+		keystoneAPI,
+	)
+	if err != nil {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.AdminServiceClientReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.AdminServiceClientReadyErrorMessage,
+			err.Error()))
+		return ctrl.Result{}, err
+	}
+	if (ctrlResult != ctrl.Result{}) {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.AdminServiceClientReadyCondition,
+			condition.RequestedReason,
+			condition.SeverityInfo,
+			keystonev1.AdminServiceClientReadyWaitingMessage))
+		return ctrlResult, nil
+	}
+	instance.Status.Conditions.MarkTrue(keystonev1.AdminServiceClientReadyCondition, keystonev1.AdminServiceClientReadyMessage)
+
+	// Handle normal mapping delete
+	if !instance.DeletionTimestamp.IsZero() {
+		return r.reconcileDelete(ctx, instance, helper, os, keystoneAPI)
+	}
+
+	// Handle non-deleted mappings
+	return r.reconcileNormal(ctx, instance, helper, os, keystoneAPI)
+}
+
+// SetupWithManager -
+func (r *KeystoneMappingReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&keystonev1.KeystoneMapping{}).
+		Complete(r)
+}
+
+func (r *KeystoneMappingReconciler) reconcileDelete(
+	ctx context.Context,
+	instance *keystonev1.KeystoneMapping,
+	helper *helper.Helper,
+	os *openstack.OpenStack,
+	keystoneAPI *keystonev1.KeystoneAPI,
+) (ctrl.Result, error) {
+	log := r.GetLogger(ctx)
+	log.Info("Reconciling Mapping delete")
+
+	// only cleanup the mapping if there is the MappingID reference in the
+	// object status and if we have an OpenStack backend to use
+	if instance.Status.MappingID != "" && os != nil {
+		if instance.Spec.DeletionPolicy == keystonev1.DeletionPolicyRetain {
+			log.Info("Retaining mapping as requested by the deletion policy", "KeystoneMapping", instance.Spec.MappingID)
+		} else {
+			err := federation.DeleteMapping(ctx, os.GetOSClient(), instance.Status.MappingID).ExtractErr()
+			if err != nil && !gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+				return ctrl.Result{}, err
+			}
+			log.Info("Deleted mapping", "KeystoneMapping", instance.Spec.MappingID)
+		}
+
+		// Clear the mapping ID so that any potential requeues after this reconcile
+		// will know that there is nothing left on the OpenStack side
+		instance.Status.MappingID = ""
+	} else {
+		log.Info("Not deleting mapping as there is no stored mapping ID", "KeystoneMapping", instance.Spec.MappingID)
+	}
+
+	// There are certain deletion scenarios where we might not have the keystoneAPI
+	if keystoneAPI != nil {
+		// Remove the finalizer for this mapping from the KeystoneAPI
+		if controllerutil.RemoveFinalizer(keystoneAPI, fmt.Sprintf("%s-%s", helper.GetFinalizer(), instance.Name)) {
+			err := r.Update(ctx, keystoneAPI)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+	}
+
+	// Mapping is deleted so remove the finalizer.
+	controllerutil.RemoveFinalizer(instance, helper.GetFinalizer())
+	log.Info("Reconciled Mapping delete successfully")
+
+	return ctrl.Result{}, nil
+}
+
+func (r *KeystoneMappingReconciler) reconcileDeleteFinalizersOnly(
+	ctx context.Context,
+	instance *keystonev1.KeystoneMapping,
+	helper *helper.Helper,
+	keystoneAPI *keystonev1.KeystoneAPI,
+) (ctrl.Result, error) {
+	log := r.GetLogger(ctx)
+	log.Info("Reconciling Mapping delete while KeystoneAPI is being deleted")
+
+	if controllerutil.RemoveFinalizer(keystoneAPI, fmt.Sprintf("%s-%s", helper.GetFinalizer(), instance.Name)) {
+		err := r.Update(ctx, keystoneAPI)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	controllerutil.RemoveFinalizer(instance, helper.GetFinalizer())
+	log.Info("Reconciled Mapping delete successfully")
+
+	return ctrl.Result{}, nil
+}
+
+func (r *KeystoneMappingReconciler) reconcileNormal(
+	ctx context.Context,
+	instance *keystonev1.KeystoneMapping,
+	helper *helper.Helper,
+	os *openstack.OpenStack,
+	keystoneAPI *keystonev1.KeystoneAPI,
+) (ctrl.Result, error) {
+	log := r.GetLogger(ctx)
+	log.Info("Reconciling Mapping")
+
+	//
+	// Add a finalizer to the KeystoneAPI for this mapping instance, so that the
+	// mapping can be removed from keystone before the KeystoneAPI goes away
+	//
+	if controllerutil.AddFinalizer(keystoneAPI, fmt.Sprintf("%s-%s", helper.GetFinalizer(), instance.Name)) {
+		err := r.Update(ctx, keystoneAPI)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	//
+	// create or update the mapping
+	//
+	err := r.reconcileMapping(ctx, instance, os)
+	if err != nil {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneMappingOSMappingReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.KeystoneMappingOSMappingReadyErrorMessage,
+			err.Error()))
+		return ctrl.Result{}, err
+	}
+	instance.Status.Conditions.MarkTrue(
+		keystonev1.KeystoneMappingOSMappingReadyCondition,
+		keystonev1.KeystoneMappingOSMappingReadyMessage,
+		instance.Status.MappingID,
+	)
+
+	log.Info("Reconciled Mapping successfully")
+	return ctrl.Result{}, nil
+}
+
+func (r *KeystoneMappingReconciler) reconcileMapping(
+	ctx context.Context,
+	instance *keystonev1.KeystoneMapping,
+	os *openstack.OpenStack,
+) error {
+	log := r.GetLogger(ctx)
+	log.Info("Reconciling Mapping", "KeystoneMapping", instance.Spec.MappingID)
+
+	rules := []federation.MappingRule{}
+	err := json.Unmarshal([]byte(instance.Spec.Rules), &rules)
+	if err != nil {
+		return fmt.Errorf("invalid mapping rules: %w", err)
+	}
+
+	// the mapping ID can change in the spec, remove the one registered before
+	if instance.Status.MappingID != "" && instance.Status.MappingID != instance.Spec.MappingID {
+		err = federation.DeleteMapping(ctx, os.GetOSClient(), instance.Status.MappingID).ExtractErr()
+		if err != nil && !gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+			return err
+		}
+		log.Info("Deleted previous mapping", "KeystoneMapping", instance.Status.MappingID)
+		instance.Status.MappingID = ""
+	}
+
+	mapping, err := federation.GetMapping(ctx, os.GetOSClient(), instance.Spec.MappingID).Extract()
+	if err != nil && !gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+		return err
+	}
+	if err != nil {
+		_, err = federation.CreateMapping(ctx, os.GetOSClient(), instance.Spec.MappingID, federation.CreateMappingOpts{
+			Rules: rules,
+		}).Extract()
+		if err != nil {
+			return err
+		}
+		log.Info("Created mapping", "KeystoneMapping", instance.Spec.MappingID)
+	} else if !equality.Semantic.DeepEqual(mapping.Rules, rules) {
+		// update the mapping ONLY if the rules changed.
+		_, err = federation.UpdateMapping(ctx, os.GetOSClient(), instance.Spec.MappingID, federation.UpdateMappingOpts{
+			Rules: rules,
+		}).Extract()
+		if err != nil {
+			return err
+		}
+	}
+	instance.Status.MappingID = instance.Spec.MappingID
+
+	log.Info("Reconciled Mapping successfully")
+	return nil
+}
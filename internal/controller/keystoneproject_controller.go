@@ -0,0 +1,443 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/projects"
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	helper "github.com/openstack-k8s-operators/lib-common/modules/common/helper"
+
+	openstack "github.com/openstack-k8s-operators/lib-common/modules/openstack"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// GetClient -
+func (r *KeystoneProjectReconciler) GetClient() client.Client {
+	return r.Client
+}
+
+// GetKClient -
+func (r *KeystoneProjectReconciler) GetKClient() kubernetes.Interface {
+	return r.Kclient
+}
+
+// GetScheme -
+func (r *KeystoneProjectReconciler) GetScheme() *runtime.Scheme {
+	return r.Scheme
+}
+
+// KeystoneProjectReconciler reconciles a KeystoneProject object
+type KeystoneProjectReconciler struct {
+	client.Client
+	Kclient kubernetes.Interface
+	Scheme  *runtime.Scheme
+}
+
+// GetLogger returns a logger object with a logging prefix of "controller.name" and additional controller context fields
+func (r *KeystoneProjectReconciler) GetLogger(ctx context.Context) logr.Logger {
+	return log.FromContext(ctx).WithName("Controllers").WithName("KeystoneProject")
+}
+
+// +kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneprojects,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneprojects/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneprojects/finalizers,verbs=update;patch
+// +kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneapis,verbs=get;list;update;patch
+// +kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneapis/finalizers,verbs=update;patch
+
+// Reconcile keystone project requests
+func (r *KeystoneProjectReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, _err error) {
+	log := r.GetLogger(ctx)
+
+	// Fetch the KeystoneProject instance
+	instance := &keystonev1.KeystoneProject{}
+	err := r.Get(ctx, req.NamespacedName, instance)
+	if err != nil {
+		if k8s_errors.IsNotFound(err) {
+			// Request object not found, could have been deleted after reconcile request.
+			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
+			// Return and don't requeue
+			return ctrl.Result{}, nil
+		}
+		// Error reading the object - requeue the request.
+		return ctrl.Result{}, err
+	}
+
+	helper, err := helper.NewHelper(
+		instance,
+		r.Client,
+		r.Kclient,
+		r.Scheme,
+		log,
+	)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// Always patch the instance status when exiting this function so we can persist any changes.
+	defer func() {
+		// Don't update the status, if Reconciler Panics
+		if r := recover(); r != nil {
+			log.Info(fmt.Sprintf("Panic during reconcile %v\n", r))
+			panic(r)
+		}
+		// update the Ready condition based on the sub conditions
+		if instance.Status.Conditions.AllSubConditionIsTrue() {
+			instance.Status.Conditions.MarkTrue(
+				condition.ReadyCondition, condition.ReadyMessage)
+		} else {
+			// something is not ready so reset the Ready condition
+			instance.Status.Conditions.MarkUnknown(
+				condition.ReadyCondition, condition.InitReason, condition.ReadyInitMessage)
+			// and recalculate it based on the state of the rest of the conditions
+			instance.Status.Conditions.Set(
+				instance.Status.Conditions.Mirror(condition.ReadyCondition))
+		}
+		err := helper.PatchInstance(ctx, instance)
+		if err != nil {
+			_err = err
+			return
+		}
+	}()
+
+	//
+	// initialize status
+	//
+	if instance.Status.Conditions == nil {
+		instance.Status.Conditions = condition.Conditions{}
+		cl := condition.CreateList(
+			condition.UnknownCondition(keystonev1.KeystoneAPIReadyCondition, condition.InitReason, keystonev1.KeystoneAPIReadyInitMessage),
+			condition.UnknownCondition(keystonev1.AdminServiceClientReadyCondition, condition.InitReason, keystonev1.AdminServiceClientReadyInitMessage),
+			condition.UnknownCondition(keystonev1.KeystoneProjectOSProjectReadyCondition, condition.InitReason, keystonev1.KeystoneProjectOSProjectReadyInitMessage))
+		instance.Status.Conditions.Init(&cl)
+
+		// Register overall status immediately to have an early feedback e.g. in the cli
+		return ctrl.Result{}, nil
+	}
+	instance.Status.ObservedGeneration = instance.Generation
+
+	// If we're not deleting this and the project object doesn't have our finalizer, add it.
+	if instance.DeletionTimestamp.IsZero() && controllerutil.AddFinalizer(instance, helper.GetFinalizer()) {
+		return ctrl.Result{}, err
+	}
+
+	//
+	// Validate that keystoneAPI is up
+	//
+	keystoneAPI, err := keystonev1.GetKeystoneAPIForObject(ctx, helper, instance)
+	if err != nil {
+		if k8s_errors.IsNotFound(err) {
+			// There is nothing to clean up on the OpenStack side if the project
+			// never got registered, don't wait for a KeystoneAPI to appear.
+			if !instance.DeletionTimestamp.IsZero() && instance.Status.ProjectID == "" {
+				return r.reconcileDelete(ctx, instance, helper, nil, nil)
+			}
+
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				keystonev1.KeystoneAPIReadyCondition,
+				condition.ErrorReason,
+				condition.SeverityWarning,
+				keystonev1.KeystoneAPIReadyNotFoundMessage,
+			))
+			log.Info("KeystoneAPI not found!")
+			return ctrl.Result{RequeueAfter: time.Second * 5}, nil
+		}
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneAPIReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.KeystoneAPIReadyErrorMessage,
+			err.Error()))
+		return ctrl.Result{}, err
+	}
+
+	// If both the project and the KeystoneAPI are deleted we can skip the
+	// cleanup on the OpenStack side, the DB is going away as well.
+	if !instance.DeletionTimestamp.IsZero() && !keystoneAPI.DeletionTimestamp.IsZero() {
+		return r.reconcileDeleteFinalizersOnly(ctx, instance, helper, keystoneAPI)
+	}
+
+	if !instance.DeletionTimestamp.IsZero() && instance.Status.ProjectID == "" {
+		return r.reconcileDelete(ctx, instance, helper, nil, keystoneAPI)
+	}
+
+	if !keystoneAPI.IsReady() {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneAPIReadyCondition,
+			condition.RequestedReason,
+			condition.SeverityInfo,
+			keystonev1.KeystoneAPIReadyWaitingMessage))
+		log.Info("KeystoneAPI not yet ready")
+		return ctrl.Result{RequeueAfter: time.Second * 5}, nil
+	}
+	instance.Status.Conditions.MarkTrue(keystonev1.KeystoneAPIReadyCondition, keystonev1.KeystoneAPIReadyMessage)
+
+	//
+	// get admin authentication OpenStack
+	//
+	os, ctrlResult, err := keystonev1.GetAdminServiceClient(
+		ctx,
+		helper,
+		keystoneAPI,
+	)
+	if err != nil {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.AdminServiceClientReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.AdminServiceClientReadyErrorMessage,
+			err.Error()))
+		return ctrl.Result{}, err
+	}
+	if (ctrlResult != ctrl.Result{}) {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.AdminServiceClientReadyCondition,
+			condition.RequestedReason,
+			condition.SeverityInfo,
+			keystonev1.AdminServiceClientReadyWaitingMessage))
+		return ctrlResult, nil
+	}
+	instance.Status.Conditions.MarkTrue(keystonev1.AdminServiceClientReadyCondition, keystonev1.AdminServiceClientReadyMessage)
+
+	// Handle normal project delete
+	if !instance.DeletionTimestamp.IsZero() {
+		return r.reconcileDelete(ctx, instance, helper, os, keystoneAPI)
+	}
+
+	// Handle non-deleted projects
+	return r.reconcileNormal(ctx, instance, helper, os, keystoneAPI)
+}
+
+// SetupWithManager -
+func (r *KeystoneProjectReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&keystonev1.KeystoneProject{}).
+		Complete(r)
+}
+
+func (r *KeystoneProjectReconciler) reconcileDelete(
+	ctx context.Context,
+	instance *keystonev1.KeystoneProject,
+	helper *helper.Helper,
+	os *openstack.OpenStack,
+	keystoneAPI *keystonev1.KeystoneAPI,
+) (ctrl.Result, error) {
+	log := r.GetLogger(ctx)
+	log.Info("Reconciling Project delete")
+
+	// only cleanup the project if there is the ProjectID reference in the
+	// object status and if we have an OpenStack backend to use
+	if instance.Status.ProjectID != "" && os != nil {
+		switch {
+		case instance.Status.Adopted:
+			log.Info("Not deleting adopted project", "KeystoneProject", instance.Spec.ProjectName)
+		case instance.Spec.DeletionPolicy == keystonev1.DeletionPolicyRetain:
+			log.Info("Retaining project as requested by the deletion policy", "KeystoneProject", instance.Spec.ProjectName)
+		default:
+			err := projects.Delete(ctx, os.GetOSClient(), instance.Status.ProjectID).ExtractErr()
+			if err != nil && !gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+				return ctrl.Result{}, err
+			}
+			log.Info("Deleted project", "KeystoneProject", instance.Spec.ProjectName)
+		}
+
+		// Clear the project ID so that any potential requeues after this reconcile
+		// will know that there is nothing left on the OpenStack side
+		instance.Status.ProjectID = ""
+	} else {
+		log.Info("Not deleting project as there is no stored project ID", "KeystoneProject", instance.Spec.ProjectName)
+	}
+
+	// There are certain deletion scenarios where we might not have the keystoneAPI
+	if keystoneAPI != nil {
+		// Remove the finalizer for this project from the KeystoneAPI
+		if controllerutil.RemoveFinalizer(keystoneAPI, fmt.Sprintf("%s-%s", helper.GetFinalizer(), instance.Name)) {
+			err := r.Update(ctx, keystoneAPI)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+	}
+
+	// Project is deleted so remove the finalizer.
+	controllerutil.RemoveFinalizer(instance, helper.GetFinalizer())
+	log.Info("Reconciled Project delete successfully")
+
+	return ctrl.Result{}, nil
+}
+
+func (r *KeystoneProjectReconciler) reconcileDeleteFinalizersOnly(
+	ctx context.Context,
+	instance *keystonev1.KeystoneProject,
+	helper *helper.Helper,
+	keystoneAPI *keystonev1.KeystoneAPI,
+) (ctrl.Result, error) {
+	log := r.GetLogger(ctx)
+	log.Info("Reconciling Project delete while KeystoneAPI is being deleted")
+
+	if controllerutil.RemoveFinalizer(keystoneAPI, fmt.Sprintf("%s-%s", helper.GetFinalizer(), instance.Name)) {
+		err := r.Update(ctx, keystoneAPI)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	controllerutil.RemoveFinalizer(instance, helper.GetFinalizer())
+	log.Info("Reconciled Project delete successfully")
+
+	return ctrl.Result{}, nil
+}
+
+func (r *KeystoneProjectReconciler) reconcileNormal(
+	ctx context.Context,
+	instance *keystonev1.KeystoneProject,
+	helper *helper.Helper,
+	os *openstack.OpenStack,
+	keystoneAPI *keystonev1.KeystoneAPI,
+) (ctrl.Result, error) {
+	log := r.GetLogger(ctx)
+	log.Info("Reconciling Project")
+
+	//
+	// Add a finalizer to the KeystoneAPI for this project instance, so that the
+	// project can be removed from keystone before the KeystoneAPI goes away
+	//
+	if controllerutil.AddFinalizer(keystoneAPI, fmt.Sprintf("%s-%s", helper.GetFinalizer(), instance.Name)) {
+		err := r.Update(ctx, keystoneAPI)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	//
+	// create or update the project
+	//
+	err := r.reconcileProject(ctx, instance, os)
+	if err != nil {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneProjectOSProjectReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.KeystoneProjectOSProjectReadyErrorMessage,
+			err.Error()))
+		return ctrl.Result{}, err
+	}
+	instance.Status.Conditions.MarkTrue(
+		keystonev1.KeystoneProjectOSProjectReadyCondition,
+		keystonev1.KeystoneProjectOSProjectReadyMessage,
+		instance.Spec.ProjectName,
+		instance.Status.ProjectID,
+	)
+
+	log.Info("Reconciled Project successfully")
+	return ctrl.Result{}, nil
+}
+
+func (r *KeystoneProjectReconciler) reconcileProject(
+	ctx context.Context,
+	instance *keystonev1.KeystoneProject,
+	os *openstack.OpenStack,
+) error {
+	log := r.GetLogger(ctx)
+	log.Info("Reconciling Project", "KeystoneProject", instance.Spec.ProjectName)
+
+	domainID, err := getDomainID(ctx, os, instance.Spec.DomainName)
+	if err != nil {
+		return err
+	}
+	instance.Status.DomainID = domainID
+
+	var project *projects.Project
+	if instance.Status.ProjectID != "" {
+		project, err = projects.Get(ctx, os.GetOSClient(), instance.Status.ProjectID).Extract()
+		if err != nil && !gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+			return err
+		}
+	}
+
+	if project == nil {
+		project, err = os.GetProject(ctx, log, instance.Spec.ProjectName, domainID)
+		switch {
+		case err == nil:
+			// the project was not created by this KeystoneProject, only take
+			// it over if requested
+			if !instance.Spec.Adopt {
+				return fmt.Errorf("project %s %w", instance.Spec.ProjectName, ErrNotAdopted)
+			}
+			log.Info("Adopting existing project", "KeystoneProject", instance.Spec.ProjectName, "ProjectID", project.ID)
+			instance.Status.Adopted = true
+		case strings.Contains(err.Error(), openstack.ProjectNotFound):
+			projectID, err := os.CreateProject(
+				ctx,
+				log,
+				openstack.Project{
+					Name:        instance.Spec.ProjectName,
+					Description: instance.Spec.Description,
+					DomainID:    domainID,
+				})
+			if err != nil {
+				return err
+			}
+			err = persistCreatedID(ctx, r.Client, instance, func(created *keystonev1.KeystoneProject) {
+				created.Status.ProjectID = projectID
+				created.Status.Adopted = false
+			})
+			if err != nil {
+				return err
+			}
+
+			project, err = projects.Get(ctx, os.GetOSClient(), projectID).Extract()
+			if err != nil {
+				return err
+			}
+			instance.Status.Adopted = false
+		default:
+			return err
+		}
+	}
+	instance.Status.ProjectID = project.ID
+
+	if project.Name != instance.Spec.ProjectName ||
+		project.Enabled != instance.Spec.Enabled ||
+		project.Description != instance.Spec.Description {
+		// update the project ONLY if Name, Enabled or Description changed.
+		_, err = projects.Update(ctx, os.GetOSClient(), project.ID, projects.UpdateOpts{
+			Name:        instance.Spec.ProjectName,
+			Description: &instance.Spec.Description,
+			Enabled:     &instance.Spec.Enabled,
+		}).Extract()
+		if err != nil {
+			return err
+		}
+	}
+
+	log.Info("Reconciled Project successfully")
+	return nil
+}
@@ -0,0 +1,497 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	helper "github.com/openstack-k8s-operators/lib-common/modules/common/helper"
+
+	openstack "github.com/openstack-k8s-operators/lib-common/modules/openstack"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// GetClient -
+func (r *KeystoneProtocolReconciler) GetClient() client.Client {
+	return r.Client
+}
+
+// GetKClient -
+func (r *KeystoneProtocolReconciler) GetKClient() kubernetes.Interface {
+	return r.Kclient
+}
+
+// GetScheme -
+func (r *KeystoneProtocolReconciler) GetScheme() *runtime.Scheme {
+	return r.Scheme
+}
+
+// KeystoneProtocolReconciler reconciles a KeystoneProtocol object
+type KeystoneProtocolReconciler struct {
+	client.Client
+	Kclient kubernetes.Interface
+	Scheme  *runtime.Scheme
+}
+
+// GetLogger returns a logger object with a logging prefix of "controller.name" and additional controller context fields
+func (r *KeystoneProtocolReconciler) GetLogger(ctx context.Context) logr.Logger {
+	return log.FromContext(ctx).WithName("Controllers").WithName("KeystoneProtocol")
+}
+
+// +kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneprotocols,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneprotocols/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneprotocols/finalizers,verbs=update;patch
+// +kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneidentityproviders,verbs=get;list;watch
+// +kubebuilder:rbac:groups=keystone.openstack.org,resources=keystonemappings,verbs=get;list;watch
+// +kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneapis,verbs=get;list;update;patch
+// +kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneapis/finalizers,verbs=update;patch
+
+// Reconcile keystone protocol requests
+func (r *KeystoneProtocolReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, _err error) {
+	log := r.GetLogger(ctx)
+
+	// Fetch the KeystoneProtocol instance
+	instance := &keystonev1.KeystoneProtocol{}
+	err := r.Get(ctx, req.NamespacedName, instance)
+	if err != nil {
+		if k8s_errors.IsNotFound(err) {
+			// Request object not found, could have been deleted after reconcile request.
+			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
+			// Return and don't requeue
+			return ctrl.Result{}, nil
+		}
+		// Error reading the object - requeue the request.
+		return ctrl.Result{}, err
+	}
+
+	helper, err := helper.NewHelper(
+		instance,
+		r.Client,
+		r.Kclient,
+		r.Scheme,
+		log,
+	)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// Always patch the instance status when exiting this function so we can persist any changes.
+	defer func() {
+		// Don't update the status, if Reconciler Panics
+		if r := recover(); r != nil {
+			log.Info(fmt.Sprintf("Panic during reconcile %v\n", r))
+			panic(r)
+		}
+		// update the Ready condition based on the sub conditions
+		if instance.Status.Conditions.AllSubConditionIsTrue() {
+			instance.Status.Conditions.MarkTrue(
+				condition.ReadyCondition, condition.ReadyMessage)
+		} else {
+			// something is not ready so reset the Ready condition
+			instance.Status.Conditions.MarkUnknown(
+				condition.ReadyCondition, condition.InitReason, condition.ReadyInitMessage)
+			// and recalculate it based on the state of the rest of the conditions
+			instance.Status.Conditions.Set(
+				instance.Status.Conditions.Mirror(condition.ReadyCondition))
+		}
+		err := helper.PatchInstance(ctx, instance)
+		if err != nil {
+			_err = err
+			return
+		}
+	}()
+
+	//
+	// initialize status
+	//
+	if instance.Status.Conditions == nil {
+		instance.Status.Conditions = condition.Conditions{}
+		cl := condition.CreateList(
+			condition.UnknownCondition(keystonev1.KeystoneAPIReadyCondition, condition.InitReason, keystonev1.KeystoneAPIReadyInitMessage),
+			condition.UnknownCondition(keystonev1.AdminServiceClientReadyCondition, condition.InitReason, keystonev1.AdminServiceClientReadyInitMessage),
+			condition.UnknownCondition(keystonev1.KeystoneProtocolOSProtocolReadyCondition, condition.InitReason, keystonev1.KeystoneProtocolOSProtocolReadyInitMessage))
+		instance.Status.Conditions.Init(&cl)
+
+		// Register overall status immediately to have an early feedback e.g. in the cli
+		return ctrl.Result{}, nil
+	}
+	instance.Status.ObservedGeneration = instance.Generation
+
+	// If we're not deleting this and the protocol object doesn't have our finalizer, add it.
+	if instance.DeletionTimestamp.IsZero() && controllerutil.AddFinalizer(instance, helper.GetFinalizer()) {
+		return ctrl.Result{}, err
+	}
+
+	//
+	// Validate that keystoneAPI is up
+	//
+	keystoneAPI, err := keystonev1.GetKeystoneAPIForObject(ctx, helper, instance)
+	if err != nil {
+		if k8s_errors.IsNotFound(err) {
+			// There is nothing to clean up on the OpenStack side if the protocol
+			// never got registered, don't wait for a KeystoneAPI to appear.
+			if !instance.DeletionTimestamp.IsZero() && instance.Status.ProtocolID == "" {
+				return r.reconcileDelete(ctx, instance, helper, nil, nil)
+			}
+
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				keystonev1.KeystoneAPIReadyCondition,
+				condition.ErrorReason,
+				condition.SeverityWarning,
+				keystonev1.KeystoneAPIReadyNotFoundMessage,
+			))
+			log.Info("KeystoneAPI not found!")
+			return ctrl.Result{RequeueAfter: time.Second * 5}, nil
+		}
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneAPIReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.KeystoneAPIReadyErrorMessage,
+			err.Error()))
+		return ctrl.Result{}, err
+	}
+
+	// If both the protocol and the KeystoneAPI are deleted we can skip the
+	// cleanup on the OpenStack side, the DB is going away as well.
+	if !instance.DeletionTimestamp.IsZero() && !keystoneAPI.DeletionTimestamp.IsZero() {
+		return r.reconcileDeleteFinalizersOnly(ctx, instance, helper, keystoneAPI)
+	}
+
+	if !instance.DeletionTimestamp.IsZero() && instance.Status.ProtocolID == "" {
+		return r.reconcileDelete(ctx, instance, helper, nil, keystoneAPI)
+	}
+
+	if !keystoneAPI.IsReady() {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneAPIReadyCondition,
+			condition.RequestedReason,
+			condition.SeverityInfo,
+			keystonev1.KeystoneAPIReadyWaitingMessage))
+		log.Info("KeystoneAPI not yet ready")
+		return ctrl.Result{RequeueAfter: time.Second * 5}, nil
+	}
+	instance.Status.Conditions.MarkTrue(keystonev1.KeystoneAPIReadyCondition, keystonev1.KeystoneAPIReadyMessage)
+
+	//
+	// get admin authentication OpenStack
+	//
+	os, ctrlResult, err := keystonev1.GetAdminServiceClient(
+		ctx,
+		helper,
+		keystoneAPI,
+	)
+	if err != nil {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.AdminServiceClientReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.AdminServiceClientReadyErrorMessage,
+			err.Error()))
+		return ctrl.Result{}, err
+	}
+	if (ctrlResult != ctrl.Result{}) {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.AdminServiceClientReadyCondition,
+			condition.RequestedReason,
+			condition.SeverityInfo,
+			keystonev1.AdminServiceClientReadyWaitingMessage))
+		return ctrlResult, nil
+	}
+	instance.Status.Conditions.MarkTrue(keystonev1.AdminServiceClientReadyCondition, keystonev1.AdminServiceClientReadyMessage)
+
+	// Handle normal protocol delete
+	if !instance.DeletionTimestamp.IsZero() {
+		return r.reconcileDelete(ctx, instance, helper, os, keystoneAPI)
+	}
+
+	// Handle non-deleted protocols
+	return r.reconcileNormal(ctx, instance, helper, os, keystoneAPI)
+}
+
+const (
+	// protocolIdentityProviderField - KeystoneProtocol field to index the referenced KeystoneIdentityProvider
+	protocolIdentityProviderField = ".spec.identityProvider"
+	// protocolMappingField - KeystoneProtocol field to index the referenced KeystoneMapping
+	protocolMappingField = ".spec.mapping"
+)
+
+// SetupWithManager -
+func (r *KeystoneProtocolReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// index protocolIdentityProviderField
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &keystonev1.KeystoneProtocol{}, protocolIdentityProviderField, func(rawObj client.Object) []string {
+		cr := rawObj.(*keystonev1.KeystoneProtocol)
+		return []string{cr.Spec.IdentityProvider}
+	}); err != nil {
+		return err
+	}
+
+	// index protocolMappingField
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &keystonev1.KeystoneProtocol{}, protocolMappingField, func(rawObj client.Object) []string {
+		cr := rawObj.(*keystonev1.KeystoneProtocol)
+		return []string{cr.Spec.Mapping}
+	}); err != nil {
+		return err
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&keystonev1.KeystoneProtocol{}).
+		Watches(
+			&keystonev1.KeystoneIdentityProvider{},
+			handler.EnqueueRequestsFromMapFunc(r.findObjectsForSrc),
+		).
+		Watches(
+			&keystonev1.KeystoneMapping{},
+			handler.EnqueueRequestsFromMapFunc(r.findObjectsForSrc),
+		).
+		Complete(r)
+}
+
+func (r *KeystoneProtocolReconciler) findObjectsForSrc(ctx context.Context, src client.Object) []reconcile.Request {
+	requests := []reconcile.Request{}
+
+	Log := r.GetLogger(ctx)
+
+	field := protocolMappingField
+	if _, ok := src.(*keystonev1.KeystoneIdentityProvider); ok {
+		field = protocolIdentityProviderField
+	}
+
+	crList := &keystonev1.KeystoneProtocolList{}
+	listOps := &client.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector(field, src.GetName()),
+		Namespace:     src.GetNamespace(),
+	}
+	err := r.List(ctx, crList, listOps)
+	if err != nil {
+		Log.Error(err, fmt.Sprintf("listing %s for field: %s - %s", crList.GroupVersionKind().Kind, field, src.GetNamespace()))
+		return requests
+	}
+
+	for _, item := range crList.Items {
+		Log.Info(fmt.Sprintf("input source %s changed, reconcile: %s - %s", src.GetName(), item.GetName(), item.GetNamespace()))
+
+		requests = append(requests,
+			reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      item.GetName(),
+					Namespace: item.GetNamespace(),
+				},
+			},
+		)
+	}
+
+	return requests
+}
+
+func (r *KeystoneProtocolReconciler) reconcileDelete(
+	ctx context.Context,
+	instance *keystonev1.KeystoneProtocol,
+	helper *helper.Helper,
+	os *openstack.OpenStack,
+	keystoneAPI *keystonev1.KeystoneAPI,
+) (ctrl.Result, error) {
+	log := r.GetLogger(ctx)
+	log.Info("Reconciling Protocol delete")
+
+	// only cleanup the protocol if there is the ProtocolID reference in the
+	// object status and if we have an OpenStack backend to use
+	if instance.Status.ProtocolID != "" && os != nil {
+		if instance.Spec.DeletionPolicy == keystonev1.DeletionPolicyRetain {
+			log.Info("Retaining protocol as requested by the deletion policy", "KeystoneProtocol", instance.Spec.ProtocolID)
+		} else {
+			err := deleteFederationProtocol(ctx, os.GetOSClient(), instance.Status.IdentityProviderID, instance.Status.ProtocolID)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			log.Info("Deleted protocol", "KeystoneProtocol", instance.Spec.ProtocolID)
+		}
+
+		// Clear the protocol ID so that any potential requeues after this reconcile
+		// will know that there is nothing left on the OpenStack side
+		instance.Status.ProtocolID = ""
+	} else {
+		log.Info("Not deleting protocol as there is no stored protocol ID", "KeystoneProtocol", instance.Spec.ProtocolID)
+	}
+
+	// There are certain deletion scenarios where we might not have the keystoneAPI
+	if keystoneAPI != nil {
+		// Remove the finalizer for this protocol from the KeystoneAPI
+		if controllerutil.RemoveFinalizer(keystoneAPI, fmt.Sprintf("%s-%s", helper.GetFinalizer(), instance.Name)) {
+			err := r.Update(ctx, keystoneAPI)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+	}
+
+	// Protocol is deleted so remove the finalizer.
+	controllerutil.RemoveFinalizer(instance, helper.GetFinalizer())
+	log.Info("Reconciled Protocol delete successfully")
+
+	return ctrl.Result{}, nil
+}
+
+func (r *KeystoneProtocolReconciler) reconcileDeleteFinalizersOnly(
+	ctx context.Context,
+	instance *keystonev1.KeystoneProtocol,
+	helper *helper.Helper,
+	keystoneAPI *keystonev1.KeystoneAPI,
+) (ctrl.Result, error) {
+	log := r.GetLogger(ctx)
+	log.Info("Reconciling Protocol delete while KeystoneAPI is being deleted")
+
+	if controllerutil.RemoveFinalizer(keystoneAPI, fmt.Sprintf("%s-%s", helper.GetFinalizer(), instance.Name)) {
+		err := r.Update(ctx, keystoneAPI)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	controllerutil.RemoveFinalizer(instance, helper.GetFinalizer())
+	log.Info("Reconciled Protocol delete successfully")
+
+	return ctrl.Result{}, nil
+}
+
+func (r *KeystoneProtocolReconciler) reconcileNormal(
+	ctx context.Context,
+	instance *keystonev1.KeystoneProtocol,
+	helper *helper.Helper,
+	os *openstack.OpenStack,
+	keystoneAPI *keystonev1.KeystoneAPI,
+) (ctrl.Result, error) {
+	log := r.GetLogger(ctx)
+	log.Info("Reconciling Protocol")
+
+	//
+	// Add a finalizer to the KeystoneAPI for this protocol instance, so that the
+	// protocol can be removed from keystone before the KeystoneAPI goes away
+	//
+	if controllerutil.AddFinalizer(keystoneAPI, fmt.Sprintf("%s-%s", helper.GetFinalizer(), instance.Name)) {
+		err := r.Update(ctx, keystoneAPI)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	//
+	// the referenced identity provider and mapping need to be registered first
+	//
+	idp := &keystonev1.KeystoneIdentityProvider{}
+	err := r.Get(ctx, types.NamespacedName{Name: instance.Spec.IdentityProvider, Namespace: instance.Namespace}, idp)
+	if err != nil && !k8s_errors.IsNotFound(err) {
+		return ctrl.Result{}, err
+	}
+	if err != nil || idp.Status.IdentityProviderID == "" {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneProtocolOSProtocolReadyCondition,
+			condition.RequestedReason,
+			condition.SeverityInfo,
+			keystonev1.KeystoneProtocolOSProtocolReadyWaitingMessage,
+			"KeystoneIdentityProvider",
+			instance.Spec.IdentityProvider))
+		return ctrl.Result{}, nil
+	}
+
+	mapping := &keystonev1.KeystoneMapping{}
+	err = r.Get(ctx, types.NamespacedName{Name: instance.Spec.Mapping, Namespace: instance.Namespace}, mapping)
+	if err != nil && !k8s_errors.IsNotFound(err) {
+		return ctrl.Result{}, err
+	}
+	if err != nil || mapping.Status.MappingID == "" {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneProtocolOSProtocolReadyCondition,
+			condition.RequestedReason,
+			condition.SeverityInfo,
+			keystonev1.KeystoneProtocolOSProtocolReadyWaitingMessage,
+			"KeystoneMapping",
+			instance.Spec.Mapping))
+		return ctrl.Result{}, nil
+	}
+
+	//
+	// create or update the protocol
+	//
+	err = r.reconcileProtocol(ctx, instance, os, idp.Status.IdentityProviderID, mapping.Status.MappingID)
+	if err != nil {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneProtocolOSProtocolReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.KeystoneProtocolOSProtocolReadyErrorMessage,
+			err.Error()))
+		return ctrl.Result{}, err
+	}
+	instance.Status.Conditions.MarkTrue(
+		keystonev1.KeystoneProtocolOSProtocolReadyCondition,
+		keystonev1.KeystoneProtocolOSProtocolReadyMessage,
+		instance.Status.ProtocolID,
+		instance.Status.IdentityProviderID,
+	)
+
+	log.Info("Reconciled Protocol successfully")
+	return ctrl.Result{}, nil
+}
+
+func (r *KeystoneProtocolReconciler) reconcileProtocol(
+	ctx context.Context,
+	instance *keystonev1.KeystoneProtocol,
+	os *openstack.OpenStack,
+	idpID string,
+	mappingID string,
+) error {
+	log := r.GetLogger(ctx)
+	log.Info("Reconciling Protocol", "KeystoneProtocol", instance.Spec.ProtocolID)
+
+	// the protocol ID or the identity provider can change in the spec,
+	// remove the protocol registered before
+	if instance.Status.ProtocolID != "" &&
+		(instance.Status.ProtocolID != instance.Spec.ProtocolID || instance.Status.IdentityProviderID != idpID) {
+		err := deleteFederationProtocol(ctx, os.GetOSClient(), instance.Status.IdentityProviderID, instance.Status.ProtocolID)
+		if err != nil {
+			return err
+		}
+		log.Info("Deleted previous protocol", "KeystoneProtocol", instance.Status.ProtocolID)
+		instance.Status.ProtocolID = ""
+	}
+
+	err := ensureFederationProtocol(ctx, os.GetOSClient(), idpID, instance.Spec.ProtocolID, mappingID)
+	if err != nil {
+		return err
+	}
+	instance.Status.ProtocolID = instance.Spec.ProtocolID
+	instance.Status.IdentityProviderID = idpID
+	instance.Status.MappingID = mappingID
+
+	log.Info("Reconciled Protocol successfully")
+	return nil
+}
@@ -0,0 +1,428 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	helper "github.com/openstack-k8s-operators/lib-common/modules/common/helper"
+
+	openstack "github.com/openstack-k8s-operators/lib-common/modules/openstack"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// GetClient -
+func (r *KeystoneRoleAssignmentReconciler) GetClient() client.Client {
+	return r.Client
+}
+
+// GetKClient -
+func (r *KeystoneRoleAssignmentReconciler) GetKClient() kubernetes.Interface {
+	return r.Kclient
+}
+
+// GetScheme -
+func (r *KeystoneRoleAssignmentReconciler) GetScheme() *runtime.Scheme {
+	return r.Scheme
+}
+
+// KeystoneRoleAssignmentReconciler reconciles a KeystoneRoleAssignment object
+type KeystoneRoleAssignmentReconciler struct {
+	client.Client
+	Kclient kubernetes.Interface
+	Scheme  *runtime.Scheme
+}
+
+// GetLogger returns a logger object with a logging prefix of "controller.name" and additional controller context fields
+func (r *KeystoneRoleAssignmentReconciler) GetLogger(ctx context.Context) logr.Logger {
+	return log.FromContext(ctx).WithName("Controllers").WithName("KeystoneRoleAssignment")
+}
+
+// +kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneroleassignments,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneroleassignments/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneroleassignments/finalizers,verbs=update;patch
+// +kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneapis,verbs=get;list;update;patch
+// +kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneapis/finalizers,verbs=update;patch
+
+// Reconcile keystone role assignment requests
+func (r *KeystoneRoleAssignmentReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, _err error) {
+	log := r.GetLogger(ctx)
+
+	// Fetch the KeystoneRoleAssignment instance
+	instance := &keystonev1.KeystoneRoleAssignment{}
+	err := r.Get(ctx, req.NamespacedName, instance)
+	if err != nil {
+		if k8s_errors.IsNotFound(err) {
+			// Request object not found, could have been deleted after reconcile request.
+			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
+			// Return and don't requeue
+			return ctrl.Result{}, nil
+		}
+		// Error reading the object - requeue the request.
+		return ctrl.Result{}, err
+	}
+
+	helper, err := helper.NewHelper(
+		instance,
+		r.Client,
+		r.Kclient,
+		r.Scheme,
+		log,
+	)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// Always patch the instance status when exiting this function so we can persist any changes.
+	defer func() {
+		// Don't update the status, if Reconciler Panics
+		if r := recover(); r != nil {
+			log.Info(fmt.Sprintf("Panic during reconcile %v\n", r))
+			panic(r)
+		}
+		// update the Ready condition based on the sub conditions
+		if instance.Status.Conditions.AllSubConditionIsTrue() {
+			instance.Status.Conditions.MarkTrue(
+				condition.ReadyCondition, condition.ReadyMessage)
+		} else {
+			// something is not ready so reset the Ready condition
+			instance.Status.Conditions.MarkUnknown(
+				condition.ReadyCondition, condition.InitReason, condition.ReadyInitMessage)
+			// and recalculate it based on the state of the rest of the conditions
+			instance.Status.Conditions.Set(
+				instance.Status.Conditions.Mirror(condition.ReadyCondition))
+		}
+		err := helper.PatchInstance(ctx, instance)
+		if err != nil {
+			_err = err
+			return
+		}
+	}()
+
+	//
+	// initialize status
+	//
+	if instance.Status.Conditions == nil {
+		instance.Status.Conditions = condition.Conditions{}
+		cl := condition.CreateList(
+			condition.UnknownCondition(keystonev1.KeystoneAPIReadyCondition, condition.InitReason, keystonev1.KeystoneAPIReadyInitMessage),
+			condition.UnknownCondition(keystonev1.AdminServiceClientReadyCondition, condition.InitReason, keystonev1.AdminServiceClientReadyInitMessage),
+			condition.UnknownCondition(keystonev1.KeystoneRoleAssignmentOSRoleAssignmentReadyCondition, condition.InitReason, keystonev1.KeystoneRoleAssignmentOSRoleAssignmentReadyInitMessage))
+		instance.Status.Conditions.Init(&cl)
+
+		// Register overall status immediately to have an early feedback e.g. in the cli
+		return ctrl.Result{}, nil
+	}
+	instance.Status.ObservedGeneration = instance.Generation
+
+	// If we're not deleting this and the assignment object doesn't have our finalizer, add it.
+	if instance.DeletionTimestamp.IsZero() && controllerutil.AddFinalizer(instance, helper.GetFinalizer()) {
+		return ctrl.Result{}, err
+	}
+
+	//
+	// Validate that keystoneAPI is up
+	//
+	keystoneAPI, err := keystonev1.GetKeystoneAPIForObject(ctx, helper, instance)
+	if err != nil {
+		if k8s_errors.IsNotFound(err) {
+			// There is nothing to clean up on the OpenStack side if the role
+			// never got assigned, don't wait for a KeystoneAPI to appear.
+			if !instance.DeletionTimestamp.IsZero() && instance.Status.RoleID == "" {
+				return r.reconcileDelete(ctx, instance, helper, nil, nil)
+			}
+
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				keystonev1.KeystoneAPIReadyCondition,
+				condition.ErrorReason,
+				condition.SeverityWarning,
+				keystonev1.KeystoneAPIReadyNotFoundMessage,
+			))
+			log.Info("KeystoneAPI not found!")
+			return ctrl.Result{RequeueAfter: time.Second * 5}, nil
+		}
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneAPIReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.KeystoneAPIReadyErrorMessage,
+			err.Error()))
+		return ctrl.Result{}, err
+	}
+
+	// If both the assignment and the KeystoneAPI are deleted we can skip the
+	// cleanup on the OpenStack side, the DB is going away as well.
+	if !instance.DeletionTimestamp.IsZero() && !keystoneAPI.DeletionTimestamp.IsZero() {
+		return r.reconcileDeleteFinalizersOnly(ctx, instance, helper, keystoneAPI)
+	}
+
+	if !instance.DeletionTimestamp.IsZero() && instance.Status.RoleID == "" {
+		return r.reconcileDelete(ctx, instance, helper, nil, keystoneAPI)
+	}
+
+	if !keystoneAPI.IsReady() {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneAPIReadyCondition,
+			condition.RequestedReason,
+			condition.SeverityInfo,
+			keystonev1.KeystoneAPIReadyWaitingMessage))
+		log.Info("KeystoneAPI not yet ready")
+		return ctrl.Result{RequeueAfter: time.Second * 5}, nil
+	}
+	instance.Status.Conditions.MarkTrue(keystonev1.KeystoneAPIReadyCondition, keystonev1.KeystoneAPIReadyMessage)
+
+	//
+	// get admin authentication OpenStack
+	//
+	os, ctrlResult, err := keystonev1.GetAdminServiceClient(
+		ctx,
+		helper,
+		keystoneAPI,
+	)
+	if err != nil {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.AdminServiceClientReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.AdminServiceClientReadyErrorMessage,
+			err.Error()))
+		return ctrl.Result{}, err
+	}
+	if (ctrlResult != ctrl.Result{}) {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.AdminServiceClientReadyCondition,
+			condition.RequestedReason,
+			condition.SeverityInfo,
+			keystonev1.AdminServiceClientReadyWaitingMessage))
+		return ctrlResult, nil
+	}
+	instance.Status.Conditions.MarkTrue(keystonev1.AdminServiceClientReadyCondition, keystonev1.AdminServiceClientReadyMessage)
+
+	// Handle normal assignment delete
+	if !instance.DeletionTimestamp.IsZero() {
+		return r.reconcileDelete(ctx, instance, helper, os, keystoneAPI)
+	}
+
+	// Handle non-deleted assignments
+	return r.reconcileNormal(ctx, instance, helper, os, keystoneAPI)
+}
+
+// SetupWithManager -
+func (r *KeystoneRoleAssignmentReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&keystonev1.KeystoneRoleAssignment{}).
+		Complete(r)
+}
+
+func (r *KeystoneRoleAssignmentReconciler) reconcileDelete(
+	ctx context.Context,
+	instance *keystonev1.KeystoneRoleAssignment,
+	helper *helper.Helper,
+	os *openstack.OpenStack,
+	keystoneAPI *keystonev1.KeystoneAPI,
+) (ctrl.Result, error) {
+	log := r.GetLogger(ctx)
+	log.Info("Reconciling Role assignment delete")
+
+	// only revoke the assignment if it is referenced in the object status
+	// and if we have an OpenStack backend to use
+	if instance.Status.RoleID != "" && os != nil {
+		if instance.Spec.DeletionPolicy == keystonev1.DeletionPolicyRetain {
+			log.Info("Retaining role assignment as requested by the deletion policy", "KeystoneRoleAssignment", instance.Name)
+		} else {
+			err := unassignRole(ctx, os, instance.Status)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			log.Info("Revoked role assignment", "KeystoneRoleAssignment", instance.Name)
+		}
+
+		// Clear the role ID so that any potential requeues after this reconcile
+		// will know that there is nothing left on the OpenStack side
+		instance.Status.RoleID = ""
+	} else {
+		log.Info("Not revoking role assignment as there is no stored role ID", "KeystoneRoleAssignment", instance.Name)
+	}
+
+	// There are certain deletion scenarios where we might not have the keystoneAPI
+	if keystoneAPI != nil {
+		// Remove the finalizer for this assignment from the KeystoneAPI
+		if controllerutil.RemoveFinalizer(keystoneAPI, fmt.Sprintf("%s-%s", helper.GetFinalizer(), instance.Name)) {
+			err := r.Update(ctx, keystoneAPI)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+	}
+
+	// Assignment is revoked so remove the finalizer.
+	controllerutil.RemoveFinalizer(instance, helper.GetFinalizer())
+	log.Info("Reconciled Role assignment delete successfully")
+
+	return ctrl.Result{}, nil
+}
+
+func (r *KeystoneRoleAssignmentReconciler) reconcileDeleteFinalizersOnly(
+	ctx context.Context,
+	instance *keystonev1.KeystoneRoleAssignment,
+	helper *helper.Helper,
+	keystoneAPI *keystonev1.KeystoneAPI,
+) (ctrl.Result, error) {
+	log := r.GetLogger(ctx)
+	log.Info("Reconciling Role assignment delete while KeystoneAPI is being deleted")
+
+	if controllerutil.RemoveFinalizer(keystoneAPI, fmt.Sprintf("%s-%s", helper.GetFinalizer(), instance.Name)) {
+		err := r.Update(ctx, keystoneAPI)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	controllerutil.RemoveFinalizer(instance, helper.GetFinalizer())
+	log.Info("Reconciled Role assignment delete successfully")
+
+	return ctrl.Result{}, nil
+}
+
+func (r *KeystoneRoleAssignmentReconciler) reconcileNormal(
+	ctx context.Context,
+	instance *keystonev1.KeystoneRoleAssignment,
+	helper *helper.Helper,
+	os *openstack.OpenStack,
+	keystoneAPI *keystonev1.KeystoneAPI,
+) (ctrl.Result, error) {
+	log := r.GetLogger(ctx)
+	log.Info("Reconciling Role assignment")
+
+	//
+	// Add a finalizer to the KeystoneAPI for this assignment instance, so that
+	// the assignment can be revoked before the KeystoneAPI goes away
+	//
+	if controllerutil.AddFinalizer(keystoneAPI, fmt.Sprintf("%s-%s", helper.GetFinalizer(), instance.Name)) {
+		err := r.Update(ctx, keystoneAPI)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	//
+	// create or update the assignment
+	//
+	err := r.reconcileRoleAssignment(ctx, instance, os)
+	if err != nil {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneRoleAssignmentOSRoleAssignmentReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.KeystoneRoleAssignmentOSRoleAssignmentReadyErrorMessage,
+			err.Error()))
+		return ctrl.Result{}, err
+	}
+	instance.Status.Conditions.MarkTrue(
+		keystonev1.KeystoneRoleAssignmentOSRoleAssignmentReadyCondition,
+		keystonev1.KeystoneRoleAssignmentOSRoleAssignmentReadyMessage,
+		instance.Spec.RoleName,
+		instance.Spec.UserName,
+	)
+
+	log.Info("Reconciled Role assignment successfully")
+	return ctrl.Result{}, nil
+}
+
+func (r *KeystoneRoleAssignmentReconciler) reconcileRoleAssignment(
+	ctx context.Context,
+	instance *keystonev1.KeystoneRoleAssignment,
+	os *openstack.OpenStack,
+) error {
+	log := r.GetLogger(ctx)
+	log.Info("Reconciling Role assignment", "KeystoneRoleAssignment", instance.Name)
+
+	roleID, err := os.CreateRole(ctx, log, instance.Spec.RoleName)
+	if err != nil {
+		return err
+	}
+
+	userDomainID, err := getDomainID(ctx, os, instance.Spec.UserDomainName)
+	if err != nil {
+		return err
+	}
+	user, err := os.GetUser(ctx, log, instance.Spec.UserName, userDomainID)
+	if err != nil {
+		return err
+	}
+
+	desired := keystonev1.KeystoneRoleAssignmentStatus{
+		RoleID: roleID,
+		UserID: user.ID,
+		System: instance.Spec.Scope.System,
+	}
+	if instance.Spec.Scope.ProjectName != "" {
+		projectDomainID, err := getDomainID(ctx, os, instance.Spec.Scope.ProjectDomainName)
+		if err != nil {
+			return err
+		}
+		project, err := os.GetProject(ctx, log, instance.Spec.Scope.ProjectName, projectDomainID)
+		if err != nil {
+			return err
+		}
+		desired.ProjectID = project.ID
+	} else if instance.Spec.Scope.DomainName != "" {
+		desired.DomainID, err = getDomainID(ctx, os, instance.Spec.Scope.DomainName)
+		if err != nil {
+			return err
+		}
+	}
+
+	// revoke the previous assignment if the role, user or scope changed
+	if instance.Status.RoleID != "" &&
+		(instance.Status.RoleID != desired.RoleID ||
+			instance.Status.UserID != desired.UserID ||
+			instance.Status.ProjectID != desired.ProjectID ||
+			instance.Status.DomainID != desired.DomainID ||
+			instance.Status.System != desired.System) {
+		err = unassignRole(ctx, os, instance.Status)
+		if err != nil {
+			return err
+		}
+		log.Info("Revoked previous role assignment", "KeystoneRoleAssignment", instance.Name)
+	}
+
+	// assignments are idempotent in keystone, no need to check for an
+	// existing one first
+	err = assignRole(ctx, os, desired)
+	if err != nil {
+		return err
+	}
+	instance.Status.RoleID = desired.RoleID
+	instance.Status.UserID = desired.UserID
+	instance.Status.ProjectID = desired.ProjectID
+	instance.Status.DomainID = desired.DomainID
+	instance.Status.System = desired.System
+
+	log.Info("Reconciled Role assignment successfully")
+	return nil
+}
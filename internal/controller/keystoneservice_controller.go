@@ -18,24 +18,35 @@ package controller
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"strings"
 	"time"
 
 	"github.com/go-logr/logr"
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/users"
 	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
 	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
 	helper "github.com/openstack-k8s-operators/lib-common/modules/common/helper"
 	secret "github.com/openstack-k8s-operators/lib-common/modules/common/secret"
 
 	openstack "github.com/openstack-k8s-operators/lib-common/modules/openstack"
+	corev1 "k8s.io/api/core/v1"
 	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
@@ -57,8 +68,9 @@ func (r *KeystoneServiceReconciler) GetScheme() *runtime.Scheme {
 // KeystoneServiceReconciler reconciles a KeystoneService object
 type KeystoneServiceReconciler struct {
 	client.Client
-	Kclient kubernetes.Interface
-	Scheme  *runtime.Scheme
+	Kclient       kubernetes.Interface
+	Scheme        *runtime.Scheme
+	EventRecorder record.EventRecorder
 }
 
 // GetLogger returns a logger object with a logging prefix of "controller.name" and additional controller context fields
@@ -71,6 +83,8 @@ func (r *KeystoneServiceReconciler) GetLogger(ctx context.Context) logr.Logger {
 // +kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneservices/finalizers,verbs=update;patch
 // +kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneapis,verbs=get;list;update;patch
 // +kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneapis/finalizers,verbs=update;patch
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 // Reconcile keystone service requests
 func (r *KeystoneServiceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, _err error) {
@@ -138,13 +152,17 @@ func (r *KeystoneServiceReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 			condition.UnknownCondition(keystonev1.KeystoneAPIReadyCondition, condition.InitReason, keystonev1.KeystoneAPIReadyInitMessage),
 			condition.UnknownCondition(keystonev1.AdminServiceClientReadyCondition, condition.InitReason, keystonev1.AdminServiceClientReadyInitMessage),
 			condition.UnknownCondition(keystonev1.KeystoneServiceOSServiceReadyCondition, condition.InitReason, keystonev1.KeystoneServiceOSServiceReadyInitMessage),
-			condition.UnknownCondition(keystonev1.KeystoneServiceOSUserReadyCondition, condition.InitReason, keystonev1.KeystoneServiceOSUserReadyInitMessage))
+			condition.UnknownCondition(keystonev1.KeystoneServiceOSUserReadyCondition, condition.InitReason, keystonev1.KeystoneServiceOSUserReadyInitMessage),
+			condition.UnknownCondition(keystonev1.KeystoneCatalogInSyncCondition, condition.InitReason, keystonev1.KeystoneCatalogInSyncInitMessage),
+			condition.UnknownCondition(keystonev1.KeystoneServicePasswordSyncedCondition, condition.InitReason, keystonev1.KeystoneServicePasswordSyncedInitMessage))
 		instance.Status.Conditions.Init(&cl)
 
 		// Register overall status immediately to have an early feedback e.g. in the cli
 		return ctrl.Result{}, nil
 	}
 
+	instance.Status.ObservedGeneration = instance.Generation
+
 	// If we're not deleting this and the service object doesn't have our finalizer, add it.
 	if instance.DeletionTimestamp.IsZero() && controllerutil.AddFinalizer(instance, helper.GetFinalizer()) {
 		return ctrl.Result{}, err
@@ -153,7 +171,7 @@ func (r *KeystoneServiceReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 	//
 	// Validate that keystoneAPI is up
 	//
-	keystoneAPI, err := keystonev1.GetKeystoneAPI(ctx, helper, instance.Namespace, map[string]string{})
+	keystoneAPI, err := keystonev1.GetKeystoneAPIWithRef(ctx, helper, instance.Spec.KeystoneAPIRef, instance)
 	if err != nil {
 		if k8s_errors.IsNotFound(err) {
 			// If this KeystoneService CR is being deleted and it has not registered any actual
@@ -164,12 +182,7 @@ func (r *KeystoneServiceReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 				return r.reconcileDelete(ctx, instance, helper, nil, nil)
 			}
 
-			instance.Status.Conditions.Set(condition.FalseCondition(
-				keystonev1.KeystoneAPIReadyCondition,
-				condition.ErrorReason,
-				condition.SeverityWarning,
-				keystonev1.KeystoneAPIReadyNotFoundMessage,
-			))
+			instance.Status.Conditions.Set(keystoneAPINotFoundCondition(instance.Spec.KeystoneAPIRef, instance.Namespace))
 			log.Info("KeystoneAPI not found!")
 			return ctrl.Result{RequeueAfter: time.Second * 5}, nil
 		}
@@ -201,11 +214,7 @@ func (r *KeystoneServiceReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 	}
 
 	if !keystoneAPI.IsReady() {
-		instance.Status.Conditions.Set(condition.FalseCondition(
-			keystonev1.KeystoneAPIReadyCondition,
-			condition.RequestedReason,
-			condition.SeverityInfo,
-			keystonev1.KeystoneAPIReadyWaitingMessage))
+		instance.Status.Conditions.Set(keystoneAPIWaitingCondition(instance.Spec.KeystoneAPIRef, instance.Namespace))
 		log.Info("KeystoneAPI not yet ready")
 		return ctrl.Result{RequeueAfter: time.Second * 5}, nil
 	}
@@ -248,13 +257,85 @@ func (r *KeystoneServiceReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 
 }
 
+// serviceSecretField - KeystoneService field to index the service user password secret
+const serviceSecretField = ".spec.secret" // #nosec G101
+
 // SetupWithManager x
 func (r *KeystoneServiceReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// index keystoneAPIRefField
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &keystonev1.KeystoneService{}, keystoneAPIRefField, func(rawObj client.Object) []string {
+		cr := rawObj.(*keystonev1.KeystoneService)
+		return keystoneAPIRefIndexValue(cr.Spec.KeystoneAPIRef, cr.Namespace)
+	}); err != nil {
+		return err
+	}
+
+	// index serviceSecretField
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &keystonev1.KeystoneService{}, serviceSecretField, func(rawObj client.Object) []string {
+		cr := rawObj.(*keystonev1.KeystoneService)
+		if cr.Spec.Secret == "" {
+			return nil
+		}
+		return []string{cr.Spec.Secret}
+	}); err != nil {
+		return err
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&keystonev1.KeystoneService{}).
+		Watches(
+			&keystonev1.KeystoneAPI{},
+			handler.EnqueueRequestsFromMapFunc(r.findObjectsForKeystoneAPI),
+			builder.WithPredicates(keystoneAPIRefPredicate),
+		).
+		Watches(
+			&corev1.Secret{},
+			handler.EnqueueRequestsFromMapFunc(r.findObjectsForSecret),
+			builder.WithPredicates(predicate.ResourceVersionChangedPredicate{}),
+		).
 		Complete(r)
 }
 
+// findObjectsForSecret - reconcile the KeystoneServices using the changed
+// secret for the password of their service user
+func (r *KeystoneServiceReconciler) findObjectsForSecret(ctx context.Context, src client.Object) []reconcile.Request {
+	requests := []reconcile.Request{}
+
+	Log := r.GetLogger(ctx)
+
+	crList := &keystonev1.KeystoneServiceList{}
+	listOps := &client.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector(serviceSecretField, src.GetName()),
+		Namespace:     src.GetNamespace(),
+	}
+	err := r.List(ctx, crList, listOps)
+	if err != nil {
+		Log.Error(err, fmt.Sprintf("listing %s for field: %s - %s", crList.GroupVersionKind().Kind, serviceSecretField, src.GetNamespace()))
+		return requests
+	}
+
+	for _, item := range crList.Items {
+		Log.Info(fmt.Sprintf("input source %s changed, reconcile: %s - %s", src.GetName(), item.GetName(), item.GetNamespace()))
+
+		requests = append(requests,
+			reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      item.GetName(),
+					Namespace: item.GetNamespace(),
+				},
+			},
+		)
+	}
+
+	return requests
+}
+
+// findObjectsForKeystoneAPI - reconcile the KeystoneServices referencing the
+// changed KeystoneAPI
+func (r *KeystoneServiceReconciler) findObjectsForKeystoneAPI(ctx context.Context, src client.Object) []reconcile.Request {
+	return findObjectsForKeystoneAPIRef(ctx, r.Client, &keystonev1.KeystoneServiceList{}, src)
+}
+
 func (r *KeystoneServiceReconciler) reconcileDelete(
 	ctx context.Context,
 	instance *keystonev1.KeystoneService,
@@ -268,23 +349,8 @@ func (r *KeystoneServiceReconciler) reconcileDelete(
 	// only cleanup the service if there is the ServiceID reference in the
 	// object status and if we have an OpenStack backend to use
 	if instance.Status.ServiceID != "" && os != nil {
-		// Delete User
-		err := os.DeleteUser(
-			ctx,
-			log,
-			instance.Spec.ServiceUser,
-			"default")
-		if err != nil {
-			return ctrl.Result{}, err
-		}
-
-		// Delete Service
-		err = os.DeleteService(
-			ctx,
-			log,
-			instance.Status.ServiceID)
+		err := r.applyDeletionPolicy(ctx, instance, os)
 		if err != nil {
-			log.Info(err.Error())
 			return ctrl.Result{}, err
 		}
 
@@ -300,7 +366,7 @@ func (r *KeystoneServiceReconciler) reconcileDelete(
 	// There are certain deletion scenarios where we might not have the keystoneAPI
 	if keystoneAPI != nil {
 		// Remove the finalizer for this service from the KeystoneAPI
-		if controllerutil.RemoveFinalizer(keystoneAPI, fmt.Sprintf("%s-%s", helper.GetFinalizer(), instance.Name)) {
+		if controllerutil.RemoveFinalizer(keystoneAPI, keystoneAPIFinalizer(helper, instance, keystoneAPI)) {
 			err := r.Update(ctx, keystoneAPI)
 
 			if err != nil {
@@ -316,6 +382,97 @@ func (r *KeystoneServiceReconciler) reconcileDelete(
 	return ctrl.Result{}, nil
 }
 
+// applyDeletionPolicy - removes the service, the service user and its role
+// assignments from keystone, or keeps them, as the deletion policy requests
+func (r *KeystoneServiceReconciler) applyDeletionPolicy(
+	ctx context.Context,
+	instance *keystonev1.KeystoneService,
+	os *openstack.OpenStack,
+) error {
+	log := r.GetLogger(ctx)
+
+	switch instance.Spec.DeletionPolicy {
+	case keystonev1.DeletionPolicyOrphan:
+		log.Info("Orphaning service as requested by the deletion policy", "KeystoneService", instance.Spec.ServiceName)
+		r.EventRecorder.Event(
+			instance,
+			corev1.EventTypeNormal,
+			"ServiceOrphaned",
+			fmt.Sprintf("Kept service %s, user %s and its role assignments in keystone",
+				instance.Spec.ServiceName, instance.Spec.ServiceUser),
+		)
+
+	case keystonev1.DeletionPolicyRetain:
+		log.Info("Retaining service as requested by the deletion policy", "KeystoneService", instance.Spec.ServiceName)
+		roleNames := []string{}
+		for _, assignment := range instance.Status.RoleAssignments {
+			err := unassignRole(ctx, os, serviceRoleAssignment(assignment))
+			if err != nil {
+				return err
+			}
+			roleNames = append(roleNames, assignment.RoleName)
+		}
+		instance.Status.RoleAssignments = nil
+		message := fmt.Sprintf("Kept service %s and user %s in keystone",
+			instance.Spec.ServiceName, instance.Spec.ServiceUser)
+		if len(roleNames) > 0 {
+			message = fmt.Sprintf("%s, revoked roles %s", message, strings.Join(roleNames, ", "))
+		}
+		r.EventRecorder.Event(
+			instance,
+			corev1.EventTypeNormal,
+			"ServiceRetained",
+			message,
+		)
+
+	default:
+		// Delete User, there is none if its domain is gone already. Its role
+		// assignments get removed with it. An adopted user might be in
+		// another domain, it gets deleted by ID.
+		if instance.Status.AdoptedUserID != "" {
+			err := users.Delete(ctx, os.GetOSClient(), instance.Status.AdoptedUserID).ExtractErr()
+			if err != nil && !gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+				return err
+			}
+		} else {
+			domainID, err := getDomainID(ctx, os, instance.Spec.GetDomainName())
+			if err != nil && !errors.Is(err, ErrDomainNotFound) {
+				return err
+			}
+			if err == nil {
+				err = os.DeleteUser(
+					ctx,
+					log,
+					instance.Spec.ServiceUser,
+					domainID)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		instance.Status.RoleAssignments = nil
+
+		// Delete Service
+		err := os.DeleteService(
+			ctx,
+			log,
+			instance.Status.ServiceID)
+		if err != nil {
+			log.Info(err.Error())
+			return err
+		}
+		r.EventRecorder.Event(
+			instance,
+			corev1.EventTypeNormal,
+			"ServiceDeleted",
+			fmt.Sprintf("Deleted service %s and user %s from keystone",
+				instance.Spec.ServiceName, instance.Spec.ServiceUser),
+		)
+	}
+
+	return nil
+}
+
 func (r *KeystoneServiceReconciler) reconcileDeleteFinalizersOnly(
 	ctx context.Context,
 	instance *keystonev1.KeystoneService,
@@ -325,7 +482,28 @@ func (r *KeystoneServiceReconciler) reconcileDeleteFinalizersOnly(
 	Log := r.GetLogger(ctx)
 	Log.Info("Reconciling Service delete while KeystoneAPI is being deleted")
 
-	if controllerutil.RemoveFinalizer(keystoneAPI, fmt.Sprintf("%s-%s", helper.GetFinalizer(), instance.Name)) {
+	// keystone goes away, the deletion policy can not be applied
+	if instance.Status.ServiceID != "" {
+		if instance.Spec.DeletionPolicy == keystonev1.DeletionPolicyOrphan {
+			r.EventRecorder.Event(
+				instance,
+				corev1.EventTypeNormal,
+				"ServiceOrphaned",
+				fmt.Sprintf("Kept service %s, user %s and its role assignments in keystone",
+					instance.Spec.ServiceName, instance.Spec.ServiceUser),
+			)
+		} else {
+			r.EventRecorder.Event(
+				instance,
+				corev1.EventTypeWarning,
+				"DeletionPolicySkipped",
+				fmt.Sprintf("KeystoneAPI %s is being deleted, kept service %s and user %s in keystone",
+					keystoneAPI.Name, instance.Spec.ServiceName, instance.Spec.ServiceUser),
+			)
+		}
+	}
+
+	if controllerutil.RemoveFinalizer(keystoneAPI, keystoneAPIFinalizer(helper, instance, keystoneAPI)) {
 		err := r.Update(ctx, keystoneAPI)
 
 		if err != nil {
@@ -349,13 +527,42 @@ func (r *KeystoneServiceReconciler) reconcileNormal(
 	log := r.GetLogger(ctx)
 	log.Info("Reconciling Service")
 
+	// the service gets registered again when it moved to another KeystoneAPI,
+	// the deletion policy gets applied in the keystone of the previous one
+	switched, err := switchKeystoneAPI(ctx, helper, instance, instance.Status.KeystoneAPI, keystoneAPI,
+		func(previousAPI *keystonev1.KeystoneAPI) error {
+			if instance.Status.ServiceID == "" {
+				return nil
+			}
+			previousOS, _, err := keystonev1.GetAdminServiceClient(ctx, helper, previousAPI)
+			if err != nil {
+				return err
+			}
+			return r.applyDeletionPolicy(ctx, instance, previousOS)
+		})
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if switched {
+		log.Info(fmt.Sprintf("Moving the service from KeystoneAPI %s to %s", instance.Status.KeystoneAPI, keystoneAPI.Name))
+		instance.Status.ServiceID = ""
+		instance.Status.Service = nil
+		instance.Status.ServiceHash = ""
+		instance.Status.PasswordHash = ""
+		instance.Status.RoleAssignments = nil
+		instance.Status.LegacyRoleAssignmentsRevoked = false
+		instance.Status.AdoptedServiceID = ""
+		instance.Status.AdoptedUserID = ""
+	}
+	instance.Status.KeystoneAPI = client.ObjectKeyFromObject(keystoneAPI).String()
+
 	//
 	// Add a finalizer to the KeystoneAPI for this service instance, as we do not want the
 	// KeystoneAPI to disappear before this service in the case where this service is deleted
 	// (so that we can properly remove the service and user from the Keystone database on the
 	// OpenStack side)
 	//
-	if controllerutil.AddFinalizer(keystoneAPI, fmt.Sprintf("%s-%s", helper.GetFinalizer(), instance.Name)) {
+	if controllerutil.AddFinalizer(keystoneAPI, keystoneAPIFinalizer(helper, instance, keystoneAPI)) {
 		err := r.Update(ctx, keystoneAPI)
 
 		if err != nil {
@@ -366,7 +573,7 @@ func (r *KeystoneServiceReconciler) reconcileNormal(
 	//
 	// Create new service if ServiceID is not already set
 	//
-	err := r.reconcileService(ctx, instance, os)
+	drift, err := r.reconcileService(ctx, instance, os)
 	if err != nil {
 		instance.Status.Conditions.Set(condition.FalseCondition(
 			keystonev1.KeystoneServiceOSServiceReadyCondition,
@@ -382,6 +589,7 @@ func (r *KeystoneServiceReconciler) reconcileNormal(
 		instance.Spec.ServiceName,
 		instance.Status.ServiceID,
 	)
+	setCatalogInSyncCondition(&instance.Status.Conditions, instance.Spec.DriftPolicy, drift)
 
 	//
 	// create/update service user
@@ -414,16 +622,91 @@ func (r *KeystoneServiceReconciler) reconcileNormal(
 	)
 
 	log.Info("Reconciled Service successfully")
-	return ctrl.Result{}, nil
+	// compare the registered service against the spec again after the
+	// resync interval, it might get changed or deleted outside of the operator
+	return catalogResyncResult(instance.Spec.ResyncInterval), nil
 }
 
+// reconcileService - creates or updates the service of the spec. A service
+// registered before gets compared against the spec, changes which were not
+// made in the spec are returned as drift and only get corrected with the
+// Correct drift policy.
 func (r *KeystoneServiceReconciler) reconcileService(
 	ctx context.Context,
 	instance *keystonev1.KeystoneService,
 	os *openstack.OpenStack,
-) error {
+) ([]string, error) {
 	log := r.GetLogger(ctx)
 	log.Info("Reconciling Service ", "KeystoneService", instance.Spec.ServiceName)
+	drift := []string{}
+
+	specHash, err := serviceSpecHash(instance.Spec)
+	if err != nil {
+		return drift, err
+	}
+
+	//
+	// take over the service to adopt instead of looking it up by type and name
+	//
+	if adoptID := instance.GetAdoptServiceID(); adoptID != "" && instance.Status.AdoptedServiceID != adoptID {
+		service, err := getService(ctx, os.GetOSClient(), adoptID)
+		if err != nil {
+			return drift, err
+		}
+		if service == nil {
+			return drift, fmt.Errorf("%w: service %s", ErrAdoptNotFound, adoptID)
+		}
+		instance.Status.ServiceID = service.ID
+		instance.Status.AdoptedServiceID = service.ID
+		// differences of the adopted service get changed to match the spec
+		// and are no drift
+		instance.Status.ServiceHash = ""
+		log.Info("Adopted service", "KeystoneService", instance.Spec.ServiceName, "ServiceID", service.ID)
+		r.EventRecorder.Event(
+			instance,
+			corev1.EventTypeNormal,
+			"ServiceAdopted",
+			fmt.Sprintf("Adopted service %s as %s", service.ID, instance.Spec.ServiceName),
+		)
+	}
+
+	if instance.Status.ServiceID != "" {
+		//
+		// compare the registered service against the spec
+		//
+		service, err := getService(ctx, os.GetOSClient(), instance.Status.ServiceID)
+		if err != nil {
+			return drift, err
+		}
+
+		diff := serviceDrift(service, instance.Spec)
+		if len(diff) > 0 {
+			// differences after a change of the spec are no drift
+			if instance.Status.ServiceHash == specHash {
+				drift = append(drift, fmt.Sprintf("service %s", strings.Join(diff, ", ")))
+				if instance.Spec.DriftPolicy == keystonev1.DriftPolicyReport {
+					log.Info(fmt.Sprintf("Service %s drifted: %s", instance.Spec.ServiceName, strings.Join(diff, ", ")))
+					instance.Status.Service = observedService(service)
+					return drift, nil
+				}
+			}
+
+			if service != nil {
+				service, err = updateService(ctx, os.GetOSClient(), instance.Spec, service.ID)
+				if err != nil {
+					return drift, err
+				}
+				log.Info(fmt.Sprintf("Service %s updated: %s", instance.Spec.ServiceName, strings.Join(diff, ", ")))
+			}
+		}
+
+		if service != nil {
+			instance.Status.Service = observedService(service)
+			instance.Status.ServiceHash = specHash
+			log.Info("Reconciled Service successfully")
+			return drift, nil
+		}
+	}
 
 	// verify if there is already a service in keystone for the type and name
 	service, err := os.GetService(
@@ -435,50 +718,110 @@ func (r *KeystoneServiceReconciler) reconcileService(
 	// If the service is not found, don't count that as an error here,
 	// it gets created below
 	if err != nil && !strings.Contains(err.Error(), openstack.ServiceNotFound) {
-		return err
+		return drift, err
 	}
 
 	if service == nil {
 		// create the service
-		instance.Status.ServiceID, err = os.CreateService(
-			ctx,
-			log,
-			openstack.Service{
-				Name:        instance.Spec.ServiceName,
-				Type:        instance.Spec.ServiceType,
-				Description: instance.Spec.ServiceDescription,
-				Enabled:     instance.Spec.Enabled,
-			})
+		service, err = createService(ctx, os.GetOSClient(), instance.Spec)
 		if err != nil {
-			return err
+			return drift, err
 		}
-	} else {
+		log.Info(fmt.Sprintf("Service %s created - %s", instance.Spec.ServiceName, service.ID))
+	} else if len(serviceDrift(service, instance.Spec)) > 0 {
 		// During adoption there are services in the keystone DB but the
 		// KeystoneService CR is fresh so we have to propagate the service ID
-		// from the DB to the KeystoneService CR.
-		instance.Status.ServiceID = service.ID
+		// from the DB to the KeystoneService CR, and update the service ONLY
+		// if it differs from the spec.
+		service, err = updateService(ctx, os.GetOSClient(), instance.Spec, service.ID)
+		if err != nil {
+			return drift, err
+		}
+	}
+	instance.Status.ServiceID = service.ID
+	instance.Status.Service = observedService(service)
+	instance.Status.ServiceHash = specHash
 
-		if service.Enabled != instance.Spec.Enabled ||
-			service.Extra["description"] != instance.Spec.ServiceDescription {
-			// update the service ONLY if Enabled or Description changed.
-			err := os.UpdateService(
-				ctx,
-				log,
-				openstack.Service{
-					Name:        instance.Spec.ServiceName,
-					Type:        instance.Spec.ServiceType,
-					Description: instance.Spec.ServiceDescription,
-					Enabled:     instance.Spec.Enabled,
-				},
-				service.ID)
-			if err != nil {
-				return err
+	log.Info("Reconciled Service successfully")
+	return drift, nil
+}
+
+// adoptUser - verifies the user to adopt exists and renames it to the
+// ServiceUser, returns its ID
+func (r *KeystoneServiceReconciler) adoptUser(
+	ctx context.Context,
+	instance *keystonev1.KeystoneService,
+	os *openstack.OpenStack,
+	adoptID string,
+) (string, error) {
+	log := r.GetLogger(ctx)
+
+	user, err := users.Get(ctx, os.GetOSClient(), adoptID).Extract()
+	if err != nil {
+		if gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+			return "", fmt.Errorf("%w: user %s", ErrAdoptNotFound, adoptID)
+		}
+		return "", err
+	}
+
+	if user.Name != instance.Spec.ServiceUser {
+		_, err = users.Update(ctx, os.GetOSClient(), user.ID, users.UpdateOpts{
+			Name: instance.Spec.ServiceUser,
+		}).Extract()
+		if err != nil {
+			return "", err
+		}
+		log.Info("Renamed adopted user", "User", user.Name, "ServiceUser", instance.Spec.ServiceUser)
+	}
+
+	if instance.Status.AdoptedUserID != user.ID {
+		instance.Status.AdoptedUserID = user.ID
+		// the password of the adopted user is not known, set the one of the
+		// secret
+		instance.Status.PasswordHash = ""
+		log.Info("Adopted user", "ServiceUser", instance.Spec.ServiceUser, "UserID", user.ID)
+		r.EventRecorder.Event(
+			instance,
+			corev1.EventTypeNormal,
+			"UserAdopted",
+			fmt.Sprintf("Adopted user %s as %s", user.ID, instance.Spec.ServiceUser),
+		)
+	}
+
+	return user.ID, nil
+}
+
+// getLegacyRoleAssignments - returns the role assignments an earlier version
+// made for the user, the legacyServiceRoles on the service project of the
+// default domain
+func (r *KeystoneServiceReconciler) getLegacyRoleAssignments(
+	ctx context.Context,
+	os *openstack.OpenStack,
+	userID string,
+) ([]keystonev1.KeystoneServiceRoleAssignment, error) {
+	log := r.GetLogger(ctx)
+
+	project, err := os.GetProject(ctx, log, "service", "default")
+	if err != nil {
+		if strings.Contains(err.Error(), openstack.ProjectNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	roleIDs := map[string]string{}
+	for _, roleName := range legacyServiceRoles {
+		role, err := os.GetRole(ctx, log, roleName)
+		if err != nil {
+			if strings.Contains(err.Error(), openstack.RoleNotFound) {
+				continue
 			}
+			return nil, err
 		}
+		roleIDs[roleName] = role.ID
 	}
 
-	log.Info("Reconciled Service successfully")
-	return nil
+	return legacyRoleAssignments(userID, project.ID, roleIDs), nil
 }
 
 func (r *KeystoneServiceReconciler) reconcileUser(
@@ -489,7 +832,6 @@ func (r *KeystoneServiceReconciler) reconcileUser(
 ) (reconcile.Result, error) {
 	log := r.GetLogger(ctx)
 	log.Info("Reconciling User", "User", instance.Spec.ServiceUser)
-	roleNames := []string{"admin", "service"}
 
 	// get the password of the service user from the secret
 	password, ctrlResult, err := secret.GetDataFromSecret(
@@ -505,6 +847,11 @@ func (r *KeystoneServiceReconciler) reconcileUser(
 		return ctrlResult, nil
 	}
 
+	domainID, err := getDomainID(ctx, os, instance.Spec.GetDomainName())
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
 	//
 	// create service project if it does not exist
 	//
@@ -512,55 +859,125 @@ func (r *KeystoneServiceReconciler) reconcileUser(
 		ctx,
 		log,
 		openstack.Project{
-			Name:        "service",
-			Description: "service",
-			DomainID:    "default",
+			Name:        instance.Spec.GetProjectName(),
+			Description: instance.Spec.GetProjectName(),
+			DomainID:    domainID,
 		})
 	if err != nil {
 		return ctrl.Result{}, err
 	}
 
 	//
-	// create user if it does not exist
+	// take over the user to adopt, or create user if it does not exist
 	//
-	userID, err := os.CreateUser(
-		ctx,
-		log,
-		openstack.User{
-			Name:      instance.Spec.ServiceUser,
-			Password:  password,
-			ProjectID: serviceProjectID,
-			DomainID:  "default",
-		})
+	var userID string
+	if adoptID := instance.GetAdoptUserID(); adoptID != "" {
+		userID, err = r.adoptUser(ctx, instance, os, adoptID)
+	} else {
+		userID, err = os.CreateUser(
+			ctx,
+			log,
+			openstack.User{
+				Name:      instance.Spec.ServiceUser,
+				Password:  password,
+				ProjectID: serviceProjectID,
+				DomainID:  domainID,
+			})
+	}
 	if err != nil {
 		return ctrl.Result{}, err
 	}
 
-	for _, roleName := range roleNames {
+	//
+	// update the password of the user when the secret changed, the user
+	// keeps its password when it exists already
+	//
+	passwordHash, err := hashPassword(password, instance.UID)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if instance.Status.PasswordHash != passwordHash {
+		log.Info("Updating password", "User", instance.Spec.ServiceUser)
+		_, err = users.Update(ctx, os.GetOSClient(), userID, users.UpdateOpts{
+			Password: password,
+		}).Extract()
+		if err != nil {
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				keystonev1.KeystoneServicePasswordSyncedCondition,
+				condition.ErrorReason,
+				condition.SeverityWarning,
+				keystonev1.KeystoneServicePasswordSyncedErrorMessage,
+				err.Error()))
+			return ctrl.Result{}, err
+		}
+		instance.Status.PasswordHash = passwordHash
+	}
+	instance.Status.Conditions.MarkTrue(
+		keystonev1.KeystoneServicePasswordSyncedCondition,
+		keystonev1.KeystoneServicePasswordSyncedMessage)
+
+	assignments := []keystonev1.KeystoneServiceRoleAssignment{}
+	addAssignment := func(roleName string, projectID string, system string) error {
 		//
 		// create role if it does not exist
 		//
-		_, err = os.CreateRole(
+		roleID, err := os.CreateRole(
 			ctx,
 			log,
 			roleName)
 		if err != nil {
+			return err
+		}
+		assignments = append(assignments, keystonev1.KeystoneServiceRoleAssignment{
+			RoleName:  roleName,
+			RoleID:    roleID,
+			UserID:    userID,
+			ProjectID: projectID,
+			System:    system,
+		})
+		return nil
+	}
+	for _, roleName := range instance.Spec.Roles {
+		if err := addAssignment(roleName, serviceProjectID, ""); err != nil {
 			return ctrl.Result{}, err
 		}
+	}
+	for _, roleName := range instance.Spec.SystemRoles {
+		if err := addAssignment(roleName, "", keystonev1.SystemScopeAll); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
 
-		//
-		// add the role to the user
-		//
-		err = os.AssignUserRole(
-			ctx,
-			log,
-			roleName,
-			userID,
-			serviceProjectID)
+	//
+	// revoke the roles removed from the spec, a user of an earlier version
+	// which did not record its roles has the legacy ones
+	//
+	previous := instance.Status.RoleAssignments
+	if needsLegacyRoleAssignments(instance.Status) {
+		previous, err = r.getLegacyRoleAssignments(ctx, os, userID)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	for _, assignment := range staleRoleAssignments(previous, assignments) {
+		log.Info("Revoking role", "Role", assignment.RoleName, "User", instance.Spec.ServiceUser)
+		err = unassignRole(ctx, os, serviceRoleAssignment(assignment))
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	instance.Status.LegacyRoleAssignmentsRevoked = true
+
+	//
+	// add the roles to the user
+	//
+	for _, assignment := range assignments {
+		err = assignRole(ctx, os, serviceRoleAssignment(assignment))
 		if err != nil {
 			return ctrl.Result{}, err
 		}
 	}
+	instance.Status.RoleAssignments = assignments
 
 	log.Info("Reconciled User successfully")
 	return ctrl.Result{}, nil
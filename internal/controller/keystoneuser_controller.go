@@ -0,0 +1,620 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/users"
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	helper "github.com/openstack-k8s-operators/lib-common/modules/common/helper"
+	secret "github.com/openstack-k8s-operators/lib-common/modules/common/secret"
+	util "github.com/openstack-k8s-operators/lib-common/modules/common/util"
+
+	openstack "github.com/openstack-k8s-operators/lib-common/modules/openstack"
+	corev1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// GetClient -
+func (r *KeystoneUserReconciler) GetClient() client.Client {
+	return r.Client
+}
+
+// GetKClient -
+func (r *KeystoneUserReconciler) GetKClient() kubernetes.Interface {
+	return r.Kclient
+}
+
+// GetScheme -
+func (r *KeystoneUserReconciler) GetScheme() *runtime.Scheme {
+	return r.Scheme
+}
+
+// KeystoneUserReconciler reconciles a KeystoneUser object
+type KeystoneUserReconciler struct {
+	client.Client
+	Kclient kubernetes.Interface
+	Scheme  *runtime.Scheme
+}
+
+// GetLogger returns a logger object with a logging prefix of "controller.name" and additional controller context fields
+func (r *KeystoneUserReconciler) GetLogger(ctx context.Context) logr.Logger {
+	return log.FromContext(ctx).WithName("Controllers").WithName("KeystoneUser")
+}
+
+// +kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneusers,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneusers/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneusers/finalizers,verbs=update;patch
+// +kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneapis,verbs=get;list;update;patch
+// +kubebuilder:rbac:groups=keystone.openstack.org,resources=keystoneapis/finalizers,verbs=update;patch
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;update;patch;delete;
+
+// Reconcile keystone user requests
+func (r *KeystoneUserReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, _err error) {
+	log := r.GetLogger(ctx)
+
+	// Fetch the KeystoneUser instance
+	instance := &keystonev1.KeystoneUser{}
+	err := r.Get(ctx, req.NamespacedName, instance)
+	if err != nil {
+		if k8s_errors.IsNotFound(err) {
+			// Request object not found, could have been deleted after reconcile request.
+			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
+			// Return and don't requeue
+			return ctrl.Result{}, nil
+		}
+		// Error reading the object - requeue the request.
+		return ctrl.Result{}, err
+	}
+
+	helper, err := helper.NewHelper(
+		instance,
+		r.Client,
+		r.Kclient,
+		r.Scheme,
+		log,
+	)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// Always patch the instance status when exiting this function so we can persist any changes.
+	defer func() {
+		// Don't update the status, if Reconciler Panics
+		if r := recover(); r != nil {
+			log.Info(fmt.Sprintf("Panic during reconcile %v\n", r))
+			panic(r)
+		}
+		// update the Ready condition based on the sub conditions
+		if instance.Status.Conditions.AllSubConditionIsTrue() {
+			instance.Status.Conditions.MarkTrue(
+				condition.ReadyCondition, condition.ReadyMessage)
+		} else {
+			// something is not ready so reset the Ready condition
+			instance.Status.Conditions.MarkUnknown(
+				condition.ReadyCondition, condition.InitReason, condition.ReadyInitMessage)
+			// and recalculate it based on the state of the rest of the conditions
+			instance.Status.Conditions.Set(
+				instance.Status.Conditions.Mirror(condition.ReadyCondition))
+		}
+		err := helper.PatchInstance(ctx, instance)
+		if err != nil {
+			_err = err
+			return
+		}
+	}()
+
+	//
+	// initialize status
+	//
+	if instance.Status.Conditions == nil {
+		instance.Status.Conditions = condition.Conditions{}
+		cl := condition.CreateList(
+			condition.UnknownCondition(condition.InputReadyCondition, condition.InitReason, condition.InputReadyInitMessage),
+			condition.UnknownCondition(keystonev1.KeystoneAPIReadyCondition, condition.InitReason, keystonev1.KeystoneAPIReadyInitMessage),
+			condition.UnknownCondition(keystonev1.AdminServiceClientReadyCondition, condition.InitReason, keystonev1.AdminServiceClientReadyInitMessage),
+			condition.UnknownCondition(keystonev1.KeystoneUserOSUserReadyCondition, condition.InitReason, keystonev1.KeystoneUserOSUserReadyInitMessage))
+		instance.Status.Conditions.Init(&cl)
+
+		// Register overall status immediately to have an early feedback e.g. in the cli
+		return ctrl.Result{}, nil
+	}
+	instance.Status.ObservedGeneration = instance.Generation
+
+	// If we're not deleting this and the user object doesn't have our finalizer, add it.
+	if instance.DeletionTimestamp.IsZero() && controllerutil.AddFinalizer(instance, helper.GetFinalizer()) {
+		return ctrl.Result{}, err
+	}
+
+	//
+	// Validate that keystoneAPI is up
+	//
+	keystoneAPI, err := keystonev1.GetKeystoneAPIForObject(ctx, helper, instance)
+	if err != nil {
+		if k8s_errors.IsNotFound(err) {
+			// There is nothing to clean up on the OpenStack side if the user
+			// never got registered, don't wait for a KeystoneAPI to appear.
+			if !instance.DeletionTimestamp.IsZero() && instance.Status.UserID == "" {
+				return r.reconcileDelete(ctx, instance, helper, nil, nil)
+			}
+
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				keystonev1.KeystoneAPIReadyCondition,
+				condition.ErrorReason,
+				condition.SeverityWarning,
+				keystonev1.KeystoneAPIReadyNotFoundMessage,
+			))
+			log.Info("KeystoneAPI not found!")
+			return ctrl.Result{RequeueAfter: time.Second * 5}, nil
+		}
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneAPIReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.KeystoneAPIReadyErrorMessage,
+			err.Error()))
+		return ctrl.Result{}, err
+	}
+
+	// If both the user and the KeystoneAPI are deleted we can skip the
+	// cleanup on the OpenStack side, the DB is going away as well.
+	if !instance.DeletionTimestamp.IsZero() && !keystoneAPI.DeletionTimestamp.IsZero() {
+		return r.reconcileDeleteFinalizersOnly(ctx, instance, helper, keystoneAPI)
+	}
+
+	if !instance.DeletionTimestamp.IsZero() && instance.Status.UserID == "" {
+		return r.reconcileDelete(ctx, instance, helper, nil, keystoneAPI)
+	}
+
+	if !keystoneAPI.IsReady() {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneAPIReadyCondition,
+			condition.RequestedReason,
+			condition.SeverityInfo,
+			keystonev1.KeystoneAPIReadyWaitingMessage))
+		log.Info("KeystoneAPI not yet ready")
+		return ctrl.Result{RequeueAfter: time.Second * 5}, nil
+	}
+	instance.Status.Conditions.MarkTrue(keystonev1.KeystoneAPIReadyCondition, keystonev1.KeystoneAPIReadyMessage)
+
+	//
+	// get admin authentication OpenStack
+	//
+	os, ctrlResult, err := keystonev1.GetAdminServiceClient(
+		ctx,
+		helper,
+		keystoneAPI,
+	)
+	if err != nil {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.AdminServiceClientReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.AdminServiceClientReadyErrorMessage,
+			err.Error()))
+		return ctrl.Result{}, err
+	}
+	if (ctrlResult != ctrl.Result{}) {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.AdminServiceClientReadyCondition,
+			condition.RequestedReason,
+			condition.SeverityInfo,
+			keystonev1.AdminServiceClientReadyWaitingMessage))
+		return ctrlResult, nil
+	}
+	instance.Status.Conditions.MarkTrue(keystonev1.AdminServiceClientReadyCondition, keystonev1.AdminServiceClientReadyMessage)
+
+	// Handle normal user delete
+	if !instance.DeletionTimestamp.IsZero() {
+		return r.reconcileDelete(ctx, instance, helper, os, keystoneAPI)
+	}
+
+	// Handle non-deleted users
+	return r.reconcileNormal(ctx, instance, helper, os, keystoneAPI)
+}
+
+// userPasswordSecretField - KeystoneUser field to index the password secret
+const userPasswordSecretField = ".spec.secret" // #nosec G101
+
+// SetupWithManager -
+func (r *KeystoneUserReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// index userPasswordSecretField
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &keystonev1.KeystoneUser{}, userPasswordSecretField, func(rawObj client.Object) []string {
+		// Extract the secret name from the spec, if one is provided
+		cr := rawObj.(*keystonev1.KeystoneUser)
+		if cr.Spec.Secret == "" {
+			return nil
+		}
+		return []string{cr.Spec.Secret}
+	}); err != nil {
+		return err
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&keystonev1.KeystoneUser{}).
+		Owns(&corev1.Secret{}).
+		Watches(
+			&corev1.Secret{},
+			handler.EnqueueRequestsFromMapFunc(r.findObjectsForSecret),
+			builder.WithPredicates(predicate.ResourceVersionChangedPredicate{}),
+		).
+		Complete(r)
+}
+
+func (r *KeystoneUserReconciler) findObjectsForSecret(ctx context.Context, src client.Object) []reconcile.Request {
+	requests := []reconcile.Request{}
+
+	Log := r.GetLogger(ctx)
+
+	crList := &keystonev1.KeystoneUserList{}
+	listOps := &client.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector(userPasswordSecretField, src.GetName()),
+		Namespace:     src.GetNamespace(),
+	}
+	err := r.List(ctx, crList, listOps)
+	if err != nil {
+		Log.Error(err, fmt.Sprintf("listing %s for field: %s - %s", crList.GroupVersionKind().Kind, userPasswordSecretField, src.GetNamespace()))
+		return requests
+	}
+
+	for _, item := range crList.Items {
+		Log.Info(fmt.Sprintf("input source %s changed, reconcile: %s - %s", src.GetName(), item.GetName(), item.GetNamespace()))
+
+		requests = append(requests,
+			reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      item.GetName(),
+					Namespace: item.GetNamespace(),
+				},
+			},
+		)
+	}
+
+	return requests
+}
+
+func (r *KeystoneUserReconciler) reconcileDelete(
+	ctx context.Context,
+	instance *keystonev1.KeystoneUser,
+	helper *helper.Helper,
+	os *openstack.OpenStack,
+	keystoneAPI *keystonev1.KeystoneAPI,
+) (ctrl.Result, error) {
+	log := r.GetLogger(ctx)
+	log.Info("Reconciling User delete")
+
+	// only cleanup the user if there is the UserID reference in the
+	// object status and if we have an OpenStack backend to use
+	if instance.Status.UserID != "" && os != nil {
+		switch {
+		case instance.Status.Adopted:
+			log.Info("Not deleting adopted user", "KeystoneUser", instance.Spec.UserName)
+		case instance.Spec.DeletionPolicy == keystonev1.DeletionPolicyRetain:
+			log.Info("Retaining user as requested by the deletion policy", "KeystoneUser", instance.Spec.UserName)
+		default:
+			err := users.Delete(ctx, os.GetOSClient(), instance.Status.UserID).ExtractErr()
+			if err != nil && !gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+				return ctrl.Result{}, err
+			}
+			log.Info("Deleted user", "KeystoneUser", instance.Spec.UserName)
+		}
+
+		// Clear the user ID so that any potential requeues after this reconcile
+		// will know that there is nothing left on the OpenStack side
+		instance.Status.UserID = ""
+	} else {
+		log.Info("Not deleting user as there is no stored user ID", "KeystoneUser", instance.Spec.UserName)
+	}
+
+	// There are certain deletion scenarios where we might not have the keystoneAPI
+	if keystoneAPI != nil {
+		// Remove the finalizer for this user from the KeystoneAPI
+		if controllerutil.RemoveFinalizer(keystoneAPI, fmt.Sprintf("%s-%s", helper.GetFinalizer(), instance.Name)) {
+			err := r.Update(ctx, keystoneAPI)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+	}
+
+	// User is deleted so remove the finalizer.
+	controllerutil.RemoveFinalizer(instance, helper.GetFinalizer())
+	log.Info("Reconciled User delete successfully")
+
+	return ctrl.Result{}, nil
+}
+
+func (r *KeystoneUserReconciler) reconcileDeleteFinalizersOnly(
+	ctx context.Context,
+	instance *keystonev1.KeystoneUser,
+	helper *helper.Helper,
+	keystoneAPI *keystonev1.KeystoneAPI,
+) (ctrl.Result, error) {
+	log := r.GetLogger(ctx)
+	log.Info("Reconciling User delete while KeystoneAPI is being deleted")
+
+	if controllerutil.RemoveFinalizer(keystoneAPI, fmt.Sprintf("%s-%s", helper.GetFinalizer(), instance.Name)) {
+		err := r.Update(ctx, keystoneAPI)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	controllerutil.RemoveFinalizer(instance, helper.GetFinalizer())
+	log.Info("Reconciled User delete successfully")
+
+	return ctrl.Result{}, nil
+}
+
+func (r *KeystoneUserReconciler) reconcileNormal(
+	ctx context.Context,
+	instance *keystonev1.KeystoneUser,
+	helper *helper.Helper,
+	os *openstack.OpenStack,
+	keystoneAPI *keystonev1.KeystoneAPI,
+) (ctrl.Result, error) {
+	log := r.GetLogger(ctx)
+	log.Info("Reconciling User")
+
+	//
+	// Add a finalizer to the KeystoneAPI for this user instance, so that the
+	// user can be removed from keystone before the KeystoneAPI goes away
+	//
+	if controllerutil.AddFinalizer(keystoneAPI, fmt.Sprintf("%s-%s", helper.GetFinalizer(), instance.Name)) {
+		err := r.Update(ctx, keystoneAPI)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	//
+	// get the password of the user, generate one if no Secret is referenced
+	//
+	password, ctrlResult, err := r.getPassword(ctx, instance, helper)
+	if err != nil {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			condition.InputReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			condition.InputReadyErrorMessage,
+			err.Error()))
+		return ctrl.Result{}, err
+	}
+	if (ctrlResult != ctrl.Result{}) {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			condition.InputReadyCondition,
+			condition.RequestedReason,
+			condition.SeverityInfo,
+			condition.InputReadyWaitingMessage))
+		return ctrlResult, nil
+	}
+	instance.Status.PasswordSecret = instance.GetPasswordSecretName()
+	instance.Status.Conditions.MarkTrue(condition.InputReadyCondition, condition.InputReadyMessage)
+
+	//
+	// create or update the user
+	//
+	err = r.reconcileUser(ctx, instance, os, password)
+	if err != nil {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			keystonev1.KeystoneUserOSUserReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			keystonev1.KeystoneUserOSUserReadyErrorMessage,
+			err.Error()))
+		return ctrl.Result{}, err
+	}
+	instance.Status.Conditions.MarkTrue(
+		keystonev1.KeystoneUserOSUserReadyCondition,
+		keystonev1.KeystoneUserOSUserReadyMessage,
+		instance.Spec.UserName,
+		instance.Status.UserID,
+	)
+
+	log.Info("Reconciled User successfully")
+	return ctrl.Result{}, nil
+}
+
+// getPassword - returns the password from the referenced Secret. If no
+// Secret is referenced, a Secret owned by the KeystoneUser with a generated
+// password gets created once and is reused afterwards.
+func (r *KeystoneUserReconciler) getPassword(
+	ctx context.Context,
+	instance *keystonev1.KeystoneUser,
+	helper *helper.Helper,
+) (string, ctrl.Result, error) {
+	if instance.Spec.Secret != "" {
+		return secret.GetDataFromSecret(
+			ctx,
+			helper,
+			instance.Spec.Secret,
+			10*time.Second,
+			instance.Spec.PasswordSelector)
+	}
+
+	secretName := instance.GetPasswordSecretName()
+	passwordSecret, _, err := secret.GetSecret(ctx, helper, secretName, instance.Namespace)
+	if err == nil {
+		password, ok := passwordSecret.Data[instance.Spec.PasswordSelector]
+		if !ok {
+			return "", ctrl.Result{}, fmt.Errorf("%w: %s not found in Secret %s",
+				util.ErrFieldNotFound, instance.Spec.PasswordSelector, secretName)
+		}
+		return string(password), ctrl.Result{}, nil
+	}
+	if !k8s_errors.IsNotFound(err) {
+		return "", ctrl.Result{}, err
+	}
+
+	password, err := util.GeneratePassword(32)
+	if err != nil {
+		return "", ctrl.Result{}, err
+	}
+	passwordSecret = &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      secretName,
+			Namespace: instance.Namespace,
+		},
+		Data: map[string][]byte{
+			instance.Spec.PasswordSelector: []byte(password),
+		},
+	}
+	err = controllerutil.SetControllerReference(instance, passwordSecret, helper.GetScheme())
+	if err != nil {
+		return "", ctrl.Result{}, err
+	}
+	err = helper.GetClient().Create(ctx, passwordSecret)
+	if err != nil {
+		return "", ctrl.Result{}, err
+	}
+	r.GetLogger(ctx).Info("Generated password Secret", "Secret", secretName)
+
+	return password, ctrl.Result{}, nil
+}
+
+func (r *KeystoneUserReconciler) reconcileUser(
+	ctx context.Context,
+	instance *keystonev1.KeystoneUser,
+	os *openstack.OpenStack,
+	password string,
+) error {
+	log := r.GetLogger(ctx)
+	log.Info("Reconciling User", "KeystoneUser", instance.Spec.UserName)
+
+	domainID, err := getDomainID(ctx, os, instance.Spec.DomainName)
+	if err != nil {
+		return err
+	}
+	instance.Status.DomainID = domainID
+
+	defaultProjectID := ""
+	if instance.Spec.DefaultProjectName != "" {
+		project, err := os.GetProject(ctx, log, instance.Spec.DefaultProjectName, domainID)
+		if err != nil {
+			return err
+		}
+		defaultProjectID = project.ID
+	}
+	instance.Status.DefaultProjectID = defaultProjectID
+
+	passwordHash, err := hashPassword(password, instance.UID)
+	if err != nil {
+		return err
+	}
+
+	var user *users.User
+	if instance.Status.UserID != "" {
+		user, err = users.Get(ctx, os.GetOSClient(), instance.Status.UserID).Extract()
+		if err != nil && !gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+			return err
+		}
+	}
+
+	if user == nil {
+		user, err = os.GetUser(ctx, log, instance.Spec.UserName, domainID)
+		switch {
+		case err == nil:
+			// the user was not created by this KeystoneUser, only take it over
+			// if requested
+			if !instance.Spec.Adopt {
+				return fmt.Errorf("user %s %w", instance.Spec.UserName, ErrNotAdopted)
+			}
+			log.Info("Adopting existing user", "KeystoneUser", instance.Spec.UserName, "UserID", user.ID)
+			instance.Status.Adopted = true
+		case strings.Contains(err.Error(), openstack.UserNotFound):
+			userID, err := os.CreateUser(
+				ctx,
+				log,
+				openstack.User{
+					Name:      instance.Spec.UserName,
+					Password:  password,
+					ProjectID: defaultProjectID,
+					DomainID:  domainID,
+				})
+			if err != nil {
+				return err
+			}
+			err = persistCreatedID(ctx, r.Client, instance, func(created *keystonev1.KeystoneUser) {
+				created.Status.UserID = userID
+				created.Status.Adopted = false
+			})
+			if err != nil {
+				return err
+			}
+
+			user, err = users.Get(ctx, os.GetOSClient(), userID).Extract()
+			if err != nil {
+				return err
+			}
+			instance.Status.Adopted = false
+		default:
+			return err
+		}
+		// the password of an adopted user is not known, reset it with the one
+		// from the Secret
+		instance.Status.PasswordHash = ""
+	}
+	instance.Status.UserID = user.ID
+
+	updateOpts := users.UpdateOpts{}
+	needsUpdate := false
+	if user.Name != instance.Spec.UserName ||
+		user.Enabled != instance.Spec.Enabled ||
+		user.Description != instance.Spec.Description ||
+		user.DefaultProjectID != defaultProjectID {
+		updateOpts.Name = instance.Spec.UserName
+		updateOpts.Description = &instance.Spec.Description
+		updateOpts.Enabled = &instance.Spec.Enabled
+		updateOpts.DefaultProjectID = defaultProjectID
+		needsUpdate = true
+	}
+	if instance.Status.PasswordHash != passwordHash {
+		updateOpts.Password = password
+		needsUpdate = true
+	}
+
+	if needsUpdate {
+		_, err = users.Update(ctx, os.GetOSClient(), user.ID, updateOpts).Extract()
+		if err != nil {
+			return err
+		}
+	}
+	instance.Status.PasswordHash = passwordHash
+
+	log.Info("Reconciled User successfully")
+	return nil
+}
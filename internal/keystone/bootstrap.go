@@ -97,7 +97,7 @@ func BootstrapJob(
 
 	job := &batchv1.Job{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      ServiceName + "-bootstrap",
+			Name:      ResourceName(instance) + "-bootstrap",
 			Namespace: instance.Namespace,
 			Labels:    labels,
 		},
@@ -112,7 +112,7 @@ func BootstrapJob(
 					Containers: []corev1.Container{
 						{
 							Name:  ServiceName + "-bootstrap",
-							Image: instance.Spec.ContainerImage,
+							Image: ContainerImage(instance),
 							Command: []string{
 								"/bin/bash",
 							},
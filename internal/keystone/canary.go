@@ -0,0 +1,82 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keystone
+
+import (
+	"fmt"
+
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	common "github.com/openstack-k8s-operators/lib-common/modules/common"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+const (
+	// CanaryAppSelector - app label of the canary pods, so the keystone
+	// services do not select them
+	CanaryAppSelector = ServiceName + "-canary"
+)
+
+// CanaryName - name of the canary Deployment of the KeystoneAPI instance
+func CanaryName(instance *keystonev1.KeystoneAPI) string {
+	return fmt.Sprintf("%s-canary", ResourceName(instance))
+}
+
+// CanaryDeployment - returns the canary of the keystone Deployment, a single
+// replica the keystone services do not send traffic to
+func CanaryDeployment(instance *keystonev1.KeystoneAPI, deployment *appsv1.Deployment) *appsv1.Deployment {
+	canary := deployment.DeepCopy()
+	canary.Name = CanaryName(instance)
+	canary.Spec.Replicas = ptr.To[int32](1)
+
+	canaryLabels := map[string]string{}
+	for k, v := range deployment.Spec.Selector.MatchLabels {
+		canaryLabels[k] = v
+	}
+	canaryLabels[common.AppSelector] = CanaryAppSelector
+	canary.Spec.Selector = &metav1.LabelSelector{
+		MatchLabels: canaryLabels,
+	}
+	for k, v := range canaryLabels {
+		canary.Spec.Template.Labels[k] = v
+	}
+
+	return canary
+}
+
+// PromotedConfigSecretName - name of the Secret holding the copy of the
+// config the keystone Deployment runs with, when the Canary rollout strategy
+// is used
+func PromotedConfigSecretName(instance *keystonev1.KeystoneAPI) string {
+	return fmt.Sprintf("%s-promoted-config-data", instance.Name)
+}
+
+// PinDeployment - sets the image of the keystone container and the Secret
+// holding the config of the keystone Deployment
+func PinDeployment(deployment *appsv1.Deployment, image string, configSecretName string) {
+	for i, container := range deployment.Spec.Template.Spec.Containers {
+		if container.Name == ServiceName+"-api" {
+			deployment.Spec.Template.Spec.Containers[i].Image = image
+		}
+	}
+	for i, volume := range deployment.Spec.Template.Spec.Volumes {
+		if volume.Name == "config-data" && volume.Secret != nil {
+			deployment.Spec.Template.Spec.Volumes[i].Secret.SecretName = configSecretName
+		}
+	}
+}
@@ -16,7 +16,20 @@ limitations under the License.
 
 package keystone
 
-import "fmt"
+import (
+	"fmt"
+
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+)
+
+const (
+	// OpenStackConfigName - name of the ConfigMap with the clouds.yaml of
+	// the KeystoneAPI named ServiceName, the one the openstackclient uses
+	OpenStackConfigName = "openstack-config"
+	// OpenStackConfigSecretName - name of the Secret with the secure.yaml of
+	// the KeystoneAPI named ServiceName
+	OpenStackConfigSecretName = "openstack-config-secret"
+)
 
 // OpenStackConfig type
 type OpenStackConfig struct {
@@ -45,6 +58,26 @@ type OpenStackConfigSecret struct {
 	}
 }
 
+// GetOpenStackConfigName - name of the clouds.yaml ConfigMap of the
+// KeystoneAPI instance. The KeystoneAPI whose resources are named after
+// ServiceName keeps the name the openstackclient expects, others get it
+// prefixed with their name.
+func GetOpenStackConfigName(instance *keystonev1.KeystoneAPI) string {
+	if ResourceName(instance) == ServiceName {
+		return OpenStackConfigName
+	}
+	return fmt.Sprintf("%s-%s", instance.Name, OpenStackConfigName)
+}
+
+// GetOpenStackConfigSecretName - name of the secure.yaml Secret of the
+// KeystoneAPI instance, see GetOpenStackConfigName
+func GetOpenStackConfigSecretName(instance *keystonev1.KeystoneAPI) string {
+	if ResourceName(instance) == ServiceName {
+		return OpenStackConfigSecretName
+	}
+	return fmt.Sprintf("%s-%s", instance.Name, OpenStackConfigSecretName)
+}
+
 // GenerateCloudrc generates file contents of a cloudrc file for the clients
 // until there is parity with openstackclient.
 func GenerateCloudrc(secret *OpenStackConfigSecret, config *OpenStackConfig) string {
@@ -22,10 +22,6 @@ import (
 const (
 	// ServiceName -
 	ServiceName = "keystone"
-	// DatabaseName -
-	DatabaseName = "keystone"
-	// DatabaseCRName -
-	DatabaseCRName = "keystone"
 	// DatabaseUsernamePrefix -
 	DatabaseUsernamePrefix = "keystone"
 	// KeystonePublicPort -
@@ -35,12 +31,20 @@ const (
 	// KeystoneUID is based on kolla
 	// https://github.com/openstack/kolla/blob/master/kolla/common/users.py
 	KeystoneUID int64 = 42425
-	// DefaultFernetMaxActiveKeys -
-	DefaultFernetMaxActiveKeys = 5
 	// DefaultFernetRotationDays -
 	DefaultFernetRotationDays = 1
+	// DefaultReceiptMaxActiveKeys -
+	DefaultReceiptMaxActiveKeys = 3
+	// DefaultReceiptRotationDays -
+	DefaultReceiptRotationDays = 1
 	// DBSyncCommand -
 	DBSyncCommand = "keystone-manage db_sync"
+	// CredentialMigrateCommand - re-encrypts the credentials with the primary credential key
+	CredentialMigrateCommand = "keystone-manage credential_migrate"
+	// UpgradeCheckCommand - checks the upgrade readiness, warnings (exit code 1) do not fail the check
+	UpgradeCheckCommand = "keystone-status upgrade check || [ $? -eq 1 ]"
+	// DoctorCommand - checks the deployment for common issues
+	DoctorCommand = "keystone-manage doctor"
 	// Keystone is the global ServiceType
 	Keystone storage.PropagationType = "Keystone"
 	// KeystoneCronJob is the CronJob ServiceType
@@ -49,8 +53,6 @@ const (
 	KeystoneBootstrap storage.PropagationType = "KeystoneBootstrap"
 	// FederationConfigKey - key for multi-realm federation config secret
 	FederationConfigKey = "federation-config.json"
-	// FederationMultiRealmSecret - secret to store processed multirealm data
-	FederationMultiRealmSecret = "keystone-multirealm-federation-secret"
 	// FederationDefaultMountPath - if user doesn't specify otherwise, this location is used
 	FederationDefaultMountPath = "/var/lib/config-data/default/multirealm-federation"
 )
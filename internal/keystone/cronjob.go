@@ -84,7 +84,7 @@ func CronJob(
 
 	cronjob := &batchv1.CronJob{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      ServiceName + "-cron",
+			Name:      ResourceName(instance) + "-cron",
 			Namespace: instance.Namespace,
 		},
 		Spec: batchv1.CronJobSpec{
@@ -104,7 +104,7 @@ func CronJob(
 							Containers: []corev1.Container{
 								{
 									Name:            ServiceName + "-cron",
-									Image:           instance.Spec.ContainerImage,
+									Image:           ContainerImage(instance),
 									Command:         cmd,
 									Env:             env.MergeEnvs([]corev1.EnvVar{}, envVars),
 									VolumeMounts:    volumeMounts,
@@ -16,6 +16,9 @@ limitations under the License.
 package keystone
 
 import (
+	"fmt"
+	"strings"
+
 	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
 
 	"github.com/openstack-k8s-operators/lib-common/modules/common/env"
@@ -25,14 +28,58 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// DatabaseCRName - name of the MariaDBDatabase CR of the KeystoneAPI instance
+func DatabaseCRName(instance *keystonev1.KeystoneAPI) string {
+	return ResourceName(instance)
+}
+
+// DatabaseName - name of the database of the KeystoneAPI instance, the name
+// used in CREATE DATABASE in mariadb
+func DatabaseName(instance *keystonev1.KeystoneAPI) string {
+	return strings.ReplaceAll(ResourceName(instance), "-", "_")
+}
+
 // DbSyncJob func
 func DbSyncJob(
 	instance *keystonev1.KeystoneAPI,
 	labels map[string]string,
 	annotations map[string]string,
 ) *batchv1.Job {
+	return dbSyncJob(instance, labels, annotations, ResourceName(instance)+"-db-sync", DBSyncCommand)
+}
+
+// DbUpgradeJob - runs the expand, migrate or contract phase of a rolling
+// upgrade of the database with the image the service gets upgraded to
+func DbUpgradeJob(
+	instance *keystonev1.KeystoneAPI,
+	labels map[string]string,
+	annotations map[string]string,
+	phase keystonev1.DatabaseUpgradePhase,
+) *batchv1.Job {
+	name := fmt.Sprintf("%s-db-%s", ResourceName(instance), strings.ToLower(string(phase)))
+	command := fmt.Sprintf("%s --%s", DBSyncCommand, strings.ToLower(string(phase)))
+	return dbSyncJob(instance, labels, annotations, name, command)
+}
+
+// ContainerImage - returns the image the service runs with. During an upgrade
+// of the database this is the image the upgrade was started for, a later
+// change of the image only gets rolled out once the upgrade completed.
+func ContainerImage(instance *keystonev1.KeystoneAPI) string {
+	if instance.IsDatabaseUpgradeInProgress() {
+		return instance.Status.DatabaseUpgrade.TargetImage
+	}
+	return instance.Spec.ContainerImage
+}
+
+func dbSyncJob(
+	instance *keystonev1.KeystoneAPI,
+	labels map[string]string,
+	annotations map[string]string,
+	name string,
+	command string,
+) *batchv1.Job {
 
-	args := []string{"-c", DBSyncCommand}
+	args := []string{"-c", command}
 
 	envVars := map[string]env.Setter{}
 	envVars["KOLLA_CONFIG_STRATEGY"] = env.SetValue("COPY_ALWAYS")
@@ -51,7 +98,7 @@ func DbSyncJob(
 
 	job := &batchv1.Job{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      ServiceName + "-db-sync",
+			Name:      name,
 			Namespace: instance.Namespace,
 			Labels:    labels,
 		},
@@ -65,12 +112,12 @@ func DbSyncJob(
 					ServiceAccountName: instance.RbacResourceName(),
 					Containers: []corev1.Container{
 						{
-							Name: ServiceName + "-db-sync",
+							Name: name,
 							Command: []string{
 								"/bin/bash",
 							},
 							Args:            args,
-							Image:           instance.Spec.ContainerImage,
+							Image:           ContainerImage(instance),
 							SecurityContext: dbSyncSecurityContext(),
 							Env:             env.MergeEnvs([]corev1.EnvVar{}, envVars),
 							VolumeMounts:    volumeMounts,
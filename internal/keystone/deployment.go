@@ -44,6 +44,7 @@ func Deployment(
 	annotations map[string]string,
 	topology *topologyv1.Topology,
 	federationFilenames []string,
+	domainFilenames []string,
 	memcached *memcachedv1.Memcached,
 ) (*appsv1.Deployment, error) {
 
@@ -106,10 +107,20 @@ func Deployment(
 			MountPath: FederationDefaultMountPath,
 		})
 
-		volumes = append(volumes, getFederationVolumes(federationFilenames)...)
+		volumes = append(volumes, getFederationVolumes(instance, federationFilenames)...)
 		volumeMounts = append(volumeMounts, getFederationVolumeMounts(FederationDefaultMountPath, federationFilenames)...)
 	}
 
+	// add domain specific config volumes and volume mounts
+	volumes = append(volumes, getDomainVolumes(instance, domainFilenames)...)
+	volumeMounts = append(volumeMounts, getDomainVolumeMounts(domainFilenames)...)
+
+	// add the JWS key repositories
+	if instance.Spec.TokenProvider == keystonev1.TokenProviderJWS {
+		volumes = append(volumes, getJWSVolume(instance))
+		volumeMounts = append(volumeMounts, getJWSVolumeMount())
+	}
+
 	// add MTLS cert if defined
 	if memcached.GetMemcachedMTLSSecret() != "" {
 		volumes = append(volumes, memcached.CreateMTLSVolume())
@@ -137,7 +148,7 @@ func Deployment(
 
 	deployment := &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      ServiceName,
+			Name:      ResourceName(instance),
 			Namespace: instance.Namespace,
 		},
 		Spec: appsv1.DeploymentSpec{
@@ -152,6 +163,7 @@ func Deployment(
 				},
 				Spec: corev1.PodSpec{
 					ServiceAccountName: instance.RbacResourceName(),
+					SecurityContext:    domainPodSecurityContext(domainFilenames),
 					Volumes:            volumes,
 					Containers: []corev1.Container{
 						{
@@ -160,7 +172,7 @@ func Deployment(
 								"/bin/bash",
 							},
 							Args:            args,
-							Image:           instance.Spec.ContainerImage,
+							Image:           ContainerImage(instance),
 							SecurityContext: httpdSecurityContext(),
 							Env:             env.MergeEnvs([]corev1.EnvVar{}, envVars),
 							VolumeMounts:    volumeMounts,
@@ -195,3 +207,13 @@ func Deployment(
 
 	return deployment, nil
 }
+
+// DeploymentImage - returns the image of the keystone container of the Deployment
+func DeploymentImage(deployment *appsv1.Deployment) string {
+	for _, container := range deployment.Spec.Template.Spec.Containers {
+		if container.Name == ServiceName+"-api" {
+			return container.Image
+		}
+	}
+	return ""
+}
@@ -0,0 +1,120 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keystone
+
+import (
+	"fmt"
+
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/util"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+)
+
+const (
+	// DomainConfigMountPath - directory keystone reads the domain specific configs from
+	DomainConfigMountPath = "/etc/keystone/domains"
+	// domainLDAPTemplate - template used to render the domain specific LDAP config
+	domainLDAPTemplate = "keystoneapi/domain/ldap.conf"
+)
+
+// DomainConfigFileName - returns the filename keystone expects for the
+// domain specific config of domainName
+func DomainConfigFileName(domainName string) string {
+	return fmt.Sprintf("keystone.%s.conf", domainName)
+}
+
+// RenderDomainLDAPConfig - renders the domain specific config for an LDAP
+// backed domain using the bind password read from the referenced Secret
+func RenderDomainLDAPConfig(
+	ldap *keystonev1.KeystoneDomainLDAP,
+	password string,
+) (string, error) {
+	return util.ExecuteTemplateFile(domainLDAPTemplate, struct {
+		keystonev1.KeystoneDomainLDAP
+		Password string
+	}{
+		KeystoneDomainLDAP: *ldap,
+		Password:           password,
+	})
+}
+
+// getDomainVolumes - get the domain specific config volume, the files are
+// stored in the %s-config-data secret. They hold the LDAP bind passwords and
+// are only readable by the keystone group, see domainPodSecurityContext.
+func getDomainVolumes(
+	instance *keystonev1.KeystoneAPI,
+	domainFilenames []string,
+) []corev1.Volume {
+	var config0640AccessMode int32 = 0640
+
+	if len(domainFilenames) == 0 {
+		return []corev1.Volume{}
+	}
+
+	items := []corev1.KeyToPath{}
+	for _, filename := range domainFilenames {
+		items = append(items, corev1.KeyToPath{
+			Key:  filename,
+			Path: filename,
+		})
+	}
+
+	return []corev1.Volume{
+		{
+			Name: "domain-config",
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					DefaultMode: &config0640AccessMode,
+					SecretName:  instance.Name + "-config-data",
+					Items:       items,
+				},
+			},
+		},
+	}
+}
+
+// getDomainVolumeMounts - get the domain specific config mountpoint
+func getDomainVolumeMounts(
+	domainFilenames []string,
+) []corev1.VolumeMount {
+	if len(domainFilenames) == 0 {
+		return []corev1.VolumeMount{}
+	}
+
+	return []corev1.VolumeMount{
+		{
+			Name:      "domain-config",
+			MountPath: DomainConfigMountPath,
+			ReadOnly:  true,
+		},
+	}
+}
+
+// domainPodSecurityContext - the domain specific configs get mounted with the
+// keystone group as fsGroup, so the keystone-api container can read them
+// without them being world-readable
+func domainPodSecurityContext(
+	domainFilenames []string,
+) *corev1.PodSecurityContext {
+	if len(domainFilenames) == 0 {
+		return nil
+	}
+
+	return &corev1.PodSecurityContext{
+		FSGroup: ptr.To(KeystoneUID), // keystone group
+	}
+}
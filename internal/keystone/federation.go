@@ -16,12 +16,137 @@ limitations under the License.
 package keystone
 
 import (
+	"fmt"
 	"path/filepath"
 	"strconv"
+	"strings"
 
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/util"
 	corev1 "k8s.io/api/core/v1"
 )
 
+const (
+	// FederationFilesPath - directory kolla copies the federation_* files of
+	// the config-data secret to, e.g. the SAML keys and metadata
+	FederationFilesPath = "/etc/httpd/federation"
+	// federationOIDCTemplate - template used to render the mod_auth_openidc config
+	federationOIDCTemplate = "keystoneapi/federation/oidc.conf"
+	// federationSAMLTemplate - template used to render the mod_auth_mellon config
+	federationSAMLTemplate = "keystoneapi/federation/saml.conf"
+	// OIDCRemoteIDAttribute - variable mod_auth_openidc passes the issuer in
+	OIDCRemoteIDAttribute = "HTTP_OIDC_ISS"
+	// SAMLRemoteIDAttribute - variable mod_auth_mellon passes the entityID of the IdP in
+	SAMLRemoteIDAttribute = "MELLON_IDP"
+)
+
+// DefaultAuthMethods - keystone default [auth] methods, the federation
+// protocols get appended
+var DefaultAuthMethods = []string{
+	"external", "password", "token", "oauth1", "mapped", "application_credential",
+}
+
+// FederationConfig - config generated from the KeystoneIdentityProvider and
+// KeystoneProtocol CRs
+type FederationConfig struct {
+	// Files - files added to the config-data secret, keyed by file name
+	Files map[string]string
+	// RemoteIDAttributes - remote_id_attribute per protocol
+	RemoteIDAttributes map[string]string
+}
+
+// FederationHttpdConfigFileName - returns the name of the httpd config of
+// the identity provider, which gets included in the public vhost
+func FederationHttpdConfigFileName(idpID string) string {
+	return fmt.Sprintf("httpd_custom_%s_federation_%s.conf", "public", idpID)
+}
+
+// federationFileName - returns the name of a file of the identity provider
+// stored in the config-data secret
+func federationFileName(idpID string, name string) string {
+	return fmt.Sprintf("federation_%s_%s", idpID, name)
+}
+
+// RemoteIDAttribute - returns the variable the remote ID of the identity
+// provider is passed in by the httpd auth module
+func RemoteIDAttribute(idp *keystonev1.KeystoneIdentityProvider) string {
+	if idp.Spec.SAML != nil {
+		return SAMLRemoteIDAttribute
+	}
+	return OIDCRemoteIDAttribute
+}
+
+// RenderFederationConfig - renders the mod_auth_openidc or mod_auth_mellon
+// config of the identity provider for the given protocols. The client
+// secrets and SAML keys are read from secretData. Returns the files to add to
+// the config-data secret keyed by file name.
+func RenderFederationConfig(
+	idp *keystonev1.KeystoneIdentityProvider,
+	protocols []string,
+	keystoneEndpoint string,
+	secretData map[string][]byte,
+) (map[string]string, error) {
+	files := map[string]string{}
+	idpID := idp.Spec.IdentityProviderID
+
+	switch {
+	case idp.Spec.OIDC != nil:
+		oidc := idp.Spec.OIDC
+		conf, err := util.ExecuteTemplateFile(federationOIDCTemplate, struct {
+			keystonev1.KeystoneIdentityProviderOIDC
+			IdentityProviderID string
+			Protocols          []string
+			KeystoneEndpoint   string
+			Scope              string
+			ClientSecret       string
+			CryptoPassphrase   string
+		}{
+			KeystoneIdentityProviderOIDC: *oidc,
+			IdentityProviderID:           idpID,
+			Protocols:                    protocols,
+			KeystoneEndpoint:             keystoneEndpoint,
+			Scope:                        strings.Join(oidc.Scopes, " "),
+			ClientSecret:                 string(secretData[oidc.ClientSecretSelector]),
+			CryptoPassphrase:             string(secretData[oidc.CryptoPassphraseSelector]),
+		})
+		if err != nil {
+			return nil, err
+		}
+		files[FederationHttpdConfigFileName(idpID)] = conf
+
+	case idp.Spec.SAML != nil:
+		saml := idp.Spec.SAML
+		spKey := federationFileName(idpID, "sp-key.pem")
+		spCert := federationFileName(idpID, "sp-cert.pem")
+		idpMetadata := federationFileName(idpID, "idp-metadata.xml")
+		files[spKey] = string(secretData[saml.SPPrivateKeySelector])
+		files[spCert] = string(secretData[saml.SPCertificateSelector])
+		files[idpMetadata] = string(secretData[saml.IdPMetadataSelector])
+
+		conf, err := util.ExecuteTemplateFile(federationSAMLTemplate, struct {
+			keystonev1.KeystoneIdentityProviderSAML
+			IdentityProviderID string
+			Protocols          []string
+			SPPrivateKeyFile   string
+			SPCertificateFile  string
+			IdPMetadataFile    string
+		}{
+			KeystoneIdentityProviderSAML: *saml,
+			IdentityProviderID:           idpID,
+			Protocols:                    protocols,
+			SPPrivateKeyFile:             filepath.Join(FederationFilesPath, spKey),
+			SPCertificateFile:            filepath.Join(FederationFilesPath, spCert),
+			IdPMetadataFile:              filepath.Join(FederationFilesPath, idpMetadata),
+		})
+		if err != nil {
+			return nil, err
+		}
+		files[FederationHttpdConfigFileName(idpID)] = conf
+	}
+
+	return files, nil
+}
+
 // getFederationVolumeMounts - get federation mountpoints
 func getFederationVolumeMounts(
 	federationMountPath string,
@@ -42,8 +167,15 @@ func getFederationVolumeMounts(
 	return vms
 }
 
+// FederationMultiRealmSecretName - name of the secret to store the processed
+// multirealm data of the KeystoneAPI instance
+func FederationMultiRealmSecretName(instance *keystonev1.KeystoneAPI) string {
+	return fmt.Sprintf("%s-multirealm-federation-secret", ResourceName(instance))
+}
+
 // getFederationVolumes - get federation volumes
 func getFederationVolumes(
+	instance *keystonev1.KeystoneAPI,
 	federationFilenames []string,
 ) []corev1.Volume {
 	var config0640AccessMode int32 = 0644
@@ -55,7 +187,7 @@ func getFederationVolumes(
 			VolumeSource: corev1.VolumeSource{
 				Secret: &corev1.SecretVolumeSource{
 					DefaultMode: &config0640AccessMode,
-					SecretName:  FederationMultiRealmSecret,
+					SecretName:  FederationMultiRealmSecretName(instance),
 				},
 			},
 		}
@@ -17,11 +17,45 @@ package keystone
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/go-logr/logr"
 )
 
+const (
+	// FernetKeysPath - key repository the fernet keys are mounted to
+	FernetKeysPath = "/etc/keystone/fernet-keys"
+	// CredentialKeysPath - key repository the credential keys are mounted to
+	CredentialKeysPath = "/etc/keystone/credential-keys"
+	// FernetKeysPrefix - prefix of the fernet keys in the keystone secret
+	FernetKeysPrefix = "FernetKeys"
+	// CredentialKeysPrefix - prefix of the credential keys in the keystone secret
+	CredentialKeysPrefix = "CredentialKeys"
+	// ReceiptKeysPath - key repository the fernet receipt keys are mounted to
+	ReceiptKeysPath = "/etc/keystone/receipt-keys"
+	// ReceiptKeysPrefix - prefix of the fernet receipt keys in the keystone secret
+	ReceiptKeysPrefix = "ReceiptKeys"
+)
+
+// ErrInvalidKeyRepository - the keys to import are not a valid keystone-manage
+// key repository
+var ErrInvalidKeyRepository = errors.New("invalid key repository")
+
+// KeyRepositoryChecksumCommand - returns the command printing the sha256
+// checksums of the keys in a key repository of a keystone pod, in the format
+// parsed by ParseKeyRepositoryChecksums
+func KeyRepositoryChecksumCommand(path string) []string {
+	return []string{"/bin/sh", "-c", fmt.Sprintf("cd %s && sha256sum [0-9]*", path)}
+}
+
 // GenerateFernetKey - returns a base64-encoded, 32-byte key using cryptographically secure random generation
 func GenerateFernetKey(logger logr.Logger) string {
 	data := make([]byte, 32)
@@ -33,3 +67,159 @@ func GenerateFernetKey(logger logr.Logger) string {
 
 	return base64.StdEncoding.EncodeToString(data)
 }
+
+// KeyRepositoryChecksums - returns the sha256 checksums of the keys stored
+// as <prefix><index> in the keystone secret, keyed by the file name they are
+// mounted as in the key repository of the keystone pods
+func KeyRepositoryChecksums(data map[string][]byte, prefix string) map[string]string {
+	checksums := map[string]string{}
+	for key, value := range data {
+		index, found := strings.CutPrefix(key, prefix)
+		if !found {
+			continue
+		}
+		if _, err := strconv.Atoi(index); err != nil {
+			continue
+		}
+		sum := sha256.Sum256(value)
+		checksums[index] = hex.EncodeToString(sum[:])
+	}
+	return checksums
+}
+
+// ParseKeyRepositoryChecksums - parses the sha256sum output of
+// KeyRepositoryChecksumCommand into checksums keyed by file name
+func ParseKeyRepositoryChecksums(out string) map[string]string {
+	checksums := map[string]string{}
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		checksums[fields[1]] = fields[0]
+	}
+	return checksums
+}
+
+// ImportKeyRepository - converts a key repository in the keystone-manage
+// directory layout, the file names 0, 1, ... as keys, into <prefix><index>
+// keys of the keystone secret. The key 0 stays the staged key and the order of
+// the others is kept, so the primary key with the highest file name becomes
+// the last key. keystone-manage does not reuse file names, so they can have
+// gaps.
+func ImportKeyRepository(data map[string][]byte, prefix string, minKeys int, maxKeys int) (map[string]string, error) {
+	files := map[int]string{}
+	for name, value := range data {
+		index, err := strconv.Atoi(name)
+		if err != nil || index < 0 {
+			return nil, fmt.Errorf("%w: unexpected file name %s", ErrInvalidKeyRepository, name)
+		}
+		if _, exists := files[index]; exists {
+			return nil, fmt.Errorf("%w: duplicate file name %s", ErrInvalidKeyRepository, name)
+		}
+		key, err := base64.URLEncoding.DecodeString(strings.TrimSpace(string(value)))
+		if err != nil || len(key) != 32 {
+			return nil, fmt.Errorf("%w: key %s is not a 32-byte url-safe base64 encoded key", ErrInvalidKeyRepository, name)
+		}
+		files[index] = name
+	}
+
+	if len(files) < minKeys || len(files) > maxKeys {
+		return nil, fmt.Errorf("%w: got %d keys, expected between %d and %d", ErrInvalidKeyRepository, len(files), minKeys, maxKeys)
+	}
+	indexes := slices.Sorted(maps.Keys(files))
+	if indexes[0] != 0 {
+		return nil, fmt.Errorf("%w: the staged key 0 is missing", ErrInvalidKeyRepository)
+	}
+
+	keys := map[string]string{}
+	for i, index := range indexes {
+		keys[fmt.Sprintf("%s%d", prefix, i)] = strings.TrimSpace(string(data[files[index]]))
+	}
+	return keys, nil
+}
+
+// RotateKeyRepository - rotates the keys stored as <prefix><index> in the
+// keystone secret the way keystone-manage does: the staged key 0 becomes the
+// primary key with the highest index and a new staged key gets generated. The
+// oldest secondary key gets removed by ResizeKeyRepository.
+func RotateKeyRepository(data map[string][]byte, prefix string, numberKeys int, generateKey func() []byte) {
+	data[fmt.Sprintf("%s%d", prefix, numberKeys)] = data[prefix+"0"]
+	data[prefix+"0"] = generateKey()
+}
+
+// RemoveKeyRepository - removes all keys stored as <prefix><index> in the
+// keystone secret
+func RemoveKeyRepository(data map[string][]byte, prefix string) {
+	for key := range data {
+		index, found := strings.CutPrefix(key, prefix)
+		if !found {
+			continue
+		}
+		if _, err := strconv.Atoi(index); err == nil {
+			delete(data, key)
+		}
+	}
+}
+
+// ResizeKeyRepository - removes the oldest secondary keys or adds new ones
+// until the key repository has numberKeys keys, returns true if keys changed
+func ResizeKeyRepository(data map[string][]byte, prefix string, numberKeys int, generateKey func() []byte) bool {
+	changedKeys := false
+
+	// the staged key, missing when the repository gets created
+	if _, exists := data[prefix+"0"]; !exists {
+		data[prefix+"0"] = generateKey()
+		changedKeys = true
+	}
+
+	//
+	// Remove extra keys when the number of keys decreases
+	//
+	extraKey := fmt.Sprintf("%s%d", prefix, numberKeys)
+	for {
+		_, exists := data[extraKey]
+		if !exists {
+			break
+		}
+		changedKeys = true
+		i := 1
+		for {
+			key := fmt.Sprintf("%s%d", prefix, i)
+			i++
+			nextKey := fmt.Sprintf("%s%d", prefix, i)
+			_, exists = data[nextKey]
+			if !exists {
+				break
+			}
+			data[key] = data[nextKey]
+			delete(data, nextKey)
+		}
+	}
+
+	//
+	// Add extra keys when the number of keys increases
+	//
+	lastKey := fmt.Sprintf("%s%d", prefix, numberKeys-1)
+	for {
+		_, exists := data[lastKey]
+		if exists {
+			break
+		}
+		changedKeys = true
+		i := 1
+		nextKeyValue := generateKey()
+		for {
+			key := fmt.Sprintf("%s%d", prefix, i)
+			i++
+			keyValue, exists := data[key]
+			data[key] = nextKeyValue
+			nextKeyValue = keyValue
+			if !exists {
+				break
+			}
+		}
+	}
+
+	return changedKeys
+}
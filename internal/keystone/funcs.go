@@ -1,6 +1,8 @@
 package keystone
 
 import (
+	"fmt"
+
 	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
 	"github.com/openstack-k8s-operators/lib-common/modules/common/util"
 	corev1 "k8s.io/api/core/v1"
@@ -64,3 +66,41 @@ func ComputeSecurityHash(spec keystonev1.KeystoneApplicationCredentialSpec) (str
 	}
 	return util.ObjectHash(securityFields)
 }
+
+// ResourceName - name the Deployment, the Jobs, the key Secret and the database
+// of the KeystoneAPI instance are named after, see KeystoneAPIStatus.ResourceName.
+// Instances deployed before the resources got named after the instance keep
+// the ServiceName ones.
+func ResourceName(instance *keystonev1.KeystoneAPI) string {
+	if instance.Status.ResourceName != "" {
+		return instance.Status.ResourceName
+	}
+	if len(instance.Status.Hash) > 0 {
+		return ServiceName
+	}
+	return instance.Name
+}
+
+// UniqueResourceName - ResourceName of a KeystoneAPI which did not record one
+// yet, suffixed if another KeystoneAPI of the namespace in others already
+// names its resources like that. A new KeystoneAPI named keystone next to one
+// deployed by an earlier version, which keeps the keystone names, gets
+// keystone-2.
+func UniqueResourceName(instance *keystonev1.KeystoneAPI, others []keystonev1.KeystoneAPI) string {
+	name := ResourceName(instance)
+	if instance.Status.ResourceName != "" || len(instance.Status.Hash) > 0 {
+		return name
+	}
+
+	taken := map[string]bool{}
+	for i := range others {
+		if others[i].Name != instance.Name {
+			taken[ResourceName(&others[i])] = true
+		}
+	}
+	for suffix := 2; taken[name]; suffix++ {
+		name = fmt.Sprintf("%s-%d", instance.Name, suffix)
+	}
+
+	return name
+}
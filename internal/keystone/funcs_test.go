@@ -0,0 +1,47 @@
+package keystone
+
+import (
+	"testing"
+
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func makeKeystoneAPI(name string, resourceName string, hash map[string]string) keystonev1.KeystoneAPI {
+	return keystonev1.KeystoneAPI{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "openstack"},
+		Status: keystonev1.KeystoneAPIStatus{
+			ResourceName: resourceName,
+			Hash:         hash,
+		},
+	}
+}
+
+func TestUniqueResourceName(t *testing.T) {
+	// deployed by an earlier version, keeps the keystone names
+	legacy := makeKeystoneAPI("openstack-keystone", "", map[string]string{"dbsync": "x"})
+	instance := makeKeystoneAPI("keystone", "", nil)
+
+	if name := UniqueResourceName(&instance, []keystonev1.KeystoneAPI{instance}); name != "keystone" {
+		t.Errorf("expected keystone, got %s", name)
+	}
+	if name := UniqueResourceName(&instance, []keystonev1.KeystoneAPI{legacy, instance}); name != "keystone-2" {
+		t.Errorf("expected keystone-2 next to the earlier version one, got %s", name)
+	}
+
+	// the suffixed names can be taken as well
+	recorded := makeKeystoneAPI("openstack-keystone", "keystone", nil)
+	other := makeKeystoneAPI("keystone-2", "", nil)
+	if name := UniqueResourceName(&instance, []keystonev1.KeystoneAPI{recorded, other, instance}); name != "keystone-3" {
+		t.Errorf("expected keystone-3, got %s", name)
+	}
+
+	// a recorded name and the one of an earlier version stay
+	if name := UniqueResourceName(&legacy, []keystonev1.KeystoneAPI{legacy, instance}); name != "keystone" {
+		t.Errorf("expected the earlier version one to keep keystone, got %s", name)
+	}
+	instance.Status.ResourceName = "keystone"
+	if name := UniqueResourceName(&instance, []keystonev1.KeystoneAPI{legacy, instance}); name != "keystone" {
+		t.Errorf("expected the recorded name to stay, got %s", name)
+	}
+}
@@ -0,0 +1,158 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keystone
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+)
+
+const (
+	// JWSKeysPath - the JWS key repositories get mounted to
+	JWSKeysPath = "/etc/keystone/jws-keys"
+	// JWSPrivateKeysPath - key repository holding the private key of the primary key pair
+	JWSPrivateKeysPath = JWSKeysPath + "/private"
+	// JWSPublicKeysPath - key repository holding the public keys of all key pairs
+	JWSPublicKeysPath = JWSKeysPath + "/public"
+	// JWSKeysPrefix - prefix of the JWS private keys in the keystone secret
+	JWSKeysPrefix = "JWSKeys"
+	// JWSPublicKeysPrefix - prefix of the JWS public keys in the keystone secret
+	JWSPublicKeysPrefix = "JWSPublicKeys"
+)
+
+// ErrInvalidJWSKey - the private key of a JWS key pair can not be parsed
+var ErrInvalidJWSKey = errors.New("invalid JWS private key")
+
+// GenerateJWSKey - returns a PEM encoded ES256 private key, like
+// keystone-manage create_jws_keypair creates
+func GenerateJWSKey(logger logr.Logger) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		logger.Error(err, "failed to generate JWS key pair")
+		return nil
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		logger.Error(err, "failed to encode JWS private key")
+		return nil
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+}
+
+// JWSPublicKey - returns the PEM encoded public key of a PEM encoded ES256
+// private key
+func JWSPublicKey(privateKey []byte) ([]byte, error) {
+	block, _ := pem.Decode(privateKey)
+	if block == nil {
+		return nil, fmt.Errorf("%w: no PEM data found", ErrInvalidJWSKey)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidJWSKey, err)
+	}
+	ecKey, ok := key.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: not an ECDSA key", ErrInvalidJWSKey)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&ecKey.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidJWSKey, err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), nil
+}
+
+// UpdateJWSPublicKeys - stores the public keys of the JWS key pairs in the
+// keystone secret and removes the ones of removed key pairs, returns true if
+// public keys changed
+func UpdateJWSPublicKeys(data map[string][]byte) (bool, error) {
+	changed := false
+	for i := 0; ; i++ {
+		privateKey, exists := data[fmt.Sprintf("%s%d", JWSKeysPrefix, i)]
+		publicKeyName := fmt.Sprintf("%s%d", JWSPublicKeysPrefix, i)
+		if !exists {
+			if _, exists := data[publicKeyName]; !exists {
+				return changed, nil
+			}
+			delete(data, publicKeyName)
+			changed = true
+			continue
+		}
+
+		publicKey, err := JWSPublicKey(privateKey)
+		if err != nil {
+			return false, err
+		}
+		if !bytes.Equal(data[publicKeyName], publicKey) {
+			data[publicKeyName] = publicKey
+			changed = true
+		}
+	}
+}
+
+// getJWSVolume - the JWS key repositories. Keystone loads every file of the
+// public key repository, so both repositories are sub directories of the
+// volume to hide the files the kubelet adds to the root of secret volumes.
+func getJWSVolume(instance *keystonev1.KeystoneAPI) corev1.Volume {
+	numberKeys := instance.Spec.GetFernetMaxActiveKeys()
+
+	// the key pair with the highest index is the primary one, it signs the tokens
+	items := []corev1.KeyToPath{
+		{
+			Key:  fmt.Sprintf("%s%d", JWSKeysPrefix, numberKeys-1),
+			Path: "private/private.pem",
+		},
+	}
+	for i := range numberKeys {
+		items = append(items, corev1.KeyToPath{
+			Key:  fmt.Sprintf("%s%d", JWSPublicKeysPrefix, i),
+			Path: fmt.Sprintf("public/%d", i),
+		})
+	}
+
+	return corev1.Volume{
+		Name: "jws-keys",
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{
+				SecretName: ResourceName(instance),
+				Items:      items,
+				// the keys get added by the controller once jws is selected
+				Optional: ptr.To(true),
+			},
+		},
+	}
+}
+
+// getJWSVolumeMount - the JWS key repositories
+func getJWSVolumeMount() corev1.VolumeMount {
+	return corev1.VolumeMount{
+		Name:      "jws-keys",
+		MountPath: JWSKeysPath,
+		ReadOnly:  true,
+	}
+}
@@ -0,0 +1,103 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keystone
+
+import (
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+
+	"github.com/openstack-k8s-operators/lib-common/modules/common/env"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+const (
+	// UpgradeCheckName - the keystone-status upgrade check
+	UpgradeCheckName = "upgrade-check"
+	// DoctorName - the keystone-manage doctor check
+	DoctorName = "doctor"
+)
+
+// UpgradeCheckJob - runs a pre-upgrade check with the image the service gets
+// upgraded to and the current config. A failed check is not retried, the end
+// of its output becomes the termination message of the pod.
+func UpgradeCheckJob(
+	instance *keystonev1.KeystoneAPI,
+	labels map[string]string,
+	annotations map[string]string,
+	name string,
+	command string,
+) *batchv1.Job {
+
+	args := []string{"-c", command}
+
+	envVars := map[string]env.Setter{}
+	envVars["KOLLA_CONFIG_STRATEGY"] = env.SetValue("COPY_ALWAYS")
+
+	// create Volume and VolumeMounts
+	upgradeCheckExtraMounts := []keystonev1.KeystoneExtraMounts{}
+	volumes := getVolumes(instance, upgradeCheckExtraMounts, DBSyncPropagation)
+	volumeMounts := getUpgradeCheckVolumeMounts()
+
+	// add CA cert if defined
+	if instance.Spec.TLS.CaBundleSecretName != "" {
+		volumes = append(volumes, instance.Spec.TLS.CreateVolume())
+		volumeMounts = append(volumeMounts, instance.Spec.TLS.CreateVolumeMounts(nil)...)
+	}
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ResourceName(instance) + "-" + name,
+			Namespace: instance.Namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: ptr.To[int32](0),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: annotations,
+				},
+				Spec: corev1.PodSpec{
+					RestartPolicy:      corev1.RestartPolicyNever,
+					ServiceAccountName: instance.RbacResourceName(),
+					Containers: []corev1.Container{
+						{
+							Name: ServiceName + "-" + name,
+							Command: []string{
+								"/bin/bash",
+							},
+							Args:                     args,
+							Image:                    ContainerImage(instance),
+							SecurityContext:          dbSyncSecurityContext(),
+							Env:                      env.MergeEnvs([]corev1.EnvVar{}, envVars),
+							VolumeMounts:             volumeMounts,
+							TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
+						},
+					},
+					Volumes: volumes,
+				},
+			},
+		},
+	}
+
+	if instance.Spec.NodeSelector != nil {
+		job.Spec.Template.Spec.NodeSelector = *instance.Spec.NodeSelector
+	}
+
+	return job
+}
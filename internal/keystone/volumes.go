@@ -20,6 +20,7 @@ import (
 	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
 	"github.com/openstack-k8s-operators/lib-common/modules/storage"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
 )
 
 // getVolumes - service volumes
@@ -29,11 +30,12 @@ func getVolumes(
 	svc []storage.PropagationType,
 ) []corev1.Volume {
 	name := instance.Name
+	keysName := ResourceName(instance)
 	var scriptsVolumeDefaultMode int32 = 0755
 	var config0640AccessMode int32 = 0644
 
 	fernetKeys := []corev1.KeyToPath{}
-	numberKeys := int(*instance.Spec.FernetMaxActiveKeys)
+	numberKeys := instance.Spec.GetFernetMaxActiveKeys()
 
 	for i := range numberKeys {
 		fernetKeys = append(
@@ -45,6 +47,22 @@ func getVolumes(
 		)
 	}
 
+	receiptKeys := []corev1.KeyToPath{}
+	numberReceiptKeys := DefaultReceiptMaxActiveKeys
+	if instance.Spec.ReceiptMaxActiveKeys != nil {
+		numberReceiptKeys = int(*instance.Spec.ReceiptMaxActiveKeys)
+	}
+
+	for i := range numberReceiptKeys {
+		receiptKeys = append(
+			receiptKeys,
+			corev1.KeyToPath{
+				Key:  fmt.Sprintf("%s%d", ReceiptKeysPrefix, i),
+				Path: fmt.Sprintf("%d", i),
+			},
+		)
+	}
+
 	res := []corev1.Volume{
 		{
 			Name: "scripts",
@@ -68,16 +86,27 @@ func getVolumes(
 			Name: "fernet-keys",
 			VolumeSource: corev1.VolumeSource{
 				Secret: &corev1.SecretVolumeSource{
-					SecretName: ServiceName,
+					SecretName: keysName,
 					Items:      fernetKeys,
 				},
 			},
 		},
+		{
+			Name: "receipt-keys",
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: keysName,
+					Items:      receiptKeys,
+					// the keys get added to the secret after the fernet keys
+					Optional: ptr.To(true),
+				},
+			},
+		},
 		{
 			Name: "credential-keys",
 			VolumeSource: corev1.VolumeSource{
 				Secret: &corev1.SecretVolumeSource{
-					SecretName: ServiceName,
+					SecretName: keysName,
 					Items: []corev1.KeyToPath{
 						{
 							Key:  "CredentialKeys0",
@@ -87,7 +116,14 @@ func getVolumes(
 							Key:  "CredentialKeys1",
 							Path: "1",
 						},
+						// only exists while the credentials get re-encrypted
+						// with a new primary key
+						{
+							Key:  "CredentialKeys2",
+							Path: "2",
+						},
 					},
+					Optional: ptr.To(true),
 				},
 			},
 		},
@@ -139,6 +175,11 @@ func getVolumeMounts(
 			ReadOnly:  true,
 			Name:      "credential-keys",
 		},
+		{
+			MountPath: ReceiptKeysPath,
+			ReadOnly:  true,
+			Name:      "receipt-keys",
+		},
 	}
 	for _, exv := range extraVol {
 		for _, vol := range exv.Propagate(svc) {
@@ -188,3 +229,26 @@ func getDBSyncVolumeMounts() []corev1.VolumeMount {
 		},
 	}
 }
+
+// getCredentialMigrateVolumeMounts - credential migrate job volumeMounts
+func getCredentialMigrateVolumeMounts() []corev1.VolumeMount {
+	return append(getDBSyncVolumeMounts(),
+		corev1.VolumeMount{
+			Name:      "credential-keys",
+			MountPath: CredentialKeysPath,
+			ReadOnly:  true,
+		},
+	)
+}
+
+// getUpgradeCheckVolumeMounts - pre-upgrade check job volumeMounts, doctor
+// also checks the key repositories
+func getUpgradeCheckVolumeMounts() []corev1.VolumeMount {
+	return append(getCredentialMigrateVolumeMounts(),
+		corev1.VolumeMount{
+			Name:      "fernet-keys",
+			MountPath: FernetKeysPath,
+			ReadOnly:  true,
+		},
+	)
+}
@@ -19,6 +19,7 @@ package v1beta1
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 
@@ -64,7 +65,7 @@ type KeystoneAPICustomDefaulter struct {
 var _ webhook.CustomDefaulter = &KeystoneAPICustomDefaulter{}
 
 // Default implements webhook.CustomDefaulter so a webhook will be registered for the Kind KeystoneAPI.
-func (d *KeystoneAPICustomDefaulter) Default(_ context.Context, obj runtime.Object) error {
+func (d *KeystoneAPICustomDefaulter) Default(ctx context.Context, obj runtime.Object) error {
 	keystoneapi, ok := obj.(*keystonev1beta1.KeystoneAPI)
 
 	if !ok {
@@ -75,6 +76,33 @@ func (d *KeystoneAPICustomDefaulter) Default(_ context.Context, obj runtime.Obje
 	// Call the defaulting logic from api/v1beta1
 	keystoneapi.Default()
 
+	return setTokenRevocationRequester(ctx, keystoneapi)
+}
+
+// setTokenRevocationRequester - records the user requesting a token
+// revocation, the requester of an earlier revocation can not be changed
+func setTokenRevocationRequester(ctx context.Context, keystoneapi *keystonev1beta1.KeystoneAPI) error {
+	revocation := keystoneapi.Spec.TokenRevocation
+	if revocation == nil {
+		return nil
+	}
+	req, err := admission.RequestFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	if req.OldObject.Raw != nil {
+		old := &keystonev1beta1.KeystoneAPI{}
+		if err := json.Unmarshal(req.OldObject.Raw, old); err != nil {
+			return err
+		}
+		if old.Spec.TokenRevocation != nil && old.Spec.TokenRevocation.Nonce == revocation.Nonce {
+			revocation.RequestedBy = old.Spec.TokenRevocation.RequestedBy
+			return nil
+		}
+	}
+	revocation.RequestedBy = req.UserInfo.Username
+
 	return nil
 }
 
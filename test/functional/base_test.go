@@ -19,7 +19,9 @@ package functional_test
 import (
 	"fmt"
 
-	. "github.com/onsi/gomega" //revive:disable:dot-imports
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/domains"
+	. "github.com/onsi/ginkgo/v2" //revive:disable:dot-imports
+	. "github.com/onsi/gomega"    //revive:disable:dot-imports
 
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -28,6 +30,7 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	keystone_test "github.com/openstack-k8s-operators/keystone-operator/api/test/helpers"
 	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
 	keystone_base "github.com/openstack-k8s-operators/keystone-operator/internal/keystone"
 	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
@@ -89,6 +92,25 @@ func GetKeystoneAPI(name types.NamespacedName) *keystonev1.KeystoneAPI {
 	return instance
 }
 
+// CreateExternalKeystoneAPI creates a KeystoneAPI with externalKeystoneAPI
+// enabled, which points to the fake keystone of the fixture. It allows to test
+// the controllers talking to keystone without deploying it.
+func CreateExternalKeystoneAPI(name types.NamespacedName, f *keystone_test.KeystoneAPIFixture) client.Object {
+	spec := GetDefaultKeystoneAPISpec()
+	spec["externalKeystoneAPI"] = true
+	spec["override"] = map[string]any{
+		"service": map[string]any{
+			"public": map[string]any{
+				"endpointURL": f.Endpoint(),
+			},
+			"internal": map[string]any{
+				"endpointURL": f.Endpoint(),
+			},
+		},
+	}
+	return CreateKeystoneAPI(name, spec)
+}
+
 func CreateKeystoneAPISecret(namespace string, name string) *corev1.Secret {
 	return th.CreateSecret(
 		types.NamespacedName{Namespace: namespace, Name: name},
@@ -333,3 +355,226 @@ func ACConditionGetter(name types.NamespacedName) condition.Conditions {
 	instance := GetApplicationCredential(name)
 	return instance.Status.Conditions
 }
+
+// KeystoneDomain helper functions
+
+func CreateKeystoneDomain(name types.NamespacedName, spec map[string]any) client.Object {
+	raw := map[string]any{
+		"apiVersion": "keystone.openstack.org/v1beta1",
+		"kind":       "KeystoneDomain",
+		"metadata": map[string]any{
+			"name":      name.Name,
+			"namespace": name.Namespace,
+		},
+		"spec": spec,
+	}
+	return th.CreateUnstructured(raw)
+}
+
+func GetKeystoneDomain(name types.NamespacedName) *keystonev1.KeystoneDomain {
+	instance := &keystonev1.KeystoneDomain{}
+	Eventually(func(g Gomega) {
+		g.Expect(k8sClient.Get(ctx, name, instance)).Should(Succeed())
+	}, timeout, interval).Should(Succeed())
+	return instance
+}
+
+func KeystoneDomainConditionGetter(name types.NamespacedName) condition.Conditions {
+	instance := GetKeystoneDomain(name)
+	return instance.Status.Conditions
+}
+
+// SimulateKeystoneDomainRegistered sets the domain ID in the status, like the
+// KeystoneDomain controller does after the domain got created in keystone
+func SimulateKeystoneDomainRegistered(name types.NamespacedName, domainID string) {
+	Eventually(func(g Gomega) {
+		domain := GetKeystoneDomain(name)
+		domain.Status.DomainID = domainID
+		g.Expect(k8sClient.Status().Update(ctx, domain)).To(Succeed())
+	}, timeout, interval).Should(Succeed())
+}
+
+// KeystoneIdentityProvider / KeystoneProtocol helper functions
+
+func CreateKeystoneIdentityProvider(name types.NamespacedName, spec map[string]any) client.Object {
+	raw := map[string]any{
+		"apiVersion": "keystone.openstack.org/v1beta1",
+		"kind":       "KeystoneIdentityProvider",
+		"metadata": map[string]any{
+			"name":      name.Name,
+			"namespace": name.Namespace,
+		},
+		"spec": spec,
+	}
+	return th.CreateUnstructured(raw)
+}
+
+func GetKeystoneIdentityProvider(name types.NamespacedName) *keystonev1.KeystoneIdentityProvider {
+	instance := &keystonev1.KeystoneIdentityProvider{}
+	Eventually(func(g Gomega) {
+		g.Expect(k8sClient.Get(ctx, name, instance)).Should(Succeed())
+	}, timeout, interval).Should(Succeed())
+	return instance
+}
+
+// SimulateKeystoneIdentityProviderRegistered sets the identity provider ID in
+// the status, like the KeystoneIdentityProvider controller does after the
+// identity provider got created in keystone
+func SimulateKeystoneIdentityProviderRegistered(name types.NamespacedName) {
+	Eventually(func(g Gomega) {
+		idp := GetKeystoneIdentityProvider(name)
+		idp.Status.IdentityProviderID = idp.Spec.IdentityProviderID
+		g.Expect(k8sClient.Status().Update(ctx, idp)).To(Succeed())
+	}, timeout, interval).Should(Succeed())
+}
+
+func CreateKeystoneProtocol(name types.NamespacedName, spec map[string]any) client.Object {
+	raw := map[string]any{
+		"apiVersion": "keystone.openstack.org/v1beta1",
+		"kind":       "KeystoneProtocol",
+		"metadata": map[string]any{
+			"name":      name.Name,
+			"namespace": name.Namespace,
+		},
+		"spec": spec,
+	}
+	return th.CreateUnstructured(raw)
+}
+
+func GetKeystoneProtocol(name types.NamespacedName) *keystonev1.KeystoneProtocol {
+	instance := &keystonev1.KeystoneProtocol{}
+	Eventually(func(g Gomega) {
+		g.Expect(k8sClient.Get(ctx, name, instance)).Should(Succeed())
+	}, timeout, interval).Should(Succeed())
+	return instance
+}
+
+// SimulateKeystoneProtocolRegistered sets the protocol ID in the status, like
+// the KeystoneProtocol controller does after the protocol got created in keystone
+func SimulateKeystoneProtocolRegistered(name types.NamespacedName) {
+	Eventually(func(g Gomega) {
+		protocol := GetKeystoneProtocol(name)
+		protocol.Status.ProtocolID = protocol.Spec.ProtocolID
+		g.Expect(k8sClient.Status().Update(ctx, protocol)).To(Succeed())
+	}, timeout, interval).Should(Succeed())
+}
+
+// KeystoneProject / KeystoneUser / KeystoneRoleAssignment helper functions
+
+func CreateKeystoneProject(name types.NamespacedName, spec map[string]any) client.Object {
+	raw := map[string]any{
+		"apiVersion": "keystone.openstack.org/v1beta1",
+		"kind":       "KeystoneProject",
+		"metadata": map[string]any{
+			"name":      name.Name,
+			"namespace": name.Namespace,
+		},
+		"spec": spec,
+	}
+	return th.CreateUnstructured(raw)
+}
+
+func GetKeystoneProject(name types.NamespacedName) *keystonev1.KeystoneProject {
+	instance := &keystonev1.KeystoneProject{}
+	Eventually(func(g Gomega) {
+		g.Expect(k8sClient.Get(ctx, name, instance)).Should(Succeed())
+	}, timeout, interval).Should(Succeed())
+	return instance
+}
+
+func KeystoneProjectConditionGetter(name types.NamespacedName) condition.Conditions {
+	instance := GetKeystoneProject(name)
+	return instance.Status.Conditions
+}
+
+func CreateKeystoneUser(name types.NamespacedName, spec map[string]any) client.Object {
+	raw := map[string]any{
+		"apiVersion": "keystone.openstack.org/v1beta1",
+		"kind":       "KeystoneUser",
+		"metadata": map[string]any{
+			"name":      name.Name,
+			"namespace": name.Namespace,
+		},
+		"spec": spec,
+	}
+	return th.CreateUnstructured(raw)
+}
+
+func GetKeystoneUser(name types.NamespacedName) *keystonev1.KeystoneUser {
+	instance := &keystonev1.KeystoneUser{}
+	Eventually(func(g Gomega) {
+		g.Expect(k8sClient.Get(ctx, name, instance)).Should(Succeed())
+	}, timeout, interval).Should(Succeed())
+	return instance
+}
+
+func KeystoneUserConditionGetter(name types.NamespacedName) condition.Conditions {
+	instance := GetKeystoneUser(name)
+	return instance.Status.Conditions
+}
+
+func CreateKeystoneRoleAssignment(name types.NamespacedName, spec map[string]any) client.Object {
+	raw := map[string]any{
+		"apiVersion": "keystone.openstack.org/v1beta1",
+		"kind":       "KeystoneRoleAssignment",
+		"metadata": map[string]any{
+			"name":      name.Name,
+			"namespace": name.Namespace,
+		},
+		"spec": spec,
+	}
+	return th.CreateUnstructured(raw)
+}
+
+func GetKeystoneRoleAssignment(name types.NamespacedName) *keystonev1.KeystoneRoleAssignment {
+	instance := &keystonev1.KeystoneRoleAssignment{}
+	Eventually(func(g Gomega) {
+		g.Expect(k8sClient.Get(ctx, name, instance)).Should(Succeed())
+	}, timeout, interval).Should(Succeed())
+	return instance
+}
+
+func KeystoneRoleAssignmentConditionGetter(name types.NamespacedName) condition.Conditions {
+	instance := GetKeystoneRoleAssignment(name)
+	return instance.Status.Conditions
+}
+
+func CreateKeystoneService(name types.NamespacedName, spec map[string]any) client.Object {
+	raw := map[string]any{
+		"apiVersion": "keystone.openstack.org/v1beta1",
+		"kind":       "KeystoneService",
+		"metadata": map[string]any{
+			"name":      name.Name,
+			"namespace": name.Namespace,
+		},
+		"spec": spec,
+	}
+	return th.CreateUnstructured(raw)
+}
+
+func GetKeystoneService(name types.NamespacedName) *keystonev1.KeystoneService {
+	instance := &keystonev1.KeystoneService{}
+	Eventually(func(g Gomega) {
+		g.Expect(k8sClient.Get(ctx, name, instance)).Should(Succeed())
+	}, timeout, interval).Should(Succeed())
+	return instance
+}
+
+func KeystoneServiceConditionGetter(name types.NamespacedName) condition.Conditions {
+	instance := GetKeystoneService(name)
+	return instance.Status.Conditions
+}
+
+// SetupKeystoneFixture starts a fake keystone, which knows the Default
+// domain, and a KeystoneAPI using it
+func SetupKeystoneFixture(keystoneAPIName types.NamespacedName) *keystone_test.KeystoneAPIFixture {
+	f := keystone_test.NewKeystoneAPIFixtureWithServer(logger)
+	f.Setup()
+	DeferCleanup(f.Cleanup)
+	f.Domains["Default"] = domains.Domain{ID: "default", Name: "Default", Enabled: true}
+
+	DeferCleanup(
+		k8sClient.Delete, ctx, CreateKeystoneAPISecret(keystoneAPIName.Namespace, SecretName))
+	DeferCleanup(th.DeleteInstance, CreateExternalKeystoneAPI(keystoneAPIName, f))
+	return f
+}
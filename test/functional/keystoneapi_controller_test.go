@@ -17,6 +17,7 @@ limitations under the License.
 package functional_test
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -34,6 +35,7 @@ import (
 
 	memcachedv1 "github.com/openstack-k8s-operators/infra-operator/apis/memcached/v1beta1"
 	topologyv1 "github.com/openstack-k8s-operators/infra-operator/apis/topology/v1beta1"
+	keystone_test "github.com/openstack-k8s-operators/keystone-operator/api/test/helpers"
 	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
 	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
 	mariadb_test "github.com/openstack-k8s-operators/mariadb-operator/api/test/helpers"
@@ -41,6 +43,7 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/utils/ptr"
@@ -1324,6 +1327,69 @@ var _ = Describe("Keystone controller", func() {
 		})
 	})
 
+	When("When the number of fernet keys is computed from the token lifetime", func() {
+		BeforeEach(func() {
+			spec := GetKeystoneAPISpec(0)
+			spec["tokenExpiration"] = 7200
+			spec["allowExpiredWindow"] = 86400
+			DeferCleanup(
+				k8sClient.Delete, ctx, CreateKeystoneMessageBusSecret(namespace, "rabbitmq-secret"))
+			DeferCleanup(th.DeleteInstance, CreateKeystoneAPI(keystoneAPIName, spec))
+			DeferCleanup(
+				k8sClient.Delete, ctx, CreateKeystoneAPISecret(namespace, SecretName))
+			DeferCleanup(infra.DeleteMemcached, infra.CreateMemcached(namespace, "memcached", memcachedSpec))
+			DeferCleanup(
+				mariadb.DeleteDBService,
+				mariadb.CreateDBService(
+					namespace,
+					GetKeystoneAPI(keystoneAPIName).Spec.DatabaseInstance,
+					corev1.ServiceSpec{
+						Ports: []corev1.ServicePort{{Port: 3306}},
+					},
+				),
+			)
+			mariadb.SimulateMariaDBAccountCompleted(keystoneAccountName)
+			mariadb.SimulateMariaDBDatabaseCompleted(keystoneDatabaseName)
+			infra.SimulateTransportURLReady(types.NamespacedName{
+				Name:      fmt.Sprintf("%s-keystone-transport", keystoneAPIName.Name),
+				Namespace: namespace,
+			})
+			infra.SimulateMemcachedReady(types.NamespacedName{
+				Name:      "memcached",
+				Namespace: namespace,
+			})
+			th.SimulateJobSuccess(dbSyncJobName)
+			th.SimulateJobSuccess(bootstrapJobName)
+			th.SimulateDeploymentReplicaReady(deploymentName)
+		})
+
+		It("creates the minimum number of keys", func() {
+			// (7200 + 86400) / 86400 rounded up + 2
+			Eventually(func(g Gomega) {
+				secret := th.GetSecret(types.NamespacedName{Namespace: keystoneAPIName.Namespace, Name: "keystone"})
+				numberFernetKeys := 0
+				for k := range secret.Data {
+					if strings.HasPrefix(k, "FernetKeys") {
+						numberFernetKeys++
+					}
+				}
+				g.Expect(numberFernetKeys).Should(BeNumerically("==", 4))
+
+				keystone := GetKeystoneAPI(keystoneAPIName)
+				g.Expect(keystone.Status.FernetKeys).ToNot(BeNil())
+				g.Expect(keystone.Status.FernetKeys.ActiveKeys).To(Equal(int32(4)))
+			}, timeout, interval).Should(Succeed())
+		})
+
+		It("renders the token lifetime", func() {
+			scrt := th.GetSecret(keystoneAPIConfigDataName)
+			configData := string(scrt.Data["keystone.conf"])
+			Expect(configData).Should(ContainSubstring("max_active_keys=4"))
+			Expect(configData).Should(ContainSubstring("expiration=7200"))
+			Expect(configData).Should(ContainSubstring("allow_expired_window=86400"))
+		})
+	})
+
 	When("When the fernet keys are created with FernetMaxActiveKeys as 100", func() {
 		BeforeEach(func() {
 			DeferCleanup(
@@ -1486,29 +1552,930 @@ var _ = Describe("Keystone controller", func() {
 				})
 			Expect(err).ToNot(HaveOccurred())
 
-			Eventually(func(g Gomega) {
-				secret = th.GetSecret(types.NamespacedName{Namespace: keystoneAPIName.Namespace, Name: "keystone"})
-				numberFernetKeys := 0
-				for k := range secret.Data {
-					if strings.HasPrefix(k, "FernetKeys") {
-						numberFernetKeys++
-					}
-				}
+			Eventually(func(g Gomega) {
+				secret = th.GetSecret(types.NamespacedName{Namespace: keystoneAPIName.Namespace, Name: "keystone"})
+				numberFernetKeys := 0
+				for k := range secret.Data {
+					if strings.HasPrefix(k, "FernetKeys") {
+						numberFernetKeys++
+					}
+				}
+
+				g.Expect(numberFernetKeys).Should(BeNumerically("==", 6))
+				for i := range 6 {
+					g.Expect(secret.Data["FernetKeys"+strconv.Itoa(i)]).NotTo(BeNil())
+				}
+			}, timeout, interval).Should(Succeed())
+		})
+	})
+
+	// Set rotated at to past date, triggering rotation
+	When("When the fernet token rotate", func() {
+		BeforeEach(func() {
+			DeferCleanup(
+				k8sClient.Delete, ctx, CreateKeystoneMessageBusSecret(namespace, "rabbitmq-secret"))
+			DeferCleanup(th.DeleteInstance, CreateKeystoneAPI(keystoneAPIName, GetDefaultKeystoneAPISpec()))
+			DeferCleanup(
+				k8sClient.Delete, ctx, CreateKeystoneAPISecret(namespace, SecretName))
+			DeferCleanup(infra.DeleteMemcached, infra.CreateMemcached(namespace, "memcached", memcachedSpec))
+			DeferCleanup(
+				mariadb.DeleteDBService,
+				mariadb.CreateDBService(
+					namespace,
+					GetKeystoneAPI(keystoneAPIName).Spec.DatabaseInstance,
+					corev1.ServiceSpec{
+						Ports: []corev1.ServicePort{{Port: 3306}},
+					},
+				),
+			)
+			mariadb.SimulateMariaDBAccountCompleted(keystoneAccountName)
+			mariadb.SimulateMariaDBDatabaseCompleted(keystoneDatabaseName)
+			infra.SimulateTransportURLReady(types.NamespacedName{
+				Name:      fmt.Sprintf("%s-keystone-transport", keystoneAPIName.Name),
+				Namespace: namespace,
+			})
+			infra.SimulateMemcachedReady(types.NamespacedName{
+				Name:      "memcached",
+				Namespace: namespace,
+			})
+			th.SimulateJobSuccess(dbSyncJobName)
+			th.SimulateJobSuccess(bootstrapJobName)
+			th.SimulateDeploymentReplicaReady(deploymentName)
+		})
+
+		It("rotates the fernet keys", func() {
+			keystone := GetKeystoneAPI(keystoneAPIName)
+			currentHash := keystone.Status.Hash["input"]
+
+			currentSecret := th.GetSecret(types.NamespacedName{Namespace: keystoneAPIName.Namespace, Name: "keystone"})
+			Expect(currentSecret).ToNot(BeNil())
+
+			rotatedAt, err := time.Parse(time.RFC3339, currentSecret.Annotations["keystone.openstack.org/rotatedat"])
+			Expect(err).ToNot(HaveOccurred())
+
+			// set date to yesterday
+			currentSecret.Annotations["keystone.openstack.org/rotatedat"] = rotatedAt.Add(-25 * time.Hour).Format(time.RFC3339)
+			err = k8sClient.Update(ctx, ptr.To(currentSecret), &client.UpdateOptions{})
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(func(g Gomega) {
+				keystone = GetKeystoneAPI(keystoneAPIName)
+				// With the new direct mounting approach, the input hash should NOT change
+				g.Expect(keystone.Status.Hash["input"]).To(Equal(currentHash))
+
+				updatedSecret := th.GetSecret(types.NamespacedName{Namespace: keystoneAPIName.Namespace, Name: "keystone"})
+				g.Expect(updatedSecret).ToNot(BeNil())
+
+				for i := range 4 {
+
+					// old idx 0 > new 4
+					if i == 0 {
+						oldKey := string(currentSecret.Data["FernetKeys"+strconv.Itoa(0)])
+						newKey := string(updatedSecret.Data["FernetKeys"+strconv.Itoa((4))])
+						g.Expect(oldKey).To(Equal(newKey))
+						continue
+					}
+
+					// old idx > new idx-1, except idx 1 which should be gone and not match new idx 0
+					oldKey := string(currentSecret.Data["FernetKeys"+strconv.Itoa(i)])
+					newKey := string(updatedSecret.Data["FernetKeys"+strconv.Itoa((i-1))])
+					if i == 1 {
+						g.Expect(oldKey).ToNot(Equal(newKey))
+					} else {
+						g.Expect(oldKey).To(Equal(newKey))
+					}
+				}
+			}, timeout, interval).Should(Succeed())
+
+		})
+
+		It("replaces all keys when the tokens get revoked", func() {
+			currentSecret := th.GetSecret(types.NamespacedName{Namespace: keystoneAPIName.Namespace, Name: "keystone"})
+			currentHash := GetKeystoneAPI(keystoneAPIName).Status.Hash["input"]
+
+			Eventually(func(g Gomega) {
+				keystone := GetKeystoneAPI(keystoneAPIName)
+				keystone.Spec.TokenRevocation = &keystonev1.KeystoneTokenRevocation{
+					Nonce:       "incident-1",
+					Reason:      "leaked admin token",
+					RequestedBy: "someone-else",
+				}
+				g.Expect(k8sClient.Update(ctx, keystone)).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(func(g Gomega) {
+				keystone := GetKeystoneAPI(keystoneAPIName)
+				// set by the webhook from the requesting user, not by the client
+				g.Expect(keystone.Spec.TokenRevocation.RequestedBy).ToNot(BeEmpty())
+				g.Expect(keystone.Spec.TokenRevocation.RequestedBy).ToNot(Equal("someone-else"))
+				g.Expect(keystone.Status.TokenRevocation).ToNot(BeNil())
+				g.Expect(keystone.Status.TokenRevocation.Nonce).To(Equal("incident-1"))
+				g.Expect(keystone.Status.TokenRevocation.Reason).To(Equal("leaked admin token"))
+				g.Expect(keystone.Status.TokenRevocation.RequestedBy).To(Equal(keystone.Spec.TokenRevocation.RequestedBy))
+				g.Expect(keystone.Status.TokenRevocation.RevokedAt).ToNot(BeNil())
+				// the pods get restarted with the new keys
+				g.Expect(keystone.Status.Hash["input"]).ToNot(Equal(currentHash))
+
+				updatedSecret := th.GetSecret(types.NamespacedName{Namespace: keystoneAPIName.Namespace, Name: "keystone"})
+				for i := range 5 {
+					for j := range 5 {
+						g.Expect(updatedSecret.Data["FernetKeys"+strconv.Itoa(i)]).ToNot(
+							Equal(currentSecret.Data["FernetKeys"+strconv.Itoa(j)]))
+					}
+				}
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(func(g Gomega) {
+				events := &corev1.EventList{}
+				g.Expect(k8sClient.List(ctx, events, client.InNamespace(namespace))).To(Succeed())
+				reasons := []string{}
+				for _, e := range events.Items {
+					reasons = append(reasons, e.Reason)
+				}
+				g.Expect(reasons).To(ContainElement("TokensRevoked"))
+			}, timeout, interval).Should(Succeed())
+
+			// the requester of a revocation can not be changed afterwards
+			requestedBy := GetKeystoneAPI(keystoneAPIName).Spec.TokenRevocation.RequestedBy
+			Eventually(func(g Gomega) {
+				keystone := GetKeystoneAPI(keystoneAPIName)
+				keystone.Spec.TokenRevocation.RequestedBy = "someone-else"
+				g.Expect(k8sClient.Update(ctx, keystone)).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+			keystone := GetKeystoneAPI(keystoneAPIName)
+			Expect(keystone.Spec.TokenRevocation.RequestedBy).To(Equal(requestedBy))
+			Expect(keystone.Status.TokenRevocation.RequestedBy).To(Equal(requestedBy))
+		})
+
+		It("rotates the receipt keys", func() {
+			th.ExpectCondition(
+				keystoneAPIName,
+				ConditionGetterFunc(KeystoneConditionGetter),
+				keystonev1.KeystoneAPIReceiptKeysReadyCondition,
+				corev1.ConditionTrue,
+			)
+			currentSecret := th.GetSecret(types.NamespacedName{Namespace: keystoneAPIName.Namespace, Name: "keystone"})
+			for i := range 3 {
+				Expect(currentSecret.Data).To(HaveKey("ReceiptKeys" + strconv.Itoa(i)))
+			}
+
+			rotatedAt, err := time.Parse(time.RFC3339, currentSecret.Annotations["keystone.openstack.org/receiptrotatedat"])
+			Expect(err).ToNot(HaveOccurred())
+			currentSecret.Annotations["keystone.openstack.org/receiptrotatedat"] = rotatedAt.Add(-25 * time.Hour).Format(time.RFC3339)
+			Expect(k8sClient.Update(ctx, ptr.To(currentSecret), &client.UpdateOptions{})).To(Succeed())
+
+			Eventually(func(g Gomega) {
+				updatedSecret := th.GetSecret(types.NamespacedName{Namespace: keystoneAPIName.Namespace, Name: "keystone"})
+				g.Expect(updatedSecret.Data["ReceiptKeys2"]).To(Equal(currentSecret.Data["ReceiptKeys0"]))
+				g.Expect(updatedSecret.Data["ReceiptKeys1"]).To(Equal(currentSecret.Data["ReceiptKeys2"]))
+				// the fernet token keys do not rotate with them
+				g.Expect(updatedSecret.Data["FernetKeys0"]).To(Equal(currentSecret.Data["FernetKeys0"]))
+
+				keystone := GetKeystoneAPI(keystoneAPIName)
+				g.Expect(keystone.Status.ReceiptKeys).ToNot(BeNil())
+				g.Expect(keystone.Status.ReceiptKeys.ActiveKeys).To(Equal(int32(3)))
+			}, timeout, interval).Should(Succeed())
+
+			scrt := th.GetSecret(keystoneAPIConfigDataName)
+			Expect(string(scrt.Data["keystone.conf"])).Should(
+				ContainSubstring("[fernet_receipts]\nkey_repository=/etc/keystone/receipt-keys\nmax_active_keys=3"))
+		})
+
+		It("holds back the rotation until all ready pods observed the current keys", func() {
+			// the key checksums of this pod can not be read, as there is no
+			// kubelet in envtest
+			podName := types.NamespacedName{Namespace: namespace, Name: "keystone-pod"}
+			keystonePod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      podName.Name,
+					Namespace: podName.Namespace,
+					Labels: map[string]string{
+						common.AppSelector:   "keystone",
+						common.OwnerSelector: keystoneAPIName.Name,
+					},
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "keystone-api", Image: "keystone"}},
+				},
+			}
+			Expect(k8sClient.Create(ctx, keystonePod)).To(Succeed())
+			th.SimulatePodReady(podName)
+
+			currentSecret := th.GetSecret(types.NamespacedName{Namespace: keystoneAPIName.Namespace, Name: "keystone"})
+			rotatedAt, err := time.Parse(time.RFC3339, currentSecret.Annotations["keystone.openstack.org/rotatedat"])
+			Expect(err).ToNot(HaveOccurred())
+			currentSecret.Annotations["keystone.openstack.org/rotatedat"] = rotatedAt.Add(-25 * time.Hour).Format(time.RFC3339)
+			Expect(k8sClient.Update(ctx, ptr.To(currentSecret), &client.UpdateOptions{})).To(Succeed())
+
+			th.ExpectConditionWithDetails(
+				keystoneAPIName,
+				ConditionGetterFunc(KeystoneConditionGetter),
+				keystonev1.KeystoneAPIFernetKeysReadyCondition,
+				corev1.ConditionFalse,
+				condition.RequestedReason,
+				"Fernet key rotation held back, pods keystone-pod did not yet observe the current keys",
+			)
+			updatedSecret := th.GetSecret(types.NamespacedName{Namespace: keystoneAPIName.Namespace, Name: "keystone"})
+			Expect(updatedSecret.Data["FernetKeys0"]).To(Equal(currentSecret.Data["FernetKeys0"]))
+
+			// without the pod the rotation proceeds
+			Expect(k8sClient.Delete(ctx, keystonePod)).To(Succeed())
+			Eventually(func(g Gomega) {
+				updatedSecret := th.GetSecret(types.NamespacedName{Namespace: keystoneAPIName.Namespace, Name: "keystone"})
+				g.Expect(updatedSecret.Data["FernetKeys4"]).To(Equal(currentSecret.Data["FernetKeys0"]))
+			}, timeout, interval).Should(Succeed())
+			th.ExpectCondition(
+				keystoneAPIName,
+				ConditionGetterFunc(KeystoneConditionGetter),
+				keystonev1.KeystoneAPIFernetKeysReadyCondition,
+				corev1.ConditionTrue,
+			)
+		})
+
+		It("records the rotation state in the status", func() {
+			Eventually(func(g Gomega) {
+				keystone := GetKeystoneAPI(keystoneAPIName)
+				g.Expect(keystone.Status.FernetKeys).ToNot(BeNil())
+				g.Expect(keystone.Status.FernetKeys.ActiveKeys).To(Equal(int32(5)))
+				g.Expect(keystone.Status.FernetKeys.LastRotated).ToNot(BeNil())
+				g.Expect(keystone.Status.FernetKeys.NextRotation.Time).To(
+					BeTemporally("==", keystone.Status.FernetKeys.LastRotated.Add(24*time.Hour)))
+			}, timeout, interval).Should(Succeed())
+
+			th.ExpectCondition(
+				keystoneAPIName,
+				ConditionGetterFunc(KeystoneConditionGetter),
+				keystonev1.KeystoneAPIFernetKeysReadyCondition,
+				corev1.ConditionTrue,
+			)
+		})
+	})
+
+	When("existing keys are imported", func() {
+		var fernetKeys map[string][]byte
+		var credentialKeys map[string][]byte
+		BeforeEach(func() {
+			// a keystone-manage key repository after a few rotations
+			fernetKeys = map[string][]byte{
+				"0": []byte(base64.URLEncoding.EncodeToString([]byte("fernet-key-0-0123456789abcdefghi"))),
+				"3": []byte(base64.URLEncoding.EncodeToString([]byte("fernet-key-3-0123456789abcdefghi"))),
+				"4": []byte(base64.URLEncoding.EncodeToString([]byte("fernet-key-4-0123456789abcdefghi"))),
+			}
+			credentialKeys = map[string][]byte{
+				"0": []byte(base64.URLEncoding.EncodeToString([]byte("credential-key-0-0123456789abcde"))),
+				"1": []byte(base64.URLEncoding.EncodeToString([]byte("credential-key-1-0123456789abcde"))),
+			}
+			DeferCleanup(k8sClient.Delete, ctx, th.CreateSecret(
+				types.NamespacedName{Namespace: namespace, Name: "fernet-import"}, fernetKeys))
+			DeferCleanup(k8sClient.Delete, ctx, th.CreateSecret(
+				types.NamespacedName{Namespace: namespace, Name: "credential-import"}, credentialKeys))
+
+			spec := GetDefaultKeystoneAPISpec()
+			spec["keyImport"] = map[string]any{
+				"fernetKeysSecret":     "fernet-import",
+				"credentialKeysSecret": "credential-import",
+			}
+			DeferCleanup(
+				k8sClient.Delete, ctx, CreateKeystoneMessageBusSecret(namespace, "rabbitmq-secret"))
+			DeferCleanup(th.DeleteInstance, CreateKeystoneAPI(keystoneAPIName, spec))
+			DeferCleanup(
+				k8sClient.Delete, ctx, CreateKeystoneAPISecret(namespace, SecretName))
+			DeferCleanup(infra.DeleteMemcached, infra.CreateMemcached(namespace, "memcached", memcachedSpec))
+			DeferCleanup(
+				mariadb.DeleteDBService,
+				mariadb.CreateDBService(
+					namespace,
+					GetKeystoneAPI(keystoneAPIName).Spec.DatabaseInstance,
+					corev1.ServiceSpec{
+						Ports: []corev1.ServicePort{{Port: 3306}},
+					},
+				),
+			)
+			mariadb.SimulateMariaDBAccountCompleted(keystoneAccountName)
+			mariadb.SimulateMariaDBDatabaseCompleted(keystoneDatabaseName)
+			infra.SimulateTransportURLReady(types.NamespacedName{
+				Name:      fmt.Sprintf("%s-keystone-transport", keystoneAPIName.Name),
+				Namespace: namespace,
+			})
+			infra.SimulateMemcachedReady(types.NamespacedName{
+				Name:      "memcached",
+				Namespace: namespace,
+			})
+		})
+
+		It("converts the key repositories into the keystone secret", func() {
+			Eventually(func(g Gomega) {
+				secret := th.GetSecret(types.NamespacedName{Namespace: namespace, Name: "keystone"})
+				// the staged and the primary key keep their role, the
+				// missing keys get added as the oldest secondary keys
+				g.Expect(secret.Data["FernetKeys0"]).To(Equal(fernetKeys["0"]))
+				g.Expect(secret.Data["FernetKeys3"]).To(Equal(fernetKeys["3"]))
+				g.Expect(secret.Data["FernetKeys4"]).To(Equal(fernetKeys["4"]))
+				g.Expect(secret.Data).To(HaveKey("FernetKeys1"))
+				g.Expect(secret.Data).To(HaveKey("FernetKeys2"))
+				g.Expect(secret.Data["CredentialKeys0"]).To(Equal(credentialKeys["0"]))
+				g.Expect(secret.Data["CredentialKeys1"]).To(Equal(credentialKeys["1"]))
+			}, timeout, interval).Should(Succeed())
+		})
+	})
+
+	When("invalid keys are imported", func() {
+		BeforeEach(func() {
+			DeferCleanup(k8sClient.Delete, ctx, th.CreateSecret(
+				types.NamespacedName{Namespace: namespace, Name: "fernet-import"},
+				map[string][]byte{
+					"0": []byte("not-a-key"),
+					"1": []byte(base64.URLEncoding.EncodeToString([]byte("fernet-key-1-0123456789abcdefghi"))),
+				}))
+
+			spec := GetDefaultKeystoneAPISpec()
+			spec["keyImport"] = map[string]any{
+				"fernetKeysSecret": "fernet-import",
+			}
+			DeferCleanup(
+				k8sClient.Delete, ctx, CreateKeystoneMessageBusSecret(namespace, "rabbitmq-secret"))
+			DeferCleanup(th.DeleteInstance, CreateKeystoneAPI(keystoneAPIName, spec))
+			DeferCleanup(
+				k8sClient.Delete, ctx, CreateKeystoneAPISecret(namespace, SecretName))
+			DeferCleanup(infra.DeleteMemcached, infra.CreateMemcached(namespace, "memcached", memcachedSpec))
+			DeferCleanup(
+				mariadb.DeleteDBService,
+				mariadb.CreateDBService(
+					namespace,
+					GetKeystoneAPI(keystoneAPIName).Spec.DatabaseInstance,
+					corev1.ServiceSpec{
+						Ports: []corev1.ServicePort{{Port: 3306}},
+					},
+				),
+			)
+			mariadb.SimulateMariaDBAccountCompleted(keystoneAccountName)
+			mariadb.SimulateMariaDBDatabaseCompleted(keystoneDatabaseName)
+			infra.SimulateTransportURLReady(types.NamespacedName{
+				Name:      fmt.Sprintf("%s-keystone-transport", keystoneAPIName.Name),
+				Namespace: namespace,
+			})
+			infra.SimulateMemcachedReady(types.NamespacedName{
+				Name:      "memcached",
+				Namespace: namespace,
+			})
+		})
+
+		It("does not create the keystone secret", func() {
+			th.ExpectConditionWithDetails(
+				keystoneAPIName,
+				ConditionGetterFunc(KeystoneConditionGetter),
+				keystonev1.KeystoneAPIFernetKeysReadyCondition,
+				corev1.ConditionFalse,
+				condition.ErrorReason,
+				"Fernet keys error occured unable to import the keys of secret fernet-import: "+
+					"invalid key repository: key 0 is not a 32-byte url-safe base64 encoded key",
+			)
+			th.AssertSecretDoesNotExist(types.NamespacedName{Namespace: namespace, Name: "keystone"})
+		})
+	})
+
+	When("the credential keys rotate", func() {
+		BeforeEach(func() {
+			spec := GetDefaultKeystoneAPISpec()
+			spec["credentialRotationDays"] = 1
+			DeferCleanup(
+				k8sClient.Delete, ctx, CreateKeystoneMessageBusSecret(namespace, "rabbitmq-secret"))
+			DeferCleanup(th.DeleteInstance, CreateKeystoneAPI(keystoneAPIName, spec))
+			DeferCleanup(
+				k8sClient.Delete, ctx, CreateKeystoneAPISecret(namespace, SecretName))
+			DeferCleanup(infra.DeleteMemcached, infra.CreateMemcached(namespace, "memcached", memcachedSpec))
+			DeferCleanup(
+				mariadb.DeleteDBService,
+				mariadb.CreateDBService(
+					namespace,
+					GetKeystoneAPI(keystoneAPIName).Spec.DatabaseInstance,
+					corev1.ServiceSpec{
+						Ports: []corev1.ServicePort{{Port: 3306}},
+					},
+				),
+			)
+			mariadb.SimulateMariaDBAccountCompleted(keystoneAccountName)
+			mariadb.SimulateMariaDBDatabaseCompleted(keystoneDatabaseName)
+			infra.SimulateTransportURLReady(types.NamespacedName{
+				Name:      fmt.Sprintf("%s-keystone-transport", keystoneAPIName.Name),
+				Namespace: namespace,
+			})
+			infra.SimulateMemcachedReady(types.NamespacedName{
+				Name:      "memcached",
+				Namespace: namespace,
+			})
+			th.SimulateJobSuccess(dbSyncJobName)
+			th.SimulateJobSuccess(bootstrapJobName)
+			th.SimulateDeploymentReplicaReady(deploymentName)
+		})
+
+		It("re-encrypts the credentials before dropping the old key", func() {
+			th.ExpectCondition(
+				keystoneAPIName,
+				ConditionGetterFunc(KeystoneConditionGetter),
+				keystonev1.KeystoneAPICredentialKeysReadyCondition,
+				corev1.ConditionTrue,
+			)
+
+			currentSecret := th.GetSecret(types.NamespacedName{Namespace: keystoneAPIName.Namespace, Name: "keystone"})
+			rotatedAt, err := time.Parse(time.RFC3339, currentSecret.Annotations["keystone.openstack.org/credentialrotatedat"])
+			Expect(err).ToNot(HaveOccurred())
+			currentSecret.Annotations["keystone.openstack.org/credentialrotatedat"] = rotatedAt.Add(-25 * time.Hour).Format(time.RFC3339)
+			Expect(k8sClient.Update(ctx, ptr.To(currentSecret), &client.UpdateOptions{})).To(Succeed())
+
+			// the old primary key stays until the credentials got migrated
+			migrateJobName := types.NamespacedName{Namespace: namespace, Name: "keystone-credential-migrate"}
+			Eventually(func(g Gomega) {
+				g.Expect(th.GetJob(migrateJobName)).ToNot(BeNil())
+				updatedSecret := th.GetSecret(types.NamespacedName{Namespace: keystoneAPIName.Namespace, Name: "keystone"})
+				g.Expect(updatedSecret.Data["CredentialKeys2"]).To(Equal(currentSecret.Data["CredentialKeys0"]))
+				g.Expect(updatedSecret.Data["CredentialKeys1"]).To(Equal(currentSecret.Data["CredentialKeys1"]))
+			}, timeout, interval).Should(Succeed())
+			th.ExpectConditionWithDetails(
+				keystoneAPIName,
+				ConditionGetterFunc(KeystoneConditionGetter),
+				keystonev1.KeystoneAPICredentialKeysReadyCondition,
+				corev1.ConditionFalse,
+				condition.RequestedReason,
+				keystonev1.KeystoneAPICredentialKeysReadyMigratingMessage,
+			)
+
+			th.SimulateJobSuccess(migrateJobName)
+			Eventually(func(g Gomega) {
+				updatedSecret := th.GetSecret(types.NamespacedName{Namespace: keystoneAPIName.Namespace, Name: "keystone"})
+				g.Expect(updatedSecret.Data).ToNot(HaveKey("CredentialKeys2"))
+				g.Expect(updatedSecret.Data["CredentialKeys1"]).To(Equal(currentSecret.Data["CredentialKeys0"]))
+				g.Expect(updatedSecret.Data["CredentialKeys0"]).ToNot(Equal(currentSecret.Data["CredentialKeys0"]))
+
+				keystone := GetKeystoneAPI(keystoneAPIName)
+				g.Expect(keystone.Status.CredentialKeys).ToNot(BeNil())
+				g.Expect(keystone.Status.CredentialKeys.ActiveKeys).To(Equal(int32(2)))
+				g.Expect(keystone.Status.CredentialKeys.NextRotation.Time).To(
+					BeTemporally("==", keystone.Status.CredentialKeys.LastRotated.Add(24*time.Hour)))
+			}, timeout, interval).Should(Succeed())
+			th.ExpectCondition(
+				keystoneAPIName,
+				ConditionGetterFunc(KeystoneConditionGetter),
+				keystonev1.KeystoneAPICredentialKeysReadyCondition,
+				corev1.ConditionTrue,
+			)
+		})
+	})
+
+	When("the container image changes", func() {
+		BeforeEach(func() {
+			DeferCleanup(
+				k8sClient.Delete, ctx, CreateKeystoneMessageBusSecret(namespace, "rabbitmq-secret"))
+			DeferCleanup(th.DeleteInstance, CreateKeystoneAPI(keystoneAPIName, GetDefaultKeystoneAPISpec()))
+			DeferCleanup(
+				k8sClient.Delete, ctx, CreateKeystoneAPISecret(namespace, SecretName))
+			DeferCleanup(infra.DeleteMemcached, infra.CreateMemcached(namespace, "memcached", memcachedSpec))
+			DeferCleanup(
+				mariadb.DeleteDBService,
+				mariadb.CreateDBService(
+					namespace,
+					GetKeystoneAPI(keystoneAPIName).Spec.DatabaseInstance,
+					corev1.ServiceSpec{
+						Ports: []corev1.ServicePort{{Port: 3306}},
+					},
+				),
+			)
+			mariadb.SimulateMariaDBAccountCompleted(keystoneAccountName)
+			mariadb.SimulateMariaDBDatabaseCompleted(keystoneDatabaseName)
+			infra.SimulateTransportURLReady(types.NamespacedName{
+				Name:      fmt.Sprintf("%s-keystone-transport", keystoneAPIName.Name),
+				Namespace: namespace,
+			})
+			infra.SimulateMemcachedReady(types.NamespacedName{
+				Name:      "memcached",
+				Namespace: namespace,
+			})
+			th.SimulateJobSuccess(dbSyncJobName)
+			th.SimulateJobSuccess(bootstrapJobName)
+			th.SimulateDeploymentReplicaReady(deploymentName)
+		})
+
+		It("upgrades the database in expand, migrate and contract phases", func() {
+			oldImage := GetKeystoneAPI(keystoneAPIName).Spec.ContainerImage
+			newImage := "quay.io/podified-antelope-centos9/openstack-keystone:new"
+			Eventually(func(g Gomega) {
+				keystone := GetKeystoneAPI(keystoneAPIName)
+				g.Expect(keystone.Status.ContainerImage).To(Equal(oldImage))
+				keystone.Spec.ContainerImage = newImage
+				g.Expect(k8sClient.Update(ctx, keystone)).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+
+			// the checks run with the new image before the upgrade
+			for _, check := range []string{"keystone-upgrade-check", "keystone-doctor"} {
+				checkJobName := types.NamespacedName{Namespace: namespace, Name: check}
+				Expect(th.GetJob(checkJobName).Spec.Template.Spec.Containers[0].Image).To(Equal(newImage))
+				th.SimulateJobSuccess(checkJobName)
+			}
+
+			// the old pods keep serving while the database gets expanded
+			expandJobName := types.NamespacedName{Namespace: namespace, Name: "keystone-db-expand"}
+			Eventually(func(g Gomega) {
+				g.Expect(th.GetJob(expandJobName).Spec.Template.Spec.Containers[0].Args).To(
+					Equal([]string{"-c", "keystone-manage db_sync --expand"}))
+				keystone := GetKeystoneAPI(keystoneAPIName)
+				g.Expect(keystone.Status.DatabaseUpgrade).ToNot(BeNil())
+				g.Expect(keystone.Status.DatabaseUpgrade.Phase).To(Equal(keystonev1.DatabaseUpgradeExpand))
+				g.Expect(keystone.Status.DatabaseUpgrade.SourceImage).To(Equal(oldImage))
+				g.Expect(keystone.Status.DatabaseUpgrade.TargetImage).To(Equal(newImage))
+			}, timeout, interval).Should(Succeed())
+			Expect(th.GetDeployment(deploymentName).Spec.Template.Spec.Containers[0].Image).To(Equal(oldImage))
+			th.ExpectConditionWithDetails(
+				keystoneAPIName,
+				ConditionGetterFunc(KeystoneConditionGetter),
+				keystonev1.KeystoneAPIDatabaseUpgradeReadyCondition,
+				corev1.ConditionFalse,
+				condition.RequestedReason,
+				fmt.Sprintf(keystonev1.KeystoneAPIDatabaseUpgradeReadyRunningMessage, newImage, keystonev1.DatabaseUpgradeExpand),
+			)
+
+			// the deployment gets rolled after the expand
+			th.SimulateJobSuccess(expandJobName)
+			Eventually(func(g Gomega) {
+				g.Expect(th.GetDeployment(deploymentName).Spec.Template.Spec.Containers[0].Image).To(Equal(newImage))
+				keystone := GetKeystoneAPI(keystoneAPIName)
+				g.Expect(keystone.Status.DatabaseUpgrade.Phase).To(Equal(keystonev1.DatabaseUpgradeRollout))
+			}, timeout, interval).Should(Succeed())
+
+			// migrate and contract once all pods run the new image
+			th.SimulateDeploymentReplicaReady(deploymentName)
+			migrateJobName := types.NamespacedName{Namespace: namespace, Name: "keystone-db-migrate"}
+			th.SimulateJobSuccess(migrateJobName)
+			contractJobName := types.NamespacedName{Namespace: namespace, Name: "keystone-db-contract"}
+			Expect(th.GetJob(contractJobName).Spec.Template.Spec.Containers[0].Args).To(
+				Equal([]string{"-c", "keystone-manage db_sync --contract"}))
+			th.SimulateJobSuccess(contractJobName)
+
+			Eventually(func(g Gomega) {
+				keystone := GetKeystoneAPI(keystoneAPIName)
+				g.Expect(keystone.Status.DatabaseUpgrade.Phase).To(Equal(keystonev1.DatabaseUpgradeCompleted))
+				g.Expect(keystone.Status.DatabaseUpgrade.CompletedAt).ToNot(BeNil())
+				g.Expect(keystone.Status.ContainerImage).To(Equal(newImage))
+			}, timeout, interval).Should(Succeed())
+			th.ExpectCondition(
+				keystoneAPIName,
+				ConditionGetterFunc(KeystoneConditionGetter),
+				keystonev1.KeystoneAPIDatabaseUpgradeReadyCondition,
+				corev1.ConditionTrue,
+			)
+		})
+
+		It("blocks the upgrade on failed checks unless they are ignored", func() {
+			oldImage := GetKeystoneAPI(keystoneAPIName).Spec.ContainerImage
+			newImage := "quay.io/podified-antelope-centos9/openstack-keystone:new"
+			Eventually(func(g Gomega) {
+				keystone := GetKeystoneAPI(keystoneAPIName)
+				g.Expect(keystone.Status.ContainerImage).To(Equal(oldImage))
+				keystone.Spec.ContainerImage = newImage
+				g.Expect(k8sClient.Update(ctx, keystone)).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+
+			th.SimulateJobSuccess(types.NamespacedName{Namespace: namespace, Name: "keystone-upgrade-check"})
+			th.SimulateJobFailure(types.NamespacedName{Namespace: namespace, Name: "keystone-doctor"})
+			th.ExpectConditionWithDetails(
+				keystoneAPIName,
+				ConditionGetterFunc(KeystoneConditionGetter),
+				keystonev1.KeystoneAPIUpgradeReadinessCondition,
+				corev1.ConditionFalse,
+				condition.ErrorReason,
+				fmt.Sprintf(keystonev1.KeystoneAPIUpgradeReadinessFailedMessage, "doctor", newImage),
+			)
+			Eventually(func(g Gomega) {
+				keystone := GetKeystoneAPI(keystoneAPIName)
+				g.Expect(keystone.Status.DatabaseUpgrade.Phase).To(Equal(keystonev1.DatabaseUpgradeCheck))
+				g.Expect(keystone.Status.DatabaseUpgrade.Checks).To(ConsistOf(
+					keystonev1.UpgradeCheckStatus{Name: "upgrade-check", Result: keystonev1.UpgradeCheckPassed},
+					keystonev1.UpgradeCheckStatus{Name: "doctor", Result: keystonev1.UpgradeCheckFailed},
+				))
+			}, timeout, interval).Should(Succeed())
+			th.AssertJobDoesNotExist(types.NamespacedName{Namespace: namespace, Name: "keystone-db-expand"})
+			Expect(th.GetDeployment(deploymentName).Spec.Template.Spec.Containers[0].Image).To(Equal(oldImage))
+
+			Eventually(func(g Gomega) {
+				keystone := GetKeystoneAPI(keystoneAPIName)
+				keystone.Spec.IgnoreUpgradeCheckFailures = true
+				g.Expect(k8sClient.Update(ctx, keystone)).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+			th.ExpectConditionWithDetails(
+				keystoneAPIName,
+				ConditionGetterFunc(KeystoneConditionGetter),
+				keystonev1.KeystoneAPIUpgradeReadinessCondition,
+				corev1.ConditionTrue,
+				condition.ReadyReason,
+				fmt.Sprintf(keystonev1.KeystoneAPIUpgradeReadinessIgnoredMessage, "doctor", newImage),
+			)
+			Expect(th.GetJob(types.NamespacedName{Namespace: namespace, Name: "keystone-db-expand"})).ToNot(BeNil())
+		})
+
+		It("restarts the upgrade when the image changes again before the rollout", func() {
+			oldImage := GetKeystoneAPI(keystoneAPIName).Spec.ContainerImage
+			newImage := "quay.io/podified-antelope-centos9/openstack-keystone:new"
+			newerImage := "quay.io/podified-antelope-centos9/openstack-keystone:newer"
+			Eventually(func(g Gomega) {
+				keystone := GetKeystoneAPI(keystoneAPIName)
+				g.Expect(keystone.Status.ContainerImage).To(Equal(oldImage))
+				keystone.Spec.ContainerImage = newImage
+				g.Expect(k8sClient.Update(ctx, keystone)).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+
+			checkJobName := types.NamespacedName{Namespace: namespace, Name: "keystone-upgrade-check"}
+			Eventually(func(g Gomega) {
+				g.Expect(th.GetJob(checkJobName).Spec.Template.Spec.Containers[0].Image).To(Equal(newImage))
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(func(g Gomega) {
+				keystone := GetKeystoneAPI(keystoneAPIName)
+				keystone.Spec.ContainerImage = newerImage
+				g.Expect(k8sClient.Update(ctx, keystone)).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+
+			// the checks run again with the newer image
+			Eventually(func(g Gomega) {
+				keystone := GetKeystoneAPI(keystoneAPIName)
+				g.Expect(keystone.Status.DatabaseUpgrade.Phase).To(Equal(keystonev1.DatabaseUpgradeCheck))
+				g.Expect(keystone.Status.DatabaseUpgrade.SourceImage).To(Equal(oldImage))
+				g.Expect(keystone.Status.DatabaseUpgrade.TargetImage).To(Equal(newerImage))
+				g.Expect(th.GetJob(checkJobName).Spec.Template.Spec.Containers[0].Image).To(Equal(newerImage))
+			}, timeout, interval).Should(Succeed())
+
+			for _, check := range []string{"keystone-upgrade-check", "keystone-doctor"} {
+				th.SimulateJobSuccess(types.NamespacedName{Namespace: namespace, Name: check})
+			}
+			expandJobName := types.NamespacedName{Namespace: namespace, Name: "keystone-db-expand"}
+			Eventually(func(g Gomega) {
+				g.Expect(th.GetJob(expandJobName).Spec.Template.Spec.Containers[0].Image).To(Equal(newerImage))
+			}, timeout, interval).Should(Succeed())
+			Expect(th.GetDeployment(deploymentName).Spec.Template.Spec.Containers[0].Image).To(Equal(oldImage))
+		})
+
+		It("cancels the upgrade when the image is set back before the rollout", func() {
+			oldImage := GetKeystoneAPI(keystoneAPIName).Spec.ContainerImage
+			newImage := "quay.io/podified-antelope-centos9/openstack-keystone:new"
+			Eventually(func(g Gomega) {
+				keystone := GetKeystoneAPI(keystoneAPIName)
+				g.Expect(keystone.Status.ContainerImage).To(Equal(oldImage))
+				keystone.Spec.ContainerImage = newImage
+				g.Expect(k8sClient.Update(ctx, keystone)).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+
+			for _, check := range []string{"keystone-upgrade-check", "keystone-doctor"} {
+				th.SimulateJobSuccess(types.NamespacedName{Namespace: namespace, Name: check})
+			}
+			Eventually(func(g Gomega) {
+				keystone := GetKeystoneAPI(keystoneAPIName)
+				g.Expect(keystone.Status.DatabaseUpgrade.Phase).To(Equal(keystonev1.DatabaseUpgradeExpand))
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(func(g Gomega) {
+				keystone := GetKeystoneAPI(keystoneAPIName)
+				keystone.Spec.ContainerImage = oldImage
+				g.Expect(k8sClient.Update(ctx, keystone)).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(func(g Gomega) {
+				keystone := GetKeystoneAPI(keystoneAPIName)
+				g.Expect(keystone.Status.DatabaseUpgrade).To(BeNil())
+				g.Expect(keystone.Status.ContainerImage).To(Equal(oldImage))
+			}, timeout, interval).Should(Succeed())
+			th.ExpectCondition(
+				keystoneAPIName,
+				ConditionGetterFunc(KeystoneConditionGetter),
+				keystonev1.KeystoneAPIDatabaseUpgradeReadyCondition,
+				corev1.ConditionTrue,
+			)
+			Expect(th.GetDeployment(deploymentName).Spec.Template.Spec.Containers[0].Image).To(Equal(oldImage))
+		})
+	})
+
+	When("a KeystoneAPI uses the canary rollout strategy", func() {
+		BeforeEach(func() {
+			spec := GetDefaultKeystoneAPISpec()
+			spec["rolloutStrategy"] = keystonev1.RolloutStrategyCanary
+			spec["canaryTimeout"] = 1
+			DeferCleanup(
+				k8sClient.Delete, ctx, CreateKeystoneMessageBusSecret(namespace, "rabbitmq-secret"))
+			DeferCleanup(th.DeleteInstance, CreateKeystoneAPI(keystoneAPIName, spec))
+			DeferCleanup(
+				k8sClient.Delete, ctx, CreateKeystoneAPISecret(namespace, SecretName))
+			DeferCleanup(infra.DeleteMemcached, infra.CreateMemcached(namespace, "memcached", memcachedSpec))
+			DeferCleanup(
+				mariadb.DeleteDBService,
+				mariadb.CreateDBService(
+					namespace,
+					GetKeystoneAPI(keystoneAPIName).Spec.DatabaseInstance,
+					corev1.ServiceSpec{
+						Ports: []corev1.ServicePort{{Port: 3306}},
+					},
+				),
+			)
+			mariadb.SimulateMariaDBAccountCompleted(keystoneAccountName)
+			mariadb.SimulateMariaDBDatabaseCompleted(keystoneDatabaseName)
+			infra.SimulateTransportURLReady(types.NamespacedName{
+				Name:      fmt.Sprintf("%s-keystone-transport", keystoneAPIName.Name),
+				Namespace: namespace,
+			})
+			infra.SimulateMemcachedReady(types.NamespacedName{
+				Name:      "memcached",
+				Namespace: namespace,
+			})
+			th.SimulateJobSuccess(dbSyncJobName)
+			th.SimulateJobSuccess(bootstrapJobName)
+			th.SimulateDeploymentReplicaReady(deploymentName)
+		})
+
+		It("promotes the initial revision", func() {
+			promotedConfigName := types.NamespacedName{Namespace: namespace, Name: "keystone-promoted-config-data"}
+			Eventually(func(g Gomega) {
+				keystone := GetKeystoneAPI(keystoneAPIName)
+				g.Expect(keystone.Status.PromotedRevision).ToNot(BeNil())
+				g.Expect(keystone.Status.PromotedRevision.ContainerImage).To(Equal(keystone.Spec.ContainerImage))
+			}, timeout, interval).Should(Succeed())
+			Expect(th.GetSecret(promotedConfigName).Data).To(HaveKey("keystone.conf"))
+
+			Eventually(func(g Gomega) {
+				volumes := th.GetDeployment(deploymentName).Spec.Template.Spec.Volumes
+				g.Expect(volumes).To(ContainElement(HaveField("VolumeSource.Secret.SecretName", promotedConfigName.Name)))
+			}, timeout, interval).Should(Succeed())
+			th.ExpectCondition(
+				keystoneAPIName,
+				ConditionGetterFunc(KeystoneConditionGetter),
+				keystonev1.KeystoneAPICanaryReadyCondition,
+				corev1.ConditionTrue,
+			)
+		})
+
+		It("rolls back a config change the canary does not pass", func() {
+			canaryName := types.NamespacedName{Namespace: namespace, Name: "keystone-canary"}
+			originalHash := ""
+			Eventually(func(g Gomega) {
+				originalHash = GetEnvVarValue(
+					th.GetDeployment(deploymentName).Spec.Template.Spec.Containers[0].Env, "CONFIG_HASH", "")
+				g.Expect(originalHash).NotTo(BeEmpty())
+				g.Expect(GetKeystoneAPI(keystoneAPIName).Status.PromotedRevision).ToNot(BeNil())
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(func(g Gomega) {
+				keystone := GetKeystoneAPI(keystoneAPIName)
+				keystone.Spec.CustomServiceConfig = "[DEFAULT]\ndebug = true"
+				g.Expect(k8sClient.Update(ctx, keystone)).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+
+			// the change only reaches the canary
+			Eventually(func(g Gomega) {
+				canaryHash := GetEnvVarValue(
+					th.GetDeployment(canaryName).Spec.Template.Spec.Containers[0].Env, "CONFIG_HASH", "")
+				g.Expect(canaryHash).NotTo(Equal(originalHash))
+				g.Expect(*th.GetDeployment(canaryName).Spec.Replicas).To(Equal(int32(1)))
+			}, timeout, interval).Should(Succeed())
+			Expect(GetEnvVarValue(
+				th.GetDeployment(deploymentName).Spec.Template.Spec.Containers[0].Env, "CONFIG_HASH", "")).To(Equal(originalHash))
+			th.ExpectConditionWithDetails(
+				keystoneAPIName,
+				ConditionGetterFunc(KeystoneConditionGetter),
+				keystonev1.KeystoneAPICanaryReadyCondition,
+				corev1.ConditionFalse,
+				condition.RequestedReason,
+				fmt.Sprintf(keystonev1.KeystoneAPICanaryReadyVerifyingMessage, "canary pod is not ready"),
+			)
+
+			// without a pod to verify the canary gets rolled back
+			th.SimulateDeploymentReplicaReady(canaryName)
+			Eventually(func(g Gomega) {
+				keystone := GetKeystoneAPI(keystoneAPIName)
+				g.Expect(keystone.Status.Canary).ToNot(BeNil())
+				g.Expect(keystone.Status.Canary.Phase).To(Equal(keystonev1.CanaryRolledBack))
+			}, timeout, interval).Should(Succeed())
+			th.ExpectConditionWithDetails(
+				keystoneAPIName,
+				ConditionGetterFunc(KeystoneConditionGetter),
+				keystonev1.KeystoneAPICanaryReadyCondition,
+				corev1.ConditionFalse,
+				condition.ErrorReason,
+				fmt.Sprintf(keystonev1.KeystoneAPICanaryReadyRolledBackMessage, "canary pod is not ready"),
+			)
+			th.AssertDeploymentDoesNotExist(canaryName)
+			Expect(GetEnvVarValue(
+				th.GetDeployment(deploymentName).Spec.Template.Spec.Containers[0].Env, "CONFIG_HASH", "")).To(Equal(originalHash))
+		})
+
+		It("rolls out the image of a database upgrade without a canary", func() {
+			canaryName := types.NamespacedName{Namespace: namespace, Name: "keystone-canary"}
+			oldImage := GetKeystoneAPI(keystoneAPIName).Spec.ContainerImage
+			newImage := "quay.io/podified-antelope-centos9/openstack-keystone:new"
+			Eventually(func(g Gomega) {
+				keystone := GetKeystoneAPI(keystoneAPIName)
+				g.Expect(keystone.Status.ContainerImage).To(Equal(oldImage))
+				g.Expect(keystone.Status.PromotedRevision).ToNot(BeNil())
+				keystone.Spec.ContainerImage = newImage
+				g.Expect(k8sClient.Update(ctx, keystone)).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+
+			for _, check := range []string{"keystone-upgrade-check", "keystone-doctor"} {
+				th.SimulateJobSuccess(types.NamespacedName{Namespace: namespace, Name: check})
+			}
+			th.SimulateJobSuccess(types.NamespacedName{Namespace: namespace, Name: "keystone-db-expand"})
+
+			// the keystone Deployment gets rolled right away, no canary can be
+			// rolled back after the expand
+			Eventually(func(g Gomega) {
+				g.Expect(th.GetDeployment(deploymentName).Spec.Template.Spec.Containers[0].Image).To(Equal(newImage))
+				keystone := GetKeystoneAPI(keystoneAPIName)
+				g.Expect(keystone.Status.PromotedRevision.ContainerImage).To(Equal(newImage))
+				g.Expect(keystone.Status.Canary).To(BeNil())
+			}, timeout, interval).Should(Succeed())
+			th.AssertDeploymentDoesNotExist(canaryName)
+
+			th.SimulateDeploymentReplicaReady(deploymentName)
+			th.SimulateJobSuccess(types.NamespacedName{Namespace: namespace, Name: "keystone-db-migrate"})
+			th.SimulateJobSuccess(types.NamespacedName{Namespace: namespace, Name: "keystone-db-contract"})
+			Eventually(func(g Gomega) {
+				keystone := GetKeystoneAPI(keystoneAPIName)
+				g.Expect(keystone.Status.DatabaseUpgrade.Phase).To(Equal(keystonev1.DatabaseUpgradeCompleted))
+				g.Expect(keystone.Status.ContainerImage).To(Equal(newImage))
+			}, timeout, interval).Should(Succeed())
+		})
+	})
+
+	When("two KeystoneAPIs are created in one namespace", func() {
+		var secondAPIName types.NamespacedName
+
+		BeforeEach(func() {
+			secondAPIName = types.NamespacedName{Name: "keystone-b", Namespace: namespace}
+			secondSpec := GetDefaultKeystoneAPISpec()
+			secondSpec["databaseAccount"] = "keystone-b-account"
+
+			DeferCleanup(
+				k8sClient.Delete, ctx, CreateKeystoneMessageBusSecret(namespace, "rabbitmq-secret"))
+			DeferCleanup(th.DeleteInstance, CreateKeystoneAPI(keystoneAPIName, GetDefaultKeystoneAPISpec()))
+			DeferCleanup(th.DeleteInstance, CreateKeystoneAPI(secondAPIName, secondSpec))
+			DeferCleanup(
+				k8sClient.Delete, ctx, CreateKeystoneAPISecret(namespace, SecretName))
+			DeferCleanup(infra.DeleteMemcached, infra.CreateMemcached(namespace, "memcached", memcachedSpec))
+			DeferCleanup(
+				mariadb.DeleteDBService,
+				mariadb.CreateDBService(
+					namespace,
+					GetKeystoneAPI(keystoneAPIName).Spec.DatabaseInstance,
+					corev1.ServiceSpec{
+						Ports: []corev1.ServicePort{{Port: 3306}},
+					},
+				),
+			)
+			infra.SimulateMemcachedReady(types.NamespacedName{
+				Name:      "memcached",
+				Namespace: namespace,
+			})
+			for _, name := range []types.NamespacedName{keystoneAPIName, secondAPIName} {
+				keystone := GetKeystoneAPI(name)
+				mariadb.SimulateMariaDBAccountCompleted(types.NamespacedName{Namespace: namespace, Name: keystone.Spec.DatabaseAccount})
+				mariadb.SimulateMariaDBDatabaseCompleted(types.NamespacedName{Namespace: namespace, Name: name.Name})
+				infra.SimulateTransportURLReady(types.NamespacedName{
+					Name:      fmt.Sprintf("%s-keystone-transport", name.Name),
+					Namespace: namespace,
+				})
+				th.SimulateJobSuccess(types.NamespacedName{Namespace: namespace, Name: name.Name + "-db-sync"})
+				th.SimulateJobSuccess(types.NamespacedName{Namespace: namespace, Name: name.Name + "-bootstrap"})
+				th.SimulateDeploymentReplicaReady(types.NamespacedName{Namespace: namespace, Name: name.Name})
+			}
+		})
+
+		It("names the resources of each KeystoneAPI after it", func() {
+			for _, name := range []types.NamespacedName{keystoneAPIName, secondAPIName} {
+				th.ExpectCondition(
+					name,
+					ConditionGetterFunc(KeystoneConditionGetter),
+					condition.ReadyCondition,
+					corev1.ConditionTrue,
+				)
+				Expect(th.GetSecret(types.NamespacedName{Namespace: namespace, Name: name.Name}).Data).To(HaveKey("FernetKeys0"))
+				Expect(th.GetSecret(types.NamespacedName{Namespace: namespace, Name: name.Name + "-config-data"}).Data).To(HaveKey("keystone.conf"))
+				Expect(GetCronJob(types.NamespacedName{Namespace: namespace, Name: name.Name + "-cron"})).ToNot(BeNil())
+			}
+
+			// the openstackclient config of the KeystoneAPI named keystone keeps
+			// its name, the one of any other KeystoneAPI gets prefixed
+			th.GetConfigMap(types.NamespacedName{Namespace: namespace, Name: "openstack-config"})
+			th.GetConfigMap(types.NamespacedName{Namespace: namespace, Name: "keystone-b-openstack-config"})
+			th.GetSecret(types.NamespacedName{Namespace: namespace, Name: "keystone-b-openstack-config-secret"})
 
-				g.Expect(numberFernetKeys).Should(BeNumerically("==", 6))
-				for i := range 6 {
-					g.Expect(secret.Data["FernetKeys"+strconv.Itoa(i)]).NotTo(BeNil())
-				}
-			}, timeout, interval).Should(Succeed())
+			db := mariadb.GetMariaDBDatabase(types.NamespacedName{Namespace: namespace, Name: secondAPIName.Name})
+			Expect(db.Spec.Name).To(Equal("keystone_b"))
 		})
 	})
 
-	// Set rotated at to past date, triggering rotation
-	When("When the fernet token rotate", func() {
+	When("a KeystoneAPI with another name got deployed before its resources were named after it", func() {
+		var legacyAPIName types.NamespacedName
+
 		BeforeEach(func() {
+			legacyAPIName = types.NamespacedName{Name: "openstack-keystone", Namespace: namespace}
+
 			DeferCleanup(
 				k8sClient.Delete, ctx, CreateKeystoneMessageBusSecret(namespace, "rabbitmq-secret"))
-			DeferCleanup(th.DeleteInstance, CreateKeystoneAPI(keystoneAPIName, GetDefaultKeystoneAPISpec()))
+			DeferCleanup(th.DeleteInstance, CreateKeystoneAPI(legacyAPIName, GetDefaultKeystoneAPISpec()))
 			DeferCleanup(
 				k8sClient.Delete, ctx, CreateKeystoneAPISecret(namespace, SecretName))
 			DeferCleanup(infra.DeleteMemcached, infra.CreateMemcached(namespace, "memcached", memcachedSpec))
@@ -1516,71 +2483,74 @@ var _ = Describe("Keystone controller", func() {
 				mariadb.DeleteDBService,
 				mariadb.CreateDBService(
 					namespace,
-					GetKeystoneAPI(keystoneAPIName).Spec.DatabaseInstance,
+					GetKeystoneAPI(legacyAPIName).Spec.DatabaseInstance,
 					corev1.ServiceSpec{
 						Ports: []corev1.ServicePort{{Port: 3306}},
 					},
 				),
 			)
+
+			// the status of an instance deployed by an older operator has
+			// hashes but no resource name
+			Eventually(func(g Gomega) {
+				g.Expect(GetKeystoneAPI(legacyAPIName).Status.ResourceName).To(Equal(legacyAPIName.Name))
+			}, timeout, interval).Should(Succeed())
+			Eventually(func(g Gomega) {
+				keystone := GetKeystoneAPI(legacyAPIName)
+				keystone.Status.ResourceName = ""
+				keystone.Status.Hash = map[string]string{keystonev1.DbSyncHash: "synced-by-an-older-operator"}
+				g.Expect(k8sClient.Status().Update(ctx, keystone)).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+
 			mariadb.SimulateMariaDBAccountCompleted(keystoneAccountName)
 			mariadb.SimulateMariaDBDatabaseCompleted(keystoneDatabaseName)
 			infra.SimulateTransportURLReady(types.NamespacedName{
-				Name:      fmt.Sprintf("%s-keystone-transport", keystoneAPIName.Name),
+				Name:      fmt.Sprintf("%s-keystone-transport", legacyAPIName.Name),
 				Namespace: namespace,
 			})
 			infra.SimulateMemcachedReady(types.NamespacedName{
 				Name:      "memcached",
 				Namespace: namespace,
 			})
-			th.SimulateJobSuccess(dbSyncJobName)
-			th.SimulateJobSuccess(bootstrapJobName)
-			th.SimulateDeploymentReplicaReady(deploymentName)
 		})
 
-		It("rotates the fernet keys", func() {
-			keystone := GetKeystoneAPI(keystoneAPIName)
-			currentHash := keystone.Status.Hash["input"]
-
-			currentSecret := th.GetSecret(types.NamespacedName{Namespace: keystoneAPIName.Namespace, Name: "keystone"})
-			Expect(currentSecret).ToNot(BeNil())
+		It("keeps the keystone names of its resources", func() {
+			Eventually(func(g Gomega) {
+				g.Expect(GetKeystoneAPI(legacyAPIName).Status.ResourceName).To(Equal("keystone"))
+			}, timeout, interval).Should(Succeed())
 
-			rotatedAt, err := time.Parse(time.RFC3339, currentSecret.Annotations["keystone.openstack.org/rotatedat"])
-			Expect(err).ToNot(HaveOccurred())
+			th.SimulateJobSuccess(dbSyncJobName)
+			th.SimulateJobSuccess(bootstrapJobName)
+			th.SimulateDeploymentReplicaReady(deploymentName)
+			th.ExpectCondition(
+				legacyAPIName,
+				ConditionGetterFunc(KeystoneConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
 
-			// set date to yesterday
-			currentSecret.Annotations["keystone.openstack.org/rotatedat"] = rotatedAt.Add(-25 * time.Hour).Format(time.RFC3339)
-			err = k8sClient.Update(ctx, ptr.To(currentSecret), &client.UpdateOptions{})
-			Expect(err).ToNot(HaveOccurred())
+			db := mariadb.GetMariaDBDatabase(keystoneDatabaseName)
+			Expect(db.Spec.Name).To(Equal("keystone"))
+			Expect(th.GetSecret(types.NamespacedName{Namespace: namespace, Name: "keystone"}).Data).To(HaveKey("FernetKeys0"))
+			Expect(GetCronJob(types.NamespacedName{Namespace: namespace, Name: "keystone-cron"})).ToNot(BeNil())
+			th.GetConfigMap(types.NamespacedName{Namespace: namespace, Name: "openstack-config"})
+			// the config keeps the name of the instance
+			Expect(th.GetSecret(types.NamespacedName{Namespace: namespace, Name: legacyAPIName.Name + "-config-data"}).Data).To(HaveKey("keystone.conf"))
+		})
 
+		It("names the resources of a new KeystoneAPI named keystone differently", func() {
 			Eventually(func(g Gomega) {
-				keystone = GetKeystoneAPI(keystoneAPIName)
-				// With the new direct mounting approach, the input hash should NOT change
-				g.Expect(keystone.Status.Hash["input"]).To(Equal(currentHash))
-
-				updatedSecret := th.GetSecret(types.NamespacedName{Namespace: keystoneAPIName.Namespace, Name: "keystone"})
-				g.Expect(updatedSecret).ToNot(BeNil())
-
-				for i := range 4 {
+				g.Expect(GetKeystoneAPI(legacyAPIName).Status.ResourceName).To(Equal("keystone"))
+			}, timeout, interval).Should(Succeed())
 
-					// old idx 0 > new 4
-					if i == 0 {
-						oldKey := string(currentSecret.Data["FernetKeys"+strconv.Itoa(0)])
-						newKey := string(updatedSecret.Data["FernetKeys"+strconv.Itoa((4))])
-						g.Expect(oldKey).To(Equal(newKey))
-						continue
-					}
+			spec := GetDefaultKeystoneAPISpec()
+			spec["databaseAccount"] = "keystone-2-account"
+			DeferCleanup(th.DeleteInstance, CreateKeystoneAPI(keystoneAPIName, spec))
 
-					// old idx > new idx-1, except idx 1 which should be gone and not match new idx 0
-					oldKey := string(currentSecret.Data["FernetKeys"+strconv.Itoa(i)])
-					newKey := string(updatedSecret.Data["FernetKeys"+strconv.Itoa((i-1))])
-					if i == 1 {
-						g.Expect(oldKey).ToNot(Equal(newKey))
-					} else {
-						g.Expect(oldKey).To(Equal(newKey))
-					}
-				}
+			Eventually(func(g Gomega) {
+				g.Expect(GetKeystoneAPI(keystoneAPIName).Status.ResourceName).To(Equal("keystone-2"))
 			}, timeout, interval).Should(Succeed())
-
+			Expect(GetKeystoneAPI(legacyAPIName).Status.ResourceName).To(Equal("keystone"))
 		})
 	})
 
@@ -2060,6 +3030,291 @@ OIDCRedirectURI "{{ .KeystoneEndpointPublic }}/v3/auth/OS-FEDERATION/websso/open
 		})
 	})
 
+	When("A KeystoneAPI is created with an LDAP backed KeystoneDomain", func() {
+		var domainName types.NamespacedName
+
+		BeforeEach(func() {
+			domainName = types.NamespacedName{Name: "corp", Namespace: namespace}
+			th.CreateSecret(
+				types.NamespacedName{Namespace: namespace, Name: "ldap-secret"},
+				map[string][]byte{"LDAPBindPassword": []byte("bind-password")},
+			)
+
+			// the KeystoneDomain controller talks to the internal endpoint
+			f := keystone_test.NewKeystoneAPIFixtureWithServer(logger)
+			f.Setup()
+			DeferCleanup(f.Cleanup)
+			spec := GetDefaultKeystoneAPISpec()
+			spec["override"] = map[string]any{
+				"service": map[string]any{
+					"internal": map[string]any{
+						"endpointURL": f.Endpoint(),
+					},
+				},
+			}
+
+			DeferCleanup(
+				k8sClient.Delete, ctx, CreateKeystoneMessageBusSecret(namespace, "rabbitmq-secret"))
+			keystone := CreateKeystoneAPI(keystoneAPIName, spec)
+			DeferCleanup(th.DeleteInstance, keystone)
+
+			// created after the KeystoneAPI so it gets cleaned up first
+			DeferCleanup(th.DeleteInstance, CreateKeystoneDomain(domainName, map[string]any{
+				"domainName": "corp",
+				"ldap": map[string]any{
+					"url":         "ldaps://ldap.example.com",
+					"user":        "cn=keystone,dc=example,dc=com",
+					"secret":      "ldap-secret",
+					"suffix":      "dc=example,dc=com",
+					"userTreeDN":  "ou=Users,dc=example,dc=com",
+					"groupTreeDN": "ou=Groups,dc=example,dc=com",
+				},
+			}))
+			SimulateKeystoneDomainRegistered(domainName, "corp-domain-id")
+			DeferCleanup(
+				k8sClient.Delete, ctx, CreateKeystoneAPISecret(namespace, SecretName))
+			DeferCleanup(infra.DeleteMemcached, infra.CreateMemcached(namespace, "memcached", memcachedSpec))
+			DeferCleanup(
+				mariadb.DeleteDBService,
+				mariadb.CreateDBService(
+					namespace,
+					GetKeystoneAPI(keystoneAPIName).Spec.DatabaseInstance,
+					corev1.ServiceSpec{
+						Ports: []corev1.ServicePort{{Port: 3306}},
+					},
+				),
+			)
+			mariadb.SimulateMariaDBAccountCompleted(keystoneAccountName)
+			mariadb.SimulateMariaDBDatabaseCompleted(keystoneDatabaseName)
+			infra.SimulateTransportURLReady(types.NamespacedName{
+				Name:      fmt.Sprintf("%s-keystone-transport", keystoneAPIName.Name),
+				Namespace: namespace,
+			})
+			infra.SimulateMemcachedReady(types.NamespacedName{
+				Name:      "memcached",
+				Namespace: namespace,
+			})
+			th.SimulateJobSuccess(dbSyncJobName)
+			th.SimulateJobSuccess(bootstrapJobName)
+			th.SimulateDeploymentReplicaReady(deploymentName)
+		})
+
+		It("renders the domain specific config into the keystone-config-data secret", func() {
+			scrt := th.GetSecret(keystoneAPIConfigDataName)
+			Expect(scrt.Data).Should(HaveKey("keystone.corp.conf"))
+			domainConfig := string(scrt.Data["keystone.corp.conf"])
+			Expect(domainConfig).Should(ContainSubstring("driver=ldap"))
+			Expect(domainConfig).Should(ContainSubstring("url=ldaps://ldap.example.com"))
+			Expect(domainConfig).Should(ContainSubstring("password=bind-password"))
+			Expect(domainConfig).Should(ContainSubstring("user_tree_dn=ou=Users,dc=example,dc=com"))
+
+			configData := string(scrt.Data["keystone.conf"])
+			Expect(configData).Should(ContainSubstring("domain_specific_drivers_enabled=true"))
+			Expect(configData).Should(ContainSubstring("domain_config_dir=/etc/keystone/domains"))
+		})
+
+		It("mounts the domain specific config into the keystone-api pods", func() {
+			d := th.GetDeployment(deploymentName)
+			container := d.Spec.Template.Spec.Containers[0]
+			th.AssertVolumeMountPathExists("domain-config", "/etc/keystone/domains", "", container.VolumeMounts)
+			th.AssertVolumeExists("domain-config", d.Spec.Template.Spec.Volumes)
+
+			// the config holds the bind password, it is only readable by the
+			// keystone group
+			for _, v := range d.Spec.Template.Spec.Volumes {
+				if v.Name == "domain-config" {
+					Expect(*v.Secret.DefaultMode).To(Equal(int32(0640)))
+				}
+			}
+			Expect(d.Spec.Template.Spec.SecurityContext).NotTo(BeNil())
+			Expect(*d.Spec.Template.Spec.SecurityContext.FSGroup).To(Equal(int64(42425)))
+		})
+
+		It("updates the domain specific config when the bind password changes", func() {
+			Eventually(func(g Gomega) {
+				ldapSecret := th.GetSecret(types.NamespacedName{Namespace: namespace, Name: "ldap-secret"})
+				ldapSecret.Data["LDAPBindPassword"] = []byte("new-bind-password")
+				g.Expect(k8sClient.Update(ctx, &ldapSecret)).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(func(g Gomega) {
+				scrt := th.GetSecret(keystoneAPIConfigDataName)
+				g.Expect(string(scrt.Data["keystone.corp.conf"])).Should(ContainSubstring("password=new-bind-password"))
+			}, timeout, interval).Should(Succeed())
+		})
+	})
+
+	When("A KeystoneAPI is created with the jws token provider", func() {
+		BeforeEach(func() {
+			spec := GetDefaultKeystoneAPISpec()
+			spec["tokenProvider"] = "jws"
+			DeferCleanup(
+				k8sClient.Delete, ctx, CreateKeystoneMessageBusSecret(namespace, "rabbitmq-secret"))
+			DeferCleanup(th.DeleteInstance, CreateKeystoneAPI(keystoneAPIName, spec))
+			DeferCleanup(
+				k8sClient.Delete, ctx, CreateKeystoneAPISecret(namespace, SecretName))
+			DeferCleanup(infra.DeleteMemcached, infra.CreateMemcached(namespace, "memcached", memcachedSpec))
+			DeferCleanup(
+				mariadb.DeleteDBService,
+				mariadb.CreateDBService(
+					namespace,
+					GetKeystoneAPI(keystoneAPIName).Spec.DatabaseInstance,
+					corev1.ServiceSpec{
+						Ports: []corev1.ServicePort{{Port: 3306}},
+					},
+				),
+			)
+			mariadb.SimulateMariaDBAccountCompleted(keystoneAccountName)
+			mariadb.SimulateMariaDBDatabaseCompleted(keystoneDatabaseName)
+			infra.SimulateTransportURLReady(types.NamespacedName{
+				Name:      fmt.Sprintf("%s-keystone-transport", keystoneAPIName.Name),
+				Namespace: namespace,
+			})
+			infra.SimulateMemcachedReady(types.NamespacedName{
+				Name:      "memcached",
+				Namespace: namespace,
+			})
+			th.SimulateJobSuccess(dbSyncJobName)
+			th.SimulateJobSuccess(bootstrapJobName)
+			th.SimulateDeploymentReplicaReady(deploymentName)
+		})
+
+		It("generates the key pairs", func() {
+			secret := th.GetSecret(types.NamespacedName{Namespace: namespace, Name: "keystone"})
+			for i := range 5 {
+				Expect(secret.Data).To(HaveKey("JWSKeys" + strconv.Itoa(i)))
+				Expect(string(secret.Data["JWSPublicKeys"+strconv.Itoa(i)])).To(HavePrefix("-----BEGIN PUBLIC KEY-----"))
+			}
+		})
+
+		It("renders the jws token provider config", func() {
+			scrt := th.GetSecret(keystoneAPIConfigDataName)
+			configData := string(scrt.Data["keystone.conf"])
+			Expect(configData).Should(ContainSubstring("provider=jws"))
+			Expect(configData).Should(ContainSubstring("jws_public_key_repository=/etc/keystone/jws-keys/public"))
+			Expect(configData).Should(ContainSubstring("jws_private_key_repository=/etc/keystone/jws-keys/private"))
+		})
+
+		It("mounts the key repositories into the keystone-api pods", func() {
+			d := th.GetDeployment(deploymentName)
+			container := d.Spec.Template.Spec.Containers[0]
+			th.AssertVolumeMountPathExists("jws-keys", "/etc/keystone/jws-keys", "", container.VolumeMounts)
+			th.AssertVolumeExists("jws-keys", d.Spec.Template.Spec.Volumes)
+		})
+
+		It("rotates the key pairs together with the fernet keys", func() {
+			currentSecret := th.GetSecret(types.NamespacedName{Namespace: namespace, Name: "keystone"})
+			rotatedAt, err := time.Parse(time.RFC3339, currentSecret.Annotations["keystone.openstack.org/rotatedat"])
+			Expect(err).ToNot(HaveOccurred())
+			currentSecret.Annotations["keystone.openstack.org/rotatedat"] = rotatedAt.Add(-25 * time.Hour).Format(time.RFC3339)
+			Expect(k8sClient.Update(ctx, ptr.To(currentSecret), &client.UpdateOptions{})).To(Succeed())
+
+			Eventually(func(g Gomega) {
+				updatedSecret := th.GetSecret(types.NamespacedName{Namespace: namespace, Name: "keystone"})
+				// the staged key pair becomes the primary one
+				g.Expect(updatedSecret.Data["JWSKeys4"]).To(Equal(currentSecret.Data["JWSKeys0"]))
+				g.Expect(updatedSecret.Data["JWSPublicKeys4"]).To(Equal(currentSecret.Data["JWSPublicKeys0"]))
+				g.Expect(updatedSecret.Data["JWSKeys0"]).ToNot(Equal(currentSecret.Data["JWSKeys0"]))
+			}, timeout, interval).Should(Succeed())
+		})
+	})
+
+	When("A KeystoneAPI is created with an OIDC KeystoneIdentityProvider", func() {
+		BeforeEach(func() {
+			idpName := types.NamespacedName{Name: "sso", Namespace: namespace}
+			protocolName := types.NamespacedName{Name: "sso-openid", Namespace: namespace}
+			th.CreateSecret(
+				types.NamespacedName{Namespace: namespace, Name: "sso-secret"},
+				map[string][]byte{
+					"ClientSecret":     []byte("client-secret"),
+					"CryptoPassphrase": []byte("passphrase"),
+				},
+			)
+			DeferCleanup(th.DeleteInstance, CreateKeystoneIdentityProvider(idpName, map[string]any{
+				"identityProviderID": "sso",
+				"remoteIDs":          []string{"https://sso.example.com/realms/openstack"},
+				"oidc": map[string]any{
+					"providerMetadataURL": "https://sso.example.com/realms/openstack/.well-known/openid-configuration",
+					"clientID":            "keystone",
+					"secret":              "sso-secret",
+				},
+			}))
+			SimulateKeystoneIdentityProviderRegistered(idpName)
+			DeferCleanup(th.DeleteInstance, CreateKeystoneProtocol(protocolName, map[string]any{
+				"protocolID":       "openid",
+				"identityProvider": "sso",
+				"mapping":          "sso-mapping",
+			}))
+			SimulateKeystoneProtocolRegistered(protocolName)
+
+			DeferCleanup(
+				k8sClient.Delete, ctx, CreateKeystoneMessageBusSecret(namespace, "rabbitmq-secret"))
+			keystone := CreateKeystoneAPI(keystoneAPIName, GetDefaultKeystoneAPISpec())
+			DeferCleanup(th.DeleteInstance, keystone)
+			DeferCleanup(
+				k8sClient.Delete, ctx, CreateKeystoneAPISecret(namespace, SecretName))
+			DeferCleanup(infra.DeleteMemcached, infra.CreateMemcached(namespace, "memcached", memcachedSpec))
+			DeferCleanup(
+				mariadb.DeleteDBService,
+				mariadb.CreateDBService(
+					namespace,
+					GetKeystoneAPI(keystoneAPIName).Spec.DatabaseInstance,
+					corev1.ServiceSpec{
+						Ports: []corev1.ServicePort{{Port: 3306}},
+					},
+				),
+			)
+			mariadb.SimulateMariaDBAccountCompleted(keystoneAccountName)
+			mariadb.SimulateMariaDBDatabaseCompleted(keystoneDatabaseName)
+			infra.SimulateTransportURLReady(types.NamespacedName{
+				Name:      fmt.Sprintf("%s-keystone-transport", keystoneAPIName.Name),
+				Namespace: namespace,
+			})
+			infra.SimulateMemcachedReady(types.NamespacedName{
+				Name:      "memcached",
+				Namespace: namespace,
+			})
+			th.SimulateJobSuccess(dbSyncJobName)
+			th.SimulateJobSuccess(bootstrapJobName)
+			th.SimulateDeploymentReplicaReady(deploymentName)
+		})
+
+		It("renders the federation config into the keystone-config-data secret", func() {
+			scrt := th.GetSecret(keystoneAPIConfigDataName)
+			Expect(scrt.Data).Should(HaveKey("httpd_custom_public_federation_sso.conf"))
+			oidcConfig := string(scrt.Data["httpd_custom_public_federation_sso.conf"])
+			Expect(oidcConfig).Should(ContainSubstring(`OIDCClientID "keystone"`))
+			Expect(oidcConfig).Should(ContainSubstring(`OIDCClientSecret "client-secret"`))
+			Expect(oidcConfig).Should(ContainSubstring(`OIDCCryptoPassphrase "passphrase"`))
+			Expect(oidcConfig).Should(ContainSubstring(`OIDCScope "openid email profile"`))
+			Expect(oidcConfig).Should(ContainSubstring(
+				"/v3/OS-FEDERATION/identity_providers/sso/protocols/openid/auth"))
+
+			configData := string(scrt.Data["keystone.conf"])
+			Expect(configData).Should(ContainSubstring(
+				"methods=external,password,token,oauth1,mapped,application_credential,openid"))
+			Expect(configData).Should(ContainSubstring("[openid]\nremote_id_attribute=HTTP_OIDC_ISS"))
+
+			httpdConfig := string(scrt.Data["httpd.conf"])
+			Expect(httpdConfig).Should(ContainSubstring("Include conf/httpd_custom_public_*"))
+			Expect(httpdConfig).ShouldNot(ContainSubstring("Include conf/httpd_custom_internal_*"))
+		})
+
+		It("updates the federation config when the client secret changes", func() {
+			Eventually(func(g Gomega) {
+				ssoSecret := th.GetSecret(types.NamespacedName{Namespace: namespace, Name: "sso-secret"})
+				ssoSecret.Data["ClientSecret"] = []byte("new-client-secret")
+				g.Expect(k8sClient.Update(ctx, &ssoSecret)).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(func(g Gomega) {
+				scrt := th.GetSecret(keystoneAPIConfigDataName)
+				g.Expect(string(scrt.Data["httpd_custom_public_federation_sso.conf"])).Should(
+					ContainSubstring(`OIDCClientSecret "new-client-secret"`))
+			}, timeout, interval).Should(Succeed())
+		})
+	})
+
 	When("A KeystoneAPI is created with quorum queues disabled", func() {
 		BeforeEach(func() {
 			DeferCleanup(th.DeleteInstance, CreateKeystoneAPI(keystoneAPIName, GetDefaultKeystoneAPISpec()))
@@ -219,6 +219,31 @@ var _ = Describe("KeystoneAPI Webhook", func() {
 		)
 	})
 
+	It("rejects fernetMaxActiveKeys the tokens outlive", func() {
+		spec := GetKeystoneAPISpec(3)
+		spec["tokenExpiration"] = 2 * 86400
+
+		raw := map[string]any{
+			"apiVersion": "keystone.openstack.org/v1beta1",
+			"kind":       "KeystoneAPI",
+			"metadata": map[string]any{
+				"name":      keystoneAPIName.Name,
+				"namespace": keystoneAPIName.Namespace,
+			},
+			"spec": spec,
+		}
+
+		unstructuredObj := &unstructured.Unstructured{Object: raw}
+		_, err := controllerutil.CreateOrPatch(
+			th.Ctx, th.K8sClient, unstructuredObj, func() error { return nil })
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(
+			ContainSubstring(
+				"invalid: spec.fernetMaxActiveKeys: Invalid value: 3: tokens outlive the fernet keys, " +
+					"at least 4 keys are required for tokenExpiration and fernetRotationDays"),
+		)
+	})
+
 	When("ExternalKeystoneAPI validation", func() {
 		It("rejects ExternalKeystoneAPI=true with nil service override", func() {
 			spec := GetDefaultKeystoneAPISpec()
@@ -0,0 +1,175 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package functional_test
+
+import (
+	"fmt"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/domains"
+	. "github.com/onsi/ginkgo/v2" //revive:disable:dot-imports
+	. "github.com/onsi/gomega"    //revive:disable:dot-imports
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	keystone_test "github.com/openstack-k8s-operators/keystone-operator/api/test/helpers"
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	//revive:disable-next-line:dot-imports
+	. "github.com/openstack-k8s-operators/lib-common/modules/common/test/helpers"
+)
+
+var _ = Describe("KeystoneDomain controller", func() {
+
+	var keystoneAPIName types.NamespacedName
+	var domainName types.NamespacedName
+	var f *keystone_test.KeystoneAPIFixture
+
+	BeforeEach(func() {
+		keystoneAPIName = types.NamespacedName{Name: "keystone", Namespace: namespace}
+		domainName = types.NamespacedName{Name: "corp", Namespace: namespace}
+
+		f = keystone_test.NewKeystoneAPIFixtureWithServer(logger)
+		f.Setup()
+		DeferCleanup(f.Cleanup)
+
+		DeferCleanup(
+			k8sClient.Delete, ctx, CreateKeystoneAPISecret(namespace, SecretName))
+		DeferCleanup(th.DeleteInstance, CreateExternalKeystoneAPI(keystoneAPIName, f))
+	})
+
+	When("a KeystoneDomain is created", func() {
+		BeforeEach(func() {
+			DeferCleanup(th.DeleteInstance, CreateKeystoneDomain(domainName, map[string]any{
+				"domainName":  "corp",
+				"description": "corporate users",
+			}))
+		})
+
+		It("creates the domain in keystone", func() {
+			th.ExpectCondition(
+				domainName,
+				ConditionGetterFunc(KeystoneDomainConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+
+			Expect(f.Domains).To(HaveKey("corp"))
+			Expect(f.Domains["corp"].Description).To(Equal("corporate users"))
+			Expect(f.Domains["corp"].Enabled).To(BeTrue())
+
+			domain := GetKeystoneDomain(domainName)
+			Expect(domain.Status.DomainID).To(Equal(f.Domains["corp"].ID))
+			Expect(domain.Status.Adopted).To(BeFalse())
+
+			keystoneAPI := keystone.GetKeystoneAPI(keystoneAPIName)
+			Expect(keystoneAPI.Finalizers).To(ContainElement(
+				fmt.Sprintf("openstack.org/keystonedomain-%s", domainName.Name)))
+		})
+
+		It("updates the domain in keystone", func() {
+			th.ExpectCondition(
+				domainName,
+				ConditionGetterFunc(KeystoneDomainConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+
+			Eventually(func(g Gomega) {
+				domain := GetKeystoneDomain(domainName)
+				domain.Spec.Description = "all corporate users"
+				domain.Spec.Enabled = false
+				g.Expect(k8sClient.Update(ctx, domain)).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(func(g Gomega) {
+				g.Expect(f.Domains).To(HaveKey("corp"))
+				g.Expect(f.Domains["corp"].Description).To(Equal("all corporate users"))
+				g.Expect(f.Domains["corp"].Enabled).To(BeFalse())
+			}, timeout, interval).Should(Succeed())
+		})
+
+		It("deletes the domain from keystone", func() {
+			th.ExpectCondition(
+				domainName,
+				ConditionGetterFunc(KeystoneDomainConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+
+			th.DeleteInstance(GetKeystoneDomain(domainName))
+
+			Expect(f.Domains).NotTo(HaveKey("corp"))
+			keystoneAPI := keystone.GetKeystoneAPI(keystoneAPIName)
+			Expect(keystoneAPI.Finalizers).NotTo(ContainElement(
+				fmt.Sprintf("openstack.org/keystonedomain-%s", domainName.Name)))
+		})
+	})
+
+	When("a KeystoneDomain is created for a domain which already exists", func() {
+		BeforeEach(func() {
+			f.Domains["corp"] = domains.Domain{
+				ID:          "existing-domain-id",
+				Name:        "corp",
+				Description: "created by hand",
+				Enabled:     true,
+			}
+		})
+
+		It("refuses to take over the domain without adopt", func() {
+			DeferCleanup(th.DeleteInstance, CreateKeystoneDomain(domainName, map[string]any{
+				"domainName": "corp",
+			}))
+
+			th.ExpectCondition(
+				domainName,
+				ConditionGetterFunc(KeystoneDomainConditionGetter),
+				keystonev1.KeystoneDomainOSDomainReadyCondition,
+				corev1.ConditionFalse,
+			)
+			Expect(GetKeystoneDomain(domainName).Status.DomainID).To(BeEmpty())
+
+			// the domain is left alone
+			Expect(f.Domains["corp"].ID).To(Equal("existing-domain-id"))
+			Expect(f.Domains["corp"].Description).To(Equal("created by hand"))
+		})
+
+		It("adopts the domain and keeps it in keystone on delete", func() {
+			CreateKeystoneDomain(domainName, map[string]any{
+				"domainName":  "corp",
+				"description": "corporate users",
+				"adopt":       true,
+			})
+
+			th.ExpectCondition(
+				domainName,
+				ConditionGetterFunc(KeystoneDomainConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+			domain := GetKeystoneDomain(domainName)
+			Expect(domain.Status.DomainID).To(Equal("existing-domain-id"))
+			Expect(domain.Status.Adopted).To(BeTrue())
+			Expect(f.Domains["corp"].Description).To(Equal("corporate users"))
+
+			th.DeleteInstance(domain)
+
+			Expect(f.Domains).To(HaveKey("corp"))
+			Expect(f.Domains["corp"].ID).To(Equal("existing-domain-id"))
+		})
+	})
+})
@@ -0,0 +1,177 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package functional_test
+
+import (
+	"fmt"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/projects"
+	. "github.com/onsi/ginkgo/v2" //revive:disable:dot-imports
+	. "github.com/onsi/gomega"    //revive:disable:dot-imports
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	keystone_test "github.com/openstack-k8s-operators/keystone-operator/api/test/helpers"
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	//revive:disable-next-line:dot-imports
+	. "github.com/openstack-k8s-operators/lib-common/modules/common/test/helpers"
+)
+
+var _ = Describe("KeystoneProject controller", func() {
+
+	var keystoneAPIName types.NamespacedName
+	var projectName types.NamespacedName
+	var f *keystone_test.KeystoneAPIFixture
+
+	BeforeEach(func() {
+		keystoneAPIName = types.NamespacedName{Name: "keystone", Namespace: namespace}
+		projectName = types.NamespacedName{Name: "demo", Namespace: namespace}
+		f = SetupKeystoneFixture(keystoneAPIName)
+	})
+
+	When("a KeystoneProject is created", func() {
+		BeforeEach(func() {
+			DeferCleanup(th.DeleteInstance, CreateKeystoneProject(projectName, map[string]any{
+				"projectName": "demo",
+				"description": "demo project",
+			}))
+			th.ExpectCondition(
+				projectName,
+				ConditionGetterFunc(KeystoneProjectConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+		})
+
+		It("creates the project in keystone", func() {
+			Expect(f.Projects).To(HaveKey("demo"))
+			Expect(f.Projects["demo"].DomainID).To(Equal("default"))
+			Expect(f.Projects["demo"].Description).To(Equal("demo project"))
+			Expect(f.Projects["demo"].Enabled).To(BeTrue())
+
+			project := GetKeystoneProject(projectName)
+			Expect(project.Status.ProjectID).To(Equal(f.Projects["demo"].ID))
+			Expect(project.Status.DomainID).To(Equal("default"))
+			Expect(project.Status.Adopted).To(BeFalse())
+
+			keystoneAPI := keystone.GetKeystoneAPI(keystoneAPIName)
+			Expect(keystoneAPI.Finalizers).To(ContainElement(
+				fmt.Sprintf("openstack.org/keystoneproject-%s", projectName.Name)))
+		})
+
+		It("updates the project in keystone", func() {
+			projectID := GetKeystoneProject(projectName).Status.ProjectID
+
+			Eventually(func(g Gomega) {
+				project := GetKeystoneProject(projectName)
+				project.Spec.ProjectName = "demo-renamed"
+				project.Spec.Description = "renamed demo project"
+				project.Spec.Enabled = false
+				g.Expect(k8sClient.Update(ctx, project)).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(func(g Gomega) {
+				g.Expect(f.Projects).NotTo(HaveKey("demo"))
+				g.Expect(f.Projects).To(HaveKey("demo-renamed"))
+				g.Expect(f.Projects["demo-renamed"].ID).To(Equal(projectID))
+				g.Expect(f.Projects["demo-renamed"].Description).To(Equal("renamed demo project"))
+				g.Expect(f.Projects["demo-renamed"].Enabled).To(BeFalse())
+			}, timeout, interval).Should(Succeed())
+		})
+
+		It("deletes the project from keystone", func() {
+			th.DeleteInstance(GetKeystoneProject(projectName))
+
+			Expect(f.Projects).NotTo(HaveKey("demo"))
+			keystoneAPI := keystone.GetKeystoneAPI(keystoneAPIName)
+			Expect(keystoneAPI.Finalizers).NotTo(ContainElement(
+				fmt.Sprintf("openstack.org/keystoneproject-%s", projectName.Name)))
+		})
+	})
+
+	When("a KeystoneProject with the Retain deletion policy is deleted", func() {
+		It("keeps the project in keystone", func() {
+			CreateKeystoneProject(projectName, map[string]any{
+				"projectName":    "demo",
+				"deletionPolicy": "Retain",
+			})
+			th.ExpectCondition(
+				projectName,
+				ConditionGetterFunc(KeystoneProjectConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+
+			th.DeleteInstance(GetKeystoneProject(projectName))
+
+			Expect(f.Projects).To(HaveKey("demo"))
+		})
+	})
+
+	When("a KeystoneProject is created for a project which already exists", func() {
+		BeforeEach(func() {
+			f.Projects["demo"] = projects.Project{
+				ID:          "existing-project-id",
+				Name:        "demo",
+				Description: "created by hand",
+				DomainID:    "default",
+				Enabled:     true,
+			}
+		})
+
+		It("refuses to take over the project without adopt", func() {
+			DeferCleanup(th.DeleteInstance, CreateKeystoneProject(projectName, map[string]any{
+				"projectName": "demo",
+			}))
+
+			th.ExpectCondition(
+				projectName,
+				ConditionGetterFunc(KeystoneProjectConditionGetter),
+				keystonev1.KeystoneProjectOSProjectReadyCondition,
+				corev1.ConditionFalse,
+			)
+			Expect(GetKeystoneProject(projectName).Status.ProjectID).To(BeEmpty())
+			Expect(f.Projects["demo"].Description).To(Equal("created by hand"))
+		})
+
+		It("adopts the project and keeps it in keystone on delete", func() {
+			CreateKeystoneProject(projectName, map[string]any{
+				"projectName": "demo",
+				"description": "demo project",
+				"adopt":       true,
+			})
+
+			th.ExpectCondition(
+				projectName,
+				ConditionGetterFunc(KeystoneProjectConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+			project := GetKeystoneProject(projectName)
+			Expect(project.Status.ProjectID).To(Equal("existing-project-id"))
+			Expect(project.Status.Adopted).To(BeTrue())
+			Expect(f.Projects["demo"].Description).To(Equal("demo project"))
+
+			th.DeleteInstance(project)
+
+			Expect(f.Projects).To(HaveKey("demo"))
+			Expect(f.Projects["demo"].ID).To(Equal("existing-project-id"))
+		})
+	})
+})
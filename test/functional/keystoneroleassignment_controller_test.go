@@ -0,0 +1,144 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package functional_test
+
+import (
+	"fmt"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/projects"
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/users"
+	. "github.com/onsi/ginkgo/v2" //revive:disable:dot-imports
+	. "github.com/onsi/gomega"    //revive:disable:dot-imports
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	keystone_test "github.com/openstack-k8s-operators/keystone-operator/api/test/helpers"
+	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	//revive:disable-next-line:dot-imports
+	. "github.com/openstack-k8s-operators/lib-common/modules/common/test/helpers"
+)
+
+var _ = Describe("KeystoneRoleAssignment controller", func() {
+
+	var keystoneAPIName types.NamespacedName
+	var assignmentName types.NamespacedName
+	var f *keystone_test.KeystoneAPIFixture
+	var projectAssignment keystone_test.RoleAssignment
+
+	BeforeEach(func() {
+		keystoneAPIName = types.NamespacedName{Name: "keystone", Namespace: namespace}
+		assignmentName = types.NamespacedName{Name: "demo-member", Namespace: namespace}
+		f = SetupKeystoneFixture(keystoneAPIName)
+
+		f.Users["demo"] = users.User{ID: "demo-user-id", Name: "demo", DomainID: "default", Enabled: true}
+		f.Projects["demo"] = projects.Project{ID: "demo-project-id", Name: "demo", DomainID: "default", Enabled: true}
+	})
+
+	When("a KeystoneRoleAssignment is created", func() {
+		BeforeEach(func() {
+			DeferCleanup(th.DeleteInstance, CreateKeystoneRoleAssignment(assignmentName, map[string]any{
+				"roleName": "member",
+				"userName": "demo",
+				"scope": map[string]any{
+					"projectName": "demo",
+				},
+			}))
+			th.ExpectCondition(
+				assignmentName,
+				ConditionGetterFunc(KeystoneRoleAssignmentConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+			projectAssignment = keystone_test.RoleAssignment{
+				RoleID:    f.Roles["member"].ID,
+				UserID:    "demo-user-id",
+				ProjectID: "demo-project-id",
+			}
+		})
+
+		It("creates the role and assigns it in keystone", func() {
+			Expect(f.Roles).To(HaveKey("member"))
+			Expect(f.RoleAssignments).To(HaveKey(projectAssignment))
+
+			assignment := GetKeystoneRoleAssignment(assignmentName)
+			Expect(assignment.Status.RoleID).To(Equal(f.Roles["member"].ID))
+			Expect(assignment.Status.UserID).To(Equal("demo-user-id"))
+			Expect(assignment.Status.ProjectID).To(Equal("demo-project-id"))
+
+			keystoneAPI := keystone.GetKeystoneAPI(keystoneAPIName)
+			Expect(keystoneAPI.Finalizers).To(ContainElement(
+				fmt.Sprintf("openstack.org/keystoneroleassignment-%s", assignmentName.Name)))
+		})
+
+		It("moves the assignment in keystone when the scope changes", func() {
+			Eventually(func(g Gomega) {
+				assignment := GetKeystoneRoleAssignment(assignmentName)
+				assignment.Spec.Scope.ProjectName = ""
+				assignment.Spec.Scope.System = "all"
+				g.Expect(k8sClient.Update(ctx, assignment)).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(func(g Gomega) {
+				g.Expect(f.RoleAssignments).NotTo(HaveKey(projectAssignment))
+				g.Expect(f.RoleAssignments).To(HaveKey(keystone_test.RoleAssignment{
+					RoleID: f.Roles["member"].ID,
+					UserID: "demo-user-id",
+					System: "all",
+				}))
+			}, timeout, interval).Should(Succeed())
+		})
+
+		It("revokes the assignment in keystone on delete", func() {
+			th.DeleteInstance(GetKeystoneRoleAssignment(assignmentName))
+
+			Expect(f.RoleAssignments).NotTo(HaveKey(projectAssignment))
+			// the role itself is left alone, it might be used elsewhere
+			Expect(f.Roles).To(HaveKey("member"))
+			keystoneAPI := keystone.GetKeystoneAPI(keystoneAPIName)
+			Expect(keystoneAPI.Finalizers).NotTo(ContainElement(
+				fmt.Sprintf("openstack.org/keystoneroleassignment-%s", assignmentName.Name)))
+		})
+	})
+
+	When("a KeystoneRoleAssignment with the Retain deletion policy is deleted", func() {
+		It("keeps the assignment in keystone", func() {
+			CreateKeystoneRoleAssignment(assignmentName, map[string]any{
+				"roleName": "member",
+				"userName": "demo",
+				"scope": map[string]any{
+					"projectName": "demo",
+				},
+				"deletionPolicy": "Retain",
+			})
+			th.ExpectCondition(
+				assignmentName,
+				ConditionGetterFunc(KeystoneRoleAssignmentConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+
+			th.DeleteInstance(GetKeystoneRoleAssignment(assignmentName))
+
+			Expect(f.RoleAssignments).To(HaveKey(keystone_test.RoleAssignment{
+				RoleID:    f.Roles["member"].ID,
+				UserID:    "demo-user-id",
+				ProjectID: "demo-project-id",
+			}))
+		})
+	})
+})
@@ -0,0 +1,224 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package functional_test
+
+import (
+	"fmt"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/domains"
+	. "github.com/onsi/ginkgo/v2" //revive:disable:dot-imports
+	. "github.com/onsi/gomega"    //revive:disable:dot-imports
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	keystone_test "github.com/openstack-k8s-operators/keystone-operator/api/test/helpers"
+	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	//revive:disable-next-line:dot-imports
+	. "github.com/openstack-k8s-operators/lib-common/modules/common/test/helpers"
+)
+
+var _ = Describe("KeystoneService controller", func() {
+
+	var keystoneAPIName types.NamespacedName
+	var serviceName types.NamespacedName
+	var passwordSecretName types.NamespacedName
+	var f *keystone_test.KeystoneAPIFixture
+
+	BeforeEach(func() {
+		keystoneAPIName = types.NamespacedName{Name: "keystone", Namespace: namespace}
+		serviceName = types.NamespacedName{Name: "placement", Namespace: namespace}
+		passwordSecretName = types.NamespacedName{Name: "osp-secret", Namespace: namespace}
+		f = SetupKeystoneFixture(keystoneAPIName)
+
+		th.CreateSecret(passwordSecretName, map[string][]byte{"PlacementPassword": []byte("placement-password")})
+	})
+
+	createKeystoneService := func(deletionPolicy string) {
+		CreateKeystoneService(serviceName, map[string]any{
+			"serviceType":      "placement",
+			"serviceName":      "placement",
+			"enabled":          true,
+			"serviceUser":      "placement",
+			"secret":           passwordSecretName.Name,
+			"passwordSelector": "PlacementPassword",
+			"deletionPolicy":   deletionPolicy,
+		})
+		th.ExpectCondition(
+			serviceName,
+			ConditionGetterFunc(KeystoneServiceConditionGetter),
+			condition.ReadyCondition,
+			corev1.ConditionTrue,
+		)
+	}
+
+	serviceAssignments := func() []keystone_test.RoleAssignment {
+		return []keystone_test.RoleAssignment{
+			{RoleID: f.Roles["admin"].ID, UserID: f.Users["placement"].ID, ProjectID: f.Projects["service"].ID},
+			{RoleID: f.Roles["service"].ID, UserID: f.Users["placement"].ID, ProjectID: f.Projects["service"].ID},
+		}
+	}
+
+	expectFinalizerRemoved := func() {
+		keystoneAPI := keystone.GetKeystoneAPI(keystoneAPIName)
+		Expect(keystoneAPI.Finalizers).NotTo(ContainElement(
+			fmt.Sprintf("openstack.org/keystoneservice-%s", serviceName.Name)))
+	}
+
+	When("a KeystoneService is created", func() {
+		BeforeEach(func() {
+			createKeystoneService("Delete")
+			DeferCleanup(th.DeleteInstance, GetKeystoneService(serviceName))
+		})
+
+		It("registers the service and its user in keystone", func() {
+			Expect(f.Services).To(HaveKey("placement"))
+			Expect(f.Services["placement"].Type).To(Equal("placement"))
+			Expect(f.Users).To(HaveKey("placement"))
+			Expect(f.Passwords["placement"]).To(Equal("placement-password"))
+			for _, assignment := range serviceAssignments() {
+				Expect(f.RoleAssignments).To(HaveKey(assignment))
+			}
+
+			service := GetKeystoneService(serviceName)
+			Expect(service.Status.ServiceID).To(Equal(f.Services["placement"].ID))
+			Expect(service.Status.Service.Name).To(Equal("placement"))
+			Expect(service.Status.PasswordHash).NotTo(BeEmpty())
+
+			keystoneAPI := keystone.GetKeystoneAPI(keystoneAPIName)
+			Expect(keystoneAPI.Finalizers).To(ContainElement(
+				fmt.Sprintf("openstack.org/keystoneservice-%s", serviceName.Name)))
+		})
+
+		It("updates the password of the service user when the secret changes", func() {
+			passwordHash := GetKeystoneService(serviceName).Status.PasswordHash
+			userID := f.Users["placement"].ID
+
+			Eventually(func(g Gomega) {
+				secret := th.GetSecret(passwordSecretName)
+				secret.Data["PlacementPassword"] = []byte("new-placement-password")
+				g.Expect(k8sClient.Update(ctx, &secret)).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(func(g Gomega) {
+				g.Expect(f.Passwords["placement"]).To(Equal("new-placement-password"))
+				g.Expect(GetKeystoneService(serviceName).Status.PasswordHash).NotTo(Equal(passwordHash))
+			}, timeout, interval).Should(Succeed())
+			Expect(f.Users["placement"].ID).To(Equal(userID))
+			th.ExpectCondition(
+				serviceName,
+				ConditionGetterFunc(KeystoneServiceConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+		})
+	})
+
+	When("a KeystoneService with the Delete deletion policy is deleted", func() {
+		It("removes the service and its user from keystone", func() {
+			createKeystoneService("Delete")
+
+			th.DeleteInstance(GetKeystoneService(serviceName))
+
+			Expect(f.Services).NotTo(HaveKey("placement"))
+			Expect(f.Users).NotTo(HaveKey("placement"))
+			Expect(f.RoleAssignments).To(BeEmpty())
+			expectFinalizerRemoved()
+		})
+	})
+
+	When("a KeystoneService with the Retain deletion policy is deleted", func() {
+		It("keeps the service and its user in keystone and revokes the roles", func() {
+			createKeystoneService("Retain")
+			assignments := serviceAssignments()
+
+			th.DeleteInstance(GetKeystoneService(serviceName))
+
+			Expect(f.Services).To(HaveKey("placement"))
+			Expect(f.Users).To(HaveKey("placement"))
+			for _, assignment := range assignments {
+				Expect(f.RoleAssignments).NotTo(HaveKey(assignment))
+			}
+			expectFinalizerRemoved()
+		})
+	})
+
+	When("a KeystoneService with the Orphan deletion policy is deleted", func() {
+		It("leaves the service, its user and the roles in keystone", func() {
+			createKeystoneService("Orphan")
+			assignments := serviceAssignments()
+
+			th.DeleteInstance(GetKeystoneService(serviceName))
+
+			Expect(f.Services).To(HaveKey("placement"))
+			Expect(f.Users).To(HaveKey("placement"))
+			for _, assignment := range assignments {
+				Expect(f.RoleAssignments).To(HaveKey(assignment))
+			}
+			expectFinalizerRemoved()
+		})
+	})
+
+	When("a KeystoneService moves to another KeystoneAPI", func() {
+		It("applies the deletion policy in the keystone of the previous KeystoneAPI", func() {
+			otherAPIName := types.NamespacedName{Name: "keystone-b", Namespace: namespace}
+			other := keystone_test.NewKeystoneAPIFixtureWithServer(logger)
+			other.Setup()
+			DeferCleanup(other.Cleanup)
+			other.Domains["Default"] = domains.Domain{ID: "default", Name: "Default", Enabled: true}
+			DeferCleanup(th.DeleteInstance, CreateExternalKeystoneAPI(otherAPIName, other))
+
+			DeferCleanup(th.DeleteInstance, CreateKeystoneService(serviceName, map[string]any{
+				"serviceType":      "placement",
+				"serviceName":      "placement",
+				"enabled":          true,
+				"serviceUser":      "placement",
+				"secret":           passwordSecretName.Name,
+				"passwordSelector": "PlacementPassword",
+				"keystoneAPIRef":   map[string]any{"name": keystoneAPIName.Name},
+			}))
+			th.ExpectCondition(
+				serviceName,
+				ConditionGetterFunc(KeystoneServiceConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+			Expect(f.Services).To(HaveKey("placement"))
+
+			Eventually(func(g Gomega) {
+				service := GetKeystoneService(serviceName)
+				service.Spec.KeystoneAPIRef.Name = otherAPIName.Name
+				g.Expect(k8sClient.Update(ctx, service)).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(func(g Gomega) {
+				service := GetKeystoneService(serviceName)
+				g.Expect(service.Status.KeystoneAPI).To(Equal(otherAPIName.String()))
+				g.Expect(other.Services).To(HaveKey("placement"))
+				g.Expect(service.Status.ServiceID).To(Equal(other.Services["placement"].ID))
+			}, timeout, interval).Should(Succeed())
+
+			// the default Delete policy removed the service and its user from
+			// the previous keystone
+			Expect(f.Services).NotTo(HaveKey("placement"))
+			Expect(f.Users).NotTo(HaveKey("placement"))
+			Expect(f.RoleAssignments).To(BeEmpty())
+			expectFinalizerRemoved()
+			Expect(other.Users).To(HaveKey("placement"))
+		})
+	})
+})
@@ -0,0 +1,218 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package functional_test
+
+import (
+	"fmt"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/users"
+	. "github.com/onsi/ginkgo/v2" //revive:disable:dot-imports
+	. "github.com/onsi/gomega"    //revive:disable:dot-imports
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	keystone_test "github.com/openstack-k8s-operators/keystone-operator/api/test/helpers"
+	keystonev1 "github.com/openstack-k8s-operators/keystone-operator/api/v1beta1"
+	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	//revive:disable-next-line:dot-imports
+	. "github.com/openstack-k8s-operators/lib-common/modules/common/test/helpers"
+)
+
+var _ = Describe("KeystoneUser controller", func() {
+
+	var keystoneAPIName types.NamespacedName
+	var userName types.NamespacedName
+	var passwordSecretName types.NamespacedName
+	var f *keystone_test.KeystoneAPIFixture
+
+	BeforeEach(func() {
+		keystoneAPIName = types.NamespacedName{Name: "keystone", Namespace: namespace}
+		userName = types.NamespacedName{Name: "demo", Namespace: namespace}
+		passwordSecretName = types.NamespacedName{Name: "demo-secret", Namespace: namespace}
+		f = SetupKeystoneFixture(keystoneAPIName)
+
+		th.CreateSecret(passwordSecretName, map[string][]byte{"Password": []byte("demo-password")})
+	})
+
+	When("a KeystoneUser is created", func() {
+		BeforeEach(func() {
+			DeferCleanup(th.DeleteInstance, CreateKeystoneUser(userName, map[string]any{
+				"userName":    "demo",
+				"description": "demo user",
+				"secret":      passwordSecretName.Name,
+			}))
+			th.ExpectCondition(
+				userName,
+				ConditionGetterFunc(KeystoneUserConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+		})
+
+		It("creates the user in keystone", func() {
+			Expect(f.Users).To(HaveKey("demo"))
+			Expect(f.Users["demo"].DomainID).To(Equal("default"))
+			Expect(f.Users["demo"].Description).To(Equal("demo user"))
+			Expect(f.Users["demo"].Enabled).To(BeTrue())
+			Expect(f.Passwords["demo"]).To(Equal("demo-password"))
+
+			user := GetKeystoneUser(userName)
+			Expect(user.Status.UserID).To(Equal(f.Users["demo"].ID))
+			Expect(user.Status.Adopted).To(BeFalse())
+			Expect(user.Status.PasswordHash).NotTo(BeEmpty())
+
+			keystoneAPI := keystone.GetKeystoneAPI(keystoneAPIName)
+			Expect(keystoneAPI.Finalizers).To(ContainElement(
+				fmt.Sprintf("openstack.org/keystoneuser-%s", userName.Name)))
+		})
+
+		It("updates the user in keystone", func() {
+			userID := GetKeystoneUser(userName).Status.UserID
+
+			Eventually(func(g Gomega) {
+				user := GetKeystoneUser(userName)
+				user.Spec.Description = "disabled demo user"
+				user.Spec.Enabled = false
+				g.Expect(k8sClient.Update(ctx, user)).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(func(g Gomega) {
+				g.Expect(f.Users).To(HaveKey("demo"))
+				g.Expect(f.Users["demo"].ID).To(Equal(userID))
+				g.Expect(f.Users["demo"].Description).To(Equal("disabled demo user"))
+				g.Expect(f.Users["demo"].Enabled).To(BeFalse())
+			}, timeout, interval).Should(Succeed())
+		})
+
+		It("updates the password in keystone when the secret changes", func() {
+			passwordHash := GetKeystoneUser(userName).Status.PasswordHash
+
+			Eventually(func(g Gomega) {
+				secret := th.GetSecret(passwordSecretName)
+				secret.Data["Password"] = []byte("new-demo-password")
+				g.Expect(k8sClient.Update(ctx, &secret)).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(func(g Gomega) {
+				g.Expect(f.Passwords["demo"]).To(Equal("new-demo-password"))
+				g.Expect(GetKeystoneUser(userName).Status.PasswordHash).NotTo(Equal(passwordHash))
+			}, timeout, interval).Should(Succeed())
+		})
+
+		It("deletes the user from keystone", func() {
+			th.DeleteInstance(GetKeystoneUser(userName))
+
+			Expect(f.Users).NotTo(HaveKey("demo"))
+			keystoneAPI := keystone.GetKeystoneAPI(keystoneAPIName)
+			Expect(keystoneAPI.Finalizers).NotTo(ContainElement(
+				fmt.Sprintf("openstack.org/keystoneuser-%s", userName.Name)))
+		})
+	})
+
+	When("a KeystoneUser without a secret is created", func() {
+		It("generates the password", func() {
+			DeferCleanup(th.DeleteInstance, CreateKeystoneUser(userName, map[string]any{
+				"userName": "demo",
+			}))
+			th.ExpectCondition(
+				userName,
+				ConditionGetterFunc(KeystoneUserConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+
+			secret := th.GetSecret(types.NamespacedName{Namespace: namespace, Name: "demo-password"})
+			Expect(secret.Data).To(HaveKey("Password"))
+			Expect(f.Passwords["demo"]).To(Equal(string(secret.Data["Password"])))
+		})
+	})
+
+	When("a KeystoneUser with the Retain deletion policy is deleted", func() {
+		It("keeps the user in keystone", func() {
+			CreateKeystoneUser(userName, map[string]any{
+				"userName":       "demo",
+				"secret":         passwordSecretName.Name,
+				"deletionPolicy": "Retain",
+			})
+			th.ExpectCondition(
+				userName,
+				ConditionGetterFunc(KeystoneUserConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+
+			th.DeleteInstance(GetKeystoneUser(userName))
+
+			Expect(f.Users).To(HaveKey("demo"))
+		})
+	})
+
+	When("a KeystoneUser is created for a user which already exists", func() {
+		BeforeEach(func() {
+			f.Users["demo"] = users.User{
+				ID:       "existing-user-id",
+				Name:     "demo",
+				DomainID: "default",
+				Enabled:  true,
+			}
+			f.Passwords["demo"] = "existing-password"
+		})
+
+		It("refuses to take over the user without adopt", func() {
+			DeferCleanup(th.DeleteInstance, CreateKeystoneUser(userName, map[string]any{
+				"userName": "demo",
+				"secret":   passwordSecretName.Name,
+			}))
+
+			th.ExpectCondition(
+				userName,
+				ConditionGetterFunc(KeystoneUserConditionGetter),
+				keystonev1.KeystoneUserOSUserReadyCondition,
+				corev1.ConditionFalse,
+			)
+			Expect(GetKeystoneUser(userName).Status.UserID).To(BeEmpty())
+
+			// the password of the existing user is not overwritten
+			Expect(f.Passwords["demo"]).To(Equal("existing-password"))
+		})
+
+		It("adopts the user and keeps it in keystone on delete", func() {
+			CreateKeystoneUser(userName, map[string]any{
+				"userName": "demo",
+				"secret":   passwordSecretName.Name,
+				"adopt":    true,
+			})
+
+			th.ExpectCondition(
+				userName,
+				ConditionGetterFunc(KeystoneUserConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+			user := GetKeystoneUser(userName)
+			Expect(user.Status.UserID).To(Equal("existing-user-id"))
+			Expect(user.Status.Adopted).To(BeTrue())
+			Expect(f.Passwords["demo"]).To(Equal("demo-password"))
+
+			th.DeleteInstance(user)
+
+			Expect(f.Users).To(HaveKey("demo"))
+			Expect(f.Users["demo"].ID).To(Equal("existing-user-id"))
+		})
+	})
+})